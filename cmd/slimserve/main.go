@@ -2,13 +2,16 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
-	"time"
+	_ "time/tzdata" // DisplayTimezone names must resolve in images without a zoneinfo database
 
 	"slimserve/internal/config"
 	"slimserve/internal/logger"
@@ -18,6 +21,9 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// stdout receives command output such as the configuration dump
+var stdout io.Writer = os.Stdout
+
 func main() {
 	// Check for version flag
 	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-v") {
@@ -37,23 +43,42 @@ func main() {
 }
 
 func Run(ctx context.Context) error {
+	if config.PrintSchemaRequested() {
+		data, err := json.MarshalIndent(config.Schema(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode configuration schema: %w", err)
+		}
+		fmt.Fprintln(stdout, string(data))
+		return nil
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	if config.PrintConfigRequested() {
+		data, err := json.MarshalIndent(cfg.Redacted(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode configuration: %w", err)
+		}
+		fmt.Fprintln(stdout, string(data))
+		return nil
+	}
+
 	if err := logger.Init(cfg); err != nil {
 		return fmt.Errorf("failed to initialize logger: %w", err)
 	}
+	defer logger.Close()
 
 	srv := server.New(cfg)
-	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+	addrs := cfg.ListenAddresses()
 	storageDir := cfg.GetStorageDir()
-	logger.Log.Info().Msgf("Starting SlimServe on %s, serving storage: %s (%s)", addr, storageDir.Path, storageDir.Type)
+	logger.Log.Info().Msgf("Starting SlimServe on %s, serving storage: %s (%s)", strings.Join(addrs, ", "), storageDir.Path, storageDir.Type)
 
 	serverErr := make(chan error, 1)
 	go func() {
-		serverErr <- srv.Run(addr)
+		serverErr <- srv.Run(addrs...)
 	}()
 
 	shutdownCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
@@ -67,10 +92,7 @@ func Run(ctx context.Context) error {
 		return nil
 	case <-shutdownCtx.Done():
 		log.Info().Msg("Shutting down server...")
-		shutdownTimeoutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		if err := srv.Shutdown(shutdownTimeoutCtx); err != nil {
+		if err := srv.GracefulShutdown(context.Background()); err != nil {
 			return fmt.Errorf("server shutdown failed: %w", err)
 		}
 		log.Info().Msg("Server gracefully stopped")
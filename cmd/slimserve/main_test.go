@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunPrintConfig(t *testing.T) {
+	for _, dumpFlag := range []string{"--print-config", "--config-check"} {
+		t.Run(strings.TrimLeft(dumpFlag, "-"), func(t *testing.T) {
+			origArgs, origStdout := os.Args, stdout
+			defer func() {
+				os.Args = origArgs
+				stdout = origStdout
+			}()
+
+			flag.CommandLine = flag.NewFlagSet("slimserve", flag.ExitOnError)
+			storageDir := t.TempDir()
+			os.Args = []string{
+				"slimserve",
+				dumpFlag,
+				"-port", "9191",
+				"-storage-path", storageDir,
+				"-enable-admin=true",
+				"-admin-username", "root",
+				"-admin-password", "hunter2",
+				"-password", "secret",
+			}
+
+			var out bytes.Buffer
+			stdout = &out
+
+			if err := Run(context.Background()); err != nil {
+				t.Fatalf("Run() returned error: %v", err)
+			}
+
+			var dumped map[string]any
+			if err := json.Unmarshal(out.Bytes(), &dumped); err != nil {
+				t.Fatalf("Output is not valid JSON: %v\n%s", err, out.String())
+			}
+
+			if dumped["port"] != float64(9191) {
+				t.Errorf("Expected port 9191, got %v", dumped["port"])
+			}
+			if dumped["storage_path"] != storageDir {
+				t.Errorf("Expected storage_path %q, got %v", storageDir, dumped["storage_path"])
+			}
+			if dumped["admin_username"] != "root" {
+				t.Errorf("Expected admin_username root, got %v", dumped["admin_username"])
+			}
+			if dumped["password"] != "[REDACTED]" || dumped["admin_password"] != "[REDACTED]" {
+				t.Errorf("Expected passwords to be redacted, got %v and %v", dumped["password"], dumped["admin_password"])
+			}
+			if strings.Contains(out.String(), "hunter2") || strings.Contains(out.String(), "secret\"") {
+				t.Errorf("Output leaks a secret:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestRunPrintSchema(t *testing.T) {
+	origArgs, origStdout := os.Args, stdout
+	defer func() {
+		os.Args = origArgs
+		stdout = origStdout
+	}()
+
+	// No storage path is configured, so loading the configuration would fail
+	t.Chdir(t.TempDir())
+	flag.CommandLine = flag.NewFlagSet("slimserve", flag.ExitOnError)
+	os.Args = []string{"slimserve", "-print-schema", "-default-storage-path="}
+
+	var out bytes.Buffer
+	stdout = &out
+
+	if err := Run(context.Background()); err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+
+	var schema struct {
+		Type       string                    `json:"type"`
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &schema); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if schema.Type != "object" {
+		t.Errorf("Expected an object schema, got %q", schema.Type)
+	}
+	if port := schema.Properties["port"]; port["type"] != "integer" || port["description"] != "Port to serve on" {
+		t.Errorf("Unexpected port schema: %v", port)
+	}
+}
@@ -1,10 +1,118 @@
 package config
 
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
 const (
 	BackendLocal = "local"
 	BackendS3    = "s3"
 )
 
+// Built-in access log formats. Any other non-empty AccessLogFormat is
+// treated as a Go text/template.
+const (
+	AccessLogCombined = "combined"
+	AccessLogJSON     = "json"
+)
+
+// Symlink policies. Symlinks that escape the served root are always blocked
+// by RootFS; these only control in-root links.
+const (
+	SymlinkDeny   = "deny"   // hide symlinks from listings and refuse to serve them
+	SymlinkFollow = "follow" // serve the link target
+	SymlinkShow   = "show"   // list symlinks as such but never follow them
+)
+
+// Listing sort keys and directions, for DefaultSort and DefaultOrder and the
+// sort and order query parameters of listings
+const (
+	SortName     = "name"
+	SortSize     = "size"
+	SortModified = "modified"
+	OrderAsc     = "asc"
+	OrderDesc    = "desc"
+)
+
+// ListingSortKeys are the values DefaultSort accepts
+var ListingSortKeys = []string{SortName, SortSize, SortModified}
+
+// DefaultRequestIDHeader carries request IDs when RequestIDHeader is empty
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// DefaultCookiePrefix names the session and CSRF cookies when CookiePrefix is empty
+const DefaultCookiePrefix = "slimserve"
+
+// MaxListingBannerLength caps ListingBanner in bytes, markup included
+const MaxListingBannerLength = 2000
+
+// DefaultDateFormat is the listing time layout used when DateFormat is empty
+const DefaultDateFormat = "Jan 2, 2006 15:04"
+
+// DateFormatPresets are the named layouts accepted for DateFormat, matched
+// case-insensitively
+var DateFormatPresets = map[string]string{
+	"default":  DefaultDateFormat,
+	"iso-8601": "2006-01-02T15:04:05Z07:00",
+	"rfc3339":  time.RFC3339,
+	"rfc1123":  time.RFC1123,
+	"date":     "2006-01-02",
+	"datetime": "2006-01-02 15:04",
+}
+
+// Cookie SameSite modes
+const (
+	CookieSameSiteLax    = "lax"
+	CookieSameSiteStrict = "strict"
+	CookieSameSiteNone   = "none" // cookies are then always marked Secure
+)
+
+// Upload conflict policies decide what happens when an uploaded file's name
+// is already taken.
+const (
+	UploadConflictRename    = "rename"    // save as name_N.ext
+	UploadConflictOverwrite = "overwrite" // replace the existing file
+	UploadConflictReject    = "reject"    // fail the upload of that file
+	UploadConflictTimestamp = "timestamp" // save as name_YYYYMMDD-HHMMSS.ext
+)
+
+// DefaultContentSecurityPolicy is applied to the listing, login and admin pages.
+// {nonce} is replaced with a fresh value on every response. Alpine.js evaluates
+// its directives at runtime, which requires 'unsafe-eval'.
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'nonce-{nonce}' 'unsafe-eval' https://cdn.jsdelivr.net; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data: blob:; " +
+	"connect-src 'self'; " +
+	"object-src 'none'; " +
+	"base-uri 'self'; " +
+	"form-action 'self'; " +
+	"frame-ancestors 'none'"
+
+// Listing themes. ThemeAuto follows the browser's prefers-color-scheme.
+const (
+	ThemeLight = "light"
+	ThemeDark  = "dark"
+	ThemeAuto  = "auto"
+)
+
+// ListingIcons are the icon names directory listings can draw, and so the
+// values IconOverrides accepts
+var ListingIcons = []string{"file", "folder", "image", "file-pdf", "file-text", "archive", "video", "audio"}
+
 type DirectoryConfig struct {
 	Path string `json:"path"`
 	Type string `json:"type"`
@@ -28,8 +136,25 @@ func (d *DirectoryConfig) IsLocal() bool {
 type Config struct {
 	Host               string   `json:"host"`
 	Port               int      `json:"port"`
+	BasePath           string   `json:"base_path"` // URL prefix when hosted under a reverse-proxy subpath
 	DisableDotFiles    bool     `json:"disable_dot_files"`
+	ServeIndexHTML     bool     `json:"serve_index_html"`   // Serve a directory's index file instead of the listing
+	DisableListing     bool     `json:"disable_listing"`    // Answer directory URLs with 403 instead of a listing
+	ShowDirSizes       bool     `json:"show_dir_sizes"`     // Show recursive folder sizes in listings
+	SymlinkPolicy      string   `json:"symlink_policy"`     // "deny", "follow" or "show"
+	ForceDownload      bool     `json:"force_download"`     // Serve every file as an attachment
+	SendServerHeader   bool     `json:"send_server_header"` // Advertise "Server: SlimServe/<version>"
+	VersionStats       bool     `json:"version_stats"`      // Add runtime and cache stats to /version
+	HideVersion        bool     `json:"hide_version"`       // Keep the build version out of pages and headers; /version answers 404
+	Theme              string   `json:"theme"`              // Default listing theme: "light", "dark" or "auto"
 	LogLevel           string   `json:"log_level"`
+	LogFile            string   `json:"log_file"`
+	LogMaxSizeMB       int      `json:"log_max_size_mb"`
+	LogMaxBackups      int      `json:"log_max_backups"`
+	LogMaxAgeDays      int      `json:"log_max_age_days"`
+	LogToStderr        bool     `json:"log_to_stderr"`     // Also log to stderr when LogFile is set
+	AccessLogFormat    string   `json:"access_log_format"` // "combined", "json" or a Go template; empty disables
+	AccessLogFile      string   `json:"access_log_file"`   // Access log destination; empty means stdout
 	EnableAuth         bool     `json:"enable_auth"`
 	Username           string   `json:"username"`
 	Password           string   `json:"password"`
@@ -39,6 +164,193 @@ type Config struct {
 	ThumbMaxFileSizeMB int      `json:"thumb_max_file_size_mb"`
 	IgnorePatterns     []string `json:"ignore_patterns"`
 
+	// URL path prefixes or globs that require login when EnableAuth is set;
+	// empty protects every path
+	ProtectedPaths []string `json:"protected_paths"`
+
+	// Log the first LogRequestBodyBytes of admin API request bodies, with
+	// credentials redacted; only takes effect when LogLevel is debug
+	LogRequestBodies    bool `json:"log_request_bodies"`
+	LogRequestBodyBytes int  `json:"log_request_body_bytes"`
+
+	// Percentages of MaxThumbCacheMB above which the thumbnail cache is pruned
+	// in the background, and down to which it is pruned; 0 uses 100 and 90
+	ThumbPruneHighPercent int `json:"thumb_prune_high_percent"`
+	ThumbPruneLowPercent  int `json:"thumb_prune_low_percent"`
+
+	// Cache-Control max-age in seconds for generated thumbnails; 0 omits the header
+	ThumbCacheMaxAge int `json:"thumb_cache_max_age"`
+
+	// How long a thumbnail may take to generate before the original is served; 0 waits indefinitely
+	ThumbGenTimeoutSeconds int `json:"thumb_gen_timeout_seconds"`
+
+	// Keep every frame of animated GIFs in their thumbnails instead of only the first
+	ThumbAnimated bool `json:"thumb_animated"`
+
+	// Serve WebP thumbnails to clients whose Accept header lists image/webp,
+	// JPEG to the rest; each format is cached separately
+	ThumbWebP bool `json:"thumb_webp"`
+
+	// Serve AVIF thumbnails to clients whose Accept header lists image/avif,
+	// ahead of WebP; encoding runs as WebAssembly and is markedly slower
+	ThumbAVIF bool `json:"thumb_avif"`
+
+	// Store cached thumbnails gzip-compressed; clients that accept gzip get
+	// the compressed file as is, others get it decompressed
+	ThumbCacheGzip bool `json:"thumb_cache_gzip"`
+
+	// Generate thumbnails for images uploaded through the admin interface
+	// right away, in the background, instead of on first view
+	ThumbOnUpload bool `json:"thumb_on_upload"`
+
+	// Watch the served directories and drop cached folder sizes, /recent and
+	// thumbnails of removed images as soon as files change; where watching is
+	// unsupported the caches simply expire
+	EnableFSWatch bool `json:"enable_fs_watch"`
+
+	// File names tried in order as a directory's index when ServeIndexHTML is
+	// set; empty means index.html
+	IndexFiles []string `json:"index_files"`
+
+	// HTML template rendered for / instead of the root listing; empty disables
+	LandingPage string `json:"landing_page"`
+
+	// HTML template rendered for directory listings instead of the built-in
+	// listing.html; empty uses the built-in one
+	ListingTemplatePath string `json:"listing_template_path"`
+
+	// Order of directory listings requested without sort and order query
+	// parameters; empty means name and asc. GroupFolders lists folders
+	// before files in either direction.
+	DefaultSort  string `json:"default_sort"`
+	DefaultOrder string `json:"default_order"`
+	GroupFolders bool   `json:"group_folders"`
+
+	// Notice shown above every directory listing, as plain text or inline HTML
+	// (a, b, strong, i, em, u, code, small, span, br); other markup is stripped
+	ListingBanner string `json:"listing_banner"`
+
+	// Answer every non-admin request with a 503 maintenance page; can be
+	// switched at runtime from the admin interface
+	MaintenanceMode bool `json:"maintenance_mode"`
+
+	// Plain text shown on the maintenance page; empty uses a generic notice
+	MaintenanceMessage string `json:"maintenance_message"`
+
+	// Image file served for /favicon.ico instead of the embedded icon
+	FaviconPath string `json:"favicon_path"`
+
+	// Served for /robots.txt, either inline or read from RobotsTxtPath; with
+	// neither set, auth-enabled servers disallow all crawling
+	RobotsTxt     string `json:"robots_txt"`
+	RobotsTxtPath string `json:"robots_txt_path"`
+
+	// Layout of listing modification times: a Go time layout or a name from
+	// DateFormatPresets; empty keeps "Jan 2, 2006 15:04"
+	DateFormat string `json:"date_format"`
+
+	// IANA time zone listing times are shown in, e.g. "Europe/Berlin"; empty
+	// uses the server's local zone
+	DisplayTimezone string `json:"display_timezone"`
+
+	// Longest file name shown in listings, in characters; longer names are
+	// cut short with an ellipsis while links keep the full name. 0 disables.
+	MaxDisplayNameLength int `json:"max_display_name_length"`
+
+	// Answer ?format=rss and ?format=atom on listings and /recent with a feed
+	// of their files
+	EnableFeeds bool `json:"enable_feeds"`
+
+	// Session and CSRF cookies are named <CookiePrefix>_session,
+	// <CookiePrefix>_admin_session and <CookiePrefix>_csrf_token, so instances
+	// sharing a domain can keep theirs apart
+	CookiePrefix   string `json:"cookie_prefix"`
+	CookieSameSite string `json:"cookie_same_site"` // "lax", "strict" or "none"
+	CookieDomain   string `json:"cookie_domain"`    // empty limits cookies to the host
+
+	// Extensions or filename globs that may be listed and served; empty allows
+	// every file. Directories are always listed.
+	AllowedServeTypes []string `json:"allowed_serve_types"`
+
+	// Show markdown files as HTML pages by default instead of only with
+	// ?render=1; ?raw=1 still serves the source
+	RenderMarkdown bool `json:"render_markdown"`
+
+	// Serve file.br or file.gz in place of file to clients that accept the encoding
+	ServePrecompressed bool `json:"serve_precompressed"`
+
+	// Largest file hashed for ?checksums=sha256 in JSON listings; 0 is no limit
+	MaxChecksumSizeMB int `json:"max_checksum_size_mb"`
+
+	// Extension to Content-Type overrides, consulted before the built-in MIME table
+	MimeOverrides map[string]string `json:"mime_overrides"`
+
+	// Extension to listing icon overrides, one of ListingIcons
+	IconOverrides map[string]string `json:"icon_overrides"`
+
+	// Local directories served under their own top-level URL segment
+	// (segment -> directory); when set they replace the storage path listing
+	Mounts map[string]string `json:"mounts"`
+
+	// Proxy IPs or CIDRs whose X-Forwarded-For and X-Real-IP headers are
+	// believed for the client IP; empty trusts no proxy
+	TrustedProxies []string `json:"trusted_proxies"`
+
+	// Internal nginx location files are handed to with X-Accel-Redirect when
+	// the request comes from a trusted proxy; empty streams them directly
+	XAccelRedirect string `json:"x_accel_redirect"`
+
+	// Content-Security-Policy for rendered pages; {nonce} is replaced per request
+	// and an empty value disables the header
+	ContentSecurityPolicy string `json:"content_security_policy"`
+
+	// How long in-flight requests get to finish on shutdown; 0 waits indefinitely
+	ShutdownTimeoutSeconds int `json:"shutdown_timeout_seconds"`
+
+	// Connection timeouts of the HTTP server; 0 disables each. Read and write
+	// timeouts also cap how long an upload or download may take.
+	ReadTimeoutSeconds  int `json:"read_timeout_seconds"`
+	WriteTimeoutSeconds int `json:"write_timeout_seconds"`
+	IdleTimeoutSeconds  int `json:"idle_timeout_seconds"`
+
+	// Deadline for a handler to start responding before it is answered with
+	// 503; 0 disables
+	HandlerTimeoutSeconds int `json:"handler_timeout_seconds"`
+
+	// Requests handled at once; further requests get 503 until one finishes.
+	// 0 is unlimited.
+	MaxConnections int `json:"max_connections"`
+
+	// Largest request header block in bytes; 0 uses net/http's 1 MB default
+	MaxHeaderBytes int `json:"max_header_bytes"`
+
+	// Longest request target (path and query) in bytes before the request is
+	// answered with 414; 0 disables the check
+	MaxURLLength int `json:"max_url_length"`
+
+	// Largest request body in MB for every route but uploads, which have
+	// MaxUploadSizeMB; larger bodies are answered with 413. 0 disables.
+	MaxRequestBodyMB int `json:"max_request_body_mb"`
+
+	// Header carrying the request ID that is read from proxies, generated when
+	// missing, echoed in responses and added to every log line of the request
+	RequestIDHeader string `json:"request_id_header"`
+
+	// host:port pairs to listen on at once, replacing Host and Port when set
+	ListenAddrs []string `json:"listen_addrs"`
+
+	// Accept HTTP/2 over plain TCP (h2c), for clients behind a TLS-terminating proxy
+	EnableH2C bool `json:"enable_h2c"`
+
+	// How often served directories are checked and reopened if recreated; 0 disables
+	RootHealthCheckSeconds int `json:"root_health_check_seconds"`
+
+	// Entries shown per directory listing, after sorting; 0 shows all
+	MaxListingItems int `json:"max_listing_items"`
+
+	// How many directory levels recursive walks descend below a root; 0 is unlimited
+	MaxTraversalDepth int `json:"max_traversal_depth"`
+
 	// Storage configuration (single backend: local or S3)
 	StoragePath string `json:"storage_path"`  // Path for local or bucket name for S3
 	StorageType string `json:"storage_type"`  // "local" or "s3"
@@ -50,6 +362,13 @@ type Config struct {
 	LRUEnabled  bool   `json:"lru_enabled"`
 	LRUMaxMB    int    `json:"lru_max_mb"`
 
+	// Local directory served when storage_path is unset; empty refuses to start instead
+	DefaultStoragePath string `json:"default_storage_path"`
+
+	// Refuse every write to the served tree, whatever the admin settings, and
+	// accept only GET and HEAD on file routes
+	ReadOnly bool `json:"read_only"`
+
 	// Admin configuration
 	EnableAdmin          bool     `json:"enable_admin"`
 	AdminUsername        string   `json:"admin_username"`
@@ -58,6 +377,32 @@ type Config struct {
 	MaxUploadSizeMB      int      `json:"max_upload_size_mb"`
 	AllowedUploadTypes   []string `json:"allowed_upload_types"`
 	MaxConcurrentUploads int      `json:"max_concurrent_uploads"`
+
+	// Upload bytes kept in memory per request; larger uploads spill to temporary files
+	MultipartMemoryMB int `json:"multipart_memory_mb"`
+
+	// How long an upload may go without progress before its slot is freed; 0 never frees it
+	UploadStaleSeconds int `json:"upload_stale_seconds"`
+
+	// Multipart fields whose file parts are uploaded; empty accepts "files" and "file"
+	UploadFieldNames []string `json:"upload_field_names"`
+
+	// What an upload does when its filename is taken: "rename", "overwrite",
+	// "reject" or "timestamp"
+	UploadConflictPolicy string `json:"upload_conflict_policy"`
+
+	// How long admin storage stats are cached; 0 recomputes on every request
+	AdminStatsRefreshSeconds int `json:"admin_stats_refresh_seconds"`
+
+	// JSON lines file admin activity is appended to, so it survives restarts;
+	// empty keeps only the recent entries in memory
+	AdminActivityLogFile string `json:"admin_activity_log_file"`
+
+	// Which source set each field, for the effective configuration view
+	provenance *provenance
+
+	// The configuration file that was loaded, written back by Save
+	file string
 }
 
 // GetStorageDir returns the storage directory configuration
@@ -73,19 +418,148 @@ func (c *Config) GetStorageDir() DirectoryConfig {
 			Prefix:    c.S3Prefix,
 		}
 	}
+	path := c.StoragePath
+	if path == "" {
+		path = c.DefaultStoragePath
+	}
 	return DirectoryConfig{
-		Path: c.StoragePath,
+		Path: path,
 		Type: BackendLocal,
 	}
 }
 
+// reservedMountNames are top-level segments used by SlimServe's own routes
+var reservedMountNames = []string{"static", "admin", "login", "version", "favicon.ico", "recent", "readyz", "robots.txt"}
+
+// IsValidMountName reports whether name can be used as a mount's URL segment
+func IsValidMountName(name string) bool {
+	return name != "" &&
+		!strings.ContainsAny(name, `/\`) &&
+		!strings.HasPrefix(name, ".") &&
+		!slices.Contains(reservedMountNames, name)
+}
+
+// invalidCookieNameRune reports runes not allowed in a cookie prefix. The set is
+// narrower than RFC 6265 tokens to keep names unambiguous.
+func invalidCookieNameRune(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.')
+}
+
+// ListenAddresses returns the addresses to serve on: ListenAddrs when set,
+// otherwise Host and Port.
+func (c *Config) ListenAddresses() []string {
+	if len(c.ListenAddrs) > 0 {
+		return c.ListenAddrs
+	}
+	return []string{net.JoinHostPort(c.Host, strconv.Itoa(c.Port))}
+}
+
+// URLPrefix returns BasePath normalized for prefixing generated links: empty
+// when serving from the root, otherwise a cleaned path without a trailing slash.
+func (c *Config) URLPrefix() string {
+	if c.BasePath == "" {
+		return ""
+	}
+	return strings.TrimSuffix(path.Clean("/"+c.BasePath), "/")
+}
+
+// DateLayout returns the Go time layout for listing modification times,
+// resolving DateFormat presets.
+func (c *Config) DateLayout() string {
+	if c.DateFormat == "" {
+		return DefaultDateFormat
+	}
+	if layout, ok := DateFormatPresets[strings.ToLower(c.DateFormat)]; ok {
+		return layout
+	}
+	return c.DateFormat
+}
+
+// DisplayLocation returns the zone listing times are shown in, the server's
+// local zone when DisplayTimezone is empty.
+func (c *Config) DisplayLocation() (*time.Location, error) {
+	if c.DisplayTimezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(c.DisplayTimezone)
+}
+
+// UploadFormFields returns the multipart field names uploads are read from,
+// falling back to "files" and "file" when none are configured.
+func (c *Config) UploadFormFields() []string {
+	if len(c.UploadFieldNames) == 0 {
+		return []string{"files", "file"}
+	}
+	return c.UploadFieldNames
+}
+
+// redactedValue replaces secrets in Redacted output
+const redactedValue = "[REDACTED]"
+
+// SessionCookieName is the cookie holding a user session
+func (c *Config) SessionCookieName() string {
+	return c.cookiePrefix() + "_session"
+}
+
+// AdminSessionCookieName is the cookie holding an admin session
+func (c *Config) AdminSessionCookieName() string {
+	return c.cookiePrefix() + "_admin_session"
+}
+
+// CSRFCookieName is the cookie holding the admin CSRF token
+func (c *Config) CSRFCookieName() string {
+	return c.cookiePrefix() + "_csrf_token"
+}
+
+func (c *Config) cookiePrefix() string {
+	return cmp.Or(c.CookiePrefix, DefaultCookiePrefix)
+}
+
+// RequestIDHeaderName returns RequestIDHeader, DefaultRequestIDHeader when unset
+func (c *Config) RequestIDHeaderName() string {
+	return cmp.Or(c.RequestIDHeader, DefaultRequestIDHeader)
+}
+
+// CookieSameSiteMode returns CookieSameSite as an http.SameSite, Lax when unset
+func (c *Config) CookieSameSiteMode() http.SameSite {
+	switch c.CookieSameSite {
+	case CookieSameSiteStrict:
+		return http.SameSiteStrictMode
+	case CookieSameSiteNone:
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// Redacted returns a copy of the configuration with secrets masked, suitable for display
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	for _, secret := range []*string{&redacted.Password, &redacted.AdminPassword, &redacted.S3SecretKey} {
+		if *secret != "" {
+			*secret = redactedValue
+		}
+	}
+	return &redacted
+}
+
 // Default returns a Config with default values
 func Default() *Config {
 	return &Config{
 		Host:               "0.0.0.0",
 		Port:               8080,
 		DisableDotFiles:    true,
+		SymlinkPolicy:      SymlinkFollow,
+		DefaultSort:        SortName,
+		DefaultOrder:       OrderAsc,
+		GroupFolders:       true,
+		Theme:              ThemeAuto,
+		SendServerHeader:   true,
 		LogLevel:           "info",
+		LogMaxSizeMB:       100,
+		LogMaxBackups:      3,
+		LogMaxAgeDays:      28,
+		LogToStderr:        true,
 		EnableAuth:         false,
 		Username:           "",
 		Password:           "",
@@ -94,16 +568,336 @@ func Default() *Config {
 		ThumbMaxFileSizeMB: 10,
 		IgnorePatterns:     []string{},
 
-		StoragePath: ".",
+		LogRequestBodyBytes: 4096,
+
+		IndexFiles: []string{"index.html"},
+
+		MaxChecksumSizeMB: 64,
+
+		CookiePrefix:   DefaultCookiePrefix,
+		CookieSameSite: CookieSameSiteLax,
+
+		ThumbCacheMaxAge: 86400,
+		ThumbWebP:        true,
+
+		ThumbGenTimeoutSeconds: 10,
+
+		ContentSecurityPolicy: DefaultContentSecurityPolicy,
+
+		ShutdownTimeoutSeconds: 5,
+
+		IdleTimeoutSeconds: 120,
+
+		MaxURLLength: 8192,
+
+		MaxRequestBodyMB: 1,
+
+		RequestIDHeader: DefaultRequestIDHeader,
+
+		RootHealthCheckSeconds: 30,
+
+		MaxTraversalDepth: 32,
+
+		StoragePath: "",
 		StorageType: BackendLocal,
 		LRUEnabled:  true,
 		LRUMaxMB:    0,
 
+		DefaultStoragePath: "./public",
+
 		EnableAdmin:          false,
 		AdminUsername:        "",
 		AdminPassword:        "",
 		MaxUploadSizeMB:      100,
 		AllowedUploadTypes:   []string{"*"},
 		MaxConcurrentUploads: 3,
+
+		MultipartMemoryMB:  8,
+		UploadStaleSeconds: 1800,
+
+		UploadFieldNames:     []string{"files", "file"},
+		UploadConflictPolicy: UploadConflictRename,
+
+		AdminStatsRefreshSeconds: 60,
+	}
+}
+
+// Validate checks the configuration for values that would otherwise only
+// surface as confusing failures at runtime. All problems are reported
+// together in a single error.
+func (c *Config) Validate() error {
+	var errs []error
+
+	for _, addr := range c.ListenAddrs {
+		if _, port, err := net.SplitHostPort(addr); err != nil {
+			errs = append(errs, fmt.Errorf("listen_addrs entry %q must be host:port: %w", addr, err))
+		} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+			errs = append(errs, fmt.Errorf("listen_addrs entry %q has an invalid port", addr))
+		}
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port must be between 1 and 65535, got %d", c.Port))
+	}
+
+	nonNegative := []struct {
+		name  string
+		value int
+	}{
+		{"shutdown_timeout_seconds", c.ShutdownTimeoutSeconds},
+		{"read_timeout_seconds", c.ReadTimeoutSeconds},
+		{"write_timeout_seconds", c.WriteTimeoutSeconds},
+		{"idle_timeout_seconds", c.IdleTimeoutSeconds},
+		{"handler_timeout_seconds", c.HandlerTimeoutSeconds},
+		{"max_connections", c.MaxConnections},
+		{"max_header_bytes", c.MaxHeaderBytes},
+		{"max_url_length", c.MaxURLLength},
+		{"max_request_body_mb", c.MaxRequestBodyMB},
+		{"root_health_check_seconds", c.RootHealthCheckSeconds},
+		{"max_traversal_depth", c.MaxTraversalDepth},
+		{"max_listing_items", c.MaxListingItems},
+		{"max_display_name_length", c.MaxDisplayNameLength},
+		{"max_checksum_size_mb", c.MaxChecksumSizeMB},
+		{"log_request_body_bytes", c.LogRequestBodyBytes},
+		{"log_max_size_mb", c.LogMaxSizeMB},
+		{"log_max_backups", c.LogMaxBackups},
+		{"log_max_age_days", c.LogMaxAgeDays},
+		{"thumb_cache_mb", c.MaxThumbCacheMB},
+		{"thumb_max_file_size_mb", c.ThumbMaxFileSizeMB},
+		{"thumb_cache_max_age", c.ThumbCacheMaxAge},
+		{"thumb_gen_timeout_seconds", c.ThumbGenTimeoutSeconds},
+		{"lru_max_mb", c.LRUMaxMB},
+		{"max_upload_size_mb", c.MaxUploadSizeMB},
+		{"max_concurrent_uploads", c.MaxConcurrentUploads},
+		{"multipart_memory_mb", c.MultipartMemoryMB},
+		{"upload_stale_seconds", c.UploadStaleSeconds},
+		{"admin_stats_refresh_seconds", c.AdminStatsRefreshSeconds},
+	}
+	for _, field := range nonNegative {
+		if field.value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %d", field.name, field.value))
+		}
+	}
+
+	switch c.AccessLogFormat {
+	case "", AccessLogCombined, AccessLogJSON:
+	default:
+		if _, err := template.New("access_log").Parse(c.AccessLogFormat); err != nil {
+			errs = append(errs, fmt.Errorf("access_log_format is not a valid template: %w", err))
+		}
+	}
+
+	if c.BasePath != "" && (!strings.HasPrefix(c.BasePath, "/") || slices.Contains(strings.Split(c.BasePath, "/"), "..")) {
+		errs = append(errs, fmt.Errorf("base_path must be an absolute URL path such as /files, got %q", c.BasePath))
+	}
+
+	if c.XAccelRedirect != "" && !strings.HasPrefix(c.XAccelRedirect, "/") {
+		errs = append(errs, fmt.Errorf("x_accel_redirect must be an absolute URL path such as /internal, got %q", c.XAccelRedirect))
+	}
+
+	switch c.SymlinkPolicy {
+	case "", SymlinkDeny, SymlinkFollow, SymlinkShow:
+	default:
+		errs = append(errs, fmt.Errorf("symlink_policy must be %q, %q or %q, got %q", SymlinkDeny, SymlinkFollow, SymlinkShow, c.SymlinkPolicy))
+	}
+
+	switch c.UploadConflictPolicy {
+	case "", UploadConflictRename, UploadConflictOverwrite, UploadConflictReject, UploadConflictTimestamp:
+	default:
+		errs = append(errs, fmt.Errorf("upload_conflict_policy must be %q, %q, %q or %q, got %q",
+			UploadConflictRename, UploadConflictOverwrite, UploadConflictReject, UploadConflictTimestamp, c.UploadConflictPolicy))
+	}
+
+	if c.CookiePrefix != "" && strings.IndexFunc(c.CookiePrefix, invalidCookieNameRune) >= 0 {
+		errs = append(errs, fmt.Errorf("cookie_prefix may only contain letters, digits, '-', '_' and '.', got %q", c.CookiePrefix))
+	}
+
+	if c.RequestIDHeader != "" && strings.IndexFunc(c.RequestIDHeader, invalidCookieNameRune) >= 0 {
+		errs = append(errs, fmt.Errorf("request_id_header may only contain letters, digits, '-', '_' and '.', got %q", c.RequestIDHeader))
+	}
+
+	switch c.CookieSameSite {
+	case "", CookieSameSiteLax, CookieSameSiteStrict, CookieSameSiteNone:
+	default:
+		errs = append(errs, fmt.Errorf("cookie_same_site must be %q, %q or %q, got %q",
+			CookieSameSiteLax, CookieSameSiteStrict, CookieSameSiteNone, c.CookieSameSite))
+	}
+
+	if strings.ContainsAny(c.CookieDomain, " ;,") {
+		errs = append(errs, fmt.Errorf("cookie_domain must be a domain name, got %q", c.CookieDomain))
+	}
+
+	switch c.Theme {
+	case "", ThemeLight, ThemeDark, ThemeAuto:
+	default:
+		errs = append(errs, fmt.Errorf("theme must be %q, %q or %q, got %q", ThemeLight, ThemeDark, ThemeAuto, c.Theme))
+	}
+
+	for ext, mimeType := range c.MimeOverrides {
+		if _, _, err := mime.ParseMediaType(mimeType); err != nil {
+			errs = append(errs, fmt.Errorf("mime_overrides[%q] is not a valid content type: %q", ext, mimeType))
+		}
+	}
+
+	for ext, icon := range c.IconOverrides {
+		if !slices.Contains(ListingIcons, icon) {
+			errs = append(errs, fmt.Errorf("icon_overrides[%q] must be one of %s, got %q", ext, strings.Join(ListingIcons, ", "), icon))
+		}
+	}
+
+	for _, name := range c.IndexFiles {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			errs = append(errs, fmt.Errorf("index_files entry %q must be a plain file name", name))
+		}
+	}
+
+	if c.LandingPage != "" {
+		// base is supplied by the server when the page is rendered
+		funcs := htmltemplate.FuncMap{"base": func() string { return "" }}
+		if _, err := htmltemplate.New("landing_page").Funcs(funcs).ParseFiles(c.LandingPage); err != nil {
+			errs = append(errs, fmt.Errorf("landing_page %q cannot be loaded: %w", c.LandingPage, err))
+		}
+	}
+
+	if c.ListingTemplatePath != "" {
+		funcs := htmltemplate.FuncMap{"base": func() string { return "" }}
+		if _, err := htmltemplate.New("listing_template").Funcs(funcs).ParseFiles(c.ListingTemplatePath); err != nil {
+			errs = append(errs, fmt.Errorf("listing_template_path %q cannot be loaded: %w", c.ListingTemplatePath, err))
+		}
+	}
+
+	if len(c.ListingBanner) > MaxListingBannerLength {
+		errs = append(errs, fmt.Errorf("listing_banner must be at most %d bytes, got %d", MaxListingBannerLength, len(c.ListingBanner)))
+	}
+
+	// A layout without any reference component would print the same text
+	// for every time
+	if layout := c.DateLayout(); time.Unix(0, 0).UTC().Format(layout) == layout {
+		errs = append(errs, fmt.Errorf("date_format %q is neither a preset nor a Go time layout", c.DateFormat))
+	}
+
+	if _, err := c.DisplayLocation(); err != nil {
+		errs = append(errs, fmt.Errorf("display_timezone %q is not a known time zone: %w", c.DisplayTimezone, err))
+	}
+
+	if c.DefaultSort != "" && !slices.Contains(ListingSortKeys, c.DefaultSort) {
+		errs = append(errs, fmt.Errorf("default_sort must be %q, %q or %q, got %q", SortName, SortSize, SortModified, c.DefaultSort))
+	}
+	switch c.DefaultOrder {
+	case "", OrderAsc, OrderDesc:
+	default:
+		errs = append(errs, fmt.Errorf("default_order must be %q or %q, got %q", OrderAsc, OrderDesc, c.DefaultOrder))
+	}
+
+	if c.FaviconPath != "" {
+		if info, err := os.Stat(c.FaviconPath); err != nil {
+			errs = append(errs, fmt.Errorf("favicon_path %q does not exist or is not accessible: %w", c.FaviconPath, err))
+		} else if !info.Mode().IsRegular() {
+			errs = append(errs, fmt.Errorf("favicon_path %q is not a regular file", c.FaviconPath))
+		}
+	}
+
+	if c.RobotsTxtPath != "" {
+		if c.RobotsTxt != "" {
+			errs = append(errs, errors.New("robots_txt and robots_txt_path are mutually exclusive"))
+		}
+		if info, err := os.Stat(c.RobotsTxtPath); err != nil {
+			errs = append(errs, fmt.Errorf("robots_txt_path %q does not exist or is not accessible: %w", c.RobotsTxtPath, err))
+		} else if !info.Mode().IsRegular() {
+			errs = append(errs, fmt.Errorf("robots_txt_path %q is not a regular file", c.RobotsTxtPath))
+		}
+	}
+
+	for _, pattern := range c.AllowedServeTypes {
+		if _, err := path.Match(strings.ToLower(strings.TrimSpace(pattern)), ""); err != nil {
+			errs = append(errs, fmt.Errorf("allowed_serve_types entry %q is not a valid glob: %w", pattern, err))
+		}
+	}
+
+	for _, pattern := range c.ProtectedPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("protected_paths entry %q is not a valid glob: %w", pattern, err))
+		}
+	}
+
+	if c.ThumbJpegQuality < 1 || c.ThumbJpegQuality > 100 {
+		errs = append(errs, fmt.Errorf("thumb_jpeg_quality must be between 1 and 100, got %d", c.ThumbJpegQuality))
+	}
+
+	for _, field := range []struct {
+		name  string
+		value int
+	}{
+		{"thumb_prune_high_percent", c.ThumbPruneHighPercent},
+		{"thumb_prune_low_percent", c.ThumbPruneLowPercent},
+	} {
+		if field.value < 0 || field.value > 100 {
+			errs = append(errs, fmt.Errorf("%s must be between 0 and 100, got %d", field.name, field.value))
+		}
+	}
+	if c.ThumbPruneHighPercent > 0 && c.ThumbPruneLowPercent > c.ThumbPruneHighPercent {
+		errs = append(errs, fmt.Errorf("thumb_prune_low_percent %d must not exceed thumb_prune_high_percent %d", c.ThumbPruneLowPercent, c.ThumbPruneHighPercent))
+	}
+
+	storageDir := c.GetStorageDir()
+	switch {
+	case c.StorageType != "" && c.StorageType != BackendLocal && c.StorageType != BackendS3:
+		errs = append(errs, fmt.Errorf("storage_type must be %q or %q, got %q", BackendLocal, BackendS3, c.StorageType))
+	case storageDir.IsS3():
+		if storageDir.Path == "" {
+			errs = append(errs, errors.New("storage_path must name an S3 bucket when storage_type is s3"))
+		}
+	case storageDir.Path == "":
+		errs = append(errs, errors.New("storage_path is not set; set it or default_storage_path to choose the directory to serve"))
+	case c.StoragePath == "":
+		if info, err := os.Stat(storageDir.Path); err != nil {
+			errs = append(errs, fmt.Errorf("storage_path is not set and default_storage_path %q does not exist or is not accessible: %w", storageDir.Path, err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("storage_path is not set and default_storage_path %q is not a directory", storageDir.Path))
+		}
+	default:
+		if info, err := os.Stat(storageDir.Path); err != nil {
+			errs = append(errs, fmt.Errorf("storage_path %q does not exist or is not accessible: %w", storageDir.Path, err))
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			errs = append(errs, fmt.Errorf("storage_path %q is not a directory or regular file", storageDir.Path))
+		} else if !info.IsDir() && c.EnableAdmin {
+			errs = append(errs, fmt.Errorf("enable_admin is not supported when storage_path %q is a single file", storageDir.Path))
+		}
+	}
+
+	if len(c.Mounts) > 0 && storageDir.IsS3() {
+		errs = append(errs, errors.New("mounts require local storage"))
+	}
+	for name, dir := range c.Mounts {
+		if !IsValidMountName(name) {
+			errs = append(errs, fmt.Errorf("mounts[%q] must be a single URL segment that does not start with a dot or clash with a built-in route", name))
+		}
+		if info, err := os.Stat(dir); err != nil {
+			errs = append(errs, fmt.Errorf("mounts[%q] directory %q does not exist or is not accessible: %w", name, dir, err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("mounts[%q] path %q is not a directory", name, dir))
+		}
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				errs = append(errs, fmt.Errorf("trusted_proxies entry %q must be an IP address or CIDR", proxy))
+			}
+		}
+	}
+
+	if c.EnableAdmin {
+		if c.AdminUsername == "" {
+			errs = append(errs, errors.New("admin_username must be set when enable_admin is true"))
+		}
+		if c.AdminPassword == "" && c.AdminPasswordHash == "" {
+			errs = append(errs, errors.New("admin_password must be set when enable_admin is true"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
 	}
+	return nil
 }
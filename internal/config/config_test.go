@@ -0,0 +1,505 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tmpDir := t.TempDir()
+	regularFile := filepath.Join(tmpDir, "file.txt")
+	if err := os.WriteFile(regularFile, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	validConfig := func() *Config {
+		cfg := Default()
+		cfg.StoragePath = tmpDir
+		return cfg
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr []string
+	}{
+		{
+			name:   "valid_defaults",
+			modify: func(cfg *Config) {},
+		},
+		{
+			name:    "port_zero",
+			modify:  func(cfg *Config) { cfg.Port = 0 },
+			wantErr: []string{"port must be between 1 and 65535, got 0"},
+		},
+		{
+			name:    "port_too_large",
+			modify:  func(cfg *Config) { cfg.Port = 70000 },
+			wantErr: []string{"port must be between 1 and 65535, got 70000"},
+		},
+		{
+			name:    "listen_addr_without_port",
+			modify:  func(cfg *Config) { cfg.ListenAddrs = []string{"127.0.0.1:8080", "localhost"} },
+			wantErr: []string{`listen_addrs entry "localhost" must be host:port`},
+		},
+		{
+			name:    "listen_addr_bad_port",
+			modify:  func(cfg *Config) { cfg.ListenAddrs = []string{"[::1]:http"} },
+			wantErr: []string{`listen_addrs entry "[::1]:http" has an invalid port`},
+		},
+		{
+			name:    "negative_thumb_cache",
+			modify:  func(cfg *Config) { cfg.MaxThumbCacheMB = -1 },
+			wantErr: []string{"thumb_cache_mb must not be negative, got -1"},
+		},
+		{
+			name:    "negative_thumb_max_file_size",
+			modify:  func(cfg *Config) { cfg.ThumbMaxFileSizeMB = -5 },
+			wantErr: []string{"thumb_max_file_size_mb must not be negative, got -5"},
+		},
+		{
+			name:    "negative_lru_max",
+			modify:  func(cfg *Config) { cfg.LRUMaxMB = -2 },
+			wantErr: []string{"lru_max_mb must not be negative, got -2"},
+		},
+		{
+			name:    "negative_max_upload_size",
+			modify:  func(cfg *Config) { cfg.MaxUploadSizeMB = -10 },
+			wantErr: []string{"max_upload_size_mb must not be negative, got -10"},
+		},
+		{
+			name:    "negative_max_concurrent_uploads",
+			modify:  func(cfg *Config) { cfg.MaxConcurrentUploads = -1 },
+			wantErr: []string{"max_concurrent_uploads must not be negative, got -1"},
+		},
+		{
+			name:    "negative_multipart_memory",
+			modify:  func(cfg *Config) { cfg.MultipartMemoryMB = -1 },
+			wantErr: []string{"multipart_memory_mb must not be negative, got -1"},
+		},
+		{
+			name:    "negative_upload_stale_seconds",
+			modify:  func(cfg *Config) { cfg.UploadStaleSeconds = -1 },
+			wantErr: []string{"upload_stale_seconds must not be negative, got -1"},
+		},
+		{
+			name:    "jpeg_quality_zero",
+			modify:  func(cfg *Config) { cfg.ThumbJpegQuality = 0 },
+			wantErr: []string{"thumb_jpeg_quality must be between 1 and 100, got 0"},
+		},
+		{
+			name:    "jpeg_quality_too_high",
+			modify:  func(cfg *Config) { cfg.ThumbJpegQuality = 101 },
+			wantErr: []string{"thumb_jpeg_quality must be between 1 and 100, got 101"},
+		},
+		{
+			name:    "thumb_prune_percent_out_of_range",
+			modify:  func(cfg *Config) { cfg.ThumbPruneHighPercent = 120 },
+			wantErr: []string{"thumb_prune_high_percent must be between 0 and 100, got 120"},
+		},
+		{
+			name: "thumb_prune_low_above_high",
+			modify: func(cfg *Config) {
+				cfg.ThumbPruneHighPercent = 70
+				cfg.ThumbPruneLowPercent = 80
+			},
+			wantErr: []string{"thumb_prune_low_percent 80 must not exceed thumb_prune_high_percent 70"},
+		},
+		{
+			name:    "default_sort_unknown",
+			modify:  func(cfg *Config) { cfg.DefaultSort = "date" },
+			wantErr: []string{`default_sort must be "name", "size" or "modified", got "date"`},
+		},
+		{
+			name:    "default_order_unknown",
+			modify:  func(cfg *Config) { cfg.DefaultOrder = "descending" },
+			wantErr: []string{`default_order must be "asc" or "desc", got "descending"`},
+		},
+		{
+			name:    "invalid_access_log_template",
+			modify:  func(cfg *Config) { cfg.AccessLogFormat = "{{.Method" },
+			wantErr: []string{"access_log_format is not a valid template"},
+		},
+		{
+			name:   "access_log_template",
+			modify: func(cfg *Config) { cfg.AccessLogFormat = "{{.Method}} {{.Path}}" },
+		},
+		{
+			name:    "cookie_prefix_with_separator",
+			modify:  func(cfg *Config) { cfg.CookiePrefix = "app;one" },
+			wantErr: []string{`cookie_prefix may only contain letters, digits, '-', '_' and '.', got "app;one"`},
+		},
+		{
+			name:    "request_id_header_with_space",
+			modify:  func(cfg *Config) { cfg.RequestIDHeader = "X Request" },
+			wantErr: []string{`request_id_header may only contain letters, digits, '-', '_' and '.', got "X Request"`},
+		},
+		{
+			name:    "unknown_cookie_same_site",
+			modify:  func(cfg *Config) { cfg.CookieSameSite = "relaxed" },
+			wantErr: []string{`cookie_same_site must be "lax", "strict" or "none", got "relaxed"`},
+		},
+		{
+			name:    "unknown_symlink_policy",
+			modify:  func(cfg *Config) { cfg.SymlinkPolicy = "ignore" },
+			wantErr: []string{`symlink_policy must be "deny", "follow" or "show", got "ignore"`},
+		},
+		{
+			name:    "unknown_upload_conflict_policy",
+			modify:  func(cfg *Config) { cfg.UploadConflictPolicy = "skip" },
+			wantErr: []string{`upload_conflict_policy must be "rename", "overwrite", "reject" or "timestamp", got "skip"`},
+		},
+		{
+			name:    "unknown_theme",
+			modify:  func(cfg *Config) { cfg.Theme = "sepia" },
+			wantErr: []string{`theme must be "light", "dark" or "auto", got "sepia"`},
+		},
+		{
+			name:    "invalid_mime_override",
+			modify:  func(cfg *Config) { cfg.MimeOverrides = map[string]string{".md": "not a type"} },
+			wantErr: []string{`mime_overrides[".md"] is not a valid content type`},
+		},
+		{
+			name:   "valid_mime_override",
+			modify: func(cfg *Config) { cfg.MimeOverrides = map[string]string{".md": "text/markdown; charset=utf-8"} },
+		},
+		{
+			name:   "mounts",
+			modify: func(cfg *Config) { cfg.Mounts = map[string]string{"photos": tmpDir, "docs": tmpDir} },
+		},
+		{
+			name:    "mount_reserved_name",
+			modify:  func(cfg *Config) { cfg.Mounts = map[string]string{"static": tmpDir} },
+			wantErr: []string{`mounts["static"] must be a single URL segment`},
+		},
+		{
+			name:    "mount_named_recent",
+			modify:  func(cfg *Config) { cfg.Mounts = map[string]string{"recent": tmpDir} },
+			wantErr: []string{`mounts["recent"] must be a single URL segment`},
+		},
+		{
+			name:    "mount_named_readyz",
+			modify:  func(cfg *Config) { cfg.Mounts = map[string]string{"readyz": tmpDir} },
+			wantErr: []string{`mounts["readyz"] must be a single URL segment`},
+		},
+		{
+			name:    "mount_named_robots_txt",
+			modify:  func(cfg *Config) { cfg.Mounts = map[string]string{"robots.txt": tmpDir} },
+			wantErr: []string{`mounts["robots.txt"] must be a single URL segment`},
+		},
+		{
+			name:    "mount_nested_name",
+			modify:  func(cfg *Config) { cfg.Mounts = map[string]string{"a/b": tmpDir} },
+			wantErr: []string{`mounts["a/b"] must be a single URL segment`},
+		},
+		{
+			name:    "mount_missing_directory",
+			modify:  func(cfg *Config) { cfg.Mounts = map[string]string{"photos": filepath.Join(tmpDir, "missing")} },
+			wantErr: []string{`mounts["photos"] directory`, "does not exist"},
+		},
+		{
+			name:    "mount_not_directory",
+			modify:  func(cfg *Config) { cfg.Mounts = map[string]string{"photos": regularFile} },
+			wantErr: []string{`mounts["photos"] path`, "is not a directory"},
+		},
+		{
+			name:    "trusted_proxy_invalid",
+			modify:  func(cfg *Config) { cfg.TrustedProxies = []string{"10.0.0.0/8", "proxy.local"} },
+			wantErr: []string{`trusted_proxies entry "proxy.local" must be an IP address or CIDR`},
+		},
+		{
+			name:    "allowed_serve_type_bad_glob",
+			modify:  func(cfg *Config) { cfg.AllowedServeTypes = []string{"jpg", "[a-"} },
+			wantErr: []string{`allowed_serve_types entry "[a-" is not a valid glob`},
+		},
+		{
+			name:    "index_file_with_path",
+			modify:  func(cfg *Config) { cfg.IndexFiles = []string{"index.html", "../index.html"} },
+			wantErr: []string{`index_files entry "../index.html" must be a plain file name`},
+		},
+		{
+			name:    "landing_page_missing",
+			modify:  func(cfg *Config) { cfg.LandingPage = "/nonexistent/landing.html" },
+			wantErr: []string{`landing_page "/nonexistent/landing.html" cannot be loaded`},
+		},
+		{
+			name:    "listing_template_missing",
+			modify:  func(cfg *Config) { cfg.ListingTemplatePath = "/nonexistent/listing.html" },
+			wantErr: []string{`listing_template_path "/nonexistent/listing.html" cannot be loaded`},
+		},
+		{
+			name:    "listing_banner_too_long",
+			modify:  func(cfg *Config) { cfg.ListingBanner = strings.Repeat("x", MaxListingBannerLength+1) },
+			wantErr: []string{"listing_banner must be at most 2000 bytes"},
+		},
+		{
+			name:    "date_format_preset",
+			modify:  func(cfg *Config) { cfg.DateFormat = "ISO-8601"; cfg.DisplayTimezone = "Europe/Berlin" },
+			wantErr: nil,
+		},
+		{
+			name:    "date_format_not_a_layout",
+			modify:  func(cfg *Config) { cfg.DateFormat = "yyyy-mm-dd" },
+			wantErr: []string{`date_format "yyyy-mm-dd" is neither a preset nor a Go time layout`},
+		},
+		{
+			name:    "display_timezone_unknown",
+			modify:  func(cfg *Config) { cfg.DisplayTimezone = "Mars/Olympus" },
+			wantErr: []string{`display_timezone "Mars/Olympus" is not a known time zone`},
+		},
+		{
+			name:    "favicon_missing",
+			modify:  func(cfg *Config) { cfg.FaviconPath = "/nonexistent/favicon.ico" },
+			wantErr: []string{`favicon_path "/nonexistent/favicon.ico" does not exist`},
+		},
+		{
+			name:    "favicon_is_directory",
+			modify:  func(cfg *Config) { cfg.FaviconPath = os.TempDir() },
+			wantErr: []string{"is not a regular file"},
+		},
+		{
+			name:    "robots_txt_path_missing",
+			modify:  func(cfg *Config) { cfg.RobotsTxtPath = "/nonexistent/robots.txt" },
+			wantErr: []string{`robots_txt_path "/nonexistent/robots.txt" does not exist`},
+		},
+		{
+			name: "robots_txt_both_set",
+			modify: func(cfg *Config) {
+				cfg.RobotsTxt = "User-agent: *"
+				cfg.RobotsTxtPath = os.TempDir()
+			},
+			wantErr: []string{"robots_txt and robots_txt_path are mutually exclusive", "is not a regular file"},
+		},
+		{
+			name:    "protected_path_bad_glob",
+			modify:  func(cfg *Config) { cfg.ProtectedPaths = []string{"/private/[a-"} },
+			wantErr: []string{`protected_paths entry "/private/[a-" is not a valid glob`},
+		},
+		{
+			name:    "relative_x_accel_redirect",
+			modify:  func(cfg *Config) { cfg.XAccelRedirect = "internal" },
+			wantErr: []string{`x_accel_redirect must be an absolute URL path such as /internal, got "internal"`},
+		},
+		{
+			name:    "relative_base_path",
+			modify:  func(cfg *Config) { cfg.BasePath = "files" },
+			wantErr: []string{`base_path must be an absolute URL path such as /files, got "files"`},
+		},
+		{
+			name:    "base_path_traversal",
+			modify:  func(cfg *Config) { cfg.BasePath = "/files/../admin" },
+			wantErr: []string{"base_path must be an absolute URL path"},
+		},
+		{
+			name:   "base_path",
+			modify: func(cfg *Config) { cfg.BasePath = "/files/" },
+		},
+		{
+			name:    "storage_path_missing",
+			modify:  func(cfg *Config) { cfg.StoragePath = filepath.Join(tmpDir, "missing") },
+			wantErr: []string{"does not exist or is not accessible"},
+		},
+		{
+			name:   "icon_override",
+			modify: func(cfg *Config) { cfg.IconOverrides = map[string]string{".epub": "file-text"} },
+		},
+		{
+			name:    "unknown_icon_override",
+			modify:  func(cfg *Config) { cfg.IconOverrides = map[string]string{".epub": "book"} },
+			wantErr: []string{`icon_overrides[".epub"] must be one of file, folder, image`},
+		},
+		{
+			name: "storage_path_unset_uses_default",
+			modify: func(cfg *Config) {
+				cfg.StoragePath = ""
+				cfg.DefaultStoragePath = tmpDir
+			},
+		},
+		{
+			name: "storage_path_unset_default_missing",
+			modify: func(cfg *Config) {
+				cfg.StoragePath = ""
+				cfg.DefaultStoragePath = filepath.Join(tmpDir, "public")
+			},
+			wantErr: []string{"storage_path is not set and default_storage_path"},
+		},
+		{
+			name: "storage_path_unset_default_single_file",
+			modify: func(cfg *Config) {
+				cfg.StoragePath = ""
+				cfg.DefaultStoragePath = regularFile
+			},
+			wantErr: []string{"is not a directory"},
+		},
+		{
+			name: "storage_path_unset_without_default",
+			modify: func(cfg *Config) {
+				cfg.StoragePath = ""
+				cfg.DefaultStoragePath = ""
+			},
+			wantErr: []string{"storage_path is not set; set it or default_storage_path"},
+		},
+		{
+			name:   "storage_path_single_file",
+			modify: func(cfg *Config) { cfg.StoragePath = regularFile },
+		},
+		{
+			name:    "storage_path_not_file_or_directory",
+			modify:  func(cfg *Config) { cfg.StoragePath = os.DevNull },
+			wantErr: []string{"is not a directory or regular file"},
+		},
+		{
+			name: "single_file_with_admin",
+			modify: func(cfg *Config) {
+				cfg.StoragePath = regularFile
+				cfg.EnableAdmin = true
+				cfg.AdminUsername = "admin"
+				cfg.AdminPassword = "secret"
+			},
+			wantErr: []string{"enable_admin is not supported"},
+		},
+		{
+			name:    "unknown_storage_type",
+			modify:  func(cfg *Config) { cfg.StorageType = "ftp" },
+			wantErr: []string{`storage_type must be "local" or "s3", got "ftp"`},
+		},
+		{
+			name: "s3_without_bucket",
+			modify: func(cfg *Config) {
+				cfg.StorageType = BackendS3
+				cfg.StoragePath = ""
+			},
+			wantErr: []string{"storage_path must name an S3 bucket"},
+		},
+		{
+			name: "s3_bucket_not_checked_on_disk",
+			modify: func(cfg *Config) {
+				cfg.StorageType = BackendS3
+				cfg.StoragePath = "my-bucket"
+			},
+		},
+		{
+			name:   "admin_without_credentials",
+			modify: func(cfg *Config) { cfg.EnableAdmin = true },
+			wantErr: []string{
+				"admin_username must be set when enable_admin is true",
+				"admin_password must be set when enable_admin is true",
+			},
+		},
+		{
+			name: "admin_with_password_hash",
+			modify: func(cfg *Config) {
+				cfg.EnableAdmin = true
+				cfg.AdminUsername = "admin"
+				cfg.AdminPasswordHash = "$2a$10$hash"
+			},
+		},
+		{
+			name: "multiple_errors_aggregated",
+			modify: func(cfg *Config) {
+				cfg.Port = -1
+				cfg.ThumbJpegQuality = 200
+				cfg.MaxThumbCacheMB = -1
+			},
+			wantErr: []string{
+				"invalid configuration",
+				"port must be between 1 and 65535, got -1",
+				"thumb_jpeg_quality must be between 1 and 100, got 200",
+				"thumb_cache_mb must not be negative, got -1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() returned unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("Validate() expected an error, got nil")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected error to contain %q, got: %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadValidatesConfig(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	cleanupEnv := setEnvVars(t, map[string]string{
+		"SLIMSERVE_PORT": "70000",
+	})
+	defer cleanupEnv()
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected Load() to reject an out-of-range port, got nil")
+	}
+	if !strings.Contains(err.Error(), "port must be between 1 and 65535") {
+		t.Errorf("Expected port validation error, got: %v", err)
+	}
+}
+
+func TestGetStorageDirDefaultPath(t *testing.T) {
+	cfg := Default()
+	if got := cfg.GetStorageDir().Path; got != "./public" {
+		t.Errorf("unset storage_path should serve %q, got %q", "./public", got)
+	}
+
+	cfg.DefaultStoragePath = ""
+	if got := cfg.GetStorageDir().Path; got != "" {
+		t.Errorf("unset storage_path without a default should stay empty, got %q", got)
+	}
+
+	cfg.StoragePath = "/srv/files"
+	if got := cfg.GetStorageDir().Path; got != "/srv/files" {
+		t.Errorf("storage_path should win over the default, got %q", got)
+	}
+}
+
+func TestLoadRefusesUnsetStoragePath(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	os.Args = []string{"slimserve", "-default-storage-path="}
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected Load() to refuse an unset storage path, got nil")
+	}
+	if !strings.Contains(err.Error(), "storage_path is not set") {
+		t.Errorf("Expected storage path error, got: %v", err)
+	}
+}
+
+func TestURLPrefix(t *testing.T) {
+	tests := map[string]string{
+		"":             "",
+		"/":            "",
+		"/files":       "/files",
+		"/files/":      "/files",
+		"/a//b/":       "/a/b",
+		"/share/files": "/share/files",
+	}
+	for basePath, want := range tests {
+		cfg := &Config{BasePath: basePath}
+		if got := cfg.URLPrefix(); got != want {
+			t.Errorf("URLPrefix() with BasePath %q = %q, want %q", basePath, got, want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+const (
+	defaultEnvFile = ".env"
+	envFileVar     = "SLIMSERVE_ENV_FILE"
+	envVarPrefix   = "SLIMSERVE_"
+)
+
+// loadDotEnv reads SLIMSERVE_* variables from a .env file into the process
+// environment. Variables already present in the real environment win. A
+// missing default .env file is not an error; a missing SLIMSERVE_ENV_FILE is.
+func loadDotEnv() error {
+	filename := os.Getenv(envFileVar)
+	explicit := filename != ""
+	if !explicit {
+		filename = defaultEnvFile
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	vars, err := parseDotEnv(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse env file %q: %w", filename, err)
+	}
+
+	for key, value := range vars {
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s from env file: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// parseDotEnv parses KEY=VALUE lines, keeping only SLIMSERVE_* keys.
+// Blank lines, # comments, an optional "export " prefix and surrounding
+// single or double quotes are supported.
+func parseDotEnv(r io.Reader) (map[string]string, error) {
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+
+		key = strings.TrimSpace(key)
+		if !strings.HasPrefix(key, envVarPrefix) {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		vars[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
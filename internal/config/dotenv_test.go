@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeEnvFile writes a .env file into a temporary directory and returns its path
+func writeEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	return envFile
+}
+
+func TestLoadConfigDotEnv(t *testing.T) {
+	t.Run("it_populates_config_from_env_file", func(t *testing.T) {
+		cleanup := setupTestEnv(t)
+		defer cleanup()
+
+		envFile := writeEnvFile(t, `
+# credentials kept out of shell history
+SLIMSERVE_PORT=9393
+export SLIMSERVE_USERNAME="dotenv-user"
+SLIMSERVE_PASSWORD='dotenv-pass'
+OTHER_VAR=ignored
+`)
+		cleanupEnv := setEnvVars(t, map[string]string{envFileVar: envFile})
+		defer cleanupEnv()
+		defer os.Unsetenv("SLIMSERVE_PORT")
+		defer os.Unsetenv("SLIMSERVE_USERNAME")
+		defer os.Unsetenv("SLIMSERVE_PASSWORD")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() returned error: %v", err)
+		}
+
+		if cfg.Port != 9393 {
+			t.Errorf("Port: expected 9393, got %d", cfg.Port)
+		}
+		if cfg.Username != "dotenv-user" {
+			t.Errorf("Username: expected %q, got %q", "dotenv-user", cfg.Username)
+		}
+		if cfg.Password != "dotenv-pass" {
+			t.Errorf("Password: expected %q, got %q", "dotenv-pass", cfg.Password)
+		}
+		if _, exists := os.LookupEnv("OTHER_VAR"); exists {
+			t.Error("Non-SLIMSERVE variables should not be loaded from the env file")
+		}
+	})
+
+	t.Run("real_environment_overrides_env_file", func(t *testing.T) {
+		cleanup := setupTestEnv(t)
+		defer cleanup()
+
+		envFile := writeEnvFile(t, "SLIMSERVE_PORT=9393\nSLIMSERVE_HOST=file-host\n")
+		cleanupEnv := setEnvVars(t, map[string]string{
+			envFileVar:       envFile,
+			"SLIMSERVE_PORT": "7070",
+		})
+		defer cleanupEnv()
+		defer os.Unsetenv("SLIMSERVE_HOST")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() returned error: %v", err)
+		}
+
+		if cfg.Port != 7070 {
+			t.Errorf("Port: expected real env value 7070, got %d", cfg.Port)
+		}
+		if cfg.Host != "file-host" {
+			t.Errorf("Host: expected %q from env file, got %q", "file-host", cfg.Host)
+		}
+	})
+
+	t.Run("default_env_file_in_working_directory", func(t *testing.T) {
+		cleanup := setupTestEnv(t)
+		defer cleanup()
+
+		envFile := writeEnvFile(t, "SLIMSERVE_LOG_LEVEL=debug\n")
+		if err := os.Mkdir(filepath.Join(filepath.Dir(envFile), "public"), 0755); err != nil {
+			t.Fatalf("Failed to create default storage directory: %v", err)
+		}
+		origDir, err := os.Getwd()
+		if err != nil {
+			t.Fatalf("Failed to get working directory: %v", err)
+		}
+		defer os.Chdir(origDir)
+		if err := os.Chdir(filepath.Dir(envFile)); err != nil {
+			t.Fatalf("Failed to change directory: %v", err)
+		}
+		defer os.Unsetenv("SLIMSERVE_LOG_LEVEL")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() returned error: %v", err)
+		}
+
+		if cfg.LogLevel != "debug" {
+			t.Errorf("LogLevel: expected %q, got %q", "debug", cfg.LogLevel)
+		}
+	})
+
+	t.Run("missing_explicit_env_file_is_an_error", func(t *testing.T) {
+		cleanup := setupTestEnv(t)
+		defer cleanup()
+
+		cleanupEnv := setEnvVars(t, map[string]string{envFileVar: "/path/that/does/not/exist/.env"})
+		defer cleanupEnv()
+
+		_, err := Load()
+		if err == nil {
+			t.Fatal("Expected error for missing env file, got nil")
+		}
+		if !strings.Contains(err.Error(), "failed to open env file") {
+			t.Errorf("Expected env file error, got: %v", err)
+		}
+	})
+
+	t.Run("malformed_line_is_an_error", func(t *testing.T) {
+		cleanup := setupTestEnv(t)
+		defer cleanup()
+
+		envFile := writeEnvFile(t, "SLIMSERVE_PORT=9393\nnot a valid line\n")
+		cleanupEnv := setEnvVars(t, map[string]string{envFileVar: envFile})
+		defer cleanupEnv()
+		defer os.Unsetenv("SLIMSERVE_PORT")
+
+		_, err := Load()
+		if err == nil {
+			t.Fatal("Expected error for malformed env file, got nil")
+		}
+		if !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("Expected error to reference line 2, got: %v", err)
+		}
+	})
+}
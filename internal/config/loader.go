@@ -24,7 +24,10 @@ type fieldMapping struct {
 var configMappings = []fieldMapping{
 	{"Host", "SLIMSERVE_HOST", "host", "Host to bind to", "string", ""},
 	{"Port", "SLIMSERVE_PORT", "port", "Port to serve on", "int", 0},
+	{"ListenAddrs", "SLIMSERVE_LISTEN_ADDRS", "listen-addrs", "Comma-separated host:port addresses to listen on, replacing host and port", "stringSlice", ""},
+	{"BasePath", "SLIMSERVE_BASE_PATH", "base-path", "URL path prefix when served behind a reverse proxy subpath (e.g. /files)", "string", ""},
 	{"StoragePath", "SLIMSERVE_STORAGE_PATH", "storage-path", "Storage path (local directory or S3 bucket name)", "string", ""},
+	{"DefaultStoragePath", "SLIMSERVE_DEFAULT_STORAGE_PATH", "default-storage-path", "Local directory served when no storage path is set; empty refuses to start", "string", ""},
 	{"StorageType", "SLIMSERVE_STORAGE_TYPE", "storage-type", "Storage type: 'local' or 's3'", "string", ""},
 	{"S3Region", "SLIMSERVE_S3_REGION", "s3-region", "S3 region", "string", ""},
 	{"S3Endpoint", "SLIMSERVE_S3_ENDPOINT", "s3-endpoint", "S3 endpoint (for MinIO, etc.)", "string", ""},
@@ -32,28 +35,138 @@ var configMappings = []fieldMapping{
 	{"S3SecretKey", "SLIMSERVE_S3_SECRET_KEY", "s3-secret-key", "S3 secret key", "string", ""},
 	{"S3Prefix", "SLIMSERVE_S3_PREFIX", "s3-prefix", "S3 key prefix", "string", ""},
 	{"DisableDotFiles", "SLIMSERVE_DISABLE_DOTFILES", "disable-dotfiles", "Block access to dot files", "bool", false},
+	{"ServeIndexHTML", "SLIMSERVE_SERVE_INDEX_HTML", "serve-index-html", "Serve index.html instead of the directory listing when present", "bool", false},
+	{"LandingPage", "SLIMSERVE_LANDING_PAGE", "landing-page", "HTML template served at / instead of the root listing", "string", ""},
+	{"ListingTemplatePath", "SLIMSERVE_LISTING_TEMPLATE_PATH", "listing-template-path", "HTML template rendered for directory listings instead of the built-in one", "string", ""},
+	{"DefaultSort", "SLIMSERVE_DEFAULT_SORT", "default-sort", "Listing sort without a sort parameter: name, size or modified", "string", ""},
+	{"DefaultOrder", "SLIMSERVE_DEFAULT_ORDER", "default-order", "Listing direction without an order parameter: asc or desc", "string", ""},
+	{"GroupFolders", "SLIMSERVE_GROUP_FOLDERS", "group-folders", "List folders before files whatever the sort", "bool", false},
+	{"ListingBanner", "SLIMSERVE_LISTING_BANNER", "listing-banner", "Notice shown above every directory listing (plain text or inline HTML)", "string", ""},
+	{"MaintenanceMode", "SLIMSERVE_MAINTENANCE_MODE", "maintenance-mode", "Answer non-admin requests with a 503 maintenance page", "bool", false},
+	{"MaintenanceMessage", "SLIMSERVE_MAINTENANCE_MESSAGE", "maintenance-message", "Plain text shown on the maintenance page", "string", ""},
+	{"FaviconPath", "SLIMSERVE_FAVICON_PATH", "favicon-path", "Image file served for /favicon.ico instead of the embedded icon", "string", ""},
+	{"RobotsTxt", "SLIMSERVE_ROBOTS_TXT", "robots-txt", "Content served for /robots.txt", "string", ""},
+	{"RobotsTxtPath", "SLIMSERVE_ROBOTS_TXT_PATH", "robots-txt-path", "File served for /robots.txt", "string", ""},
+	{"DateFormat", "SLIMSERVE_DATE_FORMAT", "date-format", "Listing time layout: a Go layout or iso-8601, rfc3339, rfc1123, date, datetime", "string", ""},
+	{"DisplayTimezone", "SLIMSERVE_DISPLAY_TIMEZONE", "display-timezone", "IANA time zone for listing times (empty uses local time)", "string", ""},
+	{"MaxDisplayNameLength", "SLIMSERVE_MAX_DISPLAY_NAME_LENGTH", "max-display-name-length", "Longest file name shown in listings before it is cut short (0 disables)", "int", 0},
+	{"EnableFeeds", "SLIMSERVE_ENABLE_FEEDS", "enable-feeds", "Serve listings and /recent as RSS or Atom feeds with ?format=rss or ?format=atom", "bool", false},
+	{"IndexFiles", "SLIMSERVE_INDEX_FILES", "index-files", "Comma-separated file names tried in order as a directory's index", "stringSlice", ""},
+	{"ReadOnly", "SLIMSERVE_READ_ONLY", "read-only", "Refuse uploads, deletes, mkdir and moves, and accept only GET and HEAD on file routes", "bool", false},
+	{"DisableListing", "SLIMSERVE_DISABLE_LISTING", "disable-listing", "Refuse directory listings with 403 while still serving files", "bool", false},
+	{"MaxListingItems", "SLIMSERVE_MAX_LISTING_ITEMS", "max-listing-items", "Entries shown per directory listing before it is truncated (0 shows all)", "int", 0},
+	{"MaxChecksumSizeMB", "SLIMSERVE_MAX_CHECKSUM_SIZE_MB", "max-checksum-size-mb", "Largest file in MB hashed for ?checksums=sha256 in JSON listings (0 is no limit)", "int", 0},
+	{"ShowDirSizes", "SLIMSERVE_SHOW_DIR_SIZES", "show-dir-sizes", "Show recursive folder sizes in listings (walks each subdirectory)", "bool", false},
+	{"SymlinkPolicy", "SLIMSERVE_SYMLINK_POLICY", "symlink-policy", "How in-root symlinks are handled: 'deny', 'follow' or 'show'", "string", ""},
+	{"RenderMarkdown", "SLIMSERVE_RENDER_MARKDOWN", "render-markdown", "Show markdown files as HTML pages by default (?raw=1 serves the source)", "bool", false},
+	{"ServePrecompressed", "SLIMSERVE_SERVE_PRECOMPRESSED", "serve-precompressed", "Serve .br/.gz sidecar files to clients that accept the encoding", "bool", false},
+	{"ForceDownload", "SLIMSERVE_FORCE_DOWNLOAD", "force-download", "Serve files as attachments instead of rendering them inline", "bool", false},
+	{"Theme", "SLIMSERVE_THEME", "theme", "Default listing theme: 'light', 'dark' or 'auto'", "string", ""},
+	{"HideVersion", "SLIMSERVE_HIDE_VERSION", "hide-version", "Keep the build version out of pages, listings and the Server header, and answer /version with 404", "bool", false},
+	{"SendServerHeader", "SLIMSERVE_SEND_SERVER_HEADER", "send-server-header", "Send a Server header with the SlimServe version", "bool", false},
+	{"VersionStats", "SLIMSERVE_VERSION_STATS", "version-stats", "Include runtime and cache stats in the /version response", "bool", false},
 	{"LogLevel", "SLIMSERVE_LOG_LEVEL", "log-level", "Log level (debug, info, warn, error)", "string", ""},
+	{"LogFile", "SLIMSERVE_LOG_FILE", "log-file", "Write logs to this file with rotation", "string", ""},
+	{"LogMaxSizeMB", "SLIMSERVE_LOG_MAX_SIZE_MB", "log-max-size-mb", "Maximum log file size in MB before rotation", "int", 0},
+	{"LogMaxBackups", "SLIMSERVE_LOG_MAX_BACKUPS", "log-max-backups", "Maximum number of rotated log files to keep (0 keeps all)", "int", 0},
+	{"LogMaxAgeDays", "SLIMSERVE_LOG_MAX_AGE_DAYS", "log-max-age-days", "Maximum age in days of rotated log files (0 keeps all)", "int", 0},
+	{"AccessLogFormat", "SLIMSERVE_ACCESS_LOG_FORMAT", "access-log-format", "Access log format: 'combined', 'json' or a Go template (empty disables)", "string", ""},
+	{"AccessLogFile", "SLIMSERVE_ACCESS_LOG_FILE", "access-log-file", "Access log file (default: stdout)", "string", ""},
+	{"LogToStderr", "SLIMSERVE_LOG_TO_STDERR", "log-to-stderr", "Also log to stderr when a log file is configured", "bool", false},
+	{"LogRequestBodies", "SLIMSERVE_LOG_REQUEST_BODIES", "log-request-bodies", "Log admin API request bodies with credentials redacted (requires log level debug)", "bool", false},
+	{"LogRequestBodyBytes", "SLIMSERVE_LOG_REQUEST_BODY_BYTES", "log-request-body-bytes", "Bytes of each admin API request body logged when log-request-bodies is set", "int", 0},
 	{"EnableAuth", "SLIMSERVE_ENABLE_AUTH", "enable-auth", "Enable basic authentication", "bool", false},
 	{"Username", "SLIMSERVE_USERNAME", "username", "Username for basic auth", "string", ""},
 	{"Password", "SLIMSERVE_PASSWORD", "password", "Password for basic auth", "string", ""},
+	{"ProtectedPaths", "SLIMSERVE_PROTECTED_PATHS", "protected-paths", "Comma-separated URL path prefixes or globs that require login (empty protects everything)", "stringSlice", ""},
+	{"CookiePrefix", "SLIMSERVE_COOKIE_PREFIX", "cookie-prefix", "Prefix of the session and CSRF cookie names", "string", ""},
+	{"CookieSameSite", "SLIMSERVE_COOKIE_SAME_SITE", "cookie-same-site", "SameSite mode of session and CSRF cookies: lax, strict or none", "string", ""},
+	{"CookieDomain", "SLIMSERVE_COOKIE_DOMAIN", "cookie-domain", "Domain attribute of session and CSRF cookies (empty limits them to the host)", "string", ""},
 	{"MaxThumbCacheMB", "SLIMSERVE_THUMB_CACHE_MB", "thumb-cache-mb", "Maximum thumbnail cache size in MB", "int", 0},
 	{"ThumbJpegQuality", "SLIMSERVE_THUMB_JPEG_QUALITY", "thumb-jpeg-quality", "Thumbnail JPEG quality (1-100)", "int", 0},
 	{"ThumbMaxFileSizeMB", "SLIMSERVE_THUMB_MAX_FILE_SIZE_MB", "thumb-max-file-size-mb", "Maximum file size in MB for thumbnail generation", "int", 0},
+	{"ThumbPruneHighPercent", "SLIMSERVE_THUMB_PRUNE_HIGH_PERCENT", "thumb-prune-high-percent", "Percent of the thumbnail cache size that starts a background prune (0 uses 100)", "int", 0},
+	{"ThumbPruneLowPercent", "SLIMSERVE_THUMB_PRUNE_LOW_PERCENT", "thumb-prune-low-percent", "Percent of the thumbnail cache size a prune evicts down to (0 uses 90)", "int", 0},
+	{"ThumbCacheMaxAge", "SLIMSERVE_THUMB_CACHE_MAX_AGE", "thumb-cache-max-age", "Cache-Control max-age in seconds for thumbnails (0 omits the header)", "int", 0},
+	{"ThumbGenTimeoutSeconds", "SLIMSERVE_THUMB_GEN_TIMEOUT_SECONDS", "thumb-gen-timeout-seconds", "Seconds to wait for thumbnail generation before serving the original (0 waits indefinitely)", "int", 0},
+	{"ThumbAnimated", "SLIMSERVE_THUMB_ANIMATED", "thumb-animated", "Keep animation in thumbnails of animated GIFs", "bool", false},
+	{"ThumbWebP", "SLIMSERVE_THUMB_WEBP", "thumb-webp", "Serve WebP thumbnails to clients that accept image/webp", "bool", false},
+	{"ThumbAVIF", "SLIMSERVE_THUMB_AVIF", "thumb-avif", "Serve AVIF thumbnails to clients that accept image/avif", "bool", false},
+	{"ThumbCacheGzip", "SLIMSERVE_THUMB_CACHE_GZIP", "thumb-cache-gzip", "Store cached thumbnails gzip-compressed", "bool", false},
+	{"ThumbOnUpload", "SLIMSERVE_THUMB_ON_UPLOAD", "thumb-on-upload", "Generate thumbnails for uploaded images right away instead of on first view", "bool", false},
+	{"EnableFSWatch", "SLIMSERVE_ENABLE_FS_WATCH", "enable-fs-watch", "Drop cached listing data as soon as served files change (Linux only)", "bool", false},
 	{"IgnorePatterns", "SLIMSERVE_IGNORE_PATTERNS", "ignore-patterns", "Comma-separated list of glob patterns to ignore", "stringSlice", ""},
+	{"AllowedServeTypes", "SLIMSERVE_ALLOWED_SERVE_TYPES", "allowed-serve-types", "Comma-separated extensions or filename globs that may be listed and served (default: all)", "stringSlice", ""},
+	{"Mounts", "SLIMSERVE_MOUNTS", "mounts", "Comma-separated name=directory pairs served under /<name> (e.g. photos=/srv/photos)", "stringMap", ""},
+	{"TrustedProxies", "SLIMSERVE_TRUSTED_PROXIES", "trusted-proxies", "Comma-separated proxy IPs or CIDRs trusted to set X-Forwarded-For (default: none)", "stringSlice", ""},
+	{"XAccelRedirect", "SLIMSERVE_X_ACCEL_REDIRECT", "x-accel-redirect", "Internal nginx location that trusted proxies serve files from via X-Accel-Redirect (empty disables)", "string", ""},
+	{"MimeOverrides", "SLIMSERVE_MIME_OVERRIDES", "mime-overrides", "Comma-separated ext=content-type overrides (e.g. .md=text/markdown)", "stringMap", ""},
+	{"IconOverrides", "SLIMSERVE_ICON_OVERRIDES", "icon-overrides", "Comma-separated ext=icon listing icon overrides (e.g. .epub=file-text)", "stringMap", ""},
+	{"ContentSecurityPolicy", "SLIMSERVE_CONTENT_SECURITY_POLICY", "content-security-policy", "Content-Security-Policy for rendered pages ({nonce} is replaced per request)", "string", ""},
+	{"ShutdownTimeoutSeconds", "SLIMSERVE_SHUTDOWN_TIMEOUT_SECONDS", "shutdown-timeout-seconds", "Seconds to wait for in-flight requests on shutdown (0 waits indefinitely)", "int", 0},
+	{"ReadTimeoutSeconds", "SLIMSERVE_READ_TIMEOUT_SECONDS", "read-timeout-seconds", "Seconds allowed to read a whole request, including uploads (0 disables)", "int", 0},
+	{"WriteTimeoutSeconds", "SLIMSERVE_WRITE_TIMEOUT_SECONDS", "write-timeout-seconds", "Seconds allowed to write a whole response, including downloads (0 disables)", "int", 0},
+	{"IdleTimeoutSeconds", "SLIMSERVE_IDLE_TIMEOUT_SECONDS", "idle-timeout-seconds", "Seconds an idle keep-alive connection stays open (0 disables)", "int", 0},
+	{"HandlerTimeoutSeconds", "SLIMSERVE_HANDLER_TIMEOUT_SECONDS", "handler-timeout-seconds", "Seconds a handler may take before its response starts; slower requests get 503 (0 disables)", "int", 0},
+	{"EnableH2C", "SLIMSERVE_ENABLE_H2C", "enable-h2c", "Accept HTTP/2 without TLS (h2c) alongside HTTP/1.1", "bool", false},
+	{"MaxConnections", "SLIMSERVE_MAX_CONNECTIONS", "max-connections", "Requests handled at once before further ones get 503 (0 is unlimited)", "int", 0},
+	{"MaxHeaderBytes", "SLIMSERVE_MAX_HEADER_BYTES", "max-header-bytes", "Largest request header block in bytes (0 uses the 1 MB default)", "int", 0},
+	{"MaxURLLength", "SLIMSERVE_MAX_URL_LENGTH", "max-url-length", "Longest request path and query in bytes before answering 414 (0 disables)", "int", 0},
+	{"MaxRequestBodyMB", "SLIMSERVE_MAX_REQUEST_BODY_MB", "max-request-body-mb", "Largest request body in MB outside uploads before answering 413 (0 disables)", "int", 0},
+	{"RequestIDHeader", "SLIMSERVE_REQUEST_ID_HEADER", "request-id-header", "Header that carries request IDs from proxies and back in responses", "string", ""},
+	{"RootHealthCheckSeconds", "SLIMSERVE_ROOT_HEALTH_CHECK_SECONDS", "root-health-check-seconds", "Seconds between checks that served directories are reachable (0 disables)", "int", 0},
+	{"MaxTraversalDepth", "SLIMSERVE_MAX_TRAVERSAL_DEPTH", "max-traversal-depth", "Directory levels recursive walks descend below a root (0 is unlimited)", "int", 0},
 	{"EnableAdmin", "SLIMSERVE_ENABLE_ADMIN", "enable-admin", "Enable admin interface", "bool", false},
 	{"AdminUsername", "SLIMSERVE_ADMIN_USERNAME", "admin-username", "Admin username", "string", ""},
 	{"AdminPassword", "SLIMSERVE_ADMIN_PASSWORD", "admin-password", "Admin password", "string", ""},
 	{"MaxUploadSizeMB", "SLIMSERVE_MAX_UPLOAD_SIZE_MB", "max-upload-size-mb", "Maximum upload size in MB", "int", 0},
 	{"AllowedUploadTypes", "SLIMSERVE_ALLOWED_UPLOAD_TYPES", "allowed-upload-types", "Comma-separated list of allowed upload file types", "stringSlice", ""},
 	{"MaxConcurrentUploads", "SLIMSERVE_MAX_CONCURRENT_UPLOADS", "max-concurrent-uploads", "Maximum concurrent uploads", "int", 0},
+	{"MultipartMemoryMB", "SLIMSERVE_MULTIPART_MEMORY_MB", "multipart-memory-mb", "MB of each upload request kept in memory before the rest spills to temporary files", "int", 0},
+	{"UploadStaleSeconds", "SLIMSERVE_UPLOAD_STALE_SECONDS", "upload-stale-seconds", "Seconds an upload may make no progress before its slot is freed (0 disables)", "int", 0},
+	{"UploadFieldNames", "SLIMSERVE_UPLOAD_FIELD_NAMES", "upload-field-names", "Comma-separated multipart field names uploads are read from", "stringSlice", ""},
+	{"UploadConflictPolicy", "SLIMSERVE_UPLOAD_CONFLICT_POLICY", "upload-conflict-policy", "What an upload does when its filename is taken: 'rename', 'overwrite', 'reject' or 'timestamp'", "string", ""},
+	{"AdminStatsRefreshSeconds", "SLIMSERVE_ADMIN_STATS_REFRESH_SECONDS", "admin-stats-refresh-seconds", "Seconds to cache admin storage stats (0 disables caching)", "int", 0},
+	{"AdminActivityLogFile", "SLIMSERVE_ADMIN_ACTIVITY_LOG_FILE", "admin-activity-log-file", "File admin activity is appended to as JSON lines (empty keeps it in memory only)", "string", ""},
 }
 
 // Load loads configuration from multiple sources with precedence:
 // 1. CLI flags (highest)
-// 2. Environment variables
+// 2. Environment variables (including a .env file; the real environment wins)
 // 3. Configuration file
 // 4. Default values (lowest)
+//
+// The merged configuration is validated before it is returned.
 func Load() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadFile loads and validates filename over the defaults, ignoring the
+// environment and command line
+func LoadFile(filename string) (*Config, error) {
+	cfg := Default()
+	if err := loadFromFile(cfg, filename); err != nil {
+		return nil, err
+	}
+	cfg.file = filename
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// load merges all configuration sources without validating the result
+func load() (*Config, error) {
 	cfg := Default()
 
 	configFile := getConfigFile()
@@ -61,6 +174,11 @@ func Load() (*Config, error) {
 		if err := loadFromFile(cfg, configFile); err != nil {
 			return nil, err
 		}
+		cfg.file = configFile
+	}
+
+	if err := loadDotEnv(); err != nil {
+		return nil, err
 	}
 
 	loadFromEnvGeneric(cfg)
@@ -95,7 +213,21 @@ func loadFromFile(cfg *Config, filename string) error {
 		return err
 	}
 
-	return json.Unmarshal(data, cfg)
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return err
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(data, &present); err != nil {
+		return err
+	}
+	fields := jsonFieldNames()
+	for key := range present {
+		if field, ok := fields[key]; ok {
+			cfg.SetSource(field, SourceFile)
+		}
+	}
+	return nil
 }
 
 // Type conversion utilities
@@ -112,6 +244,20 @@ func parseStringSlice(value string) []string {
 	return parts
 }
 
+// parseStringMap parses comma-separated key=value pairs, skipping malformed entries
+func parseStringMap(value string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range parseStringSlice(value) {
+		key, val, ok := strings.Cut(pair, "=")
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if !ok || key == "" || val == "" {
+			continue
+		}
+		result[key] = val
+	}
+	return result
+}
+
 func parseInt(value string) int {
 	if val, err := strconv.Atoi(value); err == nil {
 		return val
@@ -134,22 +280,31 @@ func loadFromEnvGeneric(cfg *Config) {
 			continue
 		}
 
+		set := true
 		switch mapping.fieldType {
 		case "string":
 			field.SetString(envValue)
 		case "int":
 			if val := parseInt(envValue); val != 0 || envValue == "0" {
 				field.SetInt(int64(val))
+			} else {
+				set = false
 			}
 		case "bool":
 			if val, err := strconv.ParseBool(envValue); err == nil {
 				field.SetBool(val)
 			} else {
+				set = false
 				fmt.Printf("config: failed to parse %s=%s as bool: %v\n", mapping.envVar, envValue, err)
 			}
 		case "stringSlice":
 			slice := parseStringSlice(envValue)
 			field.Set(reflect.ValueOf(slice))
+		case "stringMap":
+			field.Set(reflect.ValueOf(parseStringMap(envValue)))
+		}
+		if set {
+			cfg.SetSource(mapping.fieldName, SourceEnv)
 		}
 	}
 }
@@ -180,8 +335,8 @@ func registerFlags() {
 				defaultVal = mapping.defaultValue.(bool)
 			}
 			flag.Bool(mapping.flagName, defaultVal, mapping.flagDesc)
-		case "stringSlice":
-			// String slices are handled as comma-separated strings in flags
+		case "stringSlice", "stringMap":
+			// String slices and maps are handled as comma-separated strings in flags
 			flag.String(mapping.flagName, "", mapping.flagDesc)
 		}
 	}
@@ -190,6 +345,29 @@ func registerFlags() {
 	if flag.Lookup("config") == nil {
 		flag.String("config", "", "Path to configuration file")
 	}
+
+	// Dump mode flags are likewise not part of the Config struct
+	for _, name := range printConfigFlags {
+		if flag.Lookup(name) == nil {
+			flag.Bool(name, false, "Print the effective configuration as JSON and exit")
+		}
+	}
+	if flag.Lookup("print-schema") == nil {
+		flag.Bool("print-schema", false, "Print a JSON Schema of the configuration file and exit")
+	}
+}
+
+// printConfigFlags are the CLI flags that request a configuration dump
+var printConfigFlags = []string{"print-config", "config-check"}
+
+// PrintConfigRequested reports whether a configuration dump was requested on the command line
+func PrintConfigRequested() bool {
+	for _, name := range printConfigFlags {
+		if f := flag.Lookup(name); f != nil && f.Value.String() == "true" {
+			return true
+		}
+	}
+	return false
 }
 
 // loadFromFlagsGeneric loads configuration from CLI flags using field mappings
@@ -231,23 +409,28 @@ func loadFromFlagsGeneric(cfg *Config) {
 			continue
 		}
 
+		set := true
 		switch mapping.fieldType {
 		case "string":
 			field.SetString(flagValue)
 		case "int":
 			if val := parseInt(flagValue); val != 0 || flagValue == "0" {
 				field.SetInt(int64(val))
+			} else {
+				set = false
 			}
 		case "bool":
 			if val, err := strconv.ParseBool(flagValue); err == nil {
 				field.SetBool(val)
 			} else {
+				set = false
 				fmt.Printf("config: failed to parse --%s=%s as bool: %v\n", mapping.flagName, flagValue, err)
 			}
 		case "stringSlice":
+			set = flagValue != ""
 			if flagValue != "" {
 				slice := parseStringSlice(flagValue)
-				// For ignore patterns, merge with existing to avoid duplicates
+				// Ignore patterns from flags follow the earlier ones, in order
 				if mapping.fieldName == "IgnorePatterns" {
 					existing := field.Interface().([]string)
 					merged := mergeStringSlices(existing, slice)
@@ -256,25 +439,23 @@ func loadFromFlagsGeneric(cfg *Config) {
 					field.Set(reflect.ValueOf(slice))
 				}
 			}
+		case "stringMap":
+			set = flagValue != ""
+			if flagValue != "" {
+				field.Set(reflect.ValueOf(parseStringMap(flagValue)))
+			}
+		}
+		if set {
+			cfg.SetSource(mapping.fieldName, SourceFlag)
 		}
 	}
 }
 
-// mergeStringSlices merges two string slices, avoiding duplicates
+// mergeStringSlices appends new to existing. Duplicates are kept: ignore
+// patterns are matched last-match-wins, so repeating a pattern after a
+// negation changes the outcome.
 func mergeStringSlices(existing, new []string) []string {
-	existingMap := make(map[string]struct{})
-	for _, item := range existing {
-		existingMap[item] = struct{}{}
-	}
-
-	result := make([]string, len(existing))
-	copy(result, existing)
-
-	for _, item := range new {
-		if _, exists := existingMap[item]; !exists {
-			result = append(result, item)
-		}
-	}
-
-	return result
+	result := make([]string, 0, len(existing)+len(new))
+	result = append(result, existing...)
+	return append(result, new...)
 }
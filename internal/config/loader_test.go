@@ -25,6 +25,13 @@ func setupTestEnv(t *testing.T) func() {
 	// Clear environment variables
 	clearSlimServeEnvVars()
 
+	// Run from a directory holding the default storage path so Load validates
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "public"), 0755); err != nil {
+		t.Fatalf("Failed to create default storage directory: %v", err)
+	}
+	t.Chdir(dir)
+
 	// Return cleanup function
 	return func() {
 		os.Args = origArgs
@@ -240,9 +247,9 @@ func TestLoadConfigJSON(t *testing.T) {
 			})
 			defer cleanupEnv()
 
-			cfg, err := Load()
+			cfg, err := load()
 			if err != nil {
-				t.Fatalf("Load() returned error: %v", err)
+				t.Fatalf("load() returned error: %v", err)
 			}
 
 			compareConfigs(t, *cfg, tt.expected)
@@ -298,7 +305,7 @@ func TestLoadConfigEnvVars(t *testing.T) {
 			expected: Config{
 				Host:                 "partial-host",
 				Port:                 5555,
-				StoragePath:          ".",
+				StoragePath:          "",
 				StorageType:          "local",
 				DisableDotFiles:      true,       // Default
 				LogLevel:             "info",     // Default
@@ -350,7 +357,7 @@ func TestLoadConfigEnvVars(t *testing.T) {
 			expected: Config{
 				Host:                 "0.0.0.0", // Default
 				Port:                 8080,      // Default (invalid port ignored)
-				StoragePath:          ".",
+				StoragePath:          "",
 				StorageType:          "local",
 				DisableDotFiles:      true,
 				LogLevel:             "info",
@@ -377,7 +384,7 @@ func TestLoadConfigEnvVars(t *testing.T) {
 			expected: Config{
 				Host:                 "0.0.0.0", // Default
 				Port:                 8080,      // Default
-				StoragePath:          ".",
+				StoragePath:          "",
 				StorageType:          "local",
 				DisableDotFiles:      true, // Default (invalid bool ignored)
 				LogLevel:             "info",
@@ -406,9 +413,9 @@ func TestLoadConfigEnvVars(t *testing.T) {
 			cleanupEnv := setEnvVars(t, tt.envVars)
 			defer cleanupEnv()
 
-			cfg, err := Load()
+			cfg, err := load()
 			if err != nil {
-				t.Fatalf("Load() returned error: %v", err)
+				t.Fatalf("load() returned error: %v", err)
 			}
 
 			compareConfigs(t, *cfg, tt.expected)
@@ -463,7 +470,7 @@ func TestLoadConfigFlags(t *testing.T) {
 			expected: Config{
 				Host:                 "partial-flag-host",
 				Port:                 1234,
-				StoragePath:          ".",
+				StoragePath:          "",
 				StorageType:          "local",
 				DisableDotFiles:      true,       // Default
 				LogLevel:             "info",     // Default
@@ -491,9 +498,9 @@ func TestLoadConfigFlags(t *testing.T) {
 			// Set command line arguments
 			os.Args = tt.args
 
-			cfg, err := Load()
+			cfg, err := load()
 			if err != nil {
-				t.Fatalf("Load() returned error: %v", err)
+				t.Fatalf("load() returned error: %v", err)
 			}
 
 			compareConfigs(t, *cfg, tt.expected)
@@ -627,9 +634,9 @@ func TestLoadConfigPrecedence(t *testing.T) {
 			"-host", "flag-host",
 		}
 
-		cfg, err := Load()
+		cfg, err := load()
 		if err != nil {
-			t.Fatalf("Load() returned error: %v", err)
+			t.Fatalf("load() returned error: %v", err)
 		}
 
 		// Expected: flag values override env vars, env vars override file, file overrides defaults
@@ -770,22 +777,162 @@ func TestLoadConfigIgnorePatternsMerging(t *testing.T) {
 			"-ignore-patterns", "flag.pattern,env.pattern",
 		}
 
-		cfg, err := Load()
+		cfg, err := load()
 		if err != nil {
-			t.Fatalf("Load() returned an unexpected error: %v", err)
+			t.Fatalf("load() returned an unexpected error: %v", err)
 		}
 
-		// Env overwrites file, flag merges with env.
-		expected := []string{"env.pattern", "common.pattern", "flag.pattern"}
+		// Env overwrites file, flag appends to env in order.
+		expected := []string{"env.pattern", "common.pattern", "flag.pattern", "env.pattern"}
 		actual := cfg.IgnorePatterns
 
-		sort.Strings(expected)
-		sort.Strings(actual)
-
 		if !reflect.DeepEqual(expected, actual) {
 			t.Errorf("Expected IgnorePatterns to be %v, but got %v", expected, actual)
 		}
 	})
+
+	t.Run("it_keeps_patterns_repeated_after_a_negation", func(t *testing.T) {
+		cleanup := setupTestEnv(t)
+		defer cleanup()
+
+		cleanupEnv := setEnvVars(t, map[string]string{
+			"SLIMSERVE_IGNORE_PATTERNS": "*.log",
+		})
+		defer cleanupEnv()
+		os.Args = []string{"slimserve", "-ignore-patterns", "!important.log,*.log"}
+
+		cfg, err := load()
+		if err != nil {
+			t.Fatalf("load() returned an unexpected error: %v", err)
+		}
+
+		// Dropping the second *.log would re-include important.log
+		expected := []string{"*.log", "!important.log", "*.log"}
+		if !reflect.DeepEqual(expected, cfg.IgnorePatterns) {
+			t.Errorf("Expected IgnorePatterns to be %v, but got %v", expected, cfg.IgnorePatterns)
+		}
+	})
+}
+
+func TestLoadConfigMimeOverrides(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	cleanupEnv := setEnvVars(t, map[string]string{
+		"SLIMSERVE_MIME_OVERRIDES": ".md=text/markdown, .foo = application/x-foo, malformed",
+	})
+	defer cleanupEnv()
+
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("load() returned an unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		".md":  "text/markdown",
+		".foo": "application/x-foo",
+	}
+	if !reflect.DeepEqual(cfg.MimeOverrides, expected) {
+		t.Errorf("Expected MimeOverrides %v, got %v", expected, cfg.MimeOverrides)
+	}
+}
+
+func TestLoadConfigProvenance(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	configFile := filepath.Join(t.TempDir(), "slimserve.json")
+	if err := os.WriteFile(configFile, []byte(`{"theme": "dark", "port": 7000, "admin_password": "from-file"}`), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cleanupEnv := setEnvVars(t, map[string]string{
+		"SLIMSERVE_CONFIG":   configFile,
+		"SLIMSERVE_PORT":     "9000",
+		"SLIMSERVE_PASSWORD": "hunter2",
+	})
+	defer cleanupEnv()
+	os.Args = []string{"slimserve", "-log-level", "debug"}
+
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("load() returned an unexpected error: %v", err)
+	}
+
+	sources := map[string]string{
+		"Theme":         SourceFile,
+		"AdminPassword": SourceFile,
+		"Port":          SourceEnv,
+		"Password":      SourceEnv,
+		"LogLevel":      SourceFlag,
+		"Host":          SourceDefault,
+	}
+	for field, want := range sources {
+		if got := cfg.Source(field); got != want {
+			t.Errorf("Source(%q): expected %q, got %q", field, want, got)
+		}
+	}
+
+	effective := cfg.Effective()
+	if got := effective["port"]; got.Value != 9000 || got.Source != SourceEnv {
+		t.Errorf("Expected port 9000 from env, got %+v", got)
+	}
+	for _, key := range []string{"password", "admin_password"} {
+		if got := effective[key].Value; got != redactedValue {
+			t.Errorf("Expected %s to be redacted, got %v", key, got)
+		}
+	}
+	if _, ok := effective["password_hash"]; ok {
+		t.Error("Expected fields without a JSON name to be left out")
+	}
+
+	cfg.SetSource("Theme", SourceAdmin)
+	if got := cfg.Redacted().Source("Theme"); got != SourceAdmin {
+		t.Errorf("Expected copies to share sources, got %q", got)
+	}
+}
+
+func TestSaveWritesOnlyFileAndAdminSettings(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	configFile := filepath.Join(t.TempDir(), "slimserve.json")
+	if err := os.WriteFile(configFile, []byte(`{"theme": "dark", "port": 7000}`), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	cleanupEnv := setEnvVars(t, map[string]string{
+		"SLIMSERVE_CONFIG":        configFile,
+		"SLIMSERVE_PORT":          "9000",
+		"SLIMSERVE_S3_SECRET_KEY": "env-secret",
+	})
+	defer cleanupEnv()
+	os.Args = []string{"slimserve", "-log-level", "debug"}
+
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("load() returned an unexpected error: %v", err)
+	}
+	cfg.ListingBanner = "from admin"
+	cfg.SetSource("ListingBanner", SourceAdmin)
+
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() returned an unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read saved config: %v", err)
+	}
+	if strings.Contains(string(data), "env-secret") {
+		t.Errorf("Expected the env-sourced S3 secret to stay out of the file, got %s", data)
+	}
+	var saved map[string]any
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Failed to parse saved config: %v", err)
+	}
+	want := map[string]any{"theme": "dark", "port": float64(7000), "listing_banner": "from admin"}
+	if !reflect.DeepEqual(saved, want) {
+		t.Errorf("Expected file and admin settings only, with the file's port kept, got %v", saved)
+	}
 }
 
 func TestLoadConfigBooleanFlagPrecedence(t *testing.T) {
@@ -816,9 +963,9 @@ func TestLoadConfigBooleanFlagPrecedence(t *testing.T) {
 			"-enable-auth=true",
 		}
 
-		cfg, err := Load()
+		cfg, err := load()
 		if err != nil {
-			t.Fatalf("Load() returned an unexpected error: %v", err)
+			t.Fatalf("load() returned an unexpected error: %v", err)
 		}
 
 		if cfg.DisableDotFiles != false {
@@ -847,6 +994,8 @@ func clearSlimServeEnvVars() {
 		"SLIMSERVE_THUMB_JPEG_QUALITY",
 		"SLIMSERVE_IGNORE_PATTERNS",
 		"SLIMSERVE_THUMB_MAX_FILE_SIZE_MB",
+		"SLIMSERVE_ENV_FILE",
+		"SLIMSERVE_MIME_OVERRIDES",
 	}
 
 	for _, envVar := range envVars {
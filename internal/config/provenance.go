@@ -0,0 +1,100 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Configuration sources, reported per field by Config.Source
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env" // the environment, including a .env file
+	SourceFlag    = "flag"
+	SourceAdmin   = "admin" // changed at runtime through the admin API
+)
+
+// provenance records which source last set each field, keyed by Go field
+// name. It is shared by pointer so copies of a Config, such as Redacted's,
+// report the same sources.
+type provenance struct {
+	mu      sync.RWMutex
+	sources map[string]string
+}
+
+// SetSource records that field, a Config field name such as "Port", was last
+// set by source
+func (c *Config) SetSource(field, source string) {
+	if c.provenance == nil {
+		c.provenance = &provenance{}
+	}
+	c.provenance.mu.Lock()
+	defer c.provenance.mu.Unlock()
+	if c.provenance.sources == nil {
+		c.provenance.sources = make(map[string]string)
+	}
+	c.provenance.sources[field] = source
+}
+
+// Source returns where field got its value, SourceDefault when no loader or
+// admin update set it
+func (c *Config) Source(field string) string {
+	if c.provenance == nil {
+		return SourceDefault
+	}
+	c.provenance.mu.RLock()
+	defer c.provenance.mu.RUnlock()
+	if source, ok := c.provenance.sources[field]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// EffectiveSetting is one field of the effective configuration
+type EffectiveSetting struct {
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// Effective returns every serialized field, keyed by its JSON name, with its
+// value and source. Secrets are masked as in Redacted.
+func (c *Config) Effective() map[string]EffectiveSetting {
+	redacted := reflect.ValueOf(c.Redacted()).Elem()
+	settings := make(map[string]EffectiveSetting)
+	for _, field := range reflect.VisibleFields(redacted.Type()) {
+		name := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+		settings[name] = EffectiveSetting{
+			Value:  redacted.FieldByIndex(field.Index).Interface(),
+			Source: c.Source(field.Name),
+		}
+	}
+	return settings
+}
+
+// jsonFieldName returns the JSON key of a Config field, or "" for fields
+// that are not serialized
+func jsonFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" || name == "" {
+		return ""
+	}
+	return name
+}
+
+// jsonFieldNames maps the JSON keys of Config to Go field names
+func jsonFieldNames() map[string]string {
+	names := make(map[string]string)
+	for _, field := range reflect.VisibleFields(reflect.TypeFor[Config]()) {
+		if name := jsonFieldName(field); name != "" {
+			names[name] = field.Name
+		}
+	}
+	return names
+}
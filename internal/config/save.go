@@ -0,0 +1,94 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"slimserve/internal/security"
+)
+
+var (
+	// ErrNoConfigFile is returned by Save when no configuration file was loaded
+	ErrNoConfigFile = errors.New("no configuration file is in use")
+	// ErrUnsavedPassword is returned by Save when a password was changed
+	// through the admin API: only its hash is kept, which the file cannot hold
+	ErrUnsavedPassword = errors.New("a password changed at runtime cannot be written to the configuration file")
+	// ErrUnsupportedConfigFormat is returned by Save for files that are not JSON
+	ErrUnsupportedConfigFormat = errors.New("only JSON configuration files can be saved")
+)
+
+// File returns the configuration file the settings were loaded from, or ""
+// when none was
+func (c *Config) File() string {
+	return c.file
+}
+
+// Save writes the settings that came from the configuration file or the
+// admin API back to the file it was loaded from. Values from the environment,
+// a .env file or flags are left out, so secrets passed that way never reach
+// the file; keys the file already holds for such settings keep their
+// contents. The file is replaced atomically, so a failed write leaves the
+// previous contents in place. Configuration files are only read as JSON, so
+// other formats are refused rather than converted.
+func (c *Config) Save() error {
+	if c.file == "" {
+		return ErrNoConfigFile
+	}
+	if (c.PasswordHash != "" && c.Password == "") || (c.AdminPasswordHash != "" && c.AdminPassword == "") {
+		return ErrUnsavedPassword
+	}
+	switch ext := strings.ToLower(filepath.Ext(c.file)); ext {
+	case ".yaml", ".yml", ".toml":
+		return fmt.Errorf("%w: %s", ErrUnsupportedConfigFormat, c.file)
+	}
+
+	doc := make(map[string]json.RawMessage)
+	existing, err := os.ReadFile(c.file)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(existing, &doc); err != nil {
+			return fmt.Errorf("reading %s: %w", c.file, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	v := reflect.ValueOf(c).Elem()
+	for _, field := range reflect.VisibleFields(v.Type()) {
+		name := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+		if source := c.Source(field.Name); source != SourceFile && source != SourceAdmin {
+			continue
+		}
+		value, err := json.Marshal(v.FieldByIndex(field.Index).Interface())
+		if err != nil {
+			return err
+		}
+		doc[name] = value
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	root, err := security.NewRootFS(filepath.Dir(c.file))
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	return root.WriteFileAtomic(filepath.Base(c.file), 0600, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"flag"
+	"reflect"
+)
+
+// schemaDialect is the JSON Schema version Schema declares
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema returns a JSON Schema describing the configuration file. Properties
+// and types come from the json tags and field types of Config, defaults from
+// Default and descriptions from the flag descriptions in configMappings, so
+// the schema follows the loader as fields are added. Unknown keys are
+// allowed, since the loader ignores them.
+func Schema() map[string]any {
+	descriptions := make(map[string]string, len(configMappings))
+	for _, mapping := range configMappings {
+		descriptions[mapping.fieldName] = mapping.flagDesc
+	}
+	defaults := reflect.ValueOf(Default()).Elem()
+
+	properties := make(map[string]any)
+	for _, field := range reflect.VisibleFields(reflect.TypeFor[Config]()) {
+		name := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+		property := schemaFor(field.Type)
+		if description := descriptions[field.Name]; description != "" {
+			property["description"] = description
+		}
+		if def := defaults.FieldByIndex(field.Index); !def.IsZero() || isScalar(def.Kind()) {
+			property["default"] = def.Interface()
+		}
+		properties[name] = property
+	}
+
+	return map[string]any{
+		"$schema":    schemaDialect,
+		"title":      "SlimServe configuration",
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+// schemaFor returns the JSON Schema type of values of t
+func schemaFor(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem())}
+	// Unset slices and maps are written as null, which loads as empty
+	case reflect.Slice:
+		return map[string]any{"type": []string{"array", "null"}, "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": schemaFor(t.Elem())}
+	}
+	return map[string]any{}
+}
+
+// isScalar reports whether k is a kind whose zero value is a meaningful
+// default, unlike an absent slice or map
+func isScalar(k reflect.Kind) bool {
+	switch k {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Pointer, reflect.Interface, reflect.Struct:
+		return false
+	}
+	return true
+}
+
+// PrintSchemaRequested reports whether -print-schema was given. It parses
+// the command line itself, since the schema is printed without loading a
+// configuration that may not be valid yet.
+func PrintSchemaRequested() bool {
+	registerFlags()
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+	f := flag.Lookup("print-schema")
+	return f != nil && f.Value.String() == "true"
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+)
+
+// validateSchema checks value against the subset of JSON Schema that Schema
+// produces: type, properties, items and additionalProperties
+func validateSchema(schema map[string]any, value any, path string) error {
+	if types, ok := schema["type"].([]any); ok {
+		var first error
+		for _, typ := range types {
+			alternative := maps.Clone(schema)
+			alternative["type"] = typ
+			err := validateSchema(alternative, value, path)
+			if err == nil {
+				return nil
+			}
+			if first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+
+	switch schema["type"] {
+	case "null":
+		if value != nil {
+			return fmt.Errorf("%s: expected null, got %T", path, value)
+		}
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %T", path, value)
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for key, v := range obj {
+			sub, ok := properties[key].(map[string]any)
+			if !ok {
+				sub, _ = schema["additionalProperties"].(map[string]any)
+			}
+			if sub == nil {
+				continue
+			}
+			if err := validateSchema(sub, v, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %T", path, value)
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, v := range arr {
+			if err := validateSchema(items, v, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected a string, got %T", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean, got %T", path, value)
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: expected an integer, got %v", path, value)
+		}
+	}
+	return nil
+}
+
+// roundTrip passes the schema through JSON, as clients receive it
+func roundTrip(t *testing.T, v any) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	return out
+}
+
+func TestSchemaCoversMappings(t *testing.T) {
+	schema := roundTrip(t, Schema())
+	properties := schema["properties"].(map[string]any)
+
+	fields := jsonFieldNames()
+	if len(properties) != len(fields) {
+		t.Errorf("Expected %d properties, got %d", len(fields), len(properties))
+	}
+
+	jsonNames := make(map[string]string, len(fields))
+	for name, field := range fields {
+		jsonNames[field] = name
+	}
+	for _, mapping := range configMappings {
+		name, ok := jsonNames[mapping.fieldName]
+		if !ok {
+			continue
+		}
+		property := properties[name].(map[string]any)
+		if property["description"] != mapping.flagDesc {
+			t.Errorf("%s: expected description %q, got %v", name, mapping.flagDesc, property["description"])
+		}
+	}
+
+	if got := properties["port"].(map[string]any); got["type"] != "integer" || got["default"] != float64(8080) {
+		t.Errorf("Unexpected port schema: %v", got)
+	}
+	if got := properties["ignore_patterns"].(map[string]any); fmt.Sprint(got["type"]) != "[array null]" || got["items"].(map[string]any)["type"] != "string" {
+		t.Errorf("Unexpected ignore_patterns schema: %v", got)
+	}
+	if got := properties["mime_overrides"].(map[string]any); fmt.Sprint(got["type"]) != "[object null]" || got["additionalProperties"].(map[string]any)["type"] != "string" {
+		t.Errorf("Unexpected mime_overrides schema: %v", got)
+	}
+	if got := properties["disable_dot_files"].(map[string]any); got["type"] != "boolean" || got["default"] != true {
+		t.Errorf("Unexpected disable_dot_files schema: %v", got)
+	}
+	for _, hidden := range []string{"PasswordHash", "password_hash", "provenance", "file"} {
+		if _, ok := properties[hidden]; ok {
+			t.Errorf("Schema should not describe %s", hidden)
+		}
+	}
+}
+
+func TestSchemaValidatesConfigs(t *testing.T) {
+	schema := roundTrip(t, Schema())
+
+	t.Run("defaults", func(t *testing.T) {
+		if err := validateSchema(schema, roundTrip(t, Default()), "$"); err != nil {
+			t.Errorf("Default configuration does not validate: %v", err)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "known_good",
+			config: `{
+				"host": "127.0.0.1",
+				"port": 9000,
+				"storage_path": "/srv/files",
+				"disable_dot_files": true,
+				"ignore_patterns": ["*.log", ".git"],
+				"mime_overrides": {".md": "text/markdown"},
+				"default_sort": "modified",
+				"unknown_key": "ignored like the loader does"
+			}`,
+		},
+		{name: "port_as_string", config: `{"port": "9000"}`, wantErr: "$.port: expected an integer"},
+		{name: "fractional_port", config: `{"port": 80.5}`, wantErr: "$.port: expected an integer"},
+		{name: "bool_as_string", config: `{"enable_auth": "yes"}`, wantErr: "$.enable_auth: expected a boolean"},
+		{name: "slice_as_string", config: `{"ignore_patterns": "*.log"}`, wantErr: "$.ignore_patterns: expected an array"},
+		{name: "null_slice", config: `{"ignore_patterns": null}`},
+		{name: "slice_of_numbers", config: `{"ignore_patterns": [1]}`, wantErr: "$.ignore_patterns[0]: expected a string"},
+		{name: "map_of_numbers", config: `{"mime_overrides": {".md": 1}}`, wantErr: "$.mime_overrides..md: expected a string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value map[string]any
+			if err := json.Unmarshal([]byte(tt.config), &value); err != nil {
+				t.Fatalf("Invalid test config: %v", err)
+			}
+			err := validateSchema(schema, value, "$")
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Expected the config to validate, got %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+
+			// The schema agrees with the loader: what it rejects fails to load
+			var cfg Config
+			if loadErr := json.Unmarshal([]byte(tt.config), &cfg); (loadErr != nil) != (tt.wantErr != "") {
+				t.Errorf("Schema and loader disagree: schema error %v, loader error %v", err, loadErr)
+			}
+		})
+	}
+}
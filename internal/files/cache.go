@@ -7,6 +7,7 @@ import (
 	"slimserve/internal/logger"
 	"slimserve/internal/storage"
 	"strings"
+	"time"
 )
 
 type CacheManager struct {
@@ -24,6 +25,12 @@ func NewCacheManager(cacheDir string, maxCacheMB int) (*CacheManager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create thumbnail cache: %w", err)
 	}
+	// Evicted thumbnails drop out of the index too
+	thumb.OnEvict(func(keys []string) {
+		if err := forgetIndex(cacheDir, keys...); err != nil {
+			logger.Log.Warn().Err(err).Msg("Failed to update thumbnail cache index")
+		}
+	})
 
 	return &CacheManager{
 		cacheDir: cacheDir,
@@ -47,6 +54,12 @@ func (cm *CacheManager) Contains(key string) bool {
 	return cm.thumb.Contains(key)
 }
 
+// Touch records a cache hit for key so pruning evicts colder entries first.
+// It reports whether key is cached.
+func (cm *CacheManager) Touch(key string) bool {
+	return cm.thumb.Touch(key, time.Now())
+}
+
 func (cm *CacheManager) Get(key string) bool {
 	return cm.thumb.Get(key)
 }
@@ -55,6 +68,18 @@ func (cm *CacheManager) Set(key string, size int64, ext string) {
 	cm.thumb.Set(key, size, ext)
 }
 
+// SetWaterMarks sets the percentages of the cache size above which Set
+// schedules a background prune and down to which it evicts; zero keeps the
+// defaults
+func (cm *CacheManager) SetWaterMarks(highPercent, lowPercent int) {
+	cm.thumb.SetWaterMarks(highPercent, lowPercent)
+}
+
+// WaitForPrune blocks until no prune of the cache directory is running
+func (cm *CacheManager) WaitForPrune() {
+	storage.WaitForThumbPrune(cm.cacheDir)
+}
+
 func (cm *CacheManager) Delete(key string) bool {
 	return cm.thumb.Delete(key)
 }
@@ -66,7 +91,7 @@ func (cm *CacheManager) Stats() (int, int64, int64) {
 func IsImageFile(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
 	switch ext {
-	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".avif":
 		return true
 	default:
 		return false
@@ -130,6 +130,7 @@ func TestCacheManagerPruneOldestFirst(t *testing.T) {
 	t.Logf("Cache stats after rebuild - count: %d, used: %d, max: %d", added, used, maxBytes)
 
 	cacheManager.Set("new_thumb", 512*1024, ".jpg")
+	cacheManager.WaitForPrune()
 
 	added, used, maxBytes = cacheManager.Stats()
 	t.Logf("Cache stats after Set - count: %d, used: %d, max: %d", added, used, maxBytes)
@@ -157,6 +158,127 @@ func TestCacheManagerPruneOldestFirst(t *testing.T) {
 	}
 }
 
+func TestCacheManagerPruneLeastRecentlyUsed(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	os.MkdirAll(cacheDir, 0755)
+
+	// hot was generated first but is served on every request; cold was
+	// generated later and never requested again.
+	baseTime := time.Now().Add(-3 * time.Hour)
+	content := make([]byte, 512*1024)
+	for i, name := range []string{"hot.jpg", "cold.jpg"} {
+		filePath := filepath.Join(cacheDir, name)
+		if err := os.WriteFile(filePath, content, 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+		modTime := baseTime.Add(time.Duration(i) * time.Hour)
+		if err := os.Chtimes(filePath, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set mod time for %s: %v", name, err)
+		}
+	}
+
+	// Every request builds its own manager, so recency must survive a rebuild
+	for i := 0; i < 3; i++ {
+		cacheManager, err := NewCacheManager(cacheDir, 1)
+		if err != nil {
+			t.Fatalf("Failed to create cache manager: %v", err)
+		}
+		if !cacheManager.Touch("hot") {
+			t.Fatal("Expected hot thumbnail to be cached")
+		}
+	}
+
+	cacheManager, err := NewCacheManager(cacheDir, 1)
+	if err != nil {
+		t.Fatalf("Failed to create cache manager: %v", err)
+	}
+	// Prune only down to the limit, so exactly one thumbnail has to go
+	cacheManager.SetWaterMarks(100, 100)
+	cacheManager.Set("new_thumb", 512*1024, ".jpg")
+	cacheManager.WaitForPrune()
+
+	if _, err := os.Stat(filepath.Join(cacheDir, "hot.jpg")); err != nil {
+		t.Errorf("Frequently accessed 'hot.jpg' should survive pruning: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "cold.jpg")); !os.IsNotExist(err) {
+		t.Errorf("Untouched 'cold.jpg' should have been evicted, stat err: %v", err)
+	}
+}
+
+func TestCacheManagerBackgroundPrune(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	cacheManager, err := NewCacheManager(cacheDir, 1)
+	if err != nil {
+		t.Fatalf("Failed to create cache manager: %v", err)
+	}
+	cacheManager.SetWaterMarks(80, 50)
+
+	const thumbSize = 100 * 1024
+	set := func(i int) {
+		key := fmt.Sprintf("thumb_%d", i)
+		if err := os.WriteFile(filepath.Join(cacheDir, key+".jpg"), make([]byte, thumbSize), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", key, err)
+		}
+		cacheManager.Set(key, thumbSize, ".jpg")
+	}
+
+	// 800 KiB is under the 80% high-water mark of 819.2 KiB, so nothing goes
+	for i := 0; i < 8; i++ {
+		set(i)
+	}
+	cacheManager.WaitForPrune()
+	if count, used, _ := cacheManager.Stats(); count != 8 || used != 8*thumbSize {
+		t.Fatalf("Expected no pruning below the high-water mark, got %d entries and %d bytes", count, used)
+	}
+
+	// The ninth crosses it, and the prune evicts down to the 50% low-water mark
+	set(8)
+	cacheManager.WaitForPrune()
+
+	count, used, maxBytes := cacheManager.Stats()
+	if used > maxBytes/2 {
+		t.Errorf("Expected the cache under the low-water mark of %d bytes, got %d", maxBytes/2, used)
+	}
+	if count != 5 {
+		t.Errorf("Expected 5 thumbnails left, got %d", count)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := os.Stat(filepath.Join(cacheDir, fmt.Sprintf("thumb_%d.jpg", i))); !os.IsNotExist(err) {
+			t.Errorf("Expected thumb_%d.jpg to be evicted, stat err: %v", i, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "thumb_8.jpg")); err != nil {
+		t.Errorf("Expected the newest thumbnail to survive: %v", err)
+	}
+}
+
+func TestCacheManagerCountsAvifFiles(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	os.MkdirAll(cacheDir, 0755)
+
+	for _, name := range []string{"a.jpg", "b.avif"} {
+		if err := os.WriteFile(filepath.Join(cacheDir, name), make([]byte, 1024), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+	}
+
+	cacheManager, err := NewCacheManager(cacheDir, 10)
+	if err != nil {
+		t.Fatalf("Failed to create cache manager: %v", err)
+	}
+
+	count, used, _ := cacheManager.Stats()
+	if count != 2 || used != 2048 {
+		t.Errorf("Expected 2 files and 2048 bytes accounted, got %d files and %d bytes", count, used)
+	}
+	if !cacheManager.Delete("b") {
+		t.Error("Expected the .avif entry to be prunable")
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "b.avif")); !os.IsNotExist(err) {
+		t.Errorf("Expected b.avif to be removed, stat err: %v", err)
+	}
+}
+
 func TestCacheManagerPruneIfNeeded(t *testing.T) {
 	testDir := t.TempDir()
 	cacheDir := filepath.Join(testDir, "cache")
@@ -0,0 +1,228 @@
+package files
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// indexFileName is the cache index inside the cache directory. Its extension
+// keeps it out of the cache's size accounting.
+const indexFileName = "index.json"
+
+// indexWriteInterval is the least time between two writes of one index file.
+// Changes inside it stay in memory until the next change after it, or until
+// FlushIndexes, so a burst of thumbnails costs one write.
+const indexWriteInterval = time.Second
+
+// cacheIndex is one cache directory's index, held in memory once loaded
+type cacheIndex struct {
+	mu      sync.Mutex
+	dir     string
+	entries map[string]IndexEntry // nil until loaded
+	dirty   bool
+	written time.Time
+}
+
+// cacheIndexes maps a cache directory to its *cacheIndex
+var cacheIndexes sync.Map
+
+// lockIndex returns the loaded index of cacheDir with its lock held
+func lockIndex(cacheDir string) (*cacheIndex, error) {
+	dir := filepath.Clean(cacheDir)
+	v, _ := cacheIndexes.LoadOrStore(dir, &cacheIndex{dir: dir})
+	ix := v.(*cacheIndex)
+	ix.mu.Lock()
+	if ix.entries == nil {
+		entries, err := readIndex(dir)
+		if err != nil {
+			ix.mu.Unlock()
+			return nil, err
+		}
+		ix.entries = entries
+	}
+	return ix, nil
+}
+
+// changed marks the index dirty and writes it unless the last write was
+// within indexWriteInterval
+func (ix *cacheIndex) changed() error {
+	ix.dirty = true
+	if time.Since(ix.written) < indexWriteInterval {
+		return nil
+	}
+	return ix.flush()
+}
+
+// flush writes the index if it has unwritten changes. A cache directory that
+// has been removed has nothing left to index.
+func (ix *cacheIndex) flush() error {
+	if !ix.dirty {
+		return nil
+	}
+	if _, err := os.Stat(ix.dir); errors.Is(err, os.ErrNotExist) {
+		ix.dirty = false
+		return nil
+	}
+	if err := writeIndex(ix.dir, ix.entries); err != nil {
+		return err
+	}
+	ix.dirty = false
+	ix.written = time.Now()
+	return nil
+}
+
+// FlushIndexes writes every index with changes still held in memory
+func FlushIndexes() error {
+	var errs []error
+	cacheIndexes.Range(func(_, v any) bool {
+		ix := v.(*cacheIndex)
+		ix.mu.Lock()
+		errs = append(errs, ix.flush())
+		ix.mu.Unlock()
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+// IndexEntry records where a cached thumbnail came from, since cache keys are
+// one-way hashes.
+type IndexEntry struct {
+	Source  string    `json:"source"`  // absolute path of the source image
+	Size    int64     `json:"size"`    // source size when the thumbnail was made
+	MaxDim  int       `json:"max_dim"` // thumbnail bounding box
+	Ext     string    `json:"ext"`     // cache file extension, including the dot
+	Created time.Time `json:"created"`
+}
+
+// LookupIndex returns the index entry for a cache key
+func LookupIndex(cacheDir, key string) (IndexEntry, bool, error) {
+	ix, err := lockIndex(cacheDir)
+	if err != nil {
+		return IndexEntry{}, false, err
+	}
+	defer ix.mu.Unlock()
+
+	entry, ok := ix.entries[key]
+	return entry, ok, nil
+}
+
+// IndexEntries returns a copy of every entry in the cache index keyed by
+// cache key
+func IndexEntries(cacheDir string) (map[string]IndexEntry, error) {
+	ix, err := lockIndex(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	defer ix.mu.Unlock()
+
+	return maps.Clone(ix.entries), nil
+}
+
+// InvalidateSource removes every cached thumbnail generated from srcPath
+// along with its index entries, and returns how many entries were removed.
+func InvalidateSource(cacheDir, srcPath string) (int, error) {
+	source, err := filepath.Abs(srcPath)
+	if err != nil {
+		return 0, err
+	}
+
+	ix, err := lockIndex(cacheDir)
+	if err != nil {
+		return 0, err
+	}
+	defer ix.mu.Unlock()
+
+	removed := 0
+	for key, entry := range ix.entries {
+		if entry.Source != source {
+			continue
+		}
+		if err := os.Remove(filepath.Join(cacheDir, key+entry.Ext)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to remove cached thumbnail: %w", err)
+		}
+		delete(ix.entries, key)
+		removed++
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, ix.changed()
+}
+
+// recordIndex adds or replaces the entry for a cache key
+func recordIndex(cacheDir, key string, entry IndexEntry) error {
+	ix, err := lockIndex(cacheDir)
+	if err != nil {
+		return err
+	}
+	defer ix.mu.Unlock()
+
+	ix.entries[key] = entry
+	return ix.changed()
+}
+
+// forgetIndex removes the entries for cache keys the cache has evicted
+func forgetIndex(cacheDir string, keys ...string) error {
+	ix, err := lockIndex(cacheDir)
+	if err != nil {
+		return err
+	}
+	defer ix.mu.Unlock()
+
+	n := len(ix.entries)
+	for _, key := range keys {
+		delete(ix.entries, key)
+	}
+	if len(ix.entries) == n {
+		return nil
+	}
+	return ix.changed()
+}
+
+// readIndex loads the index, treating a missing or unreadable file as empty
+// so a damaged index never blocks thumbnail generation.
+func readIndex(cacheDir string) (map[string]IndexEntry, error) {
+	entries := make(map[string]IndexEntry)
+
+	data, err := os.ReadFile(filepath.Join(cacheDir, indexFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache index: %w", err)
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return make(map[string]IndexEntry), nil
+	}
+	return entries, nil
+}
+
+// writeIndex replaces the index file atomically
+func writeIndex(cacheDir string, entries map[string]IndexEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(cacheDir, indexFileName+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache index: %w", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(cacheDir, indexFileName))
+}
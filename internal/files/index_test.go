@@ -0,0 +1,138 @@
+package files
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheIndex(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("SLIMSERVE_CACHE_DIR", cacheDir)
+
+	srcDir := t.TempDir()
+	thumbs := make(map[string]string)
+	for _, name := range []string{"keep.png", "drop.png"} {
+		srcPath := filepath.Join(srcDir, name)
+		file, err := os.Create(srcPath)
+		if err != nil {
+			t.Fatalf("Failed to create test image: %v", err)
+		}
+		if err := png.Encode(file, image.NewRGBA(image.Rect(0, 0, 32, 32))); err != nil {
+			t.Fatalf("Failed to encode test image: %v", err)
+		}
+		file.Close()
+
+		thumbPath, err := GenerateWithCacheLimit(srcPath, 16, 10, 85, 10)
+		if err != nil {
+			t.Fatalf("GenerateWithCacheLimit failed: %v", err)
+		}
+		thumbs[name] = thumbPath
+	}
+
+	keyOf := func(thumbPath string) string {
+		base := filepath.Base(thumbPath)
+		return base[:len(base)-len(filepath.Ext(base))]
+	}
+
+	entries, err := IndexEntries(cacheDir)
+	if err != nil {
+		t.Fatalf("IndexEntries failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 index entries, got %d", len(entries))
+	}
+
+	entry, ok, err := LookupIndex(cacheDir, keyOf(thumbs["keep.png"]))
+	if err != nil || !ok {
+		t.Fatalf("Expected index entry for keep.png, ok=%v err=%v", ok, err)
+	}
+	if entry.Source != filepath.Join(srcDir, "keep.png") || entry.MaxDim != 16 || entry.Ext != ".jpg" {
+		t.Errorf("Unexpected index entry: %+v", entry)
+	}
+
+	removed, err := InvalidateSource(cacheDir, filepath.Join(srcDir, "drop.png"))
+	if err != nil {
+		t.Fatalf("InvalidateSource failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 entry removed, got %d", removed)
+	}
+
+	if _, err := os.Stat(thumbs["drop.png"]); !os.IsNotExist(err) {
+		t.Errorf("Expected drop.png thumbnail to be removed, stat err: %v", err)
+	}
+	if _, err := os.Stat(thumbs["keep.png"]); err != nil {
+		t.Errorf("Expected keep.png thumbnail to remain: %v", err)
+	}
+	if _, ok, _ := LookupIndex(cacheDir, keyOf(thumbs["drop.png"])); ok {
+		t.Error("Expected drop.png index entry to be removed")
+	}
+	if _, ok, _ := LookupIndex(cacheDir, keyOf(thumbs["keep.png"])); !ok {
+		t.Error("Expected keep.png index entry to remain")
+	}
+}
+
+func TestCacheIndexForgetsEvicted(t *testing.T) {
+	cacheDir := t.TempDir()
+	for _, key := range []string{"a", "b", "c", "d"} {
+		if err := recordIndex(cacheDir, key, IndexEntry{Source: "/src/" + key, Ext: ".jpg"}); err != nil {
+			t.Fatalf("recordIndex failed: %v", err)
+		}
+	}
+
+	cm, err := NewCacheManager(cacheDir, 1)
+	if err != nil {
+		t.Fatalf("NewCacheManager failed: %v", err)
+	}
+	cm.Set("a", 100, ".jpg")
+	if !cm.Delete("a") {
+		t.Fatal("Expected a to be cached")
+	}
+	// Three 400 KiB thumbnails go over 1 MB; the prune evicts the oldest
+	for _, key := range []string{"b", "c", "d"} {
+		cm.Set(key, 400*1024, ".jpg")
+	}
+	cm.WaitForPrune()
+
+	entries, err := IndexEntries(cacheDir)
+	if err != nil {
+		t.Fatalf("IndexEntries failed: %v", err)
+	}
+	for key, want := range map[string]bool{"a": false, "b": false, "c": true, "d": true} {
+		if _, ok := entries[key]; ok != want {
+			t.Errorf("Index entry %q present = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestCacheIndexBatchesWrites(t *testing.T) {
+	cacheDir := t.TempDir()
+	for _, key := range []string{"a", "b", "c"} {
+		if err := recordIndex(cacheDir, key, IndexEntry{Source: "/src/" + key, Ext: ".jpg"}); err != nil {
+			t.Fatalf("recordIndex failed: %v", err)
+		}
+	}
+
+	// Only the first change of the burst is written straight away
+	onDisk, err := readIndex(cacheDir)
+	if err != nil {
+		t.Fatalf("readIndex failed: %v", err)
+	}
+	if len(onDisk) != 1 {
+		t.Errorf("Expected 1 entry written during the burst, got %d", len(onDisk))
+	}
+
+	if err := FlushIndexes(); err != nil {
+		t.Fatalf("FlushIndexes failed: %v", err)
+	}
+	onDisk, err = readIndex(cacheDir)
+	if err != nil {
+		t.Fatalf("readIndex failed: %v", err)
+	}
+	if len(onDisk) != 3 {
+		t.Errorf("Expected 3 entries after flushing, got %d", len(onDisk))
+	}
+}
@@ -0,0 +1,81 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// sourceCopy is one file in SourceCopyDir
+type sourceCopy struct {
+	path       string
+	size       int64
+	downloaded time.Time
+}
+
+// PruneSourceCopies deletes copies in SourceCopyDir, oldest download first,
+// until they fit in what the thumbnails leave of maxCacheMB. keep, the copy
+// in use, is never deleted. A non-positive limit leaves the copies alone.
+//
+// A copy's modification time is the remote object's, so downloads are ordered
+// by change time instead, which the download's final rename sets.
+func PruneSourceCopies(keep string, maxCacheMB int) error {
+	if maxCacheMB <= 0 {
+		return nil
+	}
+
+	cm, err := NewCacheManager(CacheDir(), maxCacheMB)
+	if err != nil {
+		return err
+	}
+	_, thumbBytes, maxBytes := cm.Stats()
+	budget := maxBytes - thumbBytes
+
+	dir := SourceCopyDir()
+	dirEntries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read source copies: %w", err)
+	}
+
+	var copies []sourceCopy
+	var total int64
+	for _, entry := range dirEntries {
+		// Dot files are downloads still in progress
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		downloaded := info.ModTime()
+		if statT, ok := info.Sys().(*syscall.Stat_t); ok {
+			downloaded = time.Unix(statT.Ctim.Sec, statT.Ctim.Nsec)
+		}
+		copies = append(copies, sourceCopy{filepath.Join(dir, entry.Name()), info.Size(), downloaded})
+		total += info.Size()
+	}
+
+	sort.Slice(copies, func(i, j int) bool { return copies[i].downloaded.Before(copies[j].downloaded) })
+	for _, c := range copies {
+		if total <= budget {
+			break
+		}
+		if c.path == keep {
+			continue
+		}
+		if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove source copy: %w", err)
+		}
+		total -= c.size
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPruneSourceCopies(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("SLIMSERVE_CACHE_DIR", cacheDir)
+	dir := SourceCopyDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	// Three 400 KiB copies, downloaded in order, against a 1 MiB limit
+	var paths []string
+	for _, name := range []string{"old.png", "mid.png", "new.png"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, 400*1024), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		paths = append(paths, path)
+		time.Sleep(10 * time.Millisecond)
+	}
+	partial := filepath.Join(dir, ".download-1")
+	if err := os.WriteFile(partial, make([]byte, 400*1024), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if err := PruneSourceCopies(paths[0], 1); err != nil {
+		t.Fatalf("PruneSourceCopies: %v", err)
+	}
+
+	for path, want := range map[string]bool{
+		paths[0]: true,  // in use
+		paths[1]: false, // oldest of the rest
+		paths[2]: true,
+		partial:  true,
+	} {
+		_, err := os.Stat(path)
+		if got := err == nil; got != want {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(path), got, want)
+		}
+	}
+
+	if err := PruneSourceCopies("", 0); err != nil {
+		t.Fatalf("PruneSourceCopies without a limit: %v", err)
+	}
+	if _, err := os.Stat(paths[2]); err != nil {
+		t.Errorf("copy removed without a limit: %v", err)
+	}
+}
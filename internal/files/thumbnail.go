@@ -1,20 +1,26 @@
 package files
 
 import (
+	"compress/gzip"
+	"context"
 	"crypto/sha1"
 	"errors"
 	"fmt"
 	"image"
+	"image/gif"
 	"image/jpeg"
 	_ "image/png" // import for side effects
 	"io"
 	"os"
 	"path/filepath"
 	"slimserve/internal/logger"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/HugoSmits86/nativewebp"
 	"github.com/cespare/xxhash/v2"
+	"github.com/gen2brain/avif"
 	"golang.org/x/image/draw"
 	_ "golang.org/x/image/webp" // import for side effects
 )
@@ -22,17 +28,90 @@ import (
 var (
 	// ErrFileTooLarge is returned when a source image exceeds the size limit for thumbnailing.
 	ErrFileTooLarge = errors.New("file too large for thumbnail generation")
+	// ErrThumbnailTimeout is returned when the context ends before a thumbnail is generated.
+	ErrThumbnailTimeout = errors.New("thumbnail generation timed out")
 )
 
+// Thumbnail formats for Options.Format
+const (
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+	FormatAVIF = "avif"
+)
+
+// avifSpeed trades AVIF compression for encoding time on a 0-10 scale;
+// thumbnails are small, but WebAssembly makes the slow end too slow
+const avifSpeed = 8
+
+// CompressedExt is appended to the names of gzip-compressed thumbnails
+const CompressedExt = ".gz"
+
+// decodeImage decodes a source image. It is a variable so tests can
+// substitute a slow decoder.
+var decodeImage = func(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
 // Generate creates a thumbnail for the given source file path with the specified maximum dimension.
 // It is kept for API compatibility - external code may still call this function.
 func Generate(srcPath string, maxDim int) (string, error) {
 	return GenerateWithCacheLimit(srcPath, maxDim, 0, 85, 10)
 }
 
+// CacheDir returns the thumbnail cache directory, SLIMSERVE_CACHE_DIR when set
+func CacheDir() string {
+	if dir := os.Getenv("SLIMSERVE_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "slimserve", "thumbcache")
+}
+
+// SourceCopyDir returns where images from remote storage are copied for
+// thumbnailing. It sits beside the cache directory rather than inside it, so
+// the copies are not taken for thumbnails.
+func SourceCopyDir() string {
+	return CacheDir() + "-sources"
+}
+
 // GenerateWithCacheLimit creates a thumbnail with cache size checking and configurable generation options.
 // It now supports forcing JPEG output, configurable JPEG quality, and a conditional scaling algorithm.
 func GenerateWithCacheLimit(srcPath string, maxDim, maxCacheMB, jpegQuality, maxFileMB int) (string, error) {
+	return GenerateWithContext(context.Background(), srcPath, Options{
+		MaxDim:      maxDim,
+		MaxCacheMB:  maxCacheMB,
+		JpegQuality: jpegQuality,
+		MaxFileMB:   maxFileMB,
+	})
+}
+
+// Options controls how GenerateWithContext builds a thumbnail
+type Options struct {
+	MaxDim      int
+	MaxCacheMB  int
+	JpegQuality int
+	MaxFileMB   int
+	// Animated keeps every frame of a GIF source and writes a GIF thumbnail;
+	// otherwise only the first frame is used and the output is JPEG.
+	Animated bool
+	// Format is the encoding of still thumbnails, FormatJPEG when empty.
+	// Animated GIF thumbnails stay GIFs.
+	Format string
+	// Compress gzips the cached thumbnail; the returned path then ends in
+	// CompressedExt.
+	Compress bool
+	// Percentages of MaxCacheMB above which the cache is pruned in the
+	// background and down to which it is pruned; zero keeps the defaults
+	PruneHighPercent int
+	PruneLowPercent  int
+}
+
+// GenerateWithContext creates a thumbnail as configured by opts, bounded by
+// ctx. Once ctx is done it returns ErrThumbnailTimeout without waiting for
+// the decoder; the abandoned generation stops at its next stage and leaves
+// no file behind.
+func GenerateWithContext(ctx context.Context, srcPath string, opts Options) (string, error) {
+	maxDim, maxCacheMB, maxFileMB := opts.MaxDim, opts.MaxCacheMB, opts.MaxFileMB
 	start := time.Now()
 	logger.Log.Debug().Msgf("Starting thumbnail generation for %s (max dimension: %d)", srcPath, maxDim)
 
@@ -48,10 +127,7 @@ func GenerateWithCacheLimit(srcPath string, maxDim, maxCacheMB, jpegQuality, max
 		return "", ErrFileTooLarge
 	}
 
-	cacheDir := os.Getenv("SLIMSERVE_CACHE_DIR")
-	if cacheDir == "" {
-		cacheDir = filepath.Join(os.TempDir(), "slimserve", "thumbcache")
-	}
+	cacheDir := CacheDir()
 
 	cacheKey, err := generateCacheKey(srcPath, maxDim)
 	if err != nil {
@@ -59,6 +135,22 @@ func GenerateWithCacheLimit(srcPath string, maxDim, maxCacheMB, jpegQuality, max
 	}
 
 	outputExt := ".jpg"
+	animated := opts.Animated && strings.EqualFold(filepath.Ext(srcPath), ".gif")
+	if animated {
+		// Keep animated and first-frame thumbnails of one source apart
+		cacheKey += "-anim"
+		outputExt = ".gif"
+	} else if opts.Format == FormatWebP || opts.Format == FormatAVIF {
+		// Each format is cached under its own key
+		cacheKey += "-" + opts.Format
+		outputExt = "." + opts.Format
+	}
+	if opts.Compress {
+		// Compressed and plain thumbnails get their own entries, so toggling
+		// the option never serves a file in the wrong form
+		cacheKey += "-gz"
+		outputExt += CompressedExt
+	}
 	thumbPath := filepath.Join(cacheDir, fmt.Sprintf("%s%s", cacheKey, outputExt))
 
 	var cacheManager *CacheManager
@@ -66,9 +158,12 @@ func GenerateWithCacheLimit(srcPath string, maxDim, maxCacheMB, jpegQuality, max
 		cacheManager, err = NewCacheManager(cacheDir, maxCacheMB)
 		if err != nil {
 			logger.Log.Warn().Msgf("Failed to create cache manager: %v, proceeding without cache", err)
-		} else if cacheManager.Contains(cacheKey) {
-			logger.Log.Debug().Msgf("Using cached thumbnail for %s", srcPath)
-			return thumbPath, nil
+		} else {
+			cacheManager.SetWaterMarks(opts.PruneHighPercent, opts.PruneLowPercent)
+			if cacheManager.Touch(cacheKey) {
+				logger.Log.Debug().Msgf("Using cached thumbnail for %s", srcPath)
+				return thumbPath, nil
+			}
 		}
 	} else {
 		if err := os.MkdirAll(cacheDir, 0755); err != nil {
@@ -76,15 +171,41 @@ func GenerateWithCacheLimit(srcPath string, maxDim, maxCacheMB, jpegQuality, max
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrThumbnailTimeout, err)
+	}
+
 	scaler := draw.ApproxBiLinear
-	if err := generateThumbnail(srcPath, thumbPath, maxDim, jpegQuality, scaler); err != nil {
-		logger.Log.Error().Msgf("Failed to generate thumbnail for %s: %v", srcPath, err)
-		return "", fmt.Errorf("failed to generate thumbnail: %w", err)
+	done := make(chan error, 1)
+	go func() {
+		if animated {
+			done <- generateAnimatedThumbnail(ctx, srcPath, thumbPath, maxDim, scaler)
+			return
+		}
+		done <- generateThumbnail(ctx, srcPath, thumbPath, maxDim, opts.JpegQuality, opts.Format, scaler)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			logger.Log.Error().Msgf("Failed to generate thumbnail for %s: %v", srcPath, err)
+			return "", fmt.Errorf("failed to generate thumbnail: %w", err)
+		}
+	case <-ctx.Done():
+		logger.Log.Warn().Msgf("Abandoned thumbnail generation for %s after %v", srcPath, time.Since(start))
+		return "", fmt.Errorf("%w: %w", ErrThumbnailTimeout, ctx.Err())
+	}
+
+	if source, err := filepath.Abs(srcPath); err == nil {
+		entry := IndexEntry{Source: source, Size: info.Size(), MaxDim: maxDim, Ext: outputExt, Created: time.Now()}
+		if err := recordIndex(cacheDir, cacheKey, entry); err != nil {
+			logger.Log.Warn().Msgf("Failed to update thumbnail cache index: %v", err)
+		}
 	}
 
 	if cacheManager != nil {
 		if thumbInfo, err := os.Stat(thumbPath); err == nil {
-			cacheManager.Set(cacheKey, thumbInfo.Size(), ".jpg")
+			cacheManager.Set(cacheKey, thumbInfo.Size(), outputExt)
 		}
 	}
 
@@ -93,18 +214,23 @@ func GenerateWithCacheLimit(srcPath string, maxDim, maxCacheMB, jpegQuality, max
 	return thumbPath, nil
 }
 
-// generateThumbnail creates a thumbnail using a specific scaler and JPEG quality.
-func generateThumbnail(srcPath, thumbPath string, maxDim, jpegQuality int, scaler draw.Scaler) error {
+// generateThumbnail scales srcPath with scaler and encodes it as format, at
+// jpegQuality for both JPEG and AVIF. It gives up between stages once ctx is
+// done.
+func generateThumbnail(ctx context.Context, srcPath, thumbPath string, maxDim, jpegQuality int, format string, scaler draw.Scaler) error {
 	srcFile, err := os.Open(srcPath)
 	if err != nil {
 		return fmt.Errorf("failed to open source file: %w", err)
 	}
 	defer srcFile.Close()
 
-	srcImg, _, err := image.Decode(srcFile)
+	srcImg, err := decodeImage(srcFile)
 	if err != nil {
 		return fmt.Errorf("failed to decode image: %w", err)
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	bounds := srcImg.Bounds()
 	width, height := bounds.Dx(), bounds.Dy()
@@ -112,35 +238,154 @@ func generateThumbnail(srcPath, thumbPath string, maxDim, jpegQuality int, scale
 		return fmt.Errorf("invalid image dimensions: %dx%d", width, height)
 	}
 
-	var newWidth, newHeight int
-	if width > height {
-		newWidth = maxDim
-		newHeight = height * maxDim / width
-	} else {
-		newHeight = maxDim
-		newWidth = width * maxDim / height
+	newWidth, newHeight := fitWithin(width, height, maxDim)
+	thumbImg := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	scaler.Scale(thumbImg, thumbImg.Bounds(), srcImg, srcImg.Bounds(), draw.Over, nil)
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	if width <= maxDim && height <= maxDim {
-		newWidth, newHeight = width, height
+	if jpegQuality < 1 {
+		jpegQuality = 1
+	} else if jpegQuality > 100 {
+		jpegQuality = 100
 	}
 
-	thumbImg := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
-	scaler.Scale(thumbImg, thumbImg.Bounds(), srcImg, srcImg.Bounds(), draw.Over, nil)
+	switch format {
+	case FormatWebP:
+		// The only encoder without cgo is lossless
+		return writeThumbnail(ctx, thumbPath, func(w io.Writer) error {
+			return nativewebp.Encode(w, thumbImg, nil)
+		})
+	case FormatAVIF:
+		// libavif runs as WebAssembly, so no cgo is needed
+		return writeThumbnail(ctx, thumbPath, func(w io.Writer) error {
+			return avif.Encode(w, thumbImg, avif.Options{
+				Quality:           jpegQuality,
+				QualityAlpha:      jpegQuality,
+				Speed:             avifSpeed,
+				ChromaSubsampling: image.YCbCrSubsampleRatio420,
+			})
+		})
+	}
+	return writeThumbnail(ctx, thumbPath, func(w io.Writer) error {
+		return jpeg.Encode(w, thumbImg, &jpeg.Options{Quality: jpegQuality})
+	})
+}
+
+// generateAnimatedThumbnail scales every frame of a GIF and writes them as an
+// animated GIF with the source's timing. Frames are composited first, so each
+// output frame is a complete picture regardless of the source's disposal.
+func generateAnimatedThumbnail(ctx context.Context, srcPath, thumbPath string, maxDim int, scaler draw.Scaler) error {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer srcFile.Close()
 
-	thumbFile, err := os.Create(thumbPath)
+	src, err := gif.DecodeAll(srcFile)
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	width, height := src.Config.Width, src.Config.Height
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid image dimensions: %dx%d", width, height)
+	}
+	newWidth, newHeight := fitWithin(width, height, maxDim)
+
+	out := &gif.GIF{LoopCount: src.LoopCount}
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, frame := range src.Image {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var disposal byte
+		if i < len(src.Disposal) {
+			disposal = src.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(canvas.Bounds())
+			draw.Copy(previous, image.Point{}, canvas, canvas.Bounds(), draw.Src, nil)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		scaled := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+		scaler.Scale(scaled, scaled.Bounds(), canvas, canvas.Bounds(), draw.Src, nil)
+		paletted := image.NewPaletted(scaled.Bounds(), frame.Palette)
+		draw.Draw(paletted, paletted.Bounds(), scaled, image.Point{}, draw.Src)
+
+		out.Image = append(out.Image, paletted)
+		if i < len(src.Delay) {
+			out.Delay = append(out.Delay, src.Delay[i])
+		} else {
+			out.Delay = append(out.Delay, 0)
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return writeThumbnail(ctx, thumbPath, func(w io.Writer) error {
+		return gif.EncodeAll(w, out)
+	})
+}
+
+// fitWithin scales width and height down to fit a maxDim square, keeping the
+// aspect ratio. Images that already fit keep their size.
+func fitWithin(width, height, maxDim int) (int, int) {
+	if width <= maxDim && height <= maxDim {
+		return width, height
+	}
+	if width > height {
+		return maxDim, max(height*maxDim/width, 1)
+	}
+	return max(width*maxDim/height, 1), maxDim
+}
+
+// writeThumbnail encodes through a temporary file that is renamed into place,
+// so thumbPath never holds a partial image and nothing is left behind once
+// ctx is done. A thumbPath ending in CompressedExt is written gzipped.
+func writeThumbnail(ctx context.Context, thumbPath string, encode func(io.Writer) error) error {
+	// The temporary name does not end in an image extension, so a cache
+	// rebuild never mistakes it for a thumbnail.
+	thumbFile, err := os.CreateTemp(filepath.Dir(thumbPath), filepath.Base(thumbPath)+".tmp*")
 	if err != nil {
 		return fmt.Errorf("failed to create thumbnail file: %w", err)
 	}
-	defer thumbFile.Close()
+	tmpPath := thumbFile.Name()
+	defer os.Remove(tmpPath)
 
-	if jpegQuality < 1 {
-		jpegQuality = 1
-	} else if jpegQuality > 100 {
-		jpegQuality = 100
+	if strings.HasSuffix(thumbPath, CompressedExt) {
+		plain := encode
+		encode = func(w io.Writer) error {
+			zw := gzip.NewWriter(w)
+			if err := plain(zw); err != nil {
+				return err
+			}
+			return zw.Close()
+		}
+	}
+
+	if err := encode(thumbFile); err != nil {
+		thumbFile.Close()
+		return err
+	}
+	if err := thumbFile.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	return jpeg.Encode(thumbFile, thumbImg, &jpeg.Options{Quality: jpegQuality})
+	return os.Rename(tmpPath, thumbPath)
 }
 
 // generateCacheKey implements cache key generation using 4-step algorithm:
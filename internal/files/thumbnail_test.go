@@ -1,16 +1,23 @@
 package files
 
 import (
+	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"image"
 	"image/color"
+	"image/gif"
+	"image/jpeg"
 	"image/png"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"slimserve/internal/storage"
 )
 
 func TestGenerate(t *testing.T) {
@@ -257,6 +264,7 @@ func TestGenerateWithCacheLimit(t *testing.T) {
 
 				// For cache limit exceeded test, verify cache size is within limit after generation
 				if strings.Contains(test.name, "cache limit exceeded") {
+					storage.WaitForThumbPrune(customCacheDir)
 					cacheManager, _ := NewCacheManager(customCacheDir, test.cacheLimitMB)
 					finalCacheSize := cacheManager.SizeMB()
 					t.Logf("Final cache size: %d MB, limit: %d MB", finalCacheSize, test.cacheLimitMB)
@@ -500,3 +508,224 @@ func copyFile(src, dst string) error {
 	_, err = destFile.ReadFrom(sourceFile)
 	return err
 }
+
+func TestGenerateWithContextTimeout(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("SLIMSERVE_CACHE_DIR", cacheDir)
+
+	testImagePath := filepath.Join(t.TempDir(), "slow.png")
+	file, err := os.Create(testImagePath)
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+	if err := png.Encode(file, image.NewRGBA(image.Rect(0, 0, 32, 32))); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+	file.Close()
+
+	// The decoder stalls until the test releases it, like a pathological image
+	release := make(chan struct{})
+	decoded := make(chan struct{})
+	original := decodeImage
+	decodeImage = func(r io.Reader) (image.Image, error) {
+		defer close(decoded)
+		<-release
+		return original(r)
+	}
+	defer func() { decodeImage = original }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = GenerateWithContext(ctx, testImagePath, Options{MaxDim: 16, MaxCacheMB: 10, JpegQuality: 85, MaxFileMB: 10})
+	if !errors.Is(err, ErrThumbnailTimeout) {
+		t.Fatalf("Expected ErrThumbnailTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected generation to be abandoned promptly, took %v", elapsed)
+	}
+
+	// Let the abandoned generation finish; it must not leave anything behind
+	close(release)
+	<-decoded
+	time.Sleep(20 * time.Millisecond)
+
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatalf("Failed to read cache dir: %v", err)
+	}
+	for _, entry := range entries {
+		t.Errorf("Expected no cache files after timeout, found %s", entry.Name())
+	}
+
+	t.Run("expired_context_skips_generation", func(t *testing.T) {
+		decodeImage = original
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := GenerateWithContext(ctx, testImagePath, Options{MaxDim: 16, MaxCacheMB: 10, JpegQuality: 85, MaxFileMB: 10}); !errors.Is(err, ErrThumbnailTimeout) {
+			t.Errorf("Expected ErrThumbnailTimeout for a done context, got %v", err)
+		}
+	})
+}
+
+func TestGenerateAnimatedGIF(t *testing.T) {
+	t.Setenv("SLIMSERVE_CACHE_DIR", t.TempDir())
+
+	// A 60x40 GIF cycling through three solid colors
+	palette := color.Palette{color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 255, 255}}
+	src := &gif.GIF{}
+	for i := range palette {
+		frame := image.NewPaletted(image.Rect(0, 0, 60, 40), palette)
+		for j := range frame.Pix {
+			frame.Pix[j] = uint8(i)
+		}
+		src.Image = append(src.Image, frame)
+		src.Delay = append(src.Delay, 10)
+	}
+	testImagePath := filepath.Join(t.TempDir(), "spinner.gif")
+	file, err := os.Create(testImagePath)
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+	if err := gif.EncodeAll(file, src); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+	file.Close()
+
+	generate := func(t *testing.T, animated bool) *os.File {
+		t.Helper()
+		thumbPath, err := GenerateWithContext(context.Background(), testImagePath, Options{
+			MaxDim:      16,
+			MaxCacheMB:  10,
+			JpegQuality: 85,
+			MaxFileMB:   10,
+			Animated:    animated,
+		})
+		if err != nil {
+			t.Fatalf("GenerateWithContext failed: %v", err)
+		}
+		f, err := os.Open(thumbPath)
+		if err != nil {
+			t.Fatalf("Failed to open thumbnail: %v", err)
+		}
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+
+	t.Run("animated", func(t *testing.T) {
+		thumb, err := gif.DecodeAll(generate(t, true))
+		if err != nil {
+			t.Fatalf("Animated thumbnail should be a GIF: %v", err)
+		}
+		if len(thumb.Image) != 3 {
+			t.Errorf("Expected 3 frames, got %d", len(thumb.Image))
+		}
+		if thumb.Config.Width != 16 || thumb.Config.Height != 10 {
+			t.Errorf("Expected 16x10 thumbnail, got %dx%d", thumb.Config.Width, thumb.Config.Height)
+		}
+		if thumb.Delay[1] != 10 {
+			t.Errorf("Expected frame delay 10 to be kept, got %d", thumb.Delay[1])
+		}
+	})
+
+	t.Run("first_frame", func(t *testing.T) {
+		thumb, err := jpeg.Decode(generate(t, false))
+		if err != nil {
+			t.Fatalf("Static thumbnail should be a single JPEG frame: %v", err)
+		}
+		if b := thumb.Bounds(); b.Dx() != 16 || b.Dy() != 10 {
+			t.Errorf("Expected 16x10 thumbnail, got %dx%d", b.Dx(), b.Dy())
+		}
+	})
+}
+
+func TestGenerateCompressed(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("SLIMSERVE_CACHE_DIR", cacheDir)
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{0, 128, 255, 255})
+		}
+	}
+	testImagePath := filepath.Join(t.TempDir(), "test.png")
+	file, err := os.Create(testImagePath)
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+	file.Close()
+
+	generate := func(compress bool) (string, os.FileInfo) {
+		t.Helper()
+		thumbPath, err := GenerateWithContext(context.Background(), testImagePath, Options{
+			MaxDim:      32,
+			MaxCacheMB:  10,
+			JpegQuality: 85,
+			MaxFileMB:   10,
+			Compress:    compress,
+		})
+		if err != nil {
+			t.Fatalf("GenerateWithContext failed: %v", err)
+		}
+		info, err := os.Stat(thumbPath)
+		if err != nil {
+			t.Fatalf("Thumbnail file was not created: %v", err)
+		}
+		return thumbPath, info
+	}
+
+	plainPath, plainInfo := generate(false)
+	gzPath, gzInfo := generate(true)
+
+	if !strings.HasSuffix(gzPath, ".jpg"+CompressedExt) {
+		t.Fatalf("Expected compressed thumbnail to end in .jpg.gz, got %s", gzPath)
+	}
+	if gzPath == plainPath {
+		t.Fatal("Expected compressed and plain thumbnails to be cached separately")
+	}
+	if gzInfo.Size() >= plainInfo.Size() {
+		t.Errorf("Expected compressed thumbnail smaller than %d bytes, got %d", plainInfo.Size(), gzInfo.Size())
+	}
+
+	f, err := os.Open(gzPath)
+	if err != nil {
+		t.Fatalf("Failed to open compressed thumbnail: %v", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Compressed thumbnail is not gzip: %v", err)
+	}
+	thumb, err := jpeg.Decode(zr)
+	if err != nil {
+		t.Fatalf("Decompressed thumbnail is not a JPEG: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != 32 || b.Dy() != 32 {
+		t.Errorf("Expected 32x32 thumbnail, got %dx%d", b.Dx(), b.Dy())
+	}
+
+	// A rebuilt cache counts the compressed size and finds the entry again
+	cm, err := NewCacheManager(cacheDir, 10)
+	if err != nil {
+		t.Fatalf("Failed to create cache manager: %v", err)
+	}
+	_, usedBytes, _ := cm.Stats()
+	if want := plainInfo.Size() + gzInfo.Size(); usedBytes != want {
+		t.Errorf("Expected cache to account %d bytes, got %d", want, usedBytes)
+	}
+	gzKey := strings.TrimSuffix(filepath.Base(gzPath), ".jpg"+CompressedExt)
+	if !cm.Contains(gzKey) {
+		t.Errorf("Expected rebuilt cache to contain %s", gzKey)
+	}
+	if !cm.Delete(gzKey) {
+		t.Fatalf("Expected to delete %s", gzKey)
+	}
+	if _, err := os.Stat(gzPath); !os.IsNotExist(err) {
+		t.Errorf("Expected compressed thumbnail to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+// Package fswatch reports changes below a directory tree, so caches built
+// from it can be dropped as soon as files change instead of when they expire.
+package fswatch
+
+import "errors"
+
+// ErrUnsupported is returned by Watch on platforms without a watcher
+var ErrUnsupported = errors.New("file watching is not supported on this platform")
+
+// Event is one change below a watched root
+type Event struct {
+	// Path is the changed entry, slash-separated and relative to the root.
+	// It is "." when changes were lost and anything may have changed.
+	Path string
+	// Removed is set when the entry was deleted or moved away
+	Removed bool
+}
@@ -0,0 +1,150 @@
+package fswatch
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"slimserve/internal/logger"
+)
+
+// watchMask selects the inotify events that change what a listing shows.
+// Plain writes are reported once, when the writer closes the file.
+const watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB |
+	syscall.IN_ONLYDIR | syscall.IN_DONT_FOLLOW | syscall.IN_EXCL_UNLINK
+
+// Watcher watches a directory and every directory below it with inotify.
+// Directories created later are added as they appear; symlinks are not
+// followed.
+type Watcher struct {
+	root     string
+	fd       int
+	file     *os.File // fd wrapped for the runtime poller, so Close interrupts Read
+	onChange func(Event)
+	done     chan struct{}
+
+	mu   sync.Mutex
+	dirs map[int32]string // watch descriptor to directory, relative to root
+}
+
+// Watch starts watching root, calling onChange from a single goroutine for
+// every change until Close. It fails only when root itself cannot be
+// watched; subdirectories that cannot, for example past the system's watch
+// limit, are logged and skipped.
+func Watch(root string, onChange func(Event)) (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	w := &Watcher{
+		root:     root,
+		fd:       fd,
+		file:     os.NewFile(uintptr(fd), "inotify"),
+		onChange: onChange,
+		done:     make(chan struct{}),
+		dirs:     make(map[int32]string),
+	}
+	if err := w.addTree("."); err != nil {
+		w.file.Close() //nolint:errcheck
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Close stops the watcher and waits for its goroutine to exit
+func (w *Watcher) Close() error {
+	err := w.file.Close()
+	<-w.done
+	return err
+}
+
+// addTree watches dir, relative to the root, and every directory below it
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(filepath.Join(w.root, filepath.FromSlash(dir)), func(p string, d fs.DirEntry, err error) error {
+		rel, relErr := filepath.Rel(w.root, p)
+		if relErr != nil {
+			return relErr
+		}
+		rel = filepath.ToSlash(rel)
+		if err != nil {
+			if rel == "." {
+				return err
+			}
+			logger.Log.Debug().Err(err).Str("path", rel).Msg("Skipping unreadable directory in file watcher")
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		wd, err := syscall.InotifyAddWatch(w.fd, p, watchMask)
+		if err != nil {
+			if rel == "." {
+				return os.NewSyscallError("inotify_add_watch", err)
+			}
+			logger.Log.Warn().Err(err).Str("path", rel).Msg("Cannot watch directory, its changes show once caches expire")
+			return fs.SkipDir
+		}
+		w.mu.Lock()
+		w.dirs[int32(wd)] = rel
+		w.mu.Unlock()
+		return nil
+	})
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+
+	// Room for many events, each at most a header and a NAME_MAX name
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				logger.Log.Warn().Err(err).Str("root", w.root).Msg("File watcher stopped")
+			}
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			wd := int32(binary.NativeEndian.Uint32(buf[offset:]))
+			mask := binary.NativeEndian.Uint32(buf[offset+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[nameStart:nameStart+nameLen]), "\x00")
+			offset = nameStart + nameLen
+			w.handle(wd, mask, name)
+		}
+	}
+}
+
+func (w *Watcher) handle(wd int32, mask uint32, name string) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		w.onChange(Event{Path: "."})
+		return
+	}
+
+	w.mu.Lock()
+	dir, ok := w.dirs[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(w.dirs, wd)
+	}
+	w.mu.Unlock()
+	if !ok || mask&syscall.IN_IGNORED != 0 {
+		return
+	}
+
+	rel := path.Join(dir, name)
+	if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		if err := w.addTree(rel); err != nil {
+			logger.Log.Debug().Err(err).Str("path", rel).Msg("Cannot watch new directory")
+		}
+	}
+	w.onChange(Event{Path: rel, Removed: mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0})
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package fswatch
+
+// Watcher is a placeholder on platforms where Watch always fails
+type Watcher struct{}
+
+// Watch reports ErrUnsupported; callers fall back to their caches' expiry
+func Watch(root string, onChange func(Event)) (*Watcher, error) {
+	return nil, ErrUnsupported
+}
+
+// Close does nothing
+func (w *Watcher) Close() error {
+	return nil
+}
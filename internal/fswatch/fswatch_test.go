@@ -0,0 +1,82 @@
+package fswatch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// startWatch watches root, skipping the test where watching is unsupported
+func startWatch(t *testing.T, root string) <-chan Event {
+	t.Helper()
+	events := make(chan Event, 100)
+	w, err := Watch(root, func(ev Event) { events <- ev })
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return events
+}
+
+// waitFor consumes events until one for path with the given Removed flag,
+// failing after a few seconds
+func waitFor(t *testing.T, events <-chan Event, path string, removed bool) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Path == path && ev.Removed == removed {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("No event for %s", path)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "existing"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	events := startWatch(t, root)
+
+	t.Run("nested_write", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(root, "existing", "a.txt"), []byte("a"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, events, "existing/a.txt", false)
+	})
+
+	t.Run("new_directory", func(t *testing.T) {
+		if err := os.Mkdir(filepath.Join(root, "created"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, events, "created", false)
+
+		if err := os.WriteFile(filepath.Join(root, "created", "b.txt"), []byte("b"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, events, "created/b.txt", false)
+	})
+
+	t.Run("removal", func(t *testing.T) {
+		if err := os.Remove(filepath.Join(root, "existing", "a.txt")); err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, events, "existing/a.txt", true)
+	})
+}
+
+func TestWatchMissingRoot(t *testing.T) {
+	_, err := Watch(filepath.Join(t.TempDir(), "missing"), func(Event) {})
+	if err == nil {
+		t.Fatal("Expected an error watching a missing directory")
+	}
+}
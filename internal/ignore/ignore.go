@@ -0,0 +1,172 @@
+package ignore
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"slimserve/internal/config"
+	"slimserve/internal/logger"
+	"slimserve/internal/security"
+)
+
+const ignoreFileName = ".slimserveignore"
+
+type cachedIgnorePatterns struct {
+	patterns []*Pattern
+	modTime  time.Time
+}
+
+var (
+	ignoreCache      = make(map[string]cachedIgnorePatterns)
+	ignoreCacheMutex = &sync.RWMutex{}
+)
+
+// IsIgnored reports whether relPath is hidden by the configured global
+// patterns or by a .slimserveignore file in any directory along its path.
+func IsIgnored(relPath string, root security.FileSystem, cfg *config.Config) (bool, error) {
+	return IsIgnoredWithPatterns(relPath, root, cfg.IgnorePatterns)
+}
+
+// IsIgnoredWithPatterns evaluates globalPatterns followed by the
+// .slimserveignore file of every directory from the root down to relPath's
+// parent. Patterns in each file are relative to the directory containing it
+// and the last matching pattern wins. A nil root skips ignore files.
+func IsIgnoredWithPatterns(relPath string, root security.FileSystem, globalPatterns []string) (bool, error) {
+	decision, err := Explain(relPath, root, globalPatterns)
+	return decision.Ignored, err
+}
+
+// Sources of a Decision other than an ignore file
+const (
+	SourceConfig  = "config"  // the configured ignore patterns
+	SourceBuiltin = "builtin" // ignore files are always hidden
+)
+
+// Decision explains whether a path is ignored. Pattern, Source and
+// Line name the rule that decided, and are empty when no pattern matched.
+type Decision struct {
+	Ignored bool   `json:"ignored"`
+	Pattern string `json:"pattern,omitempty"`
+	// Source is SourceConfig, SourceBuiltin or the slash-separated path of
+	// the ignore file relative to the root
+	Source string `json:"source,omitempty"`
+	// Line is the line in the ignore file, or the position in the
+	// configured pattern list
+	Line int `json:"line,omitempty"`
+}
+
+// Explain evaluates relPath like IsIgnoredWithPatterns and reports the
+// pattern that decided the outcome.
+func Explain(relPath string, root security.FileSystem, globalPatterns []string) (Decision, error) {
+	if filepath.Base(relPath) == ignoreFileName {
+		return Decision{Ignored: true, Pattern: ignoreFileName, Source: SourceBuiltin}, nil
+	}
+
+	var lastMatch *Pattern
+	lastSource := SourceConfig
+
+	globalPatternReader := strings.NewReader(strings.Join(globalPatterns, "\n"))
+	parsedGlobal, err := Parse(globalPatternReader)
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to parse global ignore patterns: %w", err)
+	}
+	for _, p := range parsedGlobal {
+		if p.Regex.MatchString(relPath) {
+			lastMatch = p
+		}
+	}
+
+	if root == nil {
+		return decide(lastMatch, lastSource), nil
+	}
+
+	var pathSegments []string
+	if dir := filepath.Dir(relPath); dir != "." && dir != "/" {
+		pathSegments = strings.Split(dir, string(filepath.Separator))
+	}
+
+	currentCheckPath := "."
+	for i := -1; i < len(pathSegments); i++ {
+		if i > -1 {
+			currentCheckPath = filepath.Join(currentCheckPath, pathSegments[i])
+		}
+
+		ignoreFilePath := filepath.Join(currentCheckPath, ignoreFileName)
+		patterns, err := getOrReadIgnoreFile(root, ignoreFilePath)
+		if err != nil {
+			logger.Log.Warn().Err(err).Str("path", ignoreFilePath).Msg("Failed to read or parse ignore file")
+		}
+
+		for _, p := range patterns {
+			pathToCheck := relPath
+			if currentCheckPath != "." {
+				rel, err := filepath.Rel(currentCheckPath, relPath)
+				if err != nil {
+					continue
+				}
+				pathToCheck = rel
+			}
+
+			if p.Regex.MatchString(pathToCheck) {
+				lastMatch = p
+				lastSource = filepath.ToSlash(ignoreFilePath)
+			}
+		}
+	}
+
+	return decide(lastMatch, lastSource), nil
+}
+
+// decide turns the last matching pattern into a Decision
+func decide(match *Pattern, source string) Decision {
+	if match == nil {
+		return Decision{}
+	}
+	return Decision{
+		Ignored: !match.Negate,
+		Pattern: match.Text,
+		Source:  source,
+		Line:    match.Line,
+	}
+}
+
+func getOrReadIgnoreFile(root security.FileSystem, path string) ([]*Pattern, error) {
+	fullPath := filepath.Join(root.Path(), path)
+
+	info, err := root.Stat(path)
+	if err != nil {
+		return nil, nil
+	}
+	currentModTime := info.ModTime()
+
+	ignoreCacheMutex.Lock()
+	cached, found := ignoreCache[fullPath]
+	if found && cached.modTime.Equal(currentModTime) {
+		ignoreCacheMutex.Unlock()
+		return cached.patterns, nil
+	}
+	ignoreCacheMutex.Unlock()
+
+	file, err := root.Open(path)
+	if err != nil {
+		return nil, nil
+	}
+	defer file.Close()
+
+	parsedPatterns, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ignore file %q: %w", path, err)
+	}
+
+	ignoreCacheMutex.Lock()
+	ignoreCache[fullPath] = cachedIgnorePatterns{
+		patterns: parsedPatterns,
+		modTime:  currentModTime,
+	}
+	ignoreCacheMutex.Unlock()
+
+	return parsedPatterns, nil
+}
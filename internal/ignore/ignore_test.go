@@ -1,10 +1,11 @@
-package filter
+package ignore
 
 import (
 	"os"
 	"path/filepath"
 	"slimserve/internal/config"
 	"slimserve/internal/security"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -102,3 +103,62 @@ secret.*
 		})
 	}
 }
+
+func TestIsIgnoredWithPatternsNilRoot(t *testing.T) {
+	patterns := []string{"*.log", "!keep.log"}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"app.log", true},
+		{"logs/app.log", true},
+		{"keep.log", false},
+		{"readme.txt", false},
+		{".slimserveignore", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			ignored, err := IsIgnoredWithPatterns(tt.path, nil, patterns)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, ignored)
+		})
+	}
+}
+
+func TestParsePatternMatching(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		matches bool
+	}{
+		{"*.log", "app.log", true},
+		{"*.log", "logs/app.log", true},
+		{"*.log", "app.logger", false},
+		{"*.log", "applog", false},
+		{"/build", "build", true},
+		{"/build", "build/out.bin", true},
+		{"/build", "src/build", false},
+		{"tmp/", "tmp/file", true},
+		{"tmp/", "src/tmp/file", true},
+		{"**/cache", "cache", true},
+		{"**/cache", "a/b/cache", true},
+		{"docs/**", "docs/a/b.md", true},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file12.txt", false},
+		{"[ab].txt", "a.txt", true},
+		{"[ab].txt", "c.txt", false},
+		{"a+b.txt", "a+b.txt", true},
+		{"a+b.txt", "aab.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.path, func(t *testing.T) {
+			patterns, err := Parse(strings.NewReader(tt.pattern))
+			require.NoError(t, err)
+			require.Len(t, patterns, 1)
+			require.Equal(t, tt.matches, patterns[0].Regex.MatchString(tt.path))
+		})
+	}
+}
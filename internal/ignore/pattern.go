@@ -1,4 +1,4 @@
-package filter
+package ignore
 
 import (
 	"bufio"
@@ -11,6 +11,8 @@ import (
 type Pattern struct {
 	Regex  *regexp.Regexp
 	Negate bool
+	Text   string // the line as written, including any leading "!"
+	Line   int
 }
 
 func Parse(r io.Reader) ([]*Pattern, error) {
@@ -29,6 +31,7 @@ func Parse(r io.Reader) ([]*Pattern, error) {
 			continue
 		}
 
+		text := line
 		negate := false
 		if strings.HasPrefix(line, "!") {
 			negate = true
@@ -39,25 +42,32 @@ func Parse(r io.Reader) ([]*Pattern, error) {
 
 		builder.Reset()
 
-		line = strings.ReplaceAll(line, "**/", ".*/")
-		line = strings.ReplaceAll(line, "/**", "/.*")
-		line = strings.ReplaceAll(line, "*", "[^/]*")
-		line = strings.ReplaceAll(line, "?", "[^/]")
+		// Escape regex metacharacters, then turn glob tokens back into
+		// their regex equivalents. Bracket classes are passed through.
+		line = regexp.QuoteMeta(line)
+		line = strings.NewReplacer(`\[`, "[", `\]`, "]").Replace(line)
+		line = strings.ReplaceAll(line, `\*\*/`, ".*/")
+		line = strings.ReplaceAll(line, `/\*\*`, "/.*")
+		line = strings.ReplaceAll(line, `\*`, "[^/]*")
+		line = strings.ReplaceAll(line, `\?`, "[^/]")
 
+		// A pattern matches the path itself or anything beneath it;
+		// a trailing slash only matches directory contents.
+		builder.WriteString(line)
 		if strings.HasSuffix(line, "/") {
-			builder.WriteString(line)
-			builder.WriteString(".*")
+			builder.WriteString(".*$")
 		} else {
-			builder.WriteString(line)
+			builder.WriteString("(/.*)?$")
 		}
 
 		expr := builder.String()
-		if !strings.HasPrefix(expr, ".*/") {
-			if strings.HasPrefix(expr, "/") {
-				expr = "^" + expr[1:]
-			} else {
-				expr = "(.*/|^)" + expr
-			}
+		switch {
+		case strings.HasPrefix(expr, ".*/"):
+			expr = "(.*/|^)" + expr[3:]
+		case strings.HasPrefix(expr, "/"):
+			expr = "^" + expr[1:]
+		default:
+			expr = "(.*/|^)" + expr
 		}
 
 		regex, err := regexp.Compile(expr)
@@ -68,6 +78,8 @@ func Parse(r io.Reader) ([]*Pattern, error) {
 		patterns = append(patterns, &Pattern{
 			Regex:  regex,
 			Negate: negate,
+			Text:   text,
+			Line:   lineNumber,
 		})
 	}
 
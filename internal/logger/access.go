@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"slimserve/internal/config"
+)
+
+// AccessLogEntry holds the fields recorded for every request
+type AccessLogEntry struct {
+	Time       time.Time     `json:"time"`
+	RemoteIP   string        `json:"remote_ip"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	Proto      string        `json:"proto"`
+	Status     int           `json:"status"`
+	Bytes      int           `json:"bytes"`
+	Duration   time.Duration `json:"-"`
+	DurationMS float64       `json:"duration_ms"`
+	Referer    string        `json:"referer"`
+	UserAgent  string        `json:"user_agent"`
+	RequestID  string        `json:"request_id,omitempty"`
+}
+
+// accessLogFormatter renders an entry as a single line, without the trailing newline
+type accessLogFormatter func(buf *bytes.Buffer, entry *AccessLogEntry) error
+
+// parseAccessLogFormat returns the formatter for format, compiling it as a
+// template when it is not one of the built-in names.
+func parseAccessLogFormat(format string) (accessLogFormatter, error) {
+	switch format {
+	case config.AccessLogCombined:
+		return formatCombined, nil
+	case config.AccessLogJSON:
+		return func(buf *bytes.Buffer, entry *AccessLogEntry) error {
+			return json.NewEncoder(buf).Encode(entry)
+		}, nil
+	}
+
+	tmpl, err := template.New("access_log").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access log template: %w", err)
+	}
+	return func(buf *bytes.Buffer, entry *AccessLogEntry) error {
+		return tmpl.Execute(buf, entry)
+	}, nil
+}
+
+// formatCombined writes the Apache combined log format
+func formatCombined(buf *bytes.Buffer, entry *AccessLogEntry) error {
+	size := "-"
+	if entry.Bytes > 0 {
+		size = fmt.Sprintf("%d", entry.Bytes)
+	}
+	_, err := fmt.Fprintf(buf, "%s - - [%s] \"%s %s %s\" %d %s %q %q",
+		orDash(entry.RemoteIP),
+		entry.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		entry.Method, entry.Path, entry.Proto,
+		entry.Status, size,
+		orDash(entry.Referer), orDash(entry.UserAgent))
+	return err
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// AccessLogMiddleware returns a gin middleware that writes one line per
+// request to w. format is config.AccessLogCombined, config.AccessLogJSON or
+// a Go text/template executed against an AccessLogEntry.
+func AccessLogMiddleware(format string, w io.Writer) (gin.HandlerFunc, error) {
+	formatter, err := parseAccessLogFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		duration := time.Since(start)
+		entry := &AccessLogEntry{
+			Time:       start,
+			RemoteIP:   c.ClientIP(),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.RequestURI(),
+			Proto:      c.Request.Proto,
+			Status:     c.Writer.Status(),
+			Bytes:      max(c.Writer.Size(), 0),
+			Duration:   duration,
+			DurationMS: float64(duration.Microseconds()) / 1000,
+			Referer:    c.Request.Referer(),
+			UserAgent:  c.Request.UserAgent(),
+			RequestID:  RequestID(c),
+		}
+
+		var buf bytes.Buffer
+		if err := formatter(&buf, entry); err != nil {
+			Log.Warn().Err(err).Msg("Failed to format access log entry")
+			return
+		}
+		line := strings.TrimRight(buf.String(), "\n") + "\n"
+
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := io.WriteString(w, line); err != nil {
+			Log.Warn().Err(err).Msg("Failed to write access log entry")
+		}
+	}, nil
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"slimserve/internal/config"
+)
+
+// serveWithAccessLog runs a single request through AccessLogMiddleware and returns the logged line
+func serveWithAccessLog(t *testing.T, format string) string {
+	t.Helper()
+
+	var logBuf bytes.Buffer
+	middleware, err := AccessLogMiddleware(format, &logBuf)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware)
+	r.GET("/files/report.pdf", func(c *gin.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/files/report.pdf?download=1", nil)
+	req.RemoteAddr = "192.0.2.10:54321"
+	req.Header.Set("User-Agent", "test-agent/1.0")
+	req.Header.Set("Referer", "http://example.com/files/")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	line := logBuf.String()
+	require.True(t, strings.HasSuffix(line, "\n"), "access log line should end with a newline")
+	require.Equal(t, 1, strings.Count(line, "\n"), "exactly one line should be logged")
+	return strings.TrimSuffix(line, "\n")
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	line := serveWithAccessLog(t, config.AccessLogCombined)
+
+	pattern := regexp.MustCompile(`^192\.0\.2\.10 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] ` +
+		`"GET /files/report\.pdf\?download=1 HTTP/1\.1" 200 5 "http://example\.com/files/" "test-agent/1\.0"$`)
+	assert.Regexp(t, pattern, line)
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	line := serveWithAccessLog(t, config.AccessLogJSON)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(line), &entry))
+
+	assert.Equal(t, "192.0.2.10", entry["remote_ip"])
+	assert.Equal(t, http.MethodGet, entry["method"])
+	assert.Equal(t, "/files/report.pdf?download=1", entry["path"])
+	assert.Equal(t, float64(http.StatusOK), entry["status"])
+	assert.Equal(t, float64(5), entry["bytes"])
+	assert.Equal(t, "http://example.com/files/", entry["referer"])
+	assert.Equal(t, "test-agent/1.0", entry["user_agent"])
+	assert.Contains(t, entry, "duration_ms")
+	assert.Contains(t, entry, "time")
+}
+
+func TestAccessLogTemplate(t *testing.T) {
+	line := serveWithAccessLog(t, `{{.Method}} {{.Path}} {{.Status}} {{.Bytes}} {{.RemoteIP}} {{.UserAgent}} {{if .Duration}}timed{{end}}`)
+
+	assert.Equal(t, "GET /files/report.pdf?download=1 200 5 192.0.2.10 test-agent/1.0 timed", line)
+}
+
+func TestAccessLogInvalidTemplate(t *testing.T) {
+	_, err := AccessLogMiddleware("{{.Method", &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid access log template")
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"gopkg.in/natefinch/lumberjack.v2"
 
 	"slimserve/internal/config"
 )
@@ -15,8 +17,13 @@ import (
 // Log is the global logger instance
 var Log zerolog.Logger
 
+// fileWriter is the rotating log file sink, if one is configured
+var fileWriter *lumberjack.Logger
+
 // Init configures global zerolog defaults based on Config.LogLevel.
 // Accepts "panic","fatal","error","warn","info","debug","trace" (case-insensitive).
+// When Config.LogFile is set, JSON logs are written to a size-rotated file,
+// and to the console as well if Config.LogToStderr is true.
 func Init(cfg *config.Config) error {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 
@@ -25,13 +32,42 @@ func Init(cfg *config.Config) error {
 		return err
 	}
 
+	if err := Close(); err != nil {
+		return err
+	}
+
+	var out io.Writer = zerolog.ConsoleWriter{Out: os.Stderr}
+	if cfg.LogFile != "" {
+		fileWriter = &lumberjack.Logger{
+			Filename:   cfg.LogFile,
+			MaxSize:    cfg.LogMaxSizeMB,
+			MaxBackups: cfg.LogMaxBackups,
+			MaxAge:     cfg.LogMaxAgeDays,
+		}
+		if cfg.LogToStderr {
+			out = zerolog.MultiLevelWriter(out, fileWriter)
+		} else {
+			out = fileWriter
+		}
+	}
+
 	zerolog.SetGlobalLevel(level)
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr}).With().Caller().Logger()
+	log.Logger = log.Output(out).With().Caller().Logger()
 	Log = log.Logger
 
 	return nil
 }
 
+// Close flushes and closes the log file, if one is open
+func Close() error {
+	if fileWriter == nil {
+		return nil
+	}
+	err := fileWriter.Close()
+	fileWriter = nil
+	return err
+}
+
 // parseLogLevel converts string log level to zerolog.Level
 func parseLogLevel(levelStr string) (zerolog.Level, error) {
 	if levelStr == "" {
@@ -63,7 +99,7 @@ func Middleware() gin.HandlerFunc {
 		size := c.Writer.Size()
 		clientIP := c.ClientIP()
 		userAgent := c.Request.UserAgent()
-		Log.Info().
+		Ctx(c.Request.Context()).Info().
 			Str("method", method).
 			Str("path", path).
 			Int("status", status).
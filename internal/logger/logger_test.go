@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -77,3 +79,38 @@ func TestMiddleware(t *testing.T) {
 	assert.Contains(t, logOutput, "duration")
 	assert.Equal(t, "test-agent", logOutput["user_agent"])
 }
+
+func TestInitLogFile(t *testing.T) {
+	originalLogger := log.Logger
+	defer func() {
+		log.Logger = originalLogger
+		Log = originalLogger
+	}()
+
+	logFile := filepath.Join(t.TempDir(), "logs", "slimserve.log")
+	cfg := &config.Config{
+		LogLevel:      "info",
+		LogFile:       logFile,
+		LogMaxSizeMB:  1,
+		LogMaxBackups: 2,
+		LogMaxAgeDays: 1,
+		LogToStderr:   false,
+	}
+	require.NoError(t, Init(cfg))
+
+	Log.Info().Str("component", "test").Msg("written to file")
+	Log.Debug().Msg("below configured level")
+	require.NoError(t, Close())
+
+	data, err := os.ReadFile(logFile)
+	require.NoError(t, err, "log file should be created")
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "written to file", entry["message"])
+	assert.Equal(t, "test", entry["component"])
+	assert.Equal(t, "info", entry["level"])
+}
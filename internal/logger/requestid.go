@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// maxRequestIDLength bounds request IDs taken from clients, which end up in
+// every log line of their request
+const maxRequestIDLength = 128
+
+// requestIDKey is the gin context key holding the request ID
+const requestIDKey = "request_id"
+
+// ctxLoggerKey is the request context key of the request's logger
+type ctxLoggerKey struct{}
+
+// RequestIDMiddleware returns a gin middleware that takes the request ID from
+// header, or generates one when it is missing or unusable, echoes it in the
+// response under the same header and attaches it to the request's logger so
+// that every line logged through Ctx carries it.
+func RequestIDMiddleware(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(header)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Header(header, id)
+		l := Log.With().Str("request_id", id).Logger()
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ctxLoggerKey{}, &l))
+
+		c.Next()
+	}
+}
+
+// RequestID returns the ID RequestIDMiddleware gave the request, or "" when
+// it did not run
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// Ctx returns the logger of the request ctx belongs to, falling back to Log
+// outside of requests
+func Ctx(ctx context.Context) *zerolog.Logger {
+	if l, ok := ctx.Value(ctxLoggerKey{}).(*zerolog.Logger); ok {
+		return l
+	}
+	return &Log
+}
+
+// validRequestID accepts printable ASCII IDs of bounded length, so a client
+// cannot forge log lines or bloat them
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' || id[i] == '"' || id[i] == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns 128 random bits, hex-encoded
+func newRequestID() string {
+	b := make([]byte, 16)
+	// crypto/rand.Read never returns an error on supported platforms
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,74 @@
+//go:build go1.24
+
+package security
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// tempAttempts bounds how many temporary names are tried for one write
+const tempAttempts = 10
+
+// WriteFileAtomic replaces name with what write produces. The data goes to a
+// temporary file in the same directory that is synced and renamed over name
+// only once write succeeds, so readers, and a failure or crash mid-write, see
+// either the old file or the complete new one. A new file is created with
+// perm; an existing one keeps its mode.
+func (r *RootFS) WriteFileAtomic(name string, perm fs.FileMode, write func(io.Writer) error) (err error) {
+	if info, statErr := r.Stat(name); statErr == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmpName, f, err := r.createTemp(name, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()         //nolint:errcheck
+			r.Remove(tmpName) //nolint:errcheck
+		}
+	}()
+
+	if err = write(f); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return replaceFile(r.fullPath(tmpName), r.fullPath(name))
+}
+
+// createTemp creates a hidden, uniquely named file next to name
+func (r *RootFS) createTemp(name string, perm fs.FileMode) (string, *os.File, error) {
+	dir, base := path.Split(filepath.ToSlash(name))
+	for range tempAttempts {
+		var suffix [6]byte
+		rand.Read(suffix[:]) //nolint:errcheck // never fails
+		tmpName := dir + "." + base + ".tmp-" + hex.EncodeToString(suffix[:])
+
+		f, err := r.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return tmpName, f, err
+	}
+	return "", nil, &fs.PathError{Op: "createtemp", Path: name, Err: fs.ErrExist}
+}
+
+// fullPath returns name as a path on the host filesystem. It is only used
+// for renames, which os.Root cannot do before Go 1.25, after the same
+// directory was reached through the root.
+func (r *RootFS) fullPath(name string) string {
+	return filepath.Join(r.path, filepath.FromSlash(name))
+}
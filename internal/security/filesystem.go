@@ -0,0 +1,37 @@
+package security
+
+import (
+	"io"
+	"io/fs"
+)
+
+// File is an open file or directory of a FileSystem. Files are seekable so
+// they can be served with range requests, and directories can be read in
+// batches.
+type File interface {
+	fs.ReadDirFile
+	io.Seeker
+}
+
+// FileSystem is the read side of a served directory tree. RootFS is the
+// default implementation; other implementations must likewise refuse names
+// that escape the tree.
+type FileSystem interface {
+	// Open opens the named file or directory for reading
+	Open(name string) (File, error)
+	// Stat returns file information, following symlinks
+	Stat(name string) (fs.FileInfo, error)
+	// Lstat returns file information without following symlinks
+	Lstat(name string) (fs.FileInfo, error)
+	// ReadDir returns all entries of the named directory
+	ReadDir(name string) ([]fs.DirEntry, error)
+	// OpenRoot returns the named subdirectory as a FileSystem of its own
+	OpenRoot(name string) (FileSystem, error)
+	// Path returns the location the tree was opened on. It names a local
+	// directory only for implementations backed by one.
+	Path() string
+	// Check reports whether the tree is still reachable
+	Check() error
+	// Close releases the tree
+	Close() error
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package security
+
+import "os"
+
+// replaceFile renames src over dst, which rename(2) does atomically
+func replaceFile(src, dst string) error {
+	return os.Rename(src, dst)
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package security
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+	"time"
+)
+
+const (
+	// errorSharingViolation is ERROR_SHARING_VIOLATION
+	errorSharingViolation = syscall.Errno(32)
+
+	// renameAttempts bounds the retries while dst is held open
+	renameAttempts = 5
+)
+
+// replaceFile renames src over dst. os.Rename already replaces an existing
+// dst on Windows, but fails while another process holds dst open without
+// FILE_SHARE_DELETE, as happens during a download or a virus scan, so the
+// rename is retried briefly before giving up.
+func replaceFile(src, dst string) error {
+	var err error
+	for attempt := range renameAttempts {
+		err = os.Rename(src, dst)
+		if err == nil || !(errors.Is(err, fs.ErrPermission) || errors.Is(err, errorSharingViolation)) {
+			return err
+		}
+		time.Sleep(time.Duration(attempt+1) * 20 * time.Millisecond)
+	}
+	return err
+}
@@ -3,61 +3,86 @@
 package security
 
 import (
+	"errors"
+	"fmt"
 	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // RootFS provides a traversal-resistant filesystem interface using Go 1.24's os.Root
 type RootFS struct {
-	root *os.Root
-	path string // original path for legacy compatibility
+	root atomic.Pointer[os.Root] // swapped by Check when the directory is recreated
+	path string                  // original path for legacy compatibility
+
+	// retired holds roots replaced by Check. Requests that loaded one before
+	// the swap may still be using it, so they stay open until Close.
+	mu      sync.Mutex
+	retired []*os.Root
 }
 
+var _ FileSystem = (*RootFS)(nil)
+
 // NewRootFS creates a new RootFS instance for the given directory
 func NewRootFS(dir string) (*RootFS, error) {
 	root, err := os.OpenRoot(dir)
 	if err != nil {
 		return nil, err
 	}
-	return &RootFS{
-		root: root,
-		path: dir,
-	}, nil
+	r := &RootFS{path: dir}
+	r.root.Store(root)
+	return r, nil
 }
 
-// Close closes the underlying root
+// Close closes the underlying root and any roots Check replaced
 func (r *RootFS) Close() error {
-	return r.root.Close()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	errs := []error{r.root.Load().Close()}
+	for _, root := range r.retired {
+		errs = append(errs, root.Close())
+	}
+	r.retired = nil
+	return errors.Join(errs...)
 }
 
 // Open opens a file relative to the root directory in a traversal-resistant manner
-func (r *RootFS) Open(name string) (*os.File, error) {
-	return r.root.Open(name)
+func (r *RootFS) Open(name string) (File, error) {
+	f, err := r.root.Load().Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
 }
 
 // OpenFile opens a file with specified flags and permissions
 func (r *RootFS) OpenFile(name string, flag int, perm fs.FileMode) (*os.File, error) {
-	return r.root.OpenFile(name, flag, perm)
+	return r.root.Load().OpenFile(name, flag, perm)
 }
 
 // Create creates a new file relative to the root
 func (r *RootFS) Create(name string) (*os.File, error) {
-	return r.root.Create(name)
+	return r.root.Load().Create(name)
 }
 
 // Stat returns file information for the named file
 func (r *RootFS) Stat(name string) (fs.FileInfo, error) {
-	return r.root.Stat(name)
+	return r.root.Load().Stat(name)
 }
 
 // Lstat returns file information for the named file without following symlinks
 func (r *RootFS) Lstat(name string) (fs.FileInfo, error) {
-	return r.root.Lstat(name)
+	return r.root.Load().Lstat(name)
 }
 
 // ReadDir reads the directory and returns directory entries
 func (r *RootFS) ReadDir(name string) ([]fs.DirEntry, error) {
-	f, err := r.root.Open(name)
+	f, err := r.root.Load().Open(name)
 	if err != nil {
 		return nil, err
 	}
@@ -68,27 +93,109 @@ func (r *RootFS) ReadDir(name string) ([]fs.DirEntry, error) {
 
 // Mkdir creates a directory
 func (r *RootFS) Mkdir(name string, perm fs.FileMode) error {
-	return r.root.Mkdir(name, perm)
+	return r.root.Load().Mkdir(name, perm)
 }
 
 // Remove removes a file or directory
 func (r *RootFS) Remove(name string) error {
-	return r.root.Remove(name)
+	return r.root.Load().Remove(name)
+}
+
+// MkdirAll creates a directory and any missing parents, like os.MkdirAll,
+// one level at a time through the root
+func (r *RootFS) MkdirAll(name string, perm fs.FileMode) error {
+	current := ""
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Clean(name)), "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		current = path.Join(current, part)
+		err := r.Mkdir(current, perm)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		if info, statErr := r.Stat(current); statErr != nil || !info.IsDir() {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveAll removes name and everything below it, like os.RemoveAll, without
+// following symlinks out of the root. A missing name is not an error.
+func (r *RootFS) RemoveAll(name string) error {
+	info, err := r.Lstat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := r.ReadDir(name)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := r.RemoveAll(path.Join(filepath.ToSlash(name), entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	if err := r.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
 }
 
 // OpenRoot opens a subdirectory as a new RootFS
-func (r *RootFS) OpenRoot(name string) (*RootFS, error) {
-	subRoot, err := r.root.OpenRoot(name)
+func (r *RootFS) OpenRoot(name string) (FileSystem, error) {
+	subRoot, err := r.root.Load().OpenRoot(name)
 	if err != nil {
 		return nil, err
 	}
-	return &RootFS{
-		root: subRoot,
-		path: r.path + "/" + name, // for legacy compatibility
-	}, nil
+	sub := &RootFS{path: r.path + "/" + name} // for legacy compatibility
+	sub.root.Store(subRoot)
+	return sub, nil
 }
 
 // Path returns the original directory path (for compatibility)
 func (r *RootFS) Path() string {
 	return r.path
 }
+
+// Check reports whether the directory the root was opened on is still
+// reachable at its path. A root whose directory was removed keeps pointing
+// at the deleted directory, so when a directory is found at the path again
+// the root is reopened on it and subsequent calls use the new directory.
+func (r *RootFS) Check() error {
+	pathInfo, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+	if !pathInfo.IsDir() {
+		return fmt.Errorf("%s is not a directory", r.path)
+	}
+
+	current := r.root.Load()
+	if rootInfo, err := current.Stat("."); err == nil && os.SameFile(rootInfo, pathInfo) {
+		return nil
+	}
+
+	reopened, err := os.OpenRoot(r.path)
+	if err != nil {
+		return err
+	}
+	if r.root.CompareAndSwap(current, reopened) {
+		r.mu.Lock()
+		r.retired = append(r.retired, current)
+		r.mu.Unlock()
+	} else {
+		// A concurrent Check already reopened the root
+		reopened.Close()
+	}
+	return nil
+}
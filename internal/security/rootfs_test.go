@@ -4,6 +4,7 @@ package security
 
 import (
 	"errors"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -404,6 +405,73 @@ func TestRootFS_Remove(t *testing.T) {
 	assert.True(t, errors.Is(err, fs.ErrNotExist))
 }
 
+func TestRootFS_WriteFileAtomic(t *testing.T) {
+	rfs, baseDir, cleanup := setupTestFS(t, map[string]string{"docs/notes.txt": "original content", "fresh": "DIR"})
+	defer cleanup()
+
+	writeString := func(content string) func(io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		}
+	}
+	// assertOnlyFile checks that no temporary file was left next to name
+	assertOnlyFile := func(t *testing.T, dir, name string) {
+		t.Helper()
+		entries, err := os.ReadDir(filepath.Join(baseDir, dir))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, name, entries[0].Name())
+	}
+
+	t.Run("creates a new file", func(t *testing.T) {
+		require.NoError(t, rfs.WriteFileAtomic("fresh/new.txt", 0644, writeString("new")))
+		data, err := os.ReadFile(filepath.Join(baseDir, "fresh", "new.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "new", string(data))
+		assertOnlyFile(t, "fresh", "new.txt")
+	})
+
+	t.Run("replaces an existing file", func(t *testing.T) {
+		require.NoError(t, rfs.WriteFileAtomic("docs/notes.txt", 0600, writeString("replaced")))
+		data, err := os.ReadFile(filepath.Join(baseDir, "docs", "notes.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "replaced", string(data))
+		assertOnlyFile(t, "docs", "notes.txt")
+
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(filepath.Join(baseDir, "docs", "notes.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, fs.FileMode(0644), info.Mode().Perm(), "an existing file should keep its mode")
+		}
+	})
+
+	t.Run("failed write keeps the original", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(baseDir, "docs", "notes.txt"), []byte("original content"), 0644))
+		errInterrupted := errors.New("connection reset mid-upload")
+
+		err := rfs.WriteFileAtomic("docs/notes.txt", 0644, func(w io.Writer) error {
+			if _, err := io.WriteString(w, "partial"); err != nil {
+				return err
+			}
+			return errInterrupted
+		})
+		require.ErrorIs(t, err, errInterrupted)
+
+		data, err := os.ReadFile(filepath.Join(baseDir, "docs", "notes.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "original content", string(data))
+		assertOnlyFile(t, "docs", "notes.txt")
+	})
+
+	t.Run("rejects traversal", func(t *testing.T) {
+		err := rfs.WriteFileAtomic("../escape.txt", 0644, writeString("escaped"))
+		require.Error(t, err)
+		_, statErr := os.Stat(filepath.Join(filepath.Dir(baseDir), "escape.txt"))
+		assert.True(t, errors.Is(statErr, fs.ErrNotExist))
+	})
+}
+
 func TestRootFS_OpenRoot(t *testing.T) {
 	rfsMaster, baseDir, cleanupMaster := setupTestFS(t, map[string]string{
 		"sub/file.txt":          "content",
@@ -450,6 +518,33 @@ func TestRootFS_OpenRoot(t *testing.T) {
 	require.Error(t, err) // "not a directory" or similar
 }
 
+func TestRootFS_Check(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "served")
+	require.NoError(t, os.Mkdir(dir, 0755))
+
+	rfs, err := NewRootFS(dir)
+	require.NoError(t, err)
+	require.NoError(t, rfs.Check())
+
+	// A request holding the root from before the directory was recreated
+	old := rfs.root.Load()
+
+	require.NoError(t, os.RemoveAll(dir))
+	assert.Error(t, rfs.Check())
+	require.NoError(t, os.Mkdir(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "after.txt"), []byte("after"), 0644))
+	require.NoError(t, rfs.Check())
+
+	_, err = rfs.Stat("after.txt")
+	assert.NoError(t, err, "the root should be reopened on the new directory")
+	_, err = old.Stat(".")
+	assert.NoError(t, err, "the replaced root should stay open for in-flight requests")
+
+	require.NoError(t, rfs.Close())
+	_, err = old.Stat(".")
+	assert.Error(t, err, "Close should close the replaced root too")
+}
+
 func TestRootFS_Path(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "test-path")
 	require.NoError(t, err)
@@ -1,8 +1,18 @@
 package admin
 
 import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"slices"
 	"sync"
 	"time"
+
+	"slimserve/internal/logger"
 )
 
 const (
@@ -23,11 +33,15 @@ type ActivityEntry struct {
 	Details     string    `json:"details,omitempty"`
 }
 
+// ActivityStore keeps the most recent maxEntries activities in memory. A
+// store opened with OpenActivityStore also appends every entry to a JSON
+// lines file, which Query searches so history outlives the ring and restarts.
 type ActivityStore struct {
 	mu         sync.RWMutex
 	activities []ActivityEntry
 	nextID     int
 	maxEntries int
+	path       string
 }
 
 func NewActivityStore(maxEntries int) *ActivityStore {
@@ -38,6 +52,73 @@ func NewActivityStore(maxEntries int) *ActivityStore {
 	}
 }
 
+// maxActivityLineBytes bounds a single line of the activity log
+const maxActivityLineBytes = 1 << 20
+
+// OpenActivityStore returns a store persisted to path, filled with the last
+// maxEntries activities already in it. A missing file is created on the
+// first write; lines that fail to parse, such as one cut short by a crash,
+// are skipped.
+func OpenActivityStore(maxEntries int, path string) (*ActivityStore, error) {
+	as := NewActivityStore(maxEntries)
+	as.path = path
+
+	skipped := 0
+	err := as.scan(func(entry ActivityEntry) {
+		as.activities = append(as.activities, entry)
+		if len(as.activities) > as.maxEntries {
+			as.activities = as.activities[1:]
+		}
+		as.nextID = max(as.nextID, entry.ID+1)
+	}, &skipped)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if skipped > 0 {
+		logger.Log.Warn().Str("path", path).Int("lines", skipped).Msg("Skipped unreadable activity log lines")
+	}
+	return as, nil
+}
+
+// scan calls fn with every entry in the activity log, oldest first, counting
+// unparsable lines in skipped
+func (as *ActivityStore) scan(fn func(ActivityEntry), skipped *int) error {
+	f, err := os.Open(as.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxActivityLineBytes)
+	for scanner.Scan() {
+		var entry ActivityEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			*skipped++
+			continue
+		}
+		fn(entry)
+	}
+	return scanner.Err()
+}
+
+// appendToLog writes entry to the activity log; the caller holds the lock
+func (as *ActivityStore) appendToLog(entry ActivityEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(as.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func (as *ActivityStore) AddActivity(activityType, description, ip, details string) {
 	as.mu.Lock()
 	defer as.mu.Unlock()
@@ -54,6 +135,12 @@ func (as *ActivityStore) AddActivity(activityType, description, ip, details stri
 	as.activities = append(as.activities, entry)
 	as.nextID++
 
+	if as.path != "" {
+		if err := as.appendToLog(entry); err != nil {
+			logger.Log.Warn().Err(err).Str("path", as.path).Msg("Failed to persist activity")
+		}
+	}
+
 	if len(as.activities) > as.maxEntries {
 		as.activities = as.activities[len(as.activities)-as.maxEntries:]
 	}
@@ -75,6 +162,54 @@ func (as *ActivityStore) GetRecentActivities(limit int) []ActivityEntry {
 	return result
 }
 
+// ActivityFilter selects activities for Query. Zero fields match everything.
+type ActivityFilter struct {
+	Type  string
+	Since time.Time // only activities at or after Since
+	Limit int       // at most Limit entries; 0 returns all matches
+}
+
+func (f ActivityFilter) matches(entry ActivityEntry) bool {
+	return (f.Type == "" || entry.Type == f.Type) && !entry.Timestamp.Before(f.Since)
+}
+
+// Query returns the activities matching filter, newest first. A persisted
+// store searches the whole log, the others only the in-memory entries.
+func (as *ActivityStore) Query(filter ActivityFilter) ([]ActivityEntry, error) {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	var matched []ActivityEntry
+	keep := func(entry ActivityEntry) {
+		if !filter.matches(entry) {
+			return
+		}
+		matched = append(matched, entry)
+		// Only the newest Limit matches are returned, so drop older ones
+		// as the log is read rather than holding all of it
+		if filter.Limit > 0 && len(matched) >= 2*filter.Limit {
+			matched = append(matched[:0], matched[len(matched)-filter.Limit:]...)
+		}
+	}
+
+	if as.path == "" {
+		for _, entry := range as.activities {
+			keep(entry)
+		}
+	} else {
+		var skipped int
+		if err := as.scan(keep, &skipped); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[len(matched)-filter.Limit:]
+	}
+	slices.Reverse(matched)
+	return matched, nil
+}
+
 func (as *ActivityStore) CountUploadsToday() int {
 	today := time.Now().Truncate(24 * time.Hour)
 	count := 0
@@ -91,26 +226,131 @@ func (as *ActivityStore) CountUploadsToday() int {
 	return count
 }
 
+// ErrTooManyUploads is returned by Begin when the concurrent upload limit is reached
+var ErrTooManyUploads = errors.New("maximum concurrent uploads reached")
+
+// UploadManager tracks uploads in progress. Entries are registered with
+// Begin and removed with End; entries whose upload stalled for longer than
+// the TTL are dropped by Reap, so a hung request cannot hold a slot forever.
 type UploadManager struct {
 	mu            sync.RWMutex
 	activeUploads map[string]*UploadProgress
 	maxConcurrent int
+	ttl           time.Duration
 }
 
 type UploadProgress struct {
-	ID        string    `json:"id"`
-	Filename  string    `json:"filename"`
-	TotalSize int64     `json:"total_size"`
-	Uploaded  int64     `json:"uploaded"`
-	Status    string    `json:"status"`
-	StartTime time.Time `json:"start_time"`
-	Error     string    `json:"error,omitempty"`
+	ID         string    `json:"id"`
+	Filename   string    `json:"filename"`
+	TotalSize  int64     `json:"total_size"`
+	Uploaded   int64     `json:"uploaded"`
+	Status     string    `json:"status"`
+	StartTime  time.Time `json:"start_time"`
+	LastUpdate time.Time `json:"last_update"`
+	Error      string    `json:"error,omitempty"`
 }
 
-func NewUploadManager(maxConcurrent int) *UploadManager {
+// NewUploadManager returns a manager allowing maxConcurrent uploads at once
+// that reaps entries idle for longer than ttl; a ttl of 0 never reaps
+func NewUploadManager(maxConcurrent int, ttl time.Duration) *UploadManager {
 	return &UploadManager{
 		activeUploads: make(map[string]*UploadProgress),
 		maxConcurrent: maxConcurrent,
+		ttl:           ttl,
+	}
+}
+
+// newUploadID returns a random hex ID, generated like session tokens
+func newUploadID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal("Failed to generate upload ID: crypto/rand unavailable")
+	}
+	return hex.EncodeToString(b)
+}
+
+// Begin registers an upload of totalSize bytes and returns its ID, or
+// ErrTooManyUploads when the limit is reached. The limit check and the
+// registration happen together, so concurrent requests cannot overshoot it.
+func (um *UploadManager) Begin(totalSize int64) (string, error) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	if len(um.activeUploads) >= um.maxConcurrent {
+		return "", ErrTooManyUploads
+	}
+	id := newUploadID()
+	for um.activeUploads[id] != nil {
+		id = newUploadID()
+	}
+	now := time.Now()
+	um.activeUploads[id] = &UploadProgress{
+		ID:         id,
+		TotalSize:  totalSize,
+		Status:     "uploading",
+		StartTime:  now,
+		LastUpdate: now,
+	}
+	return id, nil
+}
+
+// Progress records that the upload id is storing filename after uploaded
+// bytes, which also keeps it from being reaped. Reaped IDs are ignored.
+func (um *UploadManager) Progress(id, filename string, uploaded int64) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	if upload := um.activeUploads[id]; upload != nil {
+		upload.Filename = filename
+		upload.Uploaded = uploaded
+		upload.LastUpdate = time.Now()
+	}
+}
+
+// End removes the upload id
+func (um *UploadManager) End(id string) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	delete(um.activeUploads, id)
+}
+
+// Reap removes uploads not updated within the TTL before now and returns
+// how many were removed
+func (um *UploadManager) Reap(now time.Time) int {
+	if um.ttl <= 0 {
+		return 0
+	}
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	reaped := 0
+	for id, upload := range um.activeUploads {
+		if now.Sub(upload.LastUpdate) > um.ttl {
+			delete(um.activeUploads, id)
+			reaped++
+		}
+	}
+	return reaped
+}
+
+// RunReaper calls Reap periodically until stop is closed. It returns
+// immediately when reaping is off.
+func (um *UploadManager) RunReaper(stop <-chan struct{}) {
+	if um.ttl <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(um.ttl, time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if n := um.Reap(now); n > 0 {
+				logger.Log.Warn().Int("uploads", n).Dur("ttl", um.ttl).Msg("Dropped stalled uploads")
+			}
+		case <-stop:
+			return
+		}
 	}
 }
 
@@ -120,13 +360,15 @@ func (um *UploadManager) ActiveUploadsCount() int {
 	return len(um.activeUploads)
 }
 
+// GetActiveUploads returns a snapshot of the uploads in progress
 func (um *UploadManager) GetActiveUploads() []*UploadProgress {
 	um.mu.RLock()
 	defer um.mu.RUnlock()
 
 	var uploads []*UploadProgress
 	for _, upload := range um.activeUploads {
-		uploads = append(uploads, upload)
+		snapshot := *upload
+		uploads = append(uploads, &snapshot)
 	}
 	return uploads
 }
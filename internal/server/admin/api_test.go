@@ -0,0 +1,196 @@
+package admin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadManagerReapsStalledUploads(t *testing.T) {
+	um := NewUploadManager(2, time.Minute)
+
+	stalled, err := um.Begin(100)
+	require.NoError(t, err)
+	active, err := um.Begin(100)
+	require.NoError(t, err)
+
+	_, err = um.Begin(100)
+	require.ErrorIs(t, err, ErrTooManyUploads)
+
+	um.Progress(active, "b.txt", 50)
+	um.mu.Lock()
+	um.activeUploads[stalled].LastUpdate = time.Now().Add(-2 * time.Minute)
+	um.mu.Unlock()
+
+	assert.Equal(t, 1, um.Reap(time.Now()))
+	require.Equal(t, 1, um.ActiveUploadsCount())
+	uploads := um.GetActiveUploads()
+	assert.Equal(t, active, uploads[0].ID)
+	assert.Equal(t, "b.txt", uploads[0].Filename)
+
+	// The reaped slot is free again, and late calls for the reaped ID are harmless
+	um.Progress(stalled, "a.txt", 10)
+	um.End(stalled)
+	_, err = um.Begin(100)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, um.ActiveUploadsCount())
+}
+
+func TestUploadManagerWithoutTTLNeverReaps(t *testing.T) {
+	um := NewUploadManager(1, 0)
+	_, err := um.Begin(0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, um.Reap(time.Now().Add(24*time.Hour)))
+	assert.Equal(t, 1, um.ActiveUploadsCount())
+}
+
+func TestUploadManagerRunReaper(t *testing.T) {
+	um := NewUploadManager(1, 10*time.Millisecond)
+	_, err := um.Begin(0)
+	require.NoError(t, err)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		um.RunReaper(stop)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return um.ActiveUploadsCount() == 0 }, time.Second, 5*time.Millisecond)
+	close(stop)
+	<-done
+}
+
+func TestUploadManagerConcurrentUniqueIDs(t *testing.T) {
+	const workers = 64
+	um := NewUploadManager(workers, time.Minute)
+
+	var wg sync.WaitGroup
+	ids := make(chan string, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := um.Begin(1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool)
+	for id := range ids {
+		assert.Len(t, id, 32)
+		assert.False(t, seen[id], "duplicate upload ID %s", id)
+		seen[id] = true
+	}
+	assert.Len(t, seen, workers)
+	assert.Equal(t, workers, um.ActiveUploadsCount())
+
+	_, err := um.Begin(1)
+	assert.True(t, errors.Is(err, ErrTooManyUploads), "limit holds under concurrency")
+}
+
+func TestActivityStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "activity.jsonl")
+
+	as, err := OpenActivityStore(2, path)
+	require.NoError(t, err)
+	as.AddActivity(ActivityLogin, "Admin login: admin", "10.0.0.1", "")
+	as.AddActivity(ActivityUpload, "Uploaded: a.txt", "10.0.0.1", "/data/a.txt")
+	as.AddActivity(ActivityDelete, "Deleted: a.txt", "10.0.0.2", "/data/a.txt")
+
+	// A torn last line, as a crash mid-write leaves, is skipped
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"id": 4, "type": "mo`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reloaded, err := OpenActivityStore(2, path)
+	require.NoError(t, err)
+	recent := reloaded.GetRecentActivities(10)
+	require.Len(t, recent, 2, "only maxEntries are kept in memory")
+	assert.Equal(t, 3, recent[0].ID)
+	assert.Equal(t, ActivityDelete, recent[0].Type)
+	assert.Equal(t, "/data/a.txt", recent[0].Details)
+	assert.Equal(t, ActivityUpload, recent[1].Type)
+
+	all, err := reloaded.Query(ActivityFilter{})
+	require.NoError(t, err)
+	assert.Len(t, all, 3, "the log keeps what the ring dropped")
+
+	// IDs carry on from the log
+	reloaded.AddActivity(ActivityMkdir, "Created directory: new", "10.0.0.1", "")
+	assert.Equal(t, 4, reloaded.GetRecentActivities(1)[0].ID)
+}
+
+func TestActivityStoreMissingLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "activity.jsonl")
+	as, err := OpenActivityStore(10, path)
+	require.NoError(t, err)
+	assert.Empty(t, as.GetRecentActivities(10))
+
+	as.AddActivity(ActivityConfig, "Configuration updated", "10.0.0.1", "")
+	_, err = os.Stat(path)
+	assert.NoError(t, err, "the log is created on the first write")
+}
+
+func TestActivityStoreQuery(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	entries := []ActivityEntry{
+		{ID: 1, Type: ActivityUpload, Timestamp: base},
+		{ID: 2, Type: ActivityLogin, Timestamp: base.Add(time.Hour)},
+		{ID: 3, Type: ActivityUpload, Timestamp: base.Add(2 * time.Hour)},
+		{ID: 4, Type: ActivityUpload, Timestamp: base.Add(3 * time.Hour)},
+		{ID: 5, Type: ActivityDelete, Timestamp: base.Add(4 * time.Hour)},
+	}
+
+	memory := NewActivityStore(10)
+	memory.activities = entries
+
+	path := filepath.Join(t.TempDir(), "activity.jsonl")
+	persisted, err := OpenActivityStore(1, path)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		require.NoError(t, persisted.appendToLog(entry))
+	}
+
+	tests := []struct {
+		name   string
+		filter ActivityFilter
+		want   []int
+	}{
+		{"everything", ActivityFilter{}, []int{5, 4, 3, 2, 1}},
+		{"by type", ActivityFilter{Type: ActivityUpload}, []int{4, 3, 1}},
+		{"since is inclusive", ActivityFilter{Since: base.Add(2 * time.Hour)}, []int{5, 4, 3}},
+		{"type and since", ActivityFilter{Type: ActivityUpload, Since: base.Add(30 * time.Minute)}, []int{4, 3}},
+		{"limit keeps the newest", ActivityFilter{Type: ActivityUpload, Limit: 2}, []int{4, 3}},
+		{"limit of one", ActivityFilter{Limit: 1}, []int{5}},
+		{"no match", ActivityFilter{Type: ActivityMove}, nil},
+	}
+	for _, tt := range tests {
+		for name, as := range map[string]*ActivityStore{"memory": memory, "persisted": persisted} {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				got, err := as.Query(tt.filter)
+				require.NoError(t, err)
+				var ids []int
+				for _, entry := range got {
+					ids = append(ids, entry.ID)
+				}
+				assert.Equal(t, tt.want, ids)
+			})
+		}
+	}
+}
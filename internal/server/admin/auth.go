@@ -2,14 +2,17 @@ package admin
 
 import (
 	"crypto/subtle"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"slimserve/internal/config"
 	"slimserve/internal/logger"
+	"slimserve/internal/server/apierror"
 	"slimserve/internal/server/auth"
 
 	"github.com/gin-gonic/gin"
@@ -18,8 +21,7 @@ import (
 func AdminAuthMiddleware(cfg *config.Config, store *auth.SessionStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !cfg.EnableAdmin {
-			c.JSON(http.StatusNotFound, gin.H{"error": "admin interface not enabled"})
-			c.Abort()
+			apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeAdminDisabled, "admin interface not enabled"))
 			return
 		}
 
@@ -33,13 +35,13 @@ func AdminAuthMiddleware(cfg *config.Config, store *auth.SessionStore) gin.Handl
 			return
 		}
 
-		cookie, err := c.Cookie("slimserve_admin_session")
+		cookie, err := c.Cookie(cfg.AdminSessionCookieName())
 		if err == nil && store.ValidAdmin(cookie) {
 			c.Next()
 			return
 		}
 
-		logger.Log.Warn().
+		logger.Ctx(c.Request.Context()).Warn().
 			Str("ip", c.ClientIP()).
 			Str("path", c.Request.URL.Path).
 			Str("user_agent", c.GetHeader("User-Agent")).
@@ -51,87 +53,131 @@ func AdminAuthMiddleware(cfg *config.Config, store *auth.SessionStore) gin.Handl
 
 		if isBrowser {
 			nextURL := url.QueryEscape(c.Request.URL.RequestURI())
-			c.Redirect(http.StatusFound, "/admin/login?next="+nextURL)
+			c.Redirect(http.StatusFound, cfg.URLPrefix()+"/admin/login?next="+nextURL)
 			c.Abort()
 		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "admin authentication required"})
-			c.Abort()
+			apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, "admin authentication required"))
 		}
 	}
 }
 
+// Requests each client IP may make to the admin interface per window
+const (
+	adminRateLimit  = 30
+	adminRateWindow = time.Minute
+)
+
+// AdminRateLimitMiddleware limits each client IP to 30 admin requests a
+// minute. The returned middleware must be kept and reused; a new one starts
+// with no history.
 func AdminRateLimitMiddleware() gin.HandlerFunc {
-	type rateLimiter struct {
-		mu       sync.Mutex
-		requests map[string][]time.Time
-		stopCh   chan struct{}
-	}
+	limiter := NewRateLimiter(adminRateLimit, adminRateWindow)
+	go limiter.RunPruner(nil)
+	return limiter.Middleware("Admin rate limit exceeded")
+}
+
+// RateLimiter allows each client IP limit requests in any sliding window
+type RateLimiter struct {
+	mu       sync.Mutex
+	requests map[string][]time.Time
+	limit    int
+	window   time.Duration
+	now      func() time.Time
+}
 
-	limiter := &rateLimiter{
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
 		requests: make(map[string][]time.Time),
-		stopCh:   make(chan struct{}),
+		limit:    limit,
+		window:   window,
+		now:      time.Now,
+	}
+}
+
+// allow records a request from ip if it is within the limit. It returns
+// whether it was, how many requests ip has left, and when the oldest
+// request counted leaves the window, freeing a slot.
+func (rl *RateLimiter) allow(ip string) (ok bool, remaining int, reset time.Time) {
+	now := rl.now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	requests := rl.recent(rl.requests[ip], now)
+	ok = len(requests) < rl.limit
+	if ok {
+		requests = append(requests, now)
 	}
+	rl.requests[ip] = requests
+	return ok, rl.limit - len(requests), requests[0].Add(rl.window)
+}
 
-	go func() {
-		ticker := time.NewTicker(2 * time.Minute)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				limiter.mu.Lock()
-				now := time.Now()
-				for ip, requests := range limiter.requests {
-					var validRequests []time.Time
-					for _, reqTime := range requests {
-						if now.Sub(reqTime) < time.Minute {
-							validRequests = append(validRequests, reqTime)
-						}
-					}
-					if len(validRequests) == 0 {
-						delete(limiter.requests, ip)
-					} else {
-						limiter.requests[ip] = validRequests
-					}
-				}
-				limiter.mu.Unlock()
-			case <-limiter.stopCh:
-				return
-			}
+// recent returns the requests still inside the window at now
+func (rl *RateLimiter) recent(requests []time.Time, now time.Time) []time.Time {
+	i := 0
+	for i < len(requests) && now.Sub(requests[i]) >= rl.window {
+		i++
+	}
+	return requests[i:]
+}
+
+// Prune forgets the requests that have left the window
+func (rl *RateLimiter) Prune() {
+	now := rl.now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for ip, requests := range rl.requests {
+		if requests = rl.recent(requests, now); len(requests) == 0 {
+			delete(rl.requests, ip)
+		} else {
+			rl.requests[ip] = requests
+		}
+	}
+}
+
+// RunPruner calls Prune every two windows until stop is closed
+func (rl *RateLimiter) RunPruner(stop <-chan struct{}) {
+	ticker := time.NewTicker(2 * rl.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rl.Prune()
+		case <-stop:
+			return
 		}
-	}()
+	}
+}
 
+// Middleware enforces the limit, answering 429 with Retry-After and logging
+// msg once a client is over it. Every response carries X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset, the Unix time at which a
+// request leaves the window.
+func (rl *RateLimiter) Middleware(msg string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ip := c.ClientIP()
-		now := time.Now()
-
-		limiter.mu.Lock()
-		if requests, exists := limiter.requests[ip]; exists {
-			var validRequests []time.Time
-			for _, reqTime := range requests {
-				if now.Sub(reqTime) < time.Minute {
-					validRequests = append(validRequests, reqTime)
-				}
-			}
-			limiter.requests[ip] = validRequests
-		}
+		ok, remaining, reset := rl.allow(ip)
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rl.limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
 
-		if len(limiter.requests[ip]) >= 30 {
-			limiter.mu.Unlock()
-			logger.Log.Warn().
+		if !ok {
+			logger.Ctx(c.Request.Context()).Warn().
 				Str("ip", ip).
-				Msg("Admin rate limit exceeded")
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
-			c.Abort()
+				Msg(msg)
+			retryAfter := max(1, int(math.Ceil(reset.Sub(rl.now()).Seconds())))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, "rate limit exceeded"))
 			return
 		}
-
-		limiter.requests[ip] = append(limiter.requests[ip], now)
-		limiter.mu.Unlock()
 		c.Next()
 	}
 }
 
-func CSRFProtectionMiddleware() gin.HandlerFunc {
+func CSRFProtectionMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.Method == "GET" || c.Request.URL.Path == "/admin/login" {
 			c.Next()
@@ -143,17 +189,16 @@ func CSRFProtectionMiddleware() gin.HandlerFunc {
 			token = c.PostForm("csrf_token")
 		}
 
-		expectedToken, err := c.Cookie("slimserve_csrf_token")
+		expectedToken, err := c.Cookie(cfg.CSRFCookieName())
 		if err != nil || token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expectedToken)) != 1 {
-			logger.Log.Warn().
+			logger.Ctx(c.Request.Context()).Warn().
 				Str("ip", c.ClientIP()).
 				Str("path", c.Request.URL.Path).
 				Str("user_agent", c.GetHeader("User-Agent")).
 				Bool("token_present", token != "").
 				Bool("cookie_present", err == nil).
 				Msg("CSRF token validation failed")
-			c.JSON(http.StatusForbidden, gin.H{"error": "invalid CSRF token"})
-			c.Abort()
+			apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeInvalidCSRFToken, "invalid CSRF token"))
 			return
 		}
 
@@ -164,24 +209,22 @@ func CSRFProtectionMiddleware() gin.HandlerFunc {
 func InputValidationMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.ContentLength > 100*1024*1024 {
-			logger.Log.Warn().
+			logger.Ctx(c.Request.Context()).Warn().
 				Str("ip", c.ClientIP()).
 				Int64("content_length", c.Request.ContentLength).
 				Msg("Request payload too large")
-			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "payload too large"})
-			c.Abort()
+			apierror.Abort(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "payload too large"))
 			return
 		}
 
 		if c.Request.Method == "POST" {
 			contentType := c.GetHeader("Content-Type")
 			if contentType == "" {
-				logger.Log.Warn().
+				logger.Ctx(c.Request.Context()).Warn().
 					Str("ip", c.ClientIP()).
 					Str("path", c.Request.URL.Path).
 					Msg("Missing Content-Type header")
-				c.JSON(http.StatusBadRequest, gin.H{"error": "missing content type"})
-				c.Abort()
+				apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "missing content type"))
 				return
 			}
 		}
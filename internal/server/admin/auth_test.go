@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	start := time.Unix(1700000000, 0)
+	now := start
+	rl := NewRateLimiter(3, time.Minute)
+	rl.now = func() time.Time { return now }
+
+	engine := gin.New()
+	engine.Use(rl.Middleware("rate limit exceeded"))
+	engine.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	// The first request starts the window, so the reset stays put while the
+	// remaining count goes down
+	reset := strconv.FormatInt(start.Add(time.Minute).Unix(), 10)
+	for i, want := range []string{"2", "1", "0"} {
+		w := request("10.0.0.1")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, want, w.Header().Get("X-RateLimit-Remaining"), "request %d", i+1)
+		assert.Equal(t, reset, w.Header().Get("X-RateLimit-Reset"))
+		assert.Empty(t, w.Header().Get("Retry-After"))
+		now = now.Add(10 * time.Second)
+	}
+
+	w := request("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, reset, w.Header().Get("X-RateLimit-Reset"))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"), "the first request leaves the window 30s later")
+
+	// Other clients have their own quota
+	w = request("10.0.0.2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Remaining"))
+
+	// Once the first request has left the window a slot is free again, and
+	// the reset moves to when the second one leaves
+	now = start.Add(time.Minute)
+	w = request("10.0.0.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, strconv.FormatInt(start.Add(70*time.Second).Unix(), 10), w.Header().Get("X-RateLimit-Reset"))
+}
+
+func TestRateLimiterPrune(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rl := NewRateLimiter(3, time.Minute)
+	rl.now = func() time.Time { return now }
+
+	rl.allow("10.0.0.1")
+	now = now.Add(30 * time.Second)
+	rl.allow("10.0.0.2")
+	now = now.Add(45 * time.Second)
+
+	rl.Prune()
+	assert.NotContains(t, rl.requests, "10.0.0.1")
+	assert.Len(t, rl.requests["10.0.0.2"], 1)
+}
@@ -1,17 +1,31 @@
 package server
 
 import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"slimserve/internal/config"
+	"slimserve/internal/files"
+	"slimserve/internal/ignore"
 	"slimserve/internal/logger"
 	"slimserve/internal/server/admin"
+	"slimserve/internal/server/apierror"
 	"slimserve/internal/server/auth"
+	"slimserve/internal/server/handler"
 	"slimserve/internal/storage"
 	"slimserve/internal/version"
 
@@ -21,25 +35,62 @@ import (
 type AdminHandler struct {
 	server        *Server
 	activityStore *admin.ActivityStore
+
+	// now is the clock used for stats caching; tests replace it
+	now        func() time.Time
+	statsMu    sync.Mutex
+	stats      storageStats
+	statsValid bool
+}
+
+// storageStats is the result of a single walk over the storage directory
+type storageStats struct {
+	TotalFiles int
+	TotalBytes int64
+	ComputedAt time.Time
+	Truncated  bool // the walk hit MaxTraversalDepth
 }
 
+// maxRecentActivities is how many activities are kept in memory
+const maxRecentActivities = 100
+
 func NewAdminHandler(server *Server) *AdminHandler {
+	activityStore := admin.NewActivityStore(maxRecentActivities)
+	if path := server.config.AdminActivityLogFile; path != "" {
+		persisted, err := admin.OpenActivityStore(maxRecentActivities, path)
+		if err != nil {
+			logger.Log.Warn().Err(err).Str("path", path).Msg("Failed to read activity log, keeping activity in memory only")
+		} else {
+			activityStore = persisted
+		}
+	}
 	return &AdminHandler{
 		server:        server,
-		activityStore: admin.NewActivityStore(100),
+		activityStore: activityStore,
+		now:           time.Now,
 	}
 }
 
 func (ah *AdminHandler) getSystemStats(c *gin.Context) {
-	stats := gin.H{
-		"total_files":   ah.countTotalFiles(),
+	c.JSON(http.StatusOK, ah.systemStats(ah.storageStats(false)))
+}
+
+// refreshSystemStats recomputes the cached storage stats regardless of age
+func (ah *AdminHandler) refreshSystemStats(c *gin.Context) {
+	c.JSON(http.StatusOK, ah.systemStats(ah.storageStats(true)))
+}
+
+func (ah *AdminHandler) systemStats(usage storageStats) gin.H {
+	return gin.H{
+		"total_files":   usage.TotalFiles,
 		"uploads_today": ah.countUploadsToday(),
-		"storage_used":  ah.getStorageUsed(),
+		"storage_used":  ah.formatStorageUsed(usage),
+		"storage_bytes": usage.TotalBytes,
+		"computed_at":   usage.ComputedAt.Format(time.RFC3339),
+		"truncated":     usage.Truncated,
 		"server_uptime": ah.getServerUptime(),
 		"memory_usage":  ah.getMemoryUsage(),
 	}
-
-	c.JSON(http.StatusOK, stats)
 }
 
 func (ah *AdminHandler) getSystemStatus(c *gin.Context) {
@@ -47,6 +98,7 @@ func (ah *AdminHandler) getSystemStatus(c *gin.Context) {
 	runtime.ReadMemStats(&m)
 
 	storageDir := ah.server.config.GetStorageDir()
+	storageStats := ah.storageStats(false)
 
 	status := gin.H{
 		"server": gin.H{
@@ -64,8 +116,9 @@ func (ah *AdminHandler) getSystemStatus(c *gin.Context) {
 		"storage": gin.H{
 			"storage_type": storageDir.Type,
 			"storage_path": storageDir.Path,
-			"total_files":  ah.countTotalFiles(),
-			"storage_used": ah.getStorageUsed(),
+			"total_files":  storageStats.TotalFiles,
+			"storage_used": ah.formatStorageUsed(storageStats),
+			"truncated":    storageStats.Truncated,
 		},
 		"configuration": gin.H{
 			"max_upload_size": fmt.Sprintf("%dMB", ah.server.config.MaxUploadSizeMB),
@@ -78,6 +131,25 @@ func (ah *AdminHandler) getSystemStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
+// getThumbnailCacheStats reports the usage of the on-disk thumbnail cache
+func (ah *AdminHandler) getThumbnailCacheStats(c *gin.Context) {
+	cm, err := files.NewCacheManager(files.CacheDir(), ah.server.config.MaxThumbCacheMB)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("Failed to read thumbnail cache")
+		apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "failed to read thumbnail cache"))
+		return
+	}
+
+	count, usedBytes, _ := cm.Stats()
+	c.JSON(http.StatusOK, gin.H{
+		"size_mb":    cm.SizeMB(),
+		"size_bytes": usedBytes,
+		"size":       ah.server.adminUtils.FormatBytes(uint64(usedBytes)),
+		"file_count": count,
+		"limit_mb":   ah.server.config.MaxThumbCacheMB,
+	})
+}
+
 func (ah *AdminHandler) getConfiguration(c *gin.Context) {
 	storageDir := ah.server.config.GetStorageDir()
 	config := gin.H{
@@ -96,15 +168,29 @@ func (ah *AdminHandler) getConfiguration(c *gin.Context) {
 		"max_upload_size_mb":     ah.server.config.MaxUploadSizeMB,
 		"allowed_upload_types":   ah.server.config.AllowedUploadTypes,
 		"max_concurrent_uploads": ah.server.config.MaxConcurrentUploads,
+		"listing_banner":         ah.server.config.ListingBanner,
+		"maintenance_mode":       ah.server.config.MaintenanceMode,
 	}
 
 	c.JSON(http.StatusOK, config)
 }
 
+// getEffectiveConfiguration returns every setting keyed by its JSON name,
+// with the value in effect and the source that set it. Passwords and the S3
+// secret key are redacted.
+func (ah *AdminHandler) getEffectiveConfiguration(c *gin.Context) {
+	c.JSON(http.StatusOK, ah.server.config.Effective())
+}
+
+// getConfigurationSchema returns the JSON Schema of the configuration file
+func (ah *AdminHandler) getConfigurationSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, config.Schema())
+}
+
 func (ah *AdminHandler) updateConfiguration(c *gin.Context) {
 	var updates map[string]interface{}
 	if err := c.ShouldBindJSON(&updates); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid configuration data"})
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid configuration data"))
 		return
 	}
 
@@ -112,25 +198,45 @@ func (ah *AdminHandler) updateConfiguration(c *gin.Context) {
 
 	if val, ok := updates["max_upload_size_mb"].(float64); ok && val > 0 && val <= 1000 {
 		ah.server.config.MaxUploadSizeMB = int(val)
+		ah.server.config.SetSource("MaxUploadSizeMB", config.SourceAdmin)
 		updated = true
 	}
 
 	if val, ok := updates["max_concurrent_uploads"].(float64); ok && val > 0 && val <= 10 {
 		ah.server.config.MaxConcurrentUploads = int(val)
+		ah.server.config.SetSource("MaxConcurrentUploads", config.SourceAdmin)
 		updated = true
 	}
 
 	if val, ok := updates["thumb_jpeg_quality"].(float64); ok && val >= 1 && val <= 100 {
 		ah.server.config.ThumbJpegQuality = int(val)
+		ah.server.config.SetSource("ThumbJpegQuality", config.SourceAdmin)
+		updated = true
+	}
+
+	if val, ok := updates["listing_banner"].(string); ok {
+		if len(val) > config.MaxListingBannerLength {
+			apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest,
+				fmt.Sprintf("listing_banner must be at most %d bytes", config.MaxListingBannerLength)))
+			return
+		}
+		ah.server.config.ListingBanner = val
+		ah.server.config.SetSource("ListingBanner", config.SourceAdmin)
+		updated = true
+	}
+
+	if val, ok := updates["maintenance_mode"].(bool); ok {
+		ah.server.config.MaintenanceMode = val
+		ah.server.config.SetSource("MaintenanceMode", config.SourceAdmin)
 		updated = true
 	}
 
 	if !updated {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no valid configuration updates provided"})
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "no valid configuration updates provided"))
 		return
 	}
 
-	logger.Log.Info().
+	logger.Ctx(c.Request.Context()).Info().
 		Str("ip", c.ClientIP()).
 		Interface("updates", updates).
 		Msg("Admin configuration updated")
@@ -140,6 +246,33 @@ func (ah *AdminHandler) updateConfiguration(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "configuration updated successfully"})
 }
 
+// saveConfiguration writes the file's settings, runtime updates included,
+// back to the file they were loaded from
+func (ah *AdminHandler) saveConfiguration(c *gin.Context) {
+	err := ah.server.config.Save()
+	switch {
+	case errors.Is(err, config.ErrNoConfigFile), errors.Is(err, config.ErrUnsavedPassword):
+		apierror.Write(c, apierror.New(http.StatusConflict, apierror.CodeInvalidRequest, err.Error()))
+		return
+	case errors.Is(err, config.ErrUnsupportedConfigFormat):
+		apierror.Write(c, apierror.New(http.StatusNotImplemented, apierror.CodeNotImplemented, err.Error()))
+		return
+	case err != nil:
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("file", ah.server.config.File()).Msg("Failed to save configuration")
+		apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "failed to save configuration"))
+		return
+	}
+
+	logger.Ctx(c.Request.Context()).Info().
+		Str("ip", c.ClientIP()).
+		Str("file", ah.server.config.File()).
+		Msg("Admin configuration saved")
+
+	ah.activityStore.AddActivity(admin.ActivityConfig, "Configuration saved", c.ClientIP(), ah.server.config.File())
+
+	c.JSON(http.StatusOK, gin.H{"message": "configuration saved successfully"})
+}
+
 func (ah *AdminHandler) getAuthConfig(c *gin.Context) {
 	config := gin.H{
 		"enable_auth":        ah.server.config.EnableAuth,
@@ -156,7 +289,7 @@ func (ah *AdminHandler) getAuthConfig(c *gin.Context) {
 func (ah *AdminHandler) updateAuthConfig(c *gin.Context) {
 	var updates map[string]interface{}
 	if err := c.ShouldBindJSON(&updates); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid configuration data"})
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid configuration data"))
 		return
 	}
 
@@ -164,52 +297,58 @@ func (ah *AdminHandler) updateAuthConfig(c *gin.Context) {
 
 	if val, ok := updates["enable_auth"].(bool); ok {
 		ah.server.config.EnableAuth = val
+		ah.server.config.SetSource("EnableAuth", config.SourceAdmin)
 		updated = true
 	}
 
 	if val, ok := updates["username"].(string); ok {
 		ah.server.config.Username = val
+		ah.server.config.SetSource("Username", config.SourceAdmin)
 		updated = true
 	}
 
 	if val, ok := updates["password"].(string); ok && val != "" {
 		hash, err := auth.HashPassword(val)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+			apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "failed to hash password"))
 			return
 		}
 		ah.server.config.PasswordHash = hash
+		ah.server.config.SetSource("Password", config.SourceAdmin)
 		ah.server.config.Password = ""
 		updated = true
 	}
 
 	if val, ok := updates["enable_admin"].(bool); ok {
 		ah.server.config.EnableAdmin = val
+		ah.server.config.SetSource("EnableAdmin", config.SourceAdmin)
 		updated = true
 	}
 
 	if val, ok := updates["admin_username"].(string); ok {
 		ah.server.config.AdminUsername = val
+		ah.server.config.SetSource("AdminUsername", config.SourceAdmin)
 		updated = true
 	}
 
 	if val, ok := updates["admin_password"].(string); ok && val != "" {
 		hash, err := auth.HashPassword(val)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash admin password"})
+			apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "failed to hash admin password"))
 			return
 		}
 		ah.server.config.AdminPasswordHash = hash
+		ah.server.config.SetSource("AdminPassword", config.SourceAdmin)
 		ah.server.config.AdminPassword = ""
 		updated = true
 	}
 
 	if !updated {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no valid authentication updates provided"})
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "no valid authentication updates provided"))
 		return
 	}
 
-	logger.Log.Info().
+	logger.Ctx(c.Request.Context()).Info().
 		Str("ip", c.ClientIP()).
 		Msg("Admin authentication configuration updated")
 
@@ -218,49 +357,183 @@ func (ah *AdminHandler) updateAuthConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "authentication updated successfully"})
 }
 
+// maxAdminFilesPerPage caps the per_page parameter of listFiles
+const maxAdminFilesPerPage = 1000
+
+// adminFileSorts are the keys listFiles can order entries by
+var adminFileSorts = []string{"name", "size", "modified"}
+
+// adminFileEntry is a directory entry as listFiles reports it
+type adminFileEntry struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	IsDir   bool      `json:"is_dir"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// listFiles returns the entries of a directory under the storage root. Results
+// are ordered with ?sort (name, size or modified) and ?order (asc or desc),
+// and paginated with ?page (1-based) and ?per_page; without per_page every
+// entry is returned on a single page. The weak ETag covers the whole
+// directory and those parameters, so If-None-Match only answers 304 for the
+// same query against an unchanged directory.
 func (ah *AdminHandler) listFiles(c *gin.Context) {
-	path := c.DefaultQuery("path", "/")
+	relPath, ok := ah.resolveListPath(c.DefaultQuery("path", "/"))
+	if !ok {
+		apierror.Write(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "path not allowed"))
+		return
+	}
 
-	relPath := strings.TrimPrefix(path, "/")
-	if relPath == "" {
-		relPath = "."
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid page"))
+		return
+	}
+	perPage := 0
+	if raw := c.Query("per_page"); raw != "" {
+		perPage, err = strconv.Atoi(raw)
+		if err != nil || perPage < 1 || perPage > maxAdminFilesPerPage {
+			apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("per_page must be between 1 and %d", maxAdminFilesPerPage)))
+			return
+		}
+	}
+	sortBy := c.DefaultQuery("sort", "name")
+	if !slices.Contains(adminFileSorts, sortBy) {
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "sort must be name, size or modified"))
+		return
+	}
+	order := c.DefaultQuery("order", "asc")
+	if order != "asc" && order != "desc" {
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "order must be asc or desc"))
+		return
 	}
 
 	entries, err := ah.server.backend.ReadDir(c.Request.Context(), relPath)
 	if err != nil {
-		logger.Log.Error().Err(err).Str("path", path).Msg("Failed to read directory")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read directory"})
+		if errors.Is(err, fs.ErrNotExist) {
+			apierror.Write(c, apierror.New(http.StatusNotFound, apierror.CodeNotFound, "directory not found"))
+			return
+		}
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", relPath).Msg("Failed to read directory")
+		apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "failed to read directory"))
 		return
 	}
 
-	var files []gin.H
+	visible := make([]adminFileEntry, 0, len(entries))
 	for _, entry := range entries {
 		if ah.server.config.DisableDotFiles && strings.HasPrefix(entry.Name(), ".") {
 			continue
 		}
-
-		info, _ := entry.Info()
-		var size int64
-		var modTime time.Time
-		if info != nil {
-			size = info.Size()
-			modTime = info.ModTime()
+		item := adminFileEntry{Name: entry.Name(), IsDir: entry.IsDir()}
+		if info, _ := entry.Info(); info != nil {
+			item.Size = info.Size()
+			item.ModTime = info.ModTime()
 		}
+		visible = append(visible, item)
+	}
+	sortAdminFiles(visible, sortBy, order == "desc")
 
-		files = append(files, gin.H{
-			"name":     entry.Name(),
-			"size":     size,
-			"is_dir":   entry.IsDir(),
-			"mod_time": modTime,
-		})
+	displayPath := "/"
+	if relPath != "." {
+		displayPath += filepath.ToSlash(relPath)
 	}
 
+	etag := adminFilesETag(visible, displayPath, sortBy, order, page, perPage)
+	c.Header("ETag", etag)
+	if handler.ETagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+
+	total := len(visible)
+	if perPage == 0 {
+		perPage = max(total, 1)
+	}
+	startIdx := min((page-1)*perPage, total)
+	endIdx := min(startIdx+perPage, total)
+
 	c.JSON(http.StatusOK, gin.H{
-		"path":  path,
-		"files": files,
+		"path":     displayPath,
+		"files":    visible[startIdx:endIdx],
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
+
+// sortAdminFiles orders entries by sortBy, falling back to the name so
+// equal keys keep a stable order across requests
+func sortAdminFiles(entries []adminFileEntry, sortBy string, desc bool) {
+	slices.SortFunc(entries, func(a, b adminFileEntry) int {
+		var n int
+		switch sortBy {
+		case "size":
+			n = cmp.Compare(a.Size, b.Size)
+		case "modified":
+			n = a.ModTime.Compare(b.ModTime)
+		}
+		if n == 0 {
+			n = strings.Compare(a.Name, b.Name)
+		}
+		if desc {
+			return -n
+		}
+		return n
 	})
 }
 
+// adminFilesETag returns a weak validator for a listFiles response. It
+// hashes every visible entry rather than only the requested page, plus the
+// query shaping the response, so no two queries share a validator.
+func adminFilesETag(entries []adminFileEntry, displayPath, sortBy, order string, page, perPage int) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%d\x00%d\x00", displayPath, sortBy, order, page, perPage)
+	for _, e := range entries {
+		fmt.Fprintf(h, "%s\x00%d\x00%t\x00%d\x00", e.Name, e.Size, e.IsDir, e.ModTime.UnixNano())
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// resolveListPath converts a listing path from the admin UI into a path
+// relative to the storage root, rejecting anything that would leave it.
+// Symlinks that escape are additionally refused by the RootFS-backed storage.
+func (ah *AdminHandler) resolveListPath(requestPath string) (string, bool) {
+	relPath := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(requestPath, "/")))
+	if relPath == "." {
+		return relPath, true
+	}
+	if !filepath.IsLocal(relPath) || !ah.isPathAllowed(relPath) {
+		return "", false
+	}
+	return relPath, true
+}
+
+// ignoreTestResult is the response of testIgnore
+type ignoreTestResult struct {
+	Path string `json:"path"`
+	ignore.Decision
+}
+
+// testIgnore reports whether ?path, relative to the storage root, would be
+// hidden by the ignore rules and which pattern decided it. The path does not
+// have to exist. Ignore files are only consulted for local storage.
+func (ah *AdminHandler) testIgnore(c *gin.Context) {
+	raw := c.Query("path")
+	relPath := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(raw, "/")))
+	if raw == "" || relPath == "." || !filepath.IsLocal(relPath) {
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "path must name an entry below the storage root"))
+		return
+	}
+
+	decision, err := ignore.Explain(relPath, ah.server.localRoot, ah.server.config.IgnorePatterns)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", relPath).Msg("Failed to evaluate ignore patterns")
+		apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "failed to evaluate ignore patterns"))
+		return
+	}
+	c.JSON(http.StatusOK, ignoreTestResult{Path: filepath.ToSlash(relPath), Decision: decision})
+}
+
 func (ah *AdminHandler) deleteFile(c *gin.Context) {
 	var req struct {
 		Path     string `json:"path" binding:"required"`
@@ -268,24 +541,30 @@ func (ah *AdminHandler) deleteFile(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request"))
 		return
 	}
 
 	fullPath := filepath.Join(req.Path, req.Filename)
 	if !ah.isPathAllowed(fullPath) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "path not allowed"})
+		apierror.Write(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "path not allowed"))
 		return
 	}
 
-	err := os.RemoveAll(fullPath)
-	if err != nil {
-		logger.Log.Error().Err(err).Str("path", fullPath).Msg("Failed to delete file")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete file"})
+	uploader, ok := ah.server.backend.(storage.TreeUploader)
+	if !ok {
+		apierror.Write(c, apierror.New(http.StatusNotImplemented, apierror.CodeNotImplemented, "backend does not support delete operations"))
+		return
+	}
+
+	key := strings.TrimPrefix(filepath.ToSlash(fullPath), "/")
+	if err := uploader.RemoveAll(c.Request.Context(), key); err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", fullPath).Msg("Failed to delete file")
+		apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "failed to delete file"))
 		return
 	}
 
-	logger.Log.Info().
+	logger.Ctx(c.Request.Context()).Info().
 		Str("ip", c.ClientIP()).
 		Str("path", fullPath).
 		Msg("File deleted via admin interface")
@@ -295,6 +574,94 @@ func (ah *AdminHandler) deleteFile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "file deleted successfully"})
 }
 
+// deleteFiles deletes several entries of one directory and reports a result
+// per file. Directories must be empty, matching the backend's Delete.
+func (ah *AdminHandler) deleteFiles(c *gin.Context) {
+	var req struct {
+		Path      string   `json:"path"`
+		Filenames []string `json:"filenames" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Filenames) == 0 {
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request"))
+		return
+	}
+
+	uploader, ok := ah.server.backend.(storage.Uploader)
+	if !ok {
+		apierror.Write(c, apierror.New(http.StatusNotImplemented, apierror.CodeNotImplemented, "backend does not support delete operations"))
+		return
+	}
+
+	results := make([]gin.H, 0, len(req.Filenames))
+	var deleted []string
+	for _, name := range req.Filenames {
+		result := ah.deleteOne(c, uploader, req.Path, name)
+		if result["status"] == "success" {
+			deleted = append(deleted, name)
+		}
+		results = append(results, result)
+	}
+
+	status := http.StatusOK
+	errorCount := len(results) - len(deleted)
+	if errorCount > 0 {
+		if errorCount == len(results) {
+			status = http.StatusBadRequest // All failed
+		} else {
+			status = http.StatusPartialContent // Some failed
+		}
+	}
+
+	logger.Ctx(c.Request.Context()).Info().
+		Str("ip", c.ClientIP()).
+		Str("path", req.Path).
+		Int("total_files", len(results)).
+		Int("successful", len(deleted)).
+		Int("failed", errorCount).
+		Msg("Batch delete completed")
+
+	if len(deleted) > 0 {
+		ah.activityStore.AddActivity(admin.ActivityDelete,
+			fmt.Sprintf("Deleted %d file(s) from %s", len(deleted), req.Path),
+			c.ClientIP(), strings.Join(deleted, ", "))
+	}
+
+	c.JSON(status, gin.H{
+		"message": "delete completed",
+		"results": results,
+		"summary": gin.H{
+			"total":      len(results),
+			"successful": len(deleted),
+			"failed":     errorCount,
+		},
+	})
+}
+
+// deleteOne deletes a single entry of a batch and returns its result
+func (ah *AdminHandler) deleteOne(c *gin.Context, uploader storage.Uploader, dir, name string) gin.H {
+	filename, ok := sanitizeFilename(name)
+	if !ok {
+		return gin.H{"filename": name, "status": "error", "error": "invalid filename"}
+	}
+
+	fullPath := filepath.Join(dir, filename)
+	if !ah.isPathAllowed(fullPath) {
+		return gin.H{"filename": name, "status": "error", "error": "path not allowed"}
+	}
+
+	key := strings.TrimPrefix(filepath.ToSlash(fullPath), "/")
+	if err := uploader.Delete(c.Request.Context(), key); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return gin.H{"filename": name, "status": "error", "error": "file not found"}
+		}
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", fullPath).Msg("Failed to delete file")
+		return gin.H{"filename": name, "status": "error", "error": "failed to delete file"}
+	}
+
+	return gin.H{"filename": name, "status": "success"}
+}
+
 func (ah *AdminHandler) moveFile(c *gin.Context) {
 	var req struct {
 		Source      string `json:"source" binding:"required"`
@@ -302,23 +669,23 @@ func (ah *AdminHandler) moveFile(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request"))
 		return
 	}
 
 	if !ah.isPathAllowed(req.Source) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "source path not allowed"})
+		apierror.Write(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "source path not allowed"))
 		return
 	}
 
 	if !ah.isPathAllowed(req.Destination) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "destination path not allowed"})
+		apierror.Write(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "destination path not allowed"))
 		return
 	}
 
 	uploader, ok := ah.server.backend.(storage.Uploader)
 	if !ok {
-		c.JSON(http.StatusNotImplemented, gin.H{"error": "backend does not support move operations"})
+		apierror.Write(c, apierror.New(http.StatusNotImplemented, apierror.CodeNotImplemented, "backend does not support move operations"))
 		return
 	}
 
@@ -327,15 +694,15 @@ func (ah *AdminHandler) moveFile(c *gin.Context) {
 
 	err := uploader.Move(c.Request.Context(), relSrc, relDest)
 	if err != nil {
-		logger.Log.Error().Err(err).
+		logger.Ctx(c.Request.Context()).Error().Err(err).
 			Str("source", req.Source).
 			Str("destination", req.Destination).
 			Msg("Failed to move file")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to move file"})
+		apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "failed to move file"))
 		return
 	}
 
-	logger.Log.Info().
+	logger.Ctx(c.Request.Context()).Info().
 		Str("ip", c.ClientIP()).
 		Str("source", req.Source).
 		Str("destination", req.Destination).
@@ -353,30 +720,36 @@ func (ah *AdminHandler) createDirectory(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request"))
 		return
 	}
 
 	req.Name = filepath.Base(req.Name)
 	if req.Name == "" || req.Name == "." {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid directory name"})
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid directory name"))
 		return
 	}
 
 	fullPath := filepath.Join(req.Path, req.Name)
 	if !ah.isPathAllowed(fullPath) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "path not allowed"})
+		apierror.Write(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "path not allowed"))
 		return
 	}
 
-	err := os.MkdirAll(fullPath, 0755)
-	if err != nil {
-		logger.Log.Error().Err(err).Str("path", fullPath).Msg("Failed to create directory")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create directory"})
+	uploader, ok := ah.server.backend.(storage.TreeUploader)
+	if !ok {
+		apierror.Write(c, apierror.New(http.StatusNotImplemented, apierror.CodeNotImplemented, "backend does not support directory creation"))
 		return
 	}
 
-	logger.Log.Info().
+	key := strings.TrimPrefix(filepath.ToSlash(fullPath), "/")
+	if err := uploader.MkdirAll(c.Request.Context(), key); err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", fullPath).Msg("Failed to create directory")
+		apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "failed to create directory"))
+		return
+	}
+
+	logger.Ctx(c.Request.Context()).Info().
 		Str("ip", c.ClientIP()).
 		Str("path", fullPath).
 		Msg("Directory created via admin interface")
@@ -386,8 +759,39 @@ func (ah *AdminHandler) createDirectory(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "directory created successfully"})
 }
 
+// Default and maximum of the limit parameter of getRecentActivity
+const (
+	defaultActivityLimit = 20
+	maxActivityLimit     = 1000
+)
+
+// getRecentActivity returns activities newest first, filtered by ?type and
+// ?since (RFC 3339) and capped by ?limit
 func (ah *AdminHandler) getRecentActivity(c *gin.Context) {
-	activities := ah.activityStore.GetRecentActivities(20)
+	filter := admin.ActivityFilter{Type: c.Query("type"), Limit: defaultActivityLimit}
+	if raw := c.Query("since"); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "since must be an RFC 3339 time"))
+			return
+		}
+		filter.Since = since
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxActivityLimit {
+			apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", maxActivityLimit)))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	activities, err := ah.activityStore.Query(filter)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("Failed to read activity log")
+		apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "failed to read activity log"))
+		return
+	}
 
 	result := make([]gin.H, len(activities))
 	for i, activity := range activities {
@@ -406,38 +810,88 @@ func (ah *AdminHandler) getRecentActivity(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-func (ah *AdminHandler) countTotalFiles() int {
+// storageStats returns the cached storage stats, recomputing them when force
+// is set, nothing is cached yet, or the refresh interval has elapsed.
+func (ah *AdminHandler) storageStats(force bool) storageStats {
+	ah.statsMu.Lock()
+	defer ah.statsMu.Unlock()
+
+	now := ah.now()
+	ttl := time.Duration(ah.server.config.AdminStatsRefreshSeconds) * time.Second
+	if !force && ah.statsValid && ttl > 0 && now.Sub(ah.stats.ComputedAt) < ttl {
+		return ah.stats
+	}
+
+	ah.stats = ah.computeStorageStats()
+	ah.stats.ComputedAt = now
+	ah.statsValid = true
+	return ah.stats
+}
+
+// computeStorageStats counts files and their total size in one walk. The walk
+// stops MaxTraversalDepth levels below the storage path and flags the result
+// as truncated when that skipped anything.
+func (ah *AdminHandler) computeStorageStats() storageStats {
+	var stats storageStats
 	storageDir := ah.server.config.GetStorageDir()
 	if storageDir.IsS3() {
-		return 0
+		return stats
 	}
-	count := 0
-	filepath.Walk(storageDir.Path, func(path string, info os.FileInfo, err error) error {
-		if err == nil && !info.IsDir() {
-			count++
+	maxDepth := ah.server.config.MaxTraversalDepth
+	filepath.WalkDir(storageDir.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if maxDepth > 0 && walkDepth(storageDir.Path, path) >= maxDepth {
+				if !isEmptyDir(path) {
+					stats.Truncated = true
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
 		}
+		stats.TotalFiles++
+		stats.TotalBytes += info.Size()
 		return nil
 	})
-	return count
+	return stats
 }
 
-func (ah *AdminHandler) countUploadsToday() int {
-	return ah.activityStore.CountUploadsToday()
+// walkDepth returns how many levels path lies below root
+func walkDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
 }
 
-func (ah *AdminHandler) getStorageUsed() string {
+// isEmptyDir reports whether a directory has no entries
+func isEmptyDir(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	_, err = f.Readdirnames(1)
+	return errors.Is(err, io.EOF)
+}
+
+func (ah *AdminHandler) formatStorageUsed(usage storageStats) string {
 	storageDir := ah.server.config.GetStorageDir()
 	if storageDir.IsS3() {
 		return "N/A"
 	}
-	var totalSize int64
-	filepath.Walk(storageDir.Path, func(path string, info os.FileInfo, err error) error {
-		if err == nil && !info.IsDir() {
-			totalSize += info.Size()
-		}
-		return nil
-	})
-	return ah.server.adminUtils.FormatBytes(uint64(totalSize))
+	return ah.server.adminUtils.FormatBytes(uint64(usage.TotalBytes))
+}
+
+func (ah *AdminHandler) countUploadsToday() int {
+	return ah.activityStore.CountUploadsToday()
 }
 
 func (ah *AdminHandler) getServerUptime() string {
@@ -466,5 +920,23 @@ func (ah *AdminHandler) isPathAllowed(path string) bool {
 		return false
 	}
 
-	return strings.HasPrefix(absPath, absAllowed)
+	if absPath == absAllowed {
+		return true
+	}
+	// Compare with a trailing separator so a sibling such as /srv/data2 does
+	// not pass as being inside /srv/data.
+	prefix := absAllowed
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	return strings.HasPrefix(absPath, prefix)
+}
+
+// sanitizeFilename accepts a single path element, rejecting empty names,
+// dot entries and anything containing a separator.
+func sanitizeFilename(name string) (string, bool) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	return filepath.Base(name), true
 }
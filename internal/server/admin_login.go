@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"slimserve/internal/logger"
+	"slimserve/internal/server/apierror"
 	"slimserve/internal/server/auth"
 
 	"github.com/gin-gonic/gin"
@@ -27,16 +28,7 @@ func (s *Server) showAdminLogin(c *gin.Context) {
 	csrfToken := generateCSRFToken()
 
 	// Set CSRF token cookie
-	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie(
-		"slimserve_csrf_token",
-		csrfToken,
-		0, // session cookie
-		"/admin",
-		"",
-		c.Request.TLS != nil, // secure for HTTPS
-		true,                 // httpOnly
-	)
+	s.setCookie(c, s.config.CSRFCookieName(), csrfToken, 0, s.url("/admin"))
 
 	// Prepare template data
 	data := gin.H{
@@ -50,11 +42,11 @@ func (s *Server) showAdminLogin(c *gin.Context) {
 	}
 
 	// Add version information
-	data = s.addVersionToTemplateData(data)
+	data = s.templateData(c, data)
 
 	// Check if admin login template is loaded
 	if s.adminLoginTmpl == nil {
-		logger.Log.Error().Msg("Admin login template not loaded")
+		logger.Ctx(c.Request.Context()).Error().Msg("Admin login template not loaded")
 		http.Error(c.Writer, "admin login template not loaded", http.StatusInternalServerError)
 		return
 	}
@@ -62,7 +54,7 @@ func (s *Server) showAdminLogin(c *gin.Context) {
 	// Render the admin login template
 	c.Status(http.StatusOK)
 	if err := s.adminLoginTmpl.ExecuteTemplate(c.Writer, "admin_login.html", data); err != nil {
-		logger.Log.Error().Err(err).Msg("Failed to render admin login page")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("Failed to render admin login page")
 		http.Error(c.Writer, "failed to render admin login page", http.StatusInternalServerError)
 	}
 }
@@ -82,7 +74,7 @@ func (s *Server) doAdminLogin(c *gin.Context) {
 		}
 
 		if err := c.ShouldBindJSON(&jsonData); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request format"})
+			apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request format"))
 			return
 		}
 
@@ -102,7 +94,7 @@ func (s *Server) doAdminLogin(c *gin.Context) {
 	// Validate admin credentials
 	if !s.validateAdminCredentials(username, password) {
 		// Log failed login attempt
-		logger.Log.Warn().
+		logger.Ctx(c.Request.Context()).Warn().
 			Str("ip", c.ClientIP()).
 			Str("username", username).
 			Str("user_agent", c.GetHeader("User-Agent")).
@@ -111,7 +103,7 @@ func (s *Server) doAdminLogin(c *gin.Context) {
 		// Handle failure based on Accept header
 		acceptHeader := c.GetHeader("Accept")
 		if strings.Contains(acceptHeader, "application/json") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid admin credentials"})
+			apierror.Write(c, apierror.New(http.StatusUnauthorized, apierror.CodeInvalidCredentials, "invalid admin credentials"))
 			return
 		} else {
 			// Re-render login page with error
@@ -121,7 +113,7 @@ func (s *Server) doAdminLogin(c *gin.Context) {
 				"csrf_token": s.getOrSetCSRFToken(c),
 			}
 			// Add version information
-			data = s.addVersionToTemplateData(data)
+			data = s.templateData(c, data)
 			c.Status(http.StatusUnauthorized)
 			if err := s.adminLoginTmpl.ExecuteTemplate(c.Writer, "admin_login.html", data); err != nil {
 				http.Error(c.Writer, "failed to render admin login page", http.StatusInternalServerError)
@@ -135,7 +127,7 @@ func (s *Server) doAdminLogin(c *gin.Context) {
 	s.sessionStore.AddAdmin(token)
 
 	// Log successful admin login
-	logger.Log.Info().
+	logger.Ctx(c.Request.Context()).Info().
 		Str("ip", c.ClientIP()).
 		Str("username", username).
 		Msg("Successful admin login")
@@ -145,25 +137,15 @@ func (s *Server) doAdminLogin(c *gin.Context) {
 		s.adminHandler.activityStore.AddActivity("login", fmt.Sprintf("Admin login: %s", username), c.ClientIP(), "")
 	}
 
-	// Set admin session cookie with enhanced security
-	secure := c.Request.TLS != nil
-	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie(
-		"slimserve_admin_session",
-		token,
-		0,        // session cookie
-		"/admin", // restrict to admin paths
-		"",
-		secure, // secure for HTTPS
-		true,   // httpOnly
-	)
+	// Set admin session cookie, restricted to admin paths
+	s.setCookie(c, s.config.AdminSessionCookieName(), token, 0, s.url("/admin"))
 
 	// Handle success based on content type
 	if strings.Contains(contentType, "application/json") {
-		c.JSON(http.StatusOK, gin.H{"success": true, "redirect": next})
+		c.JSON(http.StatusOK, gin.H{"success": true, "redirect": s.url(next)})
 	} else {
 		// Redirect to next page
-		c.Redirect(http.StatusFound, next)
+		c.Redirect(http.StatusFound, s.url(next))
 	}
 }
 
@@ -221,20 +203,11 @@ func generateCSRFToken() string {
 // getOrSetCSRFToken gets the existing CSRF token from cookie, or generates and sets a new one
 func (s *Server) getOrSetCSRFToken(c *gin.Context) string {
 	// Try to get existing CSRF token from cookie
-	csrfToken, err := c.Cookie("slimserve_csrf_token")
+	csrfToken, err := c.Cookie(s.config.CSRFCookieName())
 	if err != nil {
 		// Generate new token and set cookie if none exists
 		csrfToken = generateCSRFToken()
-		c.SetSameSite(http.SameSiteLaxMode)
-		c.SetCookie(
-			"slimserve_csrf_token",
-			csrfToken,
-			0, // session cookie
-			"/admin",
-			"",
-			c.Request.TLS != nil, // secure for HTTPS
-			true,                 // httpOnly
-		)
+		s.setCookie(c, s.config.CSRFCookieName(), csrfToken, 0, s.url("/admin"))
 	}
 	return csrfToken
 }
@@ -242,41 +215,23 @@ func (s *Server) getOrSetCSRFToken(c *gin.Context) string {
 // doAdminLogout handles admin logout
 func (s *Server) doAdminLogout(c *gin.Context) {
 	// Get admin session token
-	cookie, err := c.Cookie("slimserve_admin_session")
+	cookie, err := c.Cookie(s.config.AdminSessionCookieName())
 	if err == nil {
 		// Remove token from session store
 		s.sessionStore.RemoveAdmin(cookie)
 	}
 
-	// Clear admin session cookie
-	c.SetCookie(
-		"slimserve_admin_session",
-		"",
-		-1, // expire immediately
-		"/admin",
-		"",
-		c.Request.TLS != nil,
-		true,
-	)
-
-	// Clear CSRF token cookie
-	c.SetCookie(
-		"slimserve_csrf_token",
-		"",
-		-1, // expire immediately
-		"/admin",
-		"",
-		c.Request.TLS != nil,
-		true,
-	)
+	// Clear admin session and CSRF token cookies
+	s.setCookie(c, s.config.AdminSessionCookieName(), "", -1, s.url("/admin"))
+	s.setCookie(c, s.config.CSRFCookieName(), "", -1, s.url("/admin"))
 
 	// Log admin logout
-	logger.Log.Info().
+	logger.Ctx(c.Request.Context()).Info().
 		Str("ip", c.ClientIP()).
 		Msg("Admin logout")
 
 	// Redirect to admin login
-	c.Redirect(http.StatusFound, "/admin/login")
+	c.Redirect(http.StatusFound, s.url("/admin/login"))
 }
 
 // showAdminDashboard renders the admin dashboard
@@ -288,18 +243,18 @@ func (s *Server) showAdminDashboard(c *gin.Context) {
 	}
 
 	// Add version information
-	data = s.addVersionToTemplateData(data)
+	data = s.templateData(c, data)
 
 	// Check if admin template is loaded
 	if s.adminTmpl == nil {
-		logger.Log.Error().Msg("Admin template not loaded")
+		logger.Ctx(c.Request.Context()).Error().Msg("Admin template not loaded")
 		http.Error(c.Writer, "admin template not loaded", http.StatusInternalServerError)
 		return
 	}
 
 	c.Status(http.StatusOK)
 	if err := s.adminTmpl.ExecuteTemplate(c.Writer, "admin_dashboard.html", data); err != nil {
-		logger.Log.Error().Err(err).Msg("Failed to render admin dashboard")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("Failed to render admin dashboard")
 		http.Error(c.Writer, "failed to render admin dashboard", http.StatusInternalServerError)
 	}
 }
@@ -315,11 +270,11 @@ func (s *Server) showAdminUpload(c *gin.Context) {
 	}
 
 	// Add version information
-	data = s.addVersionToTemplateData(data)
+	data = s.templateData(c, data)
 
 	c.Status(http.StatusOK)
 	if err := s.adminTmpl.ExecuteTemplate(c.Writer, "admin_upload.html", data); err != nil {
-		logger.Log.Error().Err(err).Msg("Failed to render admin upload page")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("Failed to render admin upload page")
 		http.Error(c.Writer, "failed to render admin upload page", http.StatusInternalServerError)
 	}
 }
@@ -333,11 +288,11 @@ func (s *Server) showAdminFiles(c *gin.Context) {
 	}
 
 	// Add version information
-	data = s.addVersionToTemplateData(data)
+	data = s.templateData(c, data)
 
 	c.Status(http.StatusOK)
 	if err := s.adminTmpl.ExecuteTemplate(c.Writer, "admin_files.html", data); err != nil {
-		logger.Log.Error().Err(err).Msg("Failed to render admin files page")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("Failed to render admin files page")
 		http.Error(c.Writer, "failed to render admin files page", http.StatusInternalServerError)
 	}
 }
@@ -351,11 +306,11 @@ func (s *Server) showAdminConfig(c *gin.Context) {
 	}
 
 	// Add version information
-	data = s.addVersionToTemplateData(data)
+	data = s.templateData(c, data)
 
 	c.Status(http.StatusOK)
 	if err := s.adminTmpl.ExecuteTemplate(c.Writer, "admin_config.html", data); err != nil {
-		logger.Log.Error().Err(err).Msg("Failed to render admin config page")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("Failed to render admin config page")
 		http.Error(c.Writer, "failed to render admin config page", http.StatusInternalServerError)
 	}
 }
@@ -369,11 +324,11 @@ func (s *Server) showAdminStatus(c *gin.Context) {
 	}
 
 	// Add version information
-	data = s.addVersionToTemplateData(data)
+	data = s.templateData(c, data)
 
 	c.Status(http.StatusOK)
 	if err := s.adminTmpl.ExecuteTemplate(c.Writer, "admin_status.html", data); err != nil {
-		logger.Log.Error().Err(err).Msg("Failed to render admin status page")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("Failed to render admin status page")
 		http.Error(c.Writer, "failed to render admin status page", http.StatusInternalServerError)
 	}
 }
@@ -0,0 +1,472 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"slimserve/internal/version"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminUploadPath takes uploads, which are exempt from MaxRequestBodyMB
+const adminUploadPath = "/admin/api/upload"
+
+// adminOpenAPIPath serves the OpenAPI document describing adminAPIRoutes
+const adminOpenAPIPath = "/admin/api/openapi.json"
+
+// adminAPIRoute is one admin API endpoint. The same table drives routing in
+// handleAdminRoute and the OpenAPI document, so the two cannot drift apart.
+type adminAPIRoute struct {
+	method      string // GET routes also answer HEAD
+	path        string
+	summary     string
+	query       []openAPIParam
+	requestType string         // request content type, defaults to JSON
+	request     map[string]any // request body schema, nil when there is none
+	response    map[string]any // schema of the 200 response
+	partial     bool           // the route may answer 206 with the same schema
+	write       bool           // the route modifies the served tree and is refused when ReadOnly is set
+	handle      func(s *Server, c *gin.Context)
+}
+
+// openAPIParam is an optional query parameter of an admin API route
+type openAPIParam struct {
+	name        string
+	schema      map[string]any
+	description string
+}
+
+func schemaType(typ string) map[string]any {
+	return map[string]any{"type": typ}
+}
+
+func schemaObject(props map[string]any, required ...string) map[string]any {
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func schemaArray(items map[string]any) map[string]any {
+	return map[string]any{"type": "array", "items": items}
+}
+
+var (
+	schemaString   = schemaType("string")
+	schemaInteger  = schemaType("integer")
+	schemaBoolean  = schemaType("boolean")
+	schemaDateTime = map[string]any{"type": "string", "format": "date-time"}
+	schemaMessage  = schemaObject(map[string]any{"message": schemaString})
+
+	schemaStats = schemaObject(map[string]any{
+		"total_files":   schemaInteger,
+		"uploads_today": schemaInteger,
+		"storage_used":  schemaString,
+		"storage_bytes": schemaInteger,
+		"computed_at":   schemaDateTime,
+		"truncated":     schemaBoolean,
+		"server_uptime": schemaString,
+		"memory_usage":  schemaString,
+	})
+
+	schemaFileResult = schemaObject(map[string]any{
+		"filename": schemaString,
+		"status":   map[string]any{"type": "string", "enum": []string{"success", "error"}},
+		"error":    schemaString,
+	})
+)
+
+var adminAPIRoutes = []adminAPIRoute{
+	{
+		method:   "GET",
+		path:     "/admin/api/stats",
+		summary:  "Dashboard statistics, cached between refreshes",
+		response: schemaStats,
+		handle:   func(s *Server, c *gin.Context) { s.adminHandler.getSystemStats(c) },
+	},
+	{
+		method:   "POST",
+		path:     "/admin/api/stats/refresh",
+		summary:  "Recompute dashboard statistics",
+		response: schemaStats,
+		handle:   func(s *Server, c *gin.Context) { s.adminHandler.refreshSystemStats(c) },
+	},
+	{
+		method:  "GET",
+		path:    "/admin/api/status",
+		summary: "Server, memory, storage and configuration status",
+		response: schemaObject(map[string]any{
+			"server":        schemaType("object"),
+			"memory":        schemaType("object"),
+			"storage":       schemaType("object"),
+			"configuration": schemaType("object"),
+		}),
+		handle: func(s *Server, c *gin.Context) { s.adminHandler.getSystemStatus(c) },
+	},
+	{
+		method:  "GET",
+		path:    "/admin/api/cache/thumbnails",
+		summary: "Thumbnail cache usage",
+		response: schemaObject(map[string]any{
+			"size_mb":    schemaInteger,
+			"size_bytes": schemaInteger,
+			"size":       schemaString,
+			"file_count": schemaInteger,
+			"limit_mb":   schemaInteger,
+		}),
+		handle: func(s *Server, c *gin.Context) { s.adminHandler.getThumbnailCacheStats(c) },
+	},
+	{
+		method:  "GET",
+		path:    "/admin/api/activity",
+		summary: "Admin activity, newest first; searches the whole activity log when one is configured",
+		query: []openAPIParam{
+			{"type", map[string]any{"type": "string", "enum": []string{"login", "upload", "config", "delete", "mkdir", "move"}}, "only activities of this type"},
+			{"since", schemaDateTime, "only activities at or after this time"},
+			{"limit", schemaInteger, "maximum entries, 20 by default and at most 1000"},
+		},
+		response: schemaArray(schemaObject(map[string]any{
+			"id":          schemaInteger,
+			"type":        schemaString,
+			"description": schemaString,
+			"timestamp":   schemaDateTime,
+			"ip":          schemaString,
+			"details":     schemaString,
+		})),
+		handle: func(s *Server, c *gin.Context) { s.adminHandler.getRecentActivity(c) },
+	},
+	{
+		method:   "GET",
+		path:     "/admin/api/config",
+		summary:  "Current server configuration",
+		response: schemaType("object"),
+		handle:   func(s *Server, c *gin.Context) { s.adminHandler.getConfiguration(c) },
+	},
+	{
+		method:  "POST",
+		path:    "/admin/api/config",
+		summary: "Update runtime-adjustable settings",
+		request: schemaObject(map[string]any{
+			"max_upload_size_mb":     schemaInteger,
+			"max_concurrent_uploads": schemaInteger,
+			"thumb_jpeg_quality":     schemaInteger,
+			"listing_banner":         schemaString,
+			"maintenance_mode":       schemaBoolean,
+		}),
+		response: schemaMessage,
+		handle:   func(s *Server, c *gin.Context) { s.adminHandler.updateConfiguration(c) },
+	},
+	{
+		method:   "POST",
+		path:     "/admin/api/config/save",
+		summary:  "Write the settings from the loaded JSON config file, runtime updates included, back to it",
+		response: schemaMessage,
+		handle:   func(s *Server, c *gin.Context) { s.adminHandler.saveConfiguration(c) },
+	},
+	{
+		method:  "GET",
+		path:    "/admin/api/config/effective",
+		summary: "Every setting with its value and source (default, file, env, flag or admin); secrets are redacted",
+		response: map[string]any{
+			"type": "object",
+			"additionalProperties": schemaObject(map[string]any{
+				"value":  map[string]any{},
+				"source": schemaString,
+			}, "value", "source"),
+		},
+		handle: func(s *Server, c *gin.Context) { s.adminHandler.getEffectiveConfiguration(c) },
+	},
+	{
+		method:   "GET",
+		path:     "/admin/api/config/schema",
+		summary:  "JSON Schema of the configuration file, with the type, default and description of every setting",
+		response: schemaType("object"),
+		handle:   func(s *Server, c *gin.Context) { s.adminHandler.getConfigurationSchema(c) },
+	},
+	{
+		method:  "GET",
+		path:    "/admin/api/auth",
+		summary: "Authentication settings, without passwords",
+		response: schemaObject(map[string]any{
+			"enable_auth":        schemaBoolean,
+			"username":           schemaString,
+			"password_set":       schemaBoolean,
+			"enable_admin":       schemaBoolean,
+			"admin_username":     schemaString,
+			"admin_password_set": schemaBoolean,
+		}),
+		handle: func(s *Server, c *gin.Context) { s.adminHandler.getAuthConfig(c) },
+	},
+	{
+		method:  "POST",
+		path:    "/admin/api/auth",
+		summary: "Update authentication settings; empty passwords are left unchanged",
+		request: schemaObject(map[string]any{
+			"enable_auth":    schemaBoolean,
+			"username":       schemaString,
+			"password":       schemaString,
+			"enable_admin":   schemaBoolean,
+			"admin_username": schemaString,
+			"admin_password": schemaString,
+		}),
+		response: schemaMessage,
+		handle:   func(s *Server, c *gin.Context) { s.adminHandler.updateAuthConfig(c) },
+	},
+	{
+		method:  "GET",
+		path:    "/admin/api/files",
+		summary: "List a directory in the storage path; answers 304 when If-None-Match matches the ETag of the same query",
+		query: []openAPIParam{
+			{"path", schemaString, "directory relative to the storage root"},
+			{"sort", map[string]any{"type": "string", "enum": []string{"name", "size", "modified"}}, "sort key, name by default"},
+			{"order", map[string]any{"type": "string", "enum": []string{"asc", "desc"}}, "sort direction, asc by default"},
+			{"page", schemaInteger, "1-based page number"},
+			{"per_page", schemaInteger, "entries per page, 0 for all"},
+		},
+		response: schemaObject(map[string]any{
+			"path": schemaString,
+			"files": schemaArray(schemaObject(map[string]any{
+				"name":     schemaString,
+				"size":     schemaInteger,
+				"is_dir":   schemaBoolean,
+				"mod_time": schemaDateTime,
+			})),
+			"total":    schemaInteger,
+			"page":     schemaInteger,
+			"per_page": schemaInteger,
+		}),
+		handle: func(s *Server, c *gin.Context) { s.adminHandler.listFiles(c) },
+	},
+	{
+		method:  "GET",
+		path:    "/admin/api/ignore/test",
+		summary: "Check whether a path would be ignored and which pattern decides it",
+		query: []openAPIParam{
+			{"path", schemaString, "file or directory relative to the storage root; it need not exist"},
+		},
+		response: schemaObject(map[string]any{
+			"path":    schemaString,
+			"ignored": schemaBoolean,
+			"pattern": schemaString,
+			"source":  schemaString,
+			"line":    schemaInteger,
+		}, "path", "ignored"),
+		handle: func(s *Server, c *gin.Context) { s.adminHandler.testIgnore(c) },
+	},
+	{
+		method:   "POST",
+		path:     "/admin/api/files/delete",
+		summary:  "Delete a file or directory",
+		request:  schemaObject(map[string]any{"path": schemaString, "filename": schemaString}, "filename"),
+		response: schemaMessage,
+		write:    true,
+		handle:   func(s *Server, c *gin.Context) { s.adminHandler.deleteFile(c) },
+	},
+	{
+		method:  "POST",
+		path:    "/admin/api/files/delete-batch",
+		summary: "Delete several entries of one directory; answers 206 when only some succeed",
+		request: schemaObject(map[string]any{
+			"path":      schemaString,
+			"filenames": schemaArray(schemaString),
+		}, "filenames"),
+		response: schemaObject(map[string]any{
+			"message": schemaString,
+			"results": schemaArray(schemaFileResult),
+			"summary": schemaObject(map[string]any{
+				"total":      schemaInteger,
+				"successful": schemaInteger,
+				"failed":     schemaInteger,
+			}),
+		}),
+		partial: true,
+		write:   true,
+		handle:  func(s *Server, c *gin.Context) { s.adminHandler.deleteFiles(c) },
+	},
+	{
+		method:   "POST",
+		path:     "/admin/api/files/mkdir",
+		summary:  "Create a directory",
+		request:  schemaObject(map[string]any{"path": schemaString, "name": schemaString}, "name"),
+		response: schemaMessage,
+		write:    true,
+		handle:   func(s *Server, c *gin.Context) { s.adminHandler.createDirectory(c) },
+	},
+	{
+		method:   "POST",
+		path:     "/admin/api/files/move",
+		summary:  "Move or rename a file or directory",
+		request:  schemaObject(map[string]any{"source": schemaString, "destination": schemaString}, "source", "destination"),
+		response: schemaMessage,
+		write:    true,
+		handle:   func(s *Server, c *gin.Context) { s.adminHandler.moveFile(c) },
+	},
+	{
+		method:      "POST",
+		path:        adminUploadPath,
+		summary:     "Upload files from the configured form fields (files and file by default); answers 206 when only some succeed",
+		requestType: "multipart/form-data",
+		request: schemaObject(map[string]any{
+			"files": schemaArray(map[string]any{"type": "string", "format": "binary"}),
+			"file":  schemaArray(map[string]any{"type": "string", "format": "binary"}),
+		}),
+		response: schemaObject(map[string]any{
+			"message": schemaString,
+			"results": schemaArray(schemaFileResult),
+			"summary": schemaObject(map[string]any{
+				"total":      schemaInteger,
+				"successful": schemaInteger,
+				"failed":     schemaInteger,
+			}),
+		}),
+		partial: true,
+		write:   true,
+		handle:  func(s *Server, c *gin.Context) { s.handleFileUpload(c) },
+	},
+	{
+		method:  "GET",
+		path:    "/admin/api/upload/progress",
+		summary: "Uploads in progress",
+		response: schemaObject(map[string]any{
+			"active_uploads": schemaArray(schemaObject(map[string]any{
+				"id":         schemaString,
+				"filename":   schemaString,
+				"total_size": schemaInteger,
+				"uploaded":   schemaInteger,
+				"status":     schemaString,
+				"start_time": schemaDateTime,
+				"error":      schemaString,
+			})),
+			"max_concurrent": schemaInteger,
+		}),
+		handle: func(s *Server, c *gin.Context) { s.getUploadProgress(c) },
+	},
+}
+
+// findAdminAPIRoute returns the route serving method on path, or nil
+func findAdminAPIRoute(path, method string) *adminAPIRoute {
+	if method == "HEAD" {
+		method = "GET"
+	}
+	for i := range adminAPIRoutes {
+		if adminAPIRoutes[i].path == path && adminAPIRoutes[i].method == method {
+			return &adminAPIRoutes[i]
+		}
+	}
+	return nil
+}
+
+// adminOpenAPIDocument builds an OpenAPI 3 description of the admin API
+func (s *Server) adminOpenAPIDocument() map[string]any {
+	errorResponse := map[string]any{
+		"description": "Error",
+		"content": map[string]any{
+			"application/json": map[string]any{
+				"schema": map[string]any{"$ref": "#/components/schemas/Error"},
+			},
+		},
+	}
+
+	paths := make(map[string]any)
+	addOperation := func(path, method string, op map[string]any) {
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			item = make(map[string]any)
+			paths[path] = item
+		}
+		item[strings.ToLower(method)] = op
+	}
+
+	for _, route := range adminAPIRoutes {
+		ok := map[string]any{
+			"description": "OK",
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": route.response},
+			},
+		}
+		responses := map[string]any{"200": ok, "default": errorResponse}
+		if route.partial {
+			responses["206"] = map[string]any{"description": "Partial success", "content": ok["content"]}
+		}
+
+		op := map[string]any{
+			"summary":   route.summary,
+			"responses": responses,
+		}
+		if route.method == "POST" {
+			op["security"] = []map[string][]string{{"adminSession": {}, "csrfToken": {}}}
+		}
+		if len(route.query) > 0 {
+			params := make([]map[string]any, 0, len(route.query))
+			for _, p := range route.query {
+				params = append(params, map[string]any{
+					"name":        p.name,
+					"in":          "query",
+					"description": p.description,
+					"schema":      p.schema,
+				})
+			}
+			op["parameters"] = params
+		}
+		if route.request != nil {
+			contentType := route.requestType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					contentType: map[string]any{"schema": route.request},
+				},
+			}
+		}
+		addOperation(route.path, route.method, op)
+	}
+
+	addOperation(adminOpenAPIPath, "GET", map[string]any{
+		"summary": "This document",
+		"responses": map[string]any{
+			"200":     map[string]any{"description": "OpenAPI document"},
+			"default": errorResponse,
+		},
+	})
+
+	serverURL := s.config.URLPrefix()
+	if serverURL == "" {
+		serverURL = "/"
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "SlimServe Admin API",
+			"version":     version.Get().Version,
+			"description": "Admin endpoints. All routes require an admin session; POST routes also require the CSRF token from the " + s.config.CSRFCookieName() + " cookie.",
+		},
+		"servers":  []map[string]any{{"url": serverURL}},
+		"security": []map[string][]string{{"adminSession": {}}},
+		"paths":    paths,
+		"components": map[string]any{
+			"securitySchemes": map[string]any{
+				"adminSession": map[string]any{"type": "apiKey", "in": "cookie", "name": s.config.AdminSessionCookieName()},
+				"csrfToken":    map[string]any{"type": "apiKey", "in": "header", "name": "X-CSRF-Token"},
+			},
+			"schemas": map[string]any{
+				"Error": schemaObject(map[string]any{
+					"error": schemaObject(map[string]any{
+						"code":    schemaString,
+						"message": schemaString,
+						"details": map[string]any{"type": "object"},
+					}, "code", "message"),
+				}, "error"),
+			},
+		},
+	}
+}
+
+// serveAdminOpenAPI serves the OpenAPI document for the admin API
+func (s *Server) serveAdminOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, s.adminOpenAPIDocument())
+}
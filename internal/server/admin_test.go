@@ -6,15 +6,23 @@ import (
 	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"slimserve/internal/config"
+	"slimserve/internal/files"
 	"slimserve/internal/security"
 	"slimserve/internal/server/admin"
 	"slimserve/internal/server/auth"
@@ -151,7 +159,7 @@ func TestFileUploadHandler(t *testing.T) {
 
 	server := &Server{
 		config:        cfg,
-		uploadManager: admin.NewUploadManager(3),
+		uploadManager: admin.NewUploadManager(3, 0),
 		localRoot:     root,
 		backend:       backend,
 	}
@@ -227,6 +235,232 @@ func TestFileUploadHandler(t *testing.T) {
 	})
 }
 
+func TestUploadFieldNames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// upload posts a.txt and b.txt under field to a server accepting fields
+	upload := func(t *testing.T, fields []string, field string) (string, int) {
+		t.Helper()
+		tmpDir := t.TempDir()
+		root, err := security.NewRootFS(tmpDir)
+		require.NoError(t, err)
+		server := &Server{
+			config: &config.Config{
+				EnableAdmin:        true,
+				StoragePath:        tmpDir,
+				StorageType:        "local",
+				MaxUploadSizeMB:    10,
+				AllowedUploadTypes: []string{"txt"},
+				UploadFieldNames:   fields,
+			},
+			uploadManager: admin.NewUploadManager(3, 0),
+			localRoot:     root,
+			backend:       storage.NewLocalBackend(root, nil),
+		}
+		engine := gin.New()
+		engine.POST("/admin/api/upload", server.handleFileUpload)
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for _, name := range []string{"a.txt", "b.txt"} {
+			part, err := writer.CreateFormFile(field, name)
+			require.NoError(t, err)
+			_, err = part.Write([]byte(name))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest("POST", "/admin/api/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return tmpDir, w.Code
+	}
+
+	assertUploaded := func(t *testing.T, dir string) {
+		t.Helper()
+		for _, name := range []string{"a.txt", "b.txt"} {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			require.NoError(t, err)
+			assert.Equal(t, name, string(data))
+		}
+	}
+
+	t.Run("default_file_field", func(t *testing.T) {
+		dir, code := upload(t, nil, "file")
+		assert.Equal(t, http.StatusOK, code)
+		assertUploaded(t, dir)
+	})
+
+	t.Run("custom_field", func(t *testing.T) {
+		dir, code := upload(t, []string{"attachment"}, "attachment")
+		assert.Equal(t, http.StatusOK, code)
+		assertUploaded(t, dir)
+	})
+
+	t.Run("unconfigured_field", func(t *testing.T) {
+		_, code := upload(t, []string{"attachment"}, "files")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestUploadSpillsToDisk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+	spillDir := t.TempDir()
+	t.Setenv("TMPDIR", spillDir)
+
+	root, err := security.NewRootFS(tmpDir)
+	require.NoError(t, err)
+	server := &Server{
+		config: &config.Config{
+			EnableAdmin:        true,
+			StoragePath:        tmpDir,
+			StorageType:        "local",
+			MaxUploadSizeMB:    64,
+			MultipartMemoryMB:  1,
+			AllowedUploadTypes: []string{"bin"},
+		},
+		uploadManager: admin.NewUploadManager(3, 0),
+		localRoot:     root,
+		backend:       storage.NewLocalBackend(root, nil),
+	}
+	engine := gin.New()
+	engine.POST("/admin/api/upload", server.handleFileUpload)
+
+	const size = 32 << 20
+	content := bytes.Repeat([]byte("0123456789abcdef"), size/16)
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("files", "large.bin")
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/admin/api/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	engine.ServeHTTP(w, req)
+	runtime.ReadMemStats(&after)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	saved, err := os.ReadFile(filepath.Join(tmpDir, "large.bin"))
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(content, saved), "saved file differs from the upload")
+
+	// The file passes through a temporary file, not memory
+	allocated := after.TotalAlloc - before.TotalAlloc
+	assert.Less(t, allocated, uint64(size/2), "upload allocated %d bytes", allocated)
+
+	spilled, err := os.ReadDir(spillDir)
+	require.NoError(t, err)
+	assert.Empty(t, spilled, "temporary multipart files are removed")
+}
+
+func TestUploadConflictPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// upload posts notes.txt with content into a directory that already holds
+	// a notes.txt and returns the decoded per-file result
+	upload := func(t *testing.T, policy, content string) (string, int, map[string]interface{}) {
+		t.Helper()
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("original"), 0644))
+
+		root, err := security.NewRootFS(tmpDir)
+		require.NoError(t, err)
+		server := &Server{
+			config: &config.Config{
+				EnableAdmin:          true,
+				StoragePath:          tmpDir,
+				StorageType:          "local",
+				MaxUploadSizeMB:      10,
+				AllowedUploadTypes:   []string{"txt"},
+				UploadConflictPolicy: policy,
+			},
+			uploadManager: admin.NewUploadManager(3, 0),
+			localRoot:     root,
+			backend:       storage.NewLocalBackend(root, nil),
+		}
+		engine := gin.New()
+		engine.POST("/admin/api/upload", server.handleFileUpload)
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("files", "notes.txt")
+		require.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest("POST", "/admin/api/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		var response struct {
+			Results []map[string]interface{} `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Results, 1)
+		return tmpDir, w.Code, response.Results[0]
+	}
+
+	readFile := func(t *testing.T, path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("rename", func(t *testing.T) {
+		dir, code, result := upload(t, config.UploadConflictRename, "renamed")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "notes_1.txt", result["saved_as"])
+		assert.Equal(t, "original", readFile(t, filepath.Join(dir, "notes.txt")))
+		assert.Equal(t, "renamed", readFile(t, filepath.Join(dir, "notes_1.txt")))
+	})
+
+	t.Run("default_renames", func(t *testing.T) {
+		_, code, result := upload(t, "", "renamed")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "notes_1.txt", result["saved_as"])
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		dir, code, result := upload(t, config.UploadConflictOverwrite, "replaced")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "notes.txt", result["saved_as"])
+		assert.Equal(t, "replaced", readFile(t, filepath.Join(dir, "notes.txt")))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "the temporary upload file should be renamed away")
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		dir, code, result := upload(t, config.UploadConflictReject, "rejected")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "error", result["status"])
+		assert.Contains(t, result["error"], "file already exists")
+		assert.Equal(t, "original", readFile(t, filepath.Join(dir, "notes.txt")))
+	})
+
+	t.Run("timestamp", func(t *testing.T) {
+		dir, code, result := upload(t, config.UploadConflictTimestamp, "stamped")
+		assert.Equal(t, http.StatusOK, code)
+		savedAs, _ := result["saved_as"].(string)
+		assert.Regexp(t, `^notes_\d{8}-\d{6}\.txt$`, savedAs)
+		assert.Equal(t, "original", readFile(t, filepath.Join(dir, "notes.txt")))
+		assert.Equal(t, "stamped", readFile(t, filepath.Join(dir, savedAs)))
+	})
+}
+
 func TestCookieSecurity(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -462,7 +696,7 @@ func TestAdminLoginPost(t *testing.T) {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "invalid admin credentials", response["error"])
+		assert.Equal(t, map[string]interface{}{"code": "invalid_credentials", "message": "invalid admin credentials"}, response["error"])
 
 		// Should not set admin session cookie
 		sessionToken := extractAdminCookie(w, "slimserve_admin_session")
@@ -495,7 +729,7 @@ func TestAdminLoginPost(t *testing.T) {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "invalid admin credentials", response["error"])
+		assert.Equal(t, map[string]interface{}{"code": "invalid_credentials", "message": "invalid admin credentials"}, response["error"])
 
 		// Should not set admin session cookie
 		sessionToken := extractAdminCookie(w, "slimserve_admin_session")
@@ -510,10 +744,11 @@ func TestCSRFProtectionMiddleware(t *testing.T) {
 	testHandler := func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	}
+	cfg := &config.Config{}
 
 	t.Run("GET requests should bypass CSRF check", func(t *testing.T) {
 		engine := gin.New()
-		engine.Use(admin.CSRFProtectionMiddleware())
+		engine.Use(admin.CSRFProtectionMiddleware(cfg))
 		engine.GET("/admin/test", testHandler)
 
 		req := httptest.NewRequest("GET", "/admin/test", nil)
@@ -525,7 +760,7 @@ func TestCSRFProtectionMiddleware(t *testing.T) {
 
 	t.Run("Admin login should bypass CSRF check", func(t *testing.T) {
 		engine := gin.New()
-		engine.Use(admin.CSRFProtectionMiddleware())
+		engine.Use(admin.CSRFProtectionMiddleware(cfg))
 		engine.POST("/admin/login", testHandler)
 
 		req := httptest.NewRequest("POST", "/admin/login", nil)
@@ -537,7 +772,7 @@ func TestCSRFProtectionMiddleware(t *testing.T) {
 
 	t.Run("POST request with valid CSRF token in header should pass", func(t *testing.T) {
 		engine := gin.New()
-		engine.Use(admin.CSRFProtectionMiddleware())
+		engine.Use(admin.CSRFProtectionMiddleware(cfg))
 		engine.POST("/admin/test", testHandler)
 
 		// Generate a test CSRF token
@@ -557,7 +792,7 @@ func TestCSRFProtectionMiddleware(t *testing.T) {
 
 	t.Run("POST request with valid CSRF token in form should pass", func(t *testing.T) {
 		engine := gin.New()
-		engine.Use(admin.CSRFProtectionMiddleware())
+		engine.Use(admin.CSRFProtectionMiddleware(cfg))
 		engine.POST("/admin/test", testHandler)
 
 		csrfToken := "test-csrf-token-456"
@@ -579,7 +814,7 @@ func TestCSRFProtectionMiddleware(t *testing.T) {
 
 	t.Run("POST request with missing CSRF token should fail", func(t *testing.T) {
 		engine := gin.New()
-		engine.Use(admin.CSRFProtectionMiddleware())
+		engine.Use(admin.CSRFProtectionMiddleware(cfg))
 		engine.POST("/admin/test", testHandler)
 
 		req := httptest.NewRequest("POST", "/admin/test", nil)
@@ -591,12 +826,12 @@ func TestCSRFProtectionMiddleware(t *testing.T) {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "invalid CSRF token", response["error"])
+		assert.Equal(t, map[string]interface{}{"code": "invalid_csrf_token", "message": "invalid CSRF token"}, response["error"])
 	})
 
 	t.Run("POST request with mismatched CSRF token should fail", func(t *testing.T) {
 		engine := gin.New()
-		engine.Use(admin.CSRFProtectionMiddleware())
+		engine.Use(admin.CSRFProtectionMiddleware(cfg))
 		engine.POST("/admin/test", testHandler)
 
 		req := httptest.NewRequest("POST", "/admin/test", nil)
@@ -613,12 +848,12 @@ func TestCSRFProtectionMiddleware(t *testing.T) {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "invalid CSRF token", response["error"])
+		assert.Equal(t, map[string]interface{}{"code": "invalid_csrf_token", "message": "invalid CSRF token"}, response["error"])
 	})
 
 	t.Run("POST request with missing CSRF cookie should fail", func(t *testing.T) {
 		engine := gin.New()
-		engine.Use(admin.CSRFProtectionMiddleware())
+		engine.Use(admin.CSRFProtectionMiddleware(cfg))
 		engine.POST("/admin/test", testHandler)
 
 		req := httptest.NewRequest("POST", "/admin/test", nil)
@@ -631,7 +866,7 @@ func TestCSRFProtectionMiddleware(t *testing.T) {
 
 	t.Run("PUT request should also be protected by CSRF", func(t *testing.T) {
 		engine := gin.New()
-		engine.Use(admin.CSRFProtectionMiddleware())
+		engine.Use(admin.CSRFProtectionMiddleware(cfg))
 		engine.PUT("/admin/test", testHandler)
 
 		csrfToken := "test-csrf-token-put"
@@ -650,7 +885,7 @@ func TestCSRFProtectionMiddleware(t *testing.T) {
 
 	t.Run("DELETE request should also be protected by CSRF", func(t *testing.T) {
 		engine := gin.New()
-		engine.Use(admin.CSRFProtectionMiddleware())
+		engine.Use(admin.CSRFProtectionMiddleware(cfg))
 		engine.DELETE("/admin/test", testHandler)
 
 		csrfToken := "test-csrf-token-delete"
@@ -687,7 +922,7 @@ func TestCSRFTokenGeneration(t *testing.T) {
 
 	t.Run("getOrSetCSRFToken should generate new token when none exists", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		server := &Server{}
+		server := &Server{config: &config.Config{}}
 
 		engine := gin.New()
 		engine.GET("/test", func(c *gin.Context) {
@@ -711,7 +946,7 @@ func TestCSRFTokenGeneration(t *testing.T) {
 
 	t.Run("getOrSetCSRFToken should return existing token from cookie", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
-		server := &Server{}
+		server := &Server{config: &config.Config{}}
 		existingToken := "existing-csrf-token-123456789012345678901234567890123456789012"
 
 		engine := gin.New()
@@ -786,7 +1021,7 @@ func TestAdminAuthMiddleware(t *testing.T) {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "admin interface not enabled", response["error"])
+		assert.Equal(t, map[string]interface{}{"code": "admin_disabled", "message": "admin interface not enabled"}, response["error"])
 	})
 
 	t.Run("Admin login route should bypass authentication", func(t *testing.T) {
@@ -919,7 +1154,7 @@ func TestAdminAuthMiddleware(t *testing.T) {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "admin authentication required", response["error"])
+		assert.Equal(t, map[string]interface{}{"code": "unauthenticated", "message": "admin authentication required"}, response["error"])
 	})
 
 	t.Run("Missing admin session should return 401 for API requests", func(t *testing.T) {
@@ -942,7 +1177,7 @@ func TestAdminAuthMiddleware(t *testing.T) {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "admin authentication required", response["error"])
+		assert.Equal(t, map[string]interface{}{"code": "unauthenticated", "message": "admin authentication required"}, response["error"])
 	})
 
 	t.Run("XMLHttpRequest should be treated as API request", func(t *testing.T) {
@@ -966,7 +1201,7 @@ func TestAdminAuthMiddleware(t *testing.T) {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "admin authentication required", response["error"])
+		assert.Equal(t, map[string]interface{}{"code": "unauthenticated", "message": "admin authentication required"}, response["error"])
 	})
 }
 
@@ -1125,3 +1360,750 @@ func TestAdminLogout(t *testing.T) {
 		}
 	})
 }
+
+func TestAdminStatsCaching(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(tmpDir+"/a.txt", []byte("hello"), 0644))
+
+	server := &Server{
+		config: &config.Config{
+			StoragePath:              tmpDir,
+			StorageType:              "local",
+			AdminStatsRefreshSeconds: 60,
+		},
+		adminUtils: admin.NewUtils(),
+	}
+	ah := NewAdminHandler(server)
+
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ah.now = func() time.Time { return clock }
+
+	fetch := func(handler gin.HandlerFunc) map[string]interface{} {
+		t.Helper()
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/admin/api/stats", nil)
+		handler(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var stats map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		return stats
+	}
+
+	stats := fetch(ah.getSystemStats)
+	assert.Equal(t, float64(1), stats["total_files"])
+	assert.Equal(t, float64(5), stats["storage_bytes"])
+
+	require.NoError(t, os.WriteFile(tmpDir+"/b.txt", []byte("world!"), 0644))
+
+	t.Run("served from cache within interval", func(t *testing.T) {
+		clock = clock.Add(59 * time.Second)
+		stats := fetch(ah.getSystemStats)
+		assert.Equal(t, float64(1), stats["total_files"])
+		assert.Equal(t, float64(5), stats["storage_bytes"])
+	})
+
+	t.Run("recomputed after interval", func(t *testing.T) {
+		clock = clock.Add(2 * time.Second)
+		stats := fetch(ah.getSystemStats)
+		assert.Equal(t, float64(2), stats["total_files"])
+		assert.Equal(t, float64(11), stats["storage_bytes"])
+		assert.Equal(t, clock.Format(time.RFC3339), stats["computed_at"])
+	})
+
+	t.Run("manual refresh bypasses cache", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(tmpDir+"/c.txt", []byte("!"), 0644))
+
+		stats := fetch(ah.getSystemStats)
+		assert.Equal(t, float64(2), stats["total_files"])
+
+		stats = fetch(ah.refreshSystemStats)
+		assert.Equal(t, float64(3), stats["total_files"])
+		assert.Equal(t, float64(12), stats["storage_bytes"])
+	})
+}
+
+func TestAdminListFiles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	parent := t.TempDir()
+	storageDir := filepath.Join(parent, "data")
+	require.NoError(t, os.MkdirAll(filepath.Join(storageDir, "sub"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(parent, "data-evil"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(parent, "data-evil", "secret.txt"), []byte("secret"), 0644))
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(storageDir, name), []byte(name), 0644))
+	}
+
+	root, err := security.NewRootFS(storageDir)
+	require.NoError(t, err)
+	defer root.Close()
+
+	ah := NewAdminHandler(&Server{
+		config:     &config.Config{StoragePath: storageDir, StorageType: "local"},
+		backend:    storage.NewLocalBackend(root, nil),
+		adminUtils: admin.NewUtils(),
+	})
+
+	listIfNoneMatch := func(query, etag string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/admin/api/files?"+query, nil)
+		if etag != "" {
+			c.Request.Header.Set("If-None-Match", etag)
+		}
+		ah.listFiles(c)
+		return w
+	}
+	list := func(query string) *httptest.ResponseRecorder {
+		return listIfNoneMatch(query, "")
+	}
+
+	type listing struct {
+		Path  string `json:"path"`
+		Total int    `json:"total"`
+		Page  int    `json:"page"`
+		Files []struct {
+			Name  string `json:"name"`
+			Size  int64  `json:"size"`
+			IsDir bool   `json:"is_dir"`
+		} `json:"files"`
+	}
+	decode := func(w *httptest.ResponseRecorder) listing {
+		t.Helper()
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var l listing
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &l))
+		return l
+	}
+
+	t.Run("prefix confusion rejected", func(t *testing.T) {
+		for _, p := range []string{"../data-evil", "/../data-evil", "sub/../../data-evil"} {
+			w := list("path=" + url.QueryEscape(p))
+			assert.Equal(t, http.StatusForbidden, w.Code, "path %q", p)
+			assert.NotContains(t, w.Body.String(), "secret.txt")
+		}
+	})
+
+	t.Run("all entries without per_page", func(t *testing.T) {
+		l := decode(list("path=/"))
+		assert.Equal(t, "/", l.Path)
+		assert.Equal(t, 6, l.Total)
+		assert.Len(t, l.Files, 6)
+	})
+
+	t.Run("pagination returns the right slice", func(t *testing.T) {
+		l := decode(list("path=/&page=2&per_page=2"))
+		assert.Equal(t, 6, l.Total)
+		assert.Equal(t, 2, l.Page)
+		require.Len(t, l.Files, 2)
+		assert.Equal(t, "c.txt", l.Files[0].Name)
+		assert.Equal(t, "d.txt", l.Files[1].Name)
+		assert.Equal(t, int64(5), l.Files[0].Size)
+
+		l = decode(list("path=/&page=3&per_page=2"))
+		require.Len(t, l.Files, 2)
+		assert.Equal(t, "sub", l.Files[1].Name)
+		assert.True(t, l.Files[1].IsDir)
+
+		l = decode(list("path=/&page=9&per_page=2"))
+		assert.Empty(t, l.Files)
+		assert.Equal(t, 6, l.Total)
+	})
+
+	t.Run("invalid pagination", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, list("page=0").Code)
+		assert.Equal(t, http.StatusBadRequest, list("per_page=abc").Code)
+		assert.Equal(t, http.StatusBadRequest, list("per_page=100000").Code)
+	})
+
+	t.Run("sorting", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(storageDir, "b.txt"), []byte(strings.Repeat("b", 64<<10)), 0644))
+		old := time.Now().Add(-time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(storageDir, "d.txt"), old, old))
+		t.Cleanup(func() {
+			require.NoError(t, os.WriteFile(filepath.Join(storageDir, "b.txt"), []byte("b.txt"), 0644))
+		})
+
+		names := func(query string) []string {
+			var got []string
+			for _, f := range decode(list(query)).Files {
+				got = append(got, f.Name)
+			}
+			return got
+		}
+		assert.Equal(t, []string{"sub", "e.txt", "d.txt"}, names("path=/&order=desc&per_page=3"))
+		assert.Equal(t, "b.txt", names("path=/&sort=size&order=desc")[0])
+		assert.Equal(t, "d.txt", names("path=/&sort=modified&per_page=1")[0])
+
+		assert.Equal(t, http.StatusBadRequest, list("sort=owner").Code)
+		assert.Equal(t, http.StatusBadRequest, list("order=up").Code)
+	})
+
+	t.Run("conditional get", func(t *testing.T) {
+		first := list("path=/&page=1&per_page=2")
+		require.Equal(t, http.StatusOK, first.Code)
+		etag := first.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		w := listIfNoneMatch("path=/&page=1&per_page=2", etag)
+		assert.Equal(t, http.StatusNotModified, w.Code, "same page of an unchanged directory")
+		assert.Empty(t, w.Body.String())
+
+		for _, query := range []string{
+			"path=/&page=2&per_page=2",
+			"path=/&page=1&per_page=3",
+			"path=/&page=1&per_page=2&sort=size",
+			"path=/&page=1&per_page=2&order=desc",
+		} {
+			w := listIfNoneMatch(query, etag)
+			assert.Equal(t, http.StatusOK, w.Code, "query %q", query)
+			assert.NotEqual(t, etag, w.Header().Get("ETag"), "query %q", query)
+		}
+
+		// A change on another page still invalidates the first one
+		require.NoError(t, os.WriteFile(filepath.Join(storageDir, "f.txt"), []byte("new"), 0644))
+		t.Cleanup(func() { os.Remove(filepath.Join(storageDir, "f.txt")) })
+		w = listIfNoneMatch("path=/&page=1&per_page=2", etag)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, list("path=/nope").Code)
+	})
+}
+
+func TestAdminIsPathAllowed(t *testing.T) {
+	parent := t.TempDir()
+	storageDir := filepath.Join(parent, "data")
+	require.NoError(t, os.MkdirAll(storageDir, 0755))
+
+	ah := NewAdminHandler(&Server{
+		config: &config.Config{StoragePath: storageDir, StorageType: "local"},
+	})
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"", true},
+		{"/", true},
+		{".", true},
+		{"file.txt", true},
+		{"/nested/dir", true},
+		{"sub/../file.txt", true},
+		{"..", false},
+		{"../data2", false},
+		{"../data-evil/secret.txt", false},
+		{"../data/../data2", false},
+		{"sub/../../data2", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, ah.isPathAllowed(tt.path), "isPathAllowed(%q)", tt.path)
+	}
+}
+
+func TestAdminThumbnailCacheStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cacheDir := t.TempDir()
+	t.Setenv("SLIMSERVE_CACHE_DIR", cacheDir)
+
+	srcDir := t.TempDir()
+	for _, name := range []string{"a.png", "b.png"} {
+		img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+		f, err := os.Create(filepath.Join(srcDir, name))
+		require.NoError(t, err)
+		require.NoError(t, png.Encode(f, img))
+		f.Close()
+		_, err = files.GenerateWithCacheLimit(filepath.Join(srcDir, name), 250, 50, 85, 10)
+		require.NoError(t, err)
+	}
+
+	var wantBytes int64
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		if !files.IsImageFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		require.NoError(t, err)
+		wantBytes += info.Size()
+	}
+
+	server := &Server{
+		config:     &config.Config{StoragePath: srcDir, StorageType: "local", MaxThumbCacheMB: 50},
+		adminUtils: admin.NewUtils(),
+	}
+	ah := NewAdminHandler(server)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/admin/api/cache/thumbnails", nil)
+	ah.getThumbnailCacheStats(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var stats map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, float64(2), stats["file_count"])
+	assert.Equal(t, float64(wantBytes), stats["size_bytes"])
+	assert.Equal(t, float64(0), stats["size_mb"])
+	assert.Equal(t, float64(50), stats["limit_mb"])
+}
+
+func TestAdminOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("SLIMSERVE_CACHE_DIR", t.TempDir())
+
+	srv := New(&config.Config{
+		Host:                 "localhost",
+		Port:                 8080,
+		StoragePath:          t.TempDir(),
+		StorageType:          "local",
+		EnableAdmin:          true,
+		AdminUsername:        "admin",
+		AdminPassword:        "password123",
+		MaxUploadSizeMB:      10,
+		MaxConcurrentUploads: 1,
+	})
+	token := srv.sessionStore.NewToken()
+	srv.sessionStore.AddAdmin(token)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: token})
+		req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: "csrf"})
+		req.Header.Set("X-CSRF-Token", "csrf")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/admin/api/openapi.json")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			Responses map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			SecuritySchemes map[string]json.RawMessage `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.True(t, strings.HasPrefix(doc.OpenAPI, "3."), "unexpected openapi version %q", doc.OpenAPI)
+	assert.NotEmpty(t, doc.Info.Title)
+	assert.NotEmpty(t, doc.Info.Version)
+	assert.Contains(t, doc.Components.SecuritySchemes, "adminSession")
+
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			assert.NotEmpty(t, op.Responses, "%s %s has no responses", method, path)
+		}
+	}
+
+	known := []struct{ method, path string }{
+		{"get", "/admin/api/stats"},
+		{"post", "/admin/api/stats/refresh"},
+		{"get", "/admin/api/status"},
+		{"get", "/admin/api/cache/thumbnails"},
+		{"get", "/admin/api/activity"},
+		{"get", "/admin/api/config"},
+		{"post", "/admin/api/config"},
+		{"get", "/admin/api/config/effective"},
+		{"get", "/admin/api/config/schema"},
+		{"get", "/admin/api/auth"},
+		{"post", "/admin/api/auth"},
+		{"get", "/admin/api/files"},
+		{"get", "/admin/api/ignore/test"},
+		{"post", "/admin/api/files/delete"},
+		{"post", "/admin/api/files/delete-batch"},
+		{"post", "/admin/api/files/mkdir"},
+		{"post", "/admin/api/files/move"},
+		{"post", "/admin/api/upload"},
+		{"get", "/admin/api/upload/progress"},
+		{"get", "/admin/api/openapi.json"},
+	}
+	for _, k := range known {
+		assert.Contains(t, doc.Paths[k.path], k.method, "missing %s %s", k.method, k.path)
+	}
+
+	// Every documented operation must be routed. The router answers unknown
+	// admin paths with an empty 404, while handlers always write a body.
+	for path, item := range doc.Paths {
+		for method := range item {
+			w := do(strings.ToUpper(method), path)
+			assert.False(t, w.Code == http.StatusNotFound && w.Body.Len() == 0,
+				"%s %s is documented but not routed", method, path)
+		}
+	}
+}
+
+func TestAdminDeleteBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	storageDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "keep.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(storageDir, name), []byte(name), 0644))
+	}
+
+	srv := New(&config.Config{
+		Host:          "localhost",
+		Port:          8080,
+		StoragePath:   storageDir,
+		StorageType:   "local",
+		EnableAdmin:   true,
+		AdminUsername: "admin",
+		AdminPassword: "password123",
+	})
+	token := srv.sessionStore.NewToken()
+	srv.sessionStore.AddAdmin(token)
+
+	deleteBatch := func(t *testing.T, filenames ...string) (int, map[string]string) {
+		t.Helper()
+		body, err := json.Marshal(gin.H{"path": "/", "filenames": filenames})
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", "/admin/api/files/delete-batch", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: token})
+		req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: "csrf"})
+		req.Header.Set("X-CSRF-Token", "csrf")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		var resp struct {
+			Results []struct {
+				Filename string `json:"filename"`
+				Status   string `json:"status"`
+			} `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		statuses := make(map[string]string)
+		for _, r := range resp.Results {
+			statuses[r.Filename] = r.Status
+		}
+		return w.Code, statuses
+	}
+
+	t.Run("mixed batch is a partial success", func(t *testing.T) {
+		code, statuses := deleteBatch(t, "a.txt", "missing.txt", "b.txt", "../escape.txt")
+		assert.Equal(t, http.StatusPartialContent, code)
+		assert.Equal(t, map[string]string{
+			"a.txt":         "success",
+			"missing.txt":   "error",
+			"b.txt":         "success",
+			"../escape.txt": "error",
+		}, statuses)
+
+		for _, name := range []string{"a.txt", "b.txt"} {
+			_, err := os.Stat(filepath.Join(storageDir, name))
+			assert.True(t, os.IsNotExist(err), "%s should be deleted", name)
+		}
+		_, err := os.Stat(filepath.Join(storageDir, "keep.txt"))
+		assert.NoError(t, err)
+
+		activities := srv.adminHandler.activityStore.GetRecentActivities(10)
+		require.Len(t, activities, 1)
+		assert.Equal(t, admin.ActivityDelete, activities[0].Type)
+	})
+
+	t.Run("batch with no successes is rejected", func(t *testing.T) {
+		code, statuses := deleteBatch(t, "missing.txt", "a.txt")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, map[string]string{"missing.txt": "error", "a.txt": "error"}, statuses)
+		assert.Len(t, srv.adminHandler.activityStore.GetRecentActivities(10), 1)
+	})
+}
+
+func TestAdminFileOpsOutsideWorkingDir(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	storageDir := t.TempDir()
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(storageDir, "docs", "old"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, "docs", "old", "a.txt"), []byte("a"), 0644))
+	// Same names under the working directory must survive untouched.
+	require.NoError(t, os.MkdirAll(filepath.Join(workDir, "docs", "old"), 0755))
+
+	srv := New(&config.Config{
+		Host:          "localhost",
+		Port:          8080,
+		StoragePath:   storageDir,
+		StorageType:   "local",
+		EnableAdmin:   true,
+		AdminUsername: "admin",
+		AdminPassword: "password123",
+	})
+	token := srv.sessionStore.NewToken()
+	srv.sessionStore.AddAdmin(token)
+
+	post := func(t *testing.T, path string, body gin.H) int {
+		t.Helper()
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: token})
+		req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: "csrf"})
+		req.Header.Set("X-CSRF-Token", "csrf")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("mkdir creates under the storage root", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, post(t, "/admin/api/files/mkdir", gin.H{"path": "docs/new", "name": "sub"}))
+
+		info, err := os.Stat(filepath.Join(storageDir, "docs", "new", "sub"))
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+		_, err = os.Stat(filepath.Join(workDir, "docs", "new"))
+		assert.True(t, os.IsNotExist(err), "mkdir must not touch the working directory")
+	})
+
+	t.Run("delete removes under the storage root", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, post(t, "/admin/api/files/delete", gin.H{"path": "docs", "filename": "old"}))
+
+		_, err := os.Stat(filepath.Join(storageDir, "docs", "old"))
+		assert.True(t, os.IsNotExist(err), "directory should be deleted from the storage root")
+		_, err = os.Stat(filepath.Join(workDir, "docs", "old"))
+		assert.NoError(t, err, "delete must not touch the working directory")
+	})
+}
+
+func TestAdminActivityQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logFile := filepath.Join(t.TempDir(), "activity.jsonl")
+	cfg := &config.Config{StoragePath: t.TempDir(), StorageType: "local", AdminActivityLogFile: logFile}
+
+	ah := NewAdminHandler(&Server{config: cfg})
+	ah.activityStore.AddActivity(admin.ActivityLogin, "Admin login: admin", "10.0.0.1", "")
+	ah.activityStore.AddActivity(admin.ActivityUpload, "Uploaded: a.txt", "10.0.0.1", "")
+	ah.activityStore.AddActivity(admin.ActivityUpload, "Uploaded: b.txt", "10.0.0.1", "")
+
+	// A restarted server reads the same log
+	ah = NewAdminHandler(&Server{config: cfg})
+
+	query := func(rawQuery string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/admin/api/activity?"+rawQuery, nil)
+		ah.getRecentActivity(c)
+		return w
+	}
+	descriptions := func(t *testing.T, w *httptest.ResponseRecorder) []string {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var entries []struct {
+			Description string `json:"description"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+		var got []string
+		for _, entry := range entries {
+			got = append(got, entry.Description)
+		}
+		return got
+	}
+
+	assert.Equal(t, []string{"Uploaded: b.txt", "Uploaded: a.txt", "Admin login: admin"}, descriptions(t, query("")))
+	assert.Equal(t, []string{"Uploaded: b.txt", "Uploaded: a.txt"}, descriptions(t, query("type=upload")))
+	assert.Equal(t, []string{"Uploaded: b.txt"}, descriptions(t, query("type=upload&limit=1")))
+	assert.Empty(t, descriptions(t, query("since="+url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339)))))
+	assert.Len(t, descriptions(t, query("since="+url.QueryEscape(time.Now().Add(-time.Hour).Format(time.RFC3339)))), 3)
+
+	for _, bad := range []string{"since=yesterday", "limit=0", "limit=1001", "limit=ten"} {
+		assert.Equal(t, http.StatusBadRequest, query(bad).Code, bad)
+	}
+}
+
+func TestAdminRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:          "localhost",
+		Port:          8080,
+		StoragePath:   t.TempDir(),
+		StorageType:   "local",
+		EnableAdmin:   true,
+		AdminUsername: "admin",
+		AdminPassword: "password123",
+	})
+
+	form := url.Values{"username": {"admin"}, "password": {"password123"}}
+	req := httptest.NewRequest("POST", "/admin/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	require.Equal(t, http.StatusFound, w.Code)
+	session := extractAdminCookie(w, "slimserve_admin_session")
+
+	remaining := -1
+	for i := 0; ; i++ {
+		req := httptest.NewRequest("GET", "/admin/api/stats", nil)
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: session})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		assert.Equal(t, "30", w.Header().Get("X-RateLimit-Limit"))
+		assert.NotEmpty(t, w.Header().Get("X-RateLimit-Reset"))
+
+		if w.Code == http.StatusTooManyRequests {
+			assert.Equal(t, 0, remaining, "limited only once the quota is used up")
+			assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+			assert.NotEmpty(t, w.Header().Get("Retry-After"))
+			break
+		}
+		require.Equal(t, http.StatusOK, w.Code)
+		got, err := strconv.Atoi(w.Header().Get("X-RateLimit-Remaining"))
+		require.NoError(t, err)
+		if remaining >= 0 {
+			assert.Equal(t, remaining-1, got, "request %d", i)
+		}
+		remaining = got
+		require.Less(t, i, 30, "the limit is shared across requests")
+	}
+}
+
+func TestAdminStatsMaxTraversalDepth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	storageDir := t.TempDir()
+
+	// One file at every level: f0 in the root, f1 one level down, and so on
+	dir := storageDir
+	for level := 0; level <= 6; level++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", level)), []byte("x"), 0644))
+		dir = filepath.Join(dir, fmt.Sprintf("l%d", level+1))
+		if level < 6 {
+			require.NoError(t, os.Mkdir(dir, 0755))
+		}
+	}
+
+	tests := []struct {
+		name          string
+		maxDepth      int
+		wantFiles     int
+		wantTruncated bool
+	}{
+		{"unlimited", 0, 7, false},
+		{"stops_at_depth", 3, 3, true},
+		{"root_only", 1, 1, true},
+		{"deep_enough", 7, 7, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ah := NewAdminHandler(&Server{
+				config: &config.Config{
+					StoragePath:       storageDir,
+					StorageType:       "local",
+					MaxTraversalDepth: tt.maxDepth,
+				},
+				adminUtils: admin.NewUtils(),
+			})
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/admin/api/stats/refresh", nil)
+			ah.refreshSystemStats(c)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var stats map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+			assert.Equal(t, float64(tt.wantFiles), stats["total_files"])
+			assert.Equal(t, tt.wantTruncated, stats["truncated"])
+		})
+	}
+}
+
+func TestAdminStorageStatsNestedTree(t *testing.T) {
+	storageDir := t.TempDir()
+	files := map[string]int{
+		"a.bin":                  1,
+		"docs/b.bin":             10,
+		"docs/c.bin":             100,
+		"docs/deep/er/d.bin":     1000,
+		"media/photos/e.bin":     4096,
+		"media/photos/empty.txt": 0,
+	}
+	var wantBytes int64
+	for name, size := range files {
+		path := filepath.Join(storageDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0644))
+		wantBytes += int64(size)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(storageDir, "empty", "dir"), 0755))
+
+	ah := NewAdminHandler(&Server{
+		config:     &config.Config{StoragePath: storageDir, StorageType: "local"},
+		adminUtils: admin.NewUtils(),
+	})
+	stats := ah.computeStorageStats()
+	assert.Equal(t, len(files), stats.TotalFiles, "directories must not be counted as files")
+	assert.Equal(t, wantBytes, stats.TotalBytes)
+	assert.False(t, stats.Truncated)
+}
+
+func TestAdminIgnoreTest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	storageDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storageDir, "docs", "drafts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, ".slimserveignore"), []byte("# build output\n*.tmp\ndocs/drafts/\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, "docs", ".slimserveignore"), []byte("!keep.tmp\n"), 0644))
+
+	srv := New(&config.Config{
+		Host:           "localhost",
+		Port:           8080,
+		StoragePath:    storageDir,
+		StorageType:    "local",
+		EnableAdmin:    true,
+		AdminUsername:  "admin",
+		AdminPassword:  "password123",
+		IgnorePatterns: []string{"*.bak", "secret/"},
+	})
+	token := srv.sessionStore.NewToken()
+	srv.sessionStore.AddAdmin(token)
+
+	check := func(t *testing.T, path string) (int, map[string]any) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/admin/api/ignore/test?path="+url.QueryEscape(path), nil)
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: token})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	tests := []struct {
+		path string
+		want map[string]any
+	}{
+		{"notes.bak", map[string]any{"path": "notes.bak", "ignored": true, "pattern": "*.bak", "source": "config", "line": float64(1)}},
+		{"secret/key.pem", map[string]any{"path": "secret/key.pem", "ignored": true, "pattern": "secret/", "source": "config", "line": float64(2)}},
+		{"/build.tmp", map[string]any{"path": "build.tmp", "ignored": true, "pattern": "*.tmp", "source": ".slimserveignore", "line": float64(2)}},
+		{"docs/drafts/plan.md", map[string]any{"path": "docs/drafts/plan.md", "ignored": true, "pattern": "docs/drafts/", "source": ".slimserveignore", "line": float64(3)}},
+		{"docs/keep.tmp", map[string]any{"path": "docs/keep.tmp", "ignored": false, "pattern": "!keep.tmp", "source": "docs/.slimserveignore", "line": float64(1)}},
+		{"docs/.slimserveignore", map[string]any{"path": "docs/.slimserveignore", "ignored": true, "pattern": ".slimserveignore", "source": "builtin"}},
+		{"docs/readme.md", map[string]any{"path": "docs/readme.md", "ignored": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			code, body := check(t, tt.path)
+			require.Equal(t, http.StatusOK, code)
+			assert.Equal(t, tt.want, body)
+		})
+	}
+
+	for _, path := range []string{"", "/", "../outside.txt"} {
+		code, _ := check(t, path)
+		assert.Equal(t, http.StatusBadRequest, code, "path %q", path)
+	}
+}
@@ -2,16 +2,23 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"slimserve/internal/config"
+	"slimserve/internal/files"
 	"slimserve/internal/logger"
+	"slimserve/internal/server/apierror"
+	"slimserve/internal/server/handler"
 	"slimserve/internal/storage"
 
 	"github.com/gin-gonic/gin"
@@ -19,46 +26,52 @@ import (
 
 func (s *Server) handleFileUpload(c *gin.Context) {
 	// Log upload attempt
-	logger.Log.Info().
+	logger.Ctx(c.Request.Context()).Info().
 		Str("ip", c.ClientIP()).
 		Str("user_agent", c.GetHeader("User-Agent")).
 		Msg("File upload attempt")
 
-	// Check concurrent upload limit
-	if s.uploadManager.ActiveUploadsCount() >= s.uploadManager.GetMaxConcurrent() {
-		logger.Log.Warn().
+	// Register the upload, enforcing the concurrent upload limit
+	uploadID, err := s.uploadManager.Begin(c.Request.ContentLength)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Warn().
 			Str("ip", c.ClientIP()).
 			Int("active_uploads", s.uploadManager.ActiveUploadsCount()).
 			Int("max_concurrent", s.uploadManager.GetMaxConcurrent()).
 			Msg("Upload rejected: concurrent limit reached")
 
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error":          "maximum concurrent uploads reached",
-			"max_concurrent": s.uploadManager.GetMaxConcurrent(),
-		})
+		apierror.Write(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, "maximum concurrent uploads reached").
+			With("max_concurrent", s.uploadManager.GetMaxConcurrent()))
 		return
 	}
+	defer s.uploadManager.End(uploadID)
 
-	maxFormSize := int64(s.config.MaxUploadSizeMB) * 1024 * 1024
-	if err := c.Request.ParseMultipartForm(maxFormSize); err != nil {
-		logger.Log.Error().
+	// Parts beyond the memory budget spill to temporary files, which are
+	// removed once the upload is handled
+	if err := c.Request.ParseMultipartForm(int64(s.config.MultipartMemoryMB) * 1024 * 1024); err != nil {
+		logger.Ctx(c.Request.Context()).Error().
 			Err(err).
 			Str("ip", c.ClientIP()).
 			Int("max_size_mb", s.config.MaxUploadSizeMB).
 			Msg("Failed to parse multipart form")
 
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":       "failed to parse upload form - file may be too large",
-			"max_size_mb": s.config.MaxUploadSizeMB,
-		})
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "failed to parse upload form - file may be too large").
+			With("max_size_mb", s.config.MaxUploadSizeMB))
 		return
 	}
-
-	// Extract files from form
-	files := c.Request.MultipartForm.File["files"]
+	defer c.Request.MultipartForm.RemoveAll() //nolint:errcheck
+
+	// Extract files from every accepted form field
+	fields := s.config.UploadFormFields()
+	var files []*multipart.FileHeader
+	for i, field := range fields {
+		if !slices.Contains(fields[:i], field) {
+			files = append(files, c.Request.MultipartForm.File[field]...)
+		}
+	}
 	if len(files) == 0 {
-		logger.Log.Warn().Str("ip", c.ClientIP()).Msg("Upload request with no files")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no files provided"})
+		logger.Ctx(c.Request.Context()).Warn().Str("ip", c.ClientIP()).Strs("fields", fields).Msg("Upload request with no files")
+		apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "no files provided").With("fields", fields))
 		return
 	}
 
@@ -68,22 +81,22 @@ func (s *Server) handleFileUpload(c *gin.Context) {
 	if storageDir.IsS3() {
 		uploader, ok := s.backend.(storage.Uploader)
 		if !ok {
-			logger.Log.Error().Msg("Backend does not support uploads")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "upload backend does not support uploads"})
+			logger.Ctx(c.Request.Context()).Error().Msg("Backend does not support uploads")
+			apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "upload backend does not support uploads"))
 			return
 		}
-		results = s.processUploadsWithUploader(c.Request.Context(), files, uploader, c.ClientIP())
+		results = s.processUploadsWithUploader(c.Request.Context(), uploadID, files, uploader, c.ClientIP())
 	} else {
 		if err := s.ensureUploadDirectory(storageDir.Path); err != nil {
-			logger.Log.Error().
+			logger.Ctx(c.Request.Context()).Error().
 				Err(err).
 				Str("dir", storageDir.Path).
 				Msg("Failed to create upload directory")
 
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create upload directory"})
+			apierror.Write(c, apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "failed to create upload directory"))
 			return
 		}
-		results = s.processUploads(files, storageDir.Path, c.ClientIP())
+		results = s.processUploads(uploadID, files, storageDir.Path, c.ClientIP())
 	}
 
 	// Determine response status
@@ -103,7 +116,7 @@ func (s *Server) handleFileUpload(c *gin.Context) {
 		}
 	}
 
-	logger.Log.Info().
+	logger.Ctx(c.Request.Context()).Info().
 		Str("ip", c.ClientIP()).
 		Int("total_files", len(files)).
 		Int("successful", len(results)-errorCount).
@@ -121,10 +134,13 @@ func (s *Server) handleFileUpload(c *gin.Context) {
 	})
 }
 
-func (s *Server) processUploadsWithUploader(ctx context.Context, files []*multipart.FileHeader, uploader storage.Uploader, clientIP string) []gin.H {
+func (s *Server) processUploadsWithUploader(ctx context.Context, uploadID string, files []*multipart.FileHeader, uploader storage.Uploader, clientIP string) []gin.H {
 	results := make([]gin.H, 0, len(files))
 
+	var stored int64
 	for _, fileHeader := range files {
+		s.uploadManager.Progress(uploadID, fileHeader.Filename, stored)
+		stored += fileHeader.Size
 		result := s.processFileUploadWithUploader(ctx, fileHeader, uploader)
 		results = append(results, result)
 
@@ -195,19 +211,13 @@ func (s *Server) processFileUploadWithUploader(ctx context.Context, fileHeader *
 	}
 	defer src.Close() //nolint:errcheck
 
-	data, err := io.ReadAll(src)
+	key, err := s.uploadDestination(ctx, uploader, filename)
 	if err != nil {
-		logger.Log.Error().Err(err).Str("filename", filename).Msg("Failed to read uploaded file")
-		return gin.H{
-			"filename": fileHeader.Filename,
-			"status":   "error",
-			"error":    fmt.Sprintf("failed to read file %s: %v", fileHeader.Filename, err),
-		}
+		return uploadConflictResult(fileHeader.Filename, filename, err)
 	}
 
 	// Upload to backend
-	key := filename
-	if err := uploader.Put(ctx, key, data); err != nil {
+	if err := storeUpload(ctx, uploader, key, src); err != nil {
 		logger.Log.Error().Err(err).Str("key", key).Msg("Failed to upload to backend")
 		return gin.H{
 			"filename": fileHeader.Filename,
@@ -218,13 +228,13 @@ func (s *Server) processFileUploadWithUploader(ctx context.Context, fileHeader *
 
 	logger.Log.Info().
 		Str("key", key).
-		Int64("size", int64(len(data))).
+		Int64("size", fileHeader.Size).
 		Msg("File uploaded to backend successfully")
 
 	return gin.H{
 		"filename": fileHeader.Filename,
 		"key":      key,
-		"size":     int64(len(data)),
+		"size":     fileHeader.Size,
 		"status":   "success",
 	}
 }
@@ -233,7 +243,7 @@ func (s *Server) ensureUploadDirectory(uploadDir string) error {
 	return os.MkdirAll(uploadDir, 0755)
 }
 
-func (s *Server) processUploads(files []*multipart.FileHeader, uploadDir, clientIP string) []gin.H {
+func (s *Server) processUploads(uploadID string, files []*multipart.FileHeader, uploadDir, clientIP string) []gin.H {
 	uploader, ok := s.backend.(storage.Uploader)
 	if !ok {
 		logger.Log.Error().Msg("Backend does not support uploads")
@@ -251,7 +261,10 @@ func (s *Server) processUploads(files []*multipart.FileHeader, uploadDir, client
 	ctx := context.Background()
 	results := make([]gin.H, 0, len(files))
 
+	var stored int64
 	for _, fileHeader := range files {
+		s.uploadManager.Progress(uploadID, fileHeader.Filename, stored)
+		stored += fileHeader.Size
 		result := s.processFileUpload(ctx, fileHeader, uploader)
 		results = append(results, result)
 
@@ -318,17 +331,12 @@ func (s *Server) processFileUpload(ctx context.Context, fileHeader *multipart.Fi
 	}
 	defer src.Close() //nolint:errcheck
 
-	data, err := io.ReadAll(src)
+	filename, err = s.uploadDestination(ctx, uploader, filename)
 	if err != nil {
-		logger.Log.Error().Err(err).Str("filename", filename).Msg("Failed to read uploaded file")
-		return gin.H{
-			"filename": fileHeader.Filename,
-			"status":   "error",
-			"error":    fmt.Sprintf("failed to read file %s: %v", fileHeader.Filename, err),
-		}
+		return uploadConflictResult(fileHeader.Filename, filename, err)
 	}
 
-	if err := uploader.Put(ctx, filename, data); err != nil {
+	if err := storeUpload(ctx, uploader, filename, src); err != nil {
 		logger.Log.Error().Err(err).Str("filename", filename).Msg("Failed to upload file")
 		return gin.H{
 			"filename": fileHeader.Filename,
@@ -339,17 +347,135 @@ func (s *Server) processFileUpload(ctx context.Context, fileHeader *multipart.Fi
 
 	logger.Log.Info().
 		Str("filename", filename).
-		Int64("size", int64(len(data))).
+		Int64("size", fileHeader.Size).
 		Msg("File uploaded successfully")
 
+	if s.config.ThumbOnUpload {
+		s.generateUploadThumbnail(filename, fileHeader.Size)
+	}
+
 	return gin.H{
 		"filename": fileHeader.Filename,
 		"saved_as": filename,
-		"size":     int64(len(data)),
+		"size":     fileHeader.Size,
 		"status":   "success",
 	}
 }
 
+// storeUpload writes an uploaded file to key, streaming it when the uploader
+// supports that so large files are never held in memory whole
+func storeUpload(ctx context.Context, uploader storage.Uploader, key string, src io.Reader) error {
+	if streamer, ok := uploader.(storage.StreamUploader); ok {
+		return streamer.PutStream(ctx, key, src)
+	}
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	return uploader.Put(ctx, key, data)
+}
+
+// maxUploadThumbnailJobs bounds how many uploaded images are thumbnailed at
+// once, so a large batch does not decode every image together
+const maxUploadThumbnailJobs = 2
+
+// generateUploadThumbnail builds the listing thumbnail of an uploaded image in
+// the background, so the first viewer does not pay for it. Formats the
+// thumbnailer cannot decode and images over ThumbMaxFileSizeMB are skipped.
+func (s *Server) generateUploadThumbnail(name string, size int64) {
+	if s.localRoot == nil || !files.IsImageFile(name) || size > int64(s.config.ThumbMaxFileSizeMB)*1024*1024 {
+		return
+	}
+	srcPath := filepath.Join(s.localRoot.Path(), name)
+
+	go func() {
+		s.uploadThumbs <- struct{}{}
+		defer func() { <-s.uploadThumbs }()
+
+		ctx := context.Background()
+		if s.config.ThumbGenTimeoutSeconds > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.ThumbGenTimeoutSeconds)*time.Second)
+			defer cancel()
+		}
+		if _, err := files.GenerateWithContext(ctx, srcPath, handler.ThumbnailOptions(s.config)); err != nil {
+			logger.Log.Warn().Err(err).Str("filename", name).Msg("Failed to generate thumbnail for upload")
+			return
+		}
+		logger.Log.Debug().Str("filename", name).Msg("Generated thumbnail for upload")
+	}()
+}
+
+// errUploadExists is returned by uploadDestination when the reject policy
+// meets a taken filename
+var errUploadExists = errors.New("file already exists")
+
+// maxUploadRenames bounds how many name_N.ext candidates are tried for one upload
+const maxUploadRenames = 1000
+
+// uploadDestination returns the key an upload named name is stored under,
+// applying UploadConflictPolicy when name is already taken.
+func (s *Server) uploadDestination(ctx context.Context, uploader storage.Uploader, name string) (string, error) {
+	taken, err := uploadExists(ctx, uploader, name)
+	if err != nil || !taken {
+		return name, err
+	}
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	if stem == "" {
+		// Dot files such as .env have no extension to keep
+		stem, ext = name, ""
+	}
+
+	switch s.config.UploadConflictPolicy {
+	case config.UploadConflictOverwrite:
+		return name, nil
+	case config.UploadConflictReject:
+		return name, errUploadExists
+	case config.UploadConflictTimestamp:
+		stem += "_" + time.Now().Format("20060102-150405")
+		candidate := stem + ext
+		if taken, err := uploadExists(ctx, uploader, candidate); err != nil || !taken {
+			return candidate, err
+		}
+	}
+
+	// Rename, and timestamp when two uploads land in the same second
+	for i := 1; i <= maxUploadRenames; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", stem, i, ext)
+		if taken, err := uploadExists(ctx, uploader, candidate); err != nil || !taken {
+			return candidate, err
+		}
+	}
+	return name, fmt.Errorf("no free name after %d attempts", maxUploadRenames)
+}
+
+// uploadExists reports whether key is already present in the backend
+func uploadExists(ctx context.Context, uploader storage.Uploader, key string) (bool, error) {
+	_, err := uploader.Stat(ctx, key)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// uploadConflictResult is the per-file result when no destination could be chosen
+func uploadConflictResult(original, name string, err error) gin.H {
+	if !errors.Is(err, errUploadExists) {
+		logger.Log.Error().Err(err).Str("filename", name).Msg("Failed to choose upload destination")
+	}
+	return gin.H{
+		"filename": original,
+		"status":   "error",
+		"error":    fmt.Sprintf("%v: %s", err, name),
+	}
+}
+
 func (s *Server) isAllowedFileType(filename string) bool {
 	if len(s.config.AllowedUploadTypes) == 0 {
 		return true // No restrictions if list is empty
@@ -0,0 +1,60 @@
+// Package apierror gives JSON API failures one shape:
+//
+//	{"error": {"code": "not_found", "message": "directory not found"}}
+//
+// Browser-facing pages keep rendering HTML errors.
+package apierror
+
+import "github.com/gin-gonic/gin"
+
+// Machine-readable error codes. Messages may change; codes are stable.
+const (
+	CodeInvalidRequest     = "invalid_request"
+	CodeUnauthenticated    = "unauthenticated"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeInvalidCSRFToken   = "invalid_csrf_token"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeAdminDisabled      = "admin_disabled"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeRateLimited        = "rate_limited"
+	CodeNotImplemented     = "not_implemented"
+	CodeInternal           = "internal_error"
+)
+
+// Error is a failed API request: the HTTP status it is answered with and
+// the code and message clients see
+type Error struct {
+	Status  int            `json:"-"`
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// New returns an Error answered with status
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// With adds a detail such as a limit the client ran into and returns e
+func (e *Error) With(key string, value any) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// Write sends err wrapped in the {"error": ...} envelope
+func Write(c *gin.Context, err *Error) {
+	c.JSON(err.Status, gin.H{"error": err})
+}
+
+// Abort sends err like Write and stops the remaining handlers
+func Abort(c *gin.Context, err *Error) {
+	c.AbortWithStatusJSON(err.Status, gin.H{"error": err})
+}
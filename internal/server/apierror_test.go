@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"slimserve/internal/config"
+	"slimserve/internal/server/admin"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("hello"), 0644))
+
+	newServer := func(modify func(*config.Config)) *Server {
+		cfg := &config.Config{
+			Host:            "localhost",
+			Port:            8080,
+			StoragePath:     tmpDir,
+			StorageType:     "local",
+			MaxUploadSizeMB: 10,
+		}
+		if modify != nil {
+			modify(cfg)
+		}
+		return New(cfg)
+	}
+
+	// apiError requests target as a JSON client and decodes the error envelope
+	apiError := func(t *testing.T, srv http.Handler, method, target string) (int, map[string]any) {
+		t.Helper()
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		var body struct {
+			Error map[string]any `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "body: %s", w.Body.String())
+		require.NotNil(t, body.Error, "body: %s", w.Body.String())
+		return w.Code, body.Error
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(*config.Config)
+		method     string
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{"admin_not_enabled", nil, "GET", "/admin/api/stats", http.StatusNotFound, "not_found"},
+		{"admin_unauthenticated", func(c *config.Config) {
+			c.EnableAdmin = true
+			c.AdminUsername = "admin"
+			c.AdminPassword = "password123"
+		}, "GET", "/admin/api/stats", http.StatusUnauthorized, "unauthenticated"},
+		{"session_unauthenticated", func(c *config.Config) {
+			c.EnableAuth = true
+			c.Username = "user"
+			c.Password = "pass"
+		}, "GET", "/file.txt", http.StatusUnauthorized, "unauthenticated"},
+		{"missing_file", nil, "GET", "/missing.txt", http.StatusNotFound, "not_found"},
+		{"recent_bad_limit", nil, "GET", "/recent?limit=0", http.StatusBadRequest, "invalid_request"},
+		{"listing_disabled", func(c *config.Config) { c.DisableListing = true }, "GET", "/", http.StatusForbidden, "forbidden"},
+		{"bad_checksum_algorithm", nil, "GET", "/?checksums=md5", http.StatusBadRequest, "invalid_request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, apiErr := apiError(t, newServer(tt.modify), tt.method, tt.target)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantCode, apiErr["code"])
+			assert.NotEmpty(t, apiErr["message"])
+		})
+	}
+
+	t.Run("details", func(t *testing.T) {
+		srv := newServer(nil)
+		srv.uploadManager = admin.NewUploadManager(3, 0)
+		engine := gin.New()
+		engine.POST("/admin/api/upload", srv.handleFileUpload)
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		require.NoError(t, writer.WriteField("note", "no files here"))
+		require.NoError(t, writer.Close())
+		req := httptest.NewRequest("POST", "/admin/api/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error": {
+			"code": "invalid_request",
+			"message": "no files provided",
+			"details": {"fields": ["files", "file"]}
+		}}`, w.Body.String())
+	})
+
+	t.Run("browser_paths_keep_plain_errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newServer(nil).ServeHTTP(w, httptest.NewRequest("GET", "/missing.txt", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NotContains(t, w.Body.String(), `"error"`)
+	})
+}
@@ -3,24 +3,24 @@ package auth
 import (
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
 
 	"slimserve/internal/config"
+	"slimserve/internal/server/apierror"
 
 	"github.com/gin-gonic/gin"
 )
 
 const (
-	SessionCookieName = "slimserve_session"
-	LoginPath         = "/login"
-	StaticPrefix      = "/static/"
-	AdminPrefix       = "/admin"
-	FaviconPath       = "/favicon.ico"
-	LoginQueryPrefix  = "/login?next="
+	LoginPath        = "/login"
+	StaticPrefix     = "/static/"
+	AdminPrefix      = "/admin"
+	FaviconPath      = "/favicon.ico"
+	LoginQueryPrefix = "/login?next="
+	RecentPath       = "/recent"
 )
 
-var unauthorizedResponse = gin.H{"error": "unauthenticated"}
-
 func SessionAuthMiddleware(cfg *config.Config, store *SessionStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !cfg.EnableAuth {
@@ -45,7 +45,12 @@ func SessionAuthMiddleware(cfg *config.Config, store *SessionStore) gin.HandlerF
 			return
 		}
 
-		cookie, err := c.Cookie(SessionCookieName)
+		if !requiresAuth(cfg, path) {
+			c.Next()
+			return
+		}
+
+		cookie, err := c.Cookie(cfg.SessionCookieName())
 		if err == nil && store.Valid(cookie) {
 			c.Next()
 			return
@@ -57,11 +62,34 @@ func SessionAuthMiddleware(cfg *config.Config, store *SessionStore) gin.HandlerF
 
 		if isBrowser {
 			nextURL := url.QueryEscape(c.Request.URL.RequestURI())
-			c.Redirect(http.StatusFound, LoginQueryPrefix+nextURL)
+			c.Redirect(http.StatusFound, cfg.URLPrefix()+LoginQueryPrefix+nextURL)
 			c.Abort()
 		} else {
-			c.JSON(http.StatusUnauthorized, unauthorizedResponse)
-			c.Abort()
+			apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthenticated"))
+		}
+	}
+}
+
+// requiresAuth reports whether urlPath needs a session. Without ProtectedPaths
+// every path does. Otherwise only paths at or below a protected entry do, plus
+// /recent, which lists files from every directory.
+func requiresAuth(cfg *config.Config, urlPath string) bool {
+	if len(cfg.ProtectedPaths) == 0 || urlPath == RecentPath {
+		return true
+	}
+
+	urlPath = path.Clean("/" + urlPath)
+	for _, pattern := range cfg.ProtectedPaths {
+		pattern = path.Clean("/" + strings.TrimSpace(pattern))
+		// Try the path and each of its ancestors so an entry covers its subtree
+		for p := urlPath; ; p = path.Dir(p) {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+			if p == "/" {
+				break
+			}
 		}
 	}
+	return false
 }
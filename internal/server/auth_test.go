@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -108,7 +110,7 @@ func TestSessionAuthMiddleware(t *testing.T) {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "unauthenticated", response["error"])
+		assert.Equal(t, map[string]interface{}{"code": "unauthenticated", "message": "unauthenticated"}, response["error"])
 	})
 
 	t.Run("auth enabled - valid session cookie returns 200", func(t *testing.T) {
@@ -191,6 +193,75 @@ func TestSessionAuthMiddleware(t *testing.T) {
 	})
 }
 
+func TestProtectedPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	for name, content := range map[string]string{
+		"public.txt":               "public",
+		"private/secret.txt":       "secret",
+		"privateer/ship.txt":       "ship",
+		"users/bob/inbox/mail.txt": "mail",
+		"users/bob/profile.txt":    "profile",
+	} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     tmpDir,
+		StorageType:     "local",
+		DisableDotFiles: true,
+		EnableAuth:      true,
+		Username:        "admin",
+		Password:        "secret",
+		ProtectedPaths:  []string{"/private", "/users/*/inbox"},
+	})
+
+	get := func(path, accept, session string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept", accept)
+		if session != "" {
+			req.AddCookie(&http.Cookie{Name: srv.config.SessionCookieName(), Value: session})
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/", "/public.txt", "/privateer/ship.txt", "/users/bob/profile.txt"} {
+		t.Run("public"+path, func(t *testing.T) {
+			w := get(path, "*/*", "")
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+
+	for _, path := range []string{"/private", "/private/secret.txt", "/users/bob/inbox/mail.txt", "/recent"} {
+		t.Run("protected"+path, func(t *testing.T) {
+			w := get(path, "application/json", "")
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+			w = get(path, "text/html", "")
+			assert.Equal(t, http.StatusFound, w.Code)
+			assert.Contains(t, w.Header().Get("Location"), "/login?next=")
+		})
+	}
+
+	t.Run("protected_with_session", func(t *testing.T) {
+		token := srv.sessionStore.NewToken()
+		srv.sessionStore.Add(token)
+		w := get("/private/secret.txt", "*/*", token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "secret", w.Body.String())
+	})
+}
+
 func TestLoginFlow(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -304,7 +375,7 @@ func TestLoginFlow(t *testing.T) {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, "invalid credentials", response["error"])
+		assert.Equal(t, map[string]interface{}{"code": "invalid_credentials", "message": "invalid credentials"}, response["error"])
 
 		// Should not set cookie
 		sessionToken := extractCookie(w, "slimserve_session")
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListingBanner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	srv := New(&config.Config{
+		Host:          "localhost",
+		Port:          8080,
+		StoragePath:   t.TempDir(),
+		StorageType:   "local",
+		EnableAdmin:   true,
+		AdminUsername: "admin",
+		AdminPassword: "password123",
+		ListingBanner: `Maintenance <b>tonight</b> <script>alert(1)</script><a href="javascript:x()" onclick="y()">details</a>`,
+	})
+	token := srv.sessionStore.NewToken()
+	srv.sessionStore.AddAdmin(token)
+
+	listing := func(t *testing.T) string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	t.Run("rendered", func(t *testing.T) {
+		body := listing(t)
+		assert.Contains(t, body, "Maintenance <b>tonight</b>")
+		assert.Contains(t, body, "<a>details</a>")
+		assert.NotContains(t, body, "alert(1)")
+		assert.NotContains(t, body, "javascript:x()")
+		assert.NotContains(t, body, "onclick")
+	})
+
+	update := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/admin/api/config", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-CSRF-Token", "banner-csrf")
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: token})
+		req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: "banner-csrf"})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("updated_by_admin", func(t *testing.T) {
+		w := update(t, `{"listing_banner": "Back up <a href=\"https://status.example.com\">status</a>"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		body := listing(t)
+		assert.Contains(t, body, `Back up <a href="https://status.example.com">status</a>`)
+		assert.NotContains(t, body, "tonight")
+
+		require.Equal(t, http.StatusOK, update(t, `{"listing_banner": ""}`).Code)
+		assert.NotContains(t, listing(t), "listing-banner")
+	})
+
+	t.Run("too_long", func(t *testing.T) {
+		w := update(t, `{"listing_banner": "`+strings.Repeat("x", config.MaxListingBannerLength+1)+`"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
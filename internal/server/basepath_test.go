@@ -0,0 +1,159 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBasePath(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpDir, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create docs: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "docs", "notes.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatalf("Failed to write notes.txt: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	newServer := func(enableAuth bool) *Server {
+		return New(&config.Config{
+			Host:            "localhost",
+			Port:            8080,
+			BasePath:        "/files/",
+			StoragePath:     tmpDir,
+			StorageType:     "local",
+			DisableDotFiles: true,
+			EnableAuth:      enableAuth,
+			Username:        "user",
+			Password:        "pass",
+		})
+	}
+
+	serve := func(srv *Server, req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("listing_links_include_prefix", func(t *testing.T) {
+		w := serve(newServer(false), httptest.NewRequest("GET", "/files/docs", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		body := w.Body.String()
+		for _, want := range []string{
+			`href="/files/static/css/theme.css"`,
+			`href="/files/"`,
+			`href="/files/docs"`,
+			`href="/files/docs/notes.txt"`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected listing to contain %s", want)
+			}
+		}
+	})
+
+	t.Run("prefixed_requests_resolve", func(t *testing.T) {
+		srv := newServer(false)
+
+		w := serve(srv, httptest.NewRequest("GET", "/files/docs/notes.txt", nil))
+		if w.Code != http.StatusOK || w.Body.String() != "notes" {
+			t.Errorf("Expected file content, got %d %q", w.Code, w.Body.String())
+		}
+
+		if w := serve(srv, httptest.NewRequest("GET", "/files/static/css/theme.css", nil)); w.Code != http.StatusOK {
+			t.Errorf("Expected static asset under prefix, got %d", w.Code)
+		}
+	})
+
+	t.Run("paths_outside_prefix_not_found", func(t *testing.T) {
+		srv := newServer(false)
+		for _, path := range []string{"/docs/notes.txt", "/", "/filesdocs", "/static/css/theme.css"} {
+			if w := serve(srv, httptest.NewRequest("GET", path, nil)); w.Code != http.StatusNotFound {
+				t.Errorf("GET %s: expected status 404, got %d", path, w.Code)
+			}
+		}
+	})
+
+	t.Run("bare_prefix_redirects", func(t *testing.T) {
+		w := serve(newServer(false), httptest.NewRequest("GET", "/files?sort=name", nil))
+		if w.Code != http.StatusMovedPermanently {
+			t.Fatalf("Expected status 301, got %d", w.Code)
+		}
+		if loc := w.Header().Get("Location"); loc != "/files/?sort=name" {
+			t.Errorf("Expected redirect to /files/?sort=name, got %q", loc)
+		}
+	})
+
+	t.Run("login_redirects_respect_prefix", func(t *testing.T) {
+		srv := newServer(true)
+
+		req := httptest.NewRequest("GET", "/files/docs", nil)
+		req.Header.Set("Accept", "text/html")
+		w := serve(srv, req)
+		if w.Code != http.StatusFound {
+			t.Fatalf("Expected status 302, got %d", w.Code)
+		}
+		if loc := w.Header().Get("Location"); loc != "/files/login?next=%2Fdocs" {
+			t.Errorf("Expected redirect to prefixed login, got %q", loc)
+		}
+
+		form := url.Values{"username": {"user"}, "password": {"pass"}, "next": {"/docs"}}
+		req = httptest.NewRequest("POST", "/files/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w = serve(srv, req)
+		if w.Code != http.StatusFound {
+			t.Fatalf("Expected status 302 after login, got %d", w.Code)
+		}
+		if loc := w.Header().Get("Location"); loc != "/files/docs" {
+			t.Errorf("Expected redirect to /files/docs, got %q", loc)
+		}
+		if cookie := w.Header().Get("Set-Cookie"); !strings.Contains(cookie, "Path=/files/") {
+			t.Errorf("Expected session cookie scoped to the prefix, got %q", cookie)
+		}
+	})
+
+	t.Run("admin_redirects_respect_prefix", func(t *testing.T) {
+		srv := New(&config.Config{
+			Host:          "localhost",
+			Port:          8080,
+			BasePath:      "/files",
+			StoragePath:   tmpDir,
+			StorageType:   "local",
+			EnableAdmin:   true,
+			AdminUsername: "admin",
+			AdminPassword: "secret",
+		})
+
+		req := httptest.NewRequest("GET", "/files/admin", nil)
+		req.Header.Set("Accept", "text/html")
+		w := serve(srv, req)
+		if w.Code != http.StatusFound {
+			t.Fatalf("Expected status 302, got %d", w.Code)
+		}
+		if loc := w.Header().Get("Location"); loc != "/files/admin/login?next=%2Fadmin" {
+			t.Errorf("Expected redirect to prefixed admin login, got %q", loc)
+		}
+
+		w = serve(srv, httptest.NewRequest("GET", "/files/admin/login", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected admin login page, got %d", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `action="/files/admin/login"`) {
+			t.Error("Expected admin login form to post to the prefixed URL")
+		}
+		if cookie := w.Header().Get("Set-Cookie"); !strings.Contains(cookie, "Path=/files/admin") {
+			t.Errorf("Expected CSRF cookie scoped to the prefixed admin path, got %q", cookie)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxRequestBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+
+	for _, name := range []string{"small.txt", "big.txt", "chunked.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte("hello"), 0644))
+	}
+
+	cfg := config.Default()
+	cfg.StoragePath = tmpDir
+	cfg.EnableAuth = true
+	cfg.Username = "user"
+	cfg.Password = "password123"
+	cfg.EnableAdmin = true
+	cfg.AdminUsername = "admin"
+	cfg.AdminPassword = "password123"
+	cfg.MaxUploadSizeMB = 10
+	cfg.MaxRequestBodyMB = 1
+	srv := New(cfg)
+	oversized := strings.Repeat("x", 1024*1024+1)
+
+	post := func(path, contentType string, body io.Reader, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, body)
+		req.Header.Set("Content-Type", contentType)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	loginForm := func(password string) string {
+		return url.Values{"username": {"admin"}, "password": {password}}.Encode()
+	}
+
+	t.Run("login", func(t *testing.T) {
+		form := url.Values{"username": {"user"}, "password": {"password123"}}.Encode()
+		w := post("/login", "application/x-www-form-urlencoded", strings.NewReader(form))
+		assert.Equal(t, http.StatusFound, w.Code)
+
+		w = post("/login", "application/x-www-form-urlencoded", strings.NewReader(form+"&pad="+oversized))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	w := post("/admin/login", "application/x-www-form-urlencoded", strings.NewReader(loginForm("password123")))
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	session := &http.Cookie{Name: "slimserve_admin_session", Value: extractAdminCookie(w, "slimserve_admin_session")}
+	csrf := &http.Cookie{Name: "slimserve_csrf_token", Value: "limit-csrf"}
+	admin := func(path, contentType string, body io.Reader) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-CSRF-Token", csrf.Value)
+		req.AddCookie(session)
+		req.AddCookie(csrf)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("admin_login", func(t *testing.T) {
+		w := post("/admin/login", "application/x-www-form-urlencoded", strings.NewReader(loginForm(oversized)))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("admin_api", func(t *testing.T) {
+		w := admin("/admin/api/files/delete-batch", "application/json", strings.NewReader(`{"path": "", "filenames": ["small.txt"]}`))
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NoFileExists(t, filepath.Join(tmpDir, "small.txt"))
+
+		w = admin("/admin/api/files/delete-batch", "application/json", strings.NewReader(`{"path": "", "filenames": ["big.txt"], "pad": "`+oversized+`"}`))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.FileExists(t, filepath.Join(tmpDir, "big.txt"))
+	})
+
+	t.Run("unknown_length_is_cut_off", func(t *testing.T) {
+		body := io.MultiReader(strings.NewReader(`{"path": "", "filenames": ["chunked.txt"], "pad": "`), strings.NewReader(oversized), strings.NewReader(`"}`))
+		req := httptest.NewRequest("POST", "/admin/api/files/delete-batch", body)
+		req.ContentLength = -1
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-CSRF-Token", csrf.Value)
+		req.AddCookie(session)
+		req.AddCookie(csrf)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		assert.NotEqual(t, http.StatusOK, w.Code)
+		assert.FileExists(t, filepath.Join(tmpDir, "chunked.txt"))
+	})
+
+	t.Run("uploads_keep_their_own_limit", func(t *testing.T) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("files", "large.bin")
+		require.NoError(t, err)
+		_, err = part.Write(bytes.Repeat([]byte("a"), 2*1024*1024))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		w := admin("/admin/api/upload", writer.FormDataContentType(), &body)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		info, err := os.Stat(filepath.Join(tmpDir, "large.bin"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2*1024*1024), info.Size())
+	})
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+	"slimserve/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestBodyLogging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logBuf bytes.Buffer
+	originalLogger := logger.Log
+	logger.Log = zerolog.New(&logBuf).Level(zerolog.DebugLevel)
+	t.Cleanup(func() { logger.Log = originalLogger })
+
+	tmpDir := t.TempDir()
+	newServer := func(logLevel string) *Server {
+		return New(&config.Config{
+			Host:                "localhost",
+			Port:                8080,
+			StoragePath:         tmpDir,
+			StorageType:         "local",
+			EnableAdmin:         true,
+			AdminUsername:       "admin",
+			AdminPassword:       "secret123",
+			LogLevel:            logLevel,
+			LogRequestBodies:    true,
+			LogRequestBodyBytes: 4096,
+		})
+	}
+
+	// mkdir logs in and creates name through the admin API, sending password
+	// and csrf_token fields alongside so they reach the body log
+	mkdir := func(t *testing.T, srv *Server, name string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/admin/login", nil))
+		var csrf string
+		for _, cookie := range (&http.Response{Header: w.Header()}).Cookies() {
+			if cookie.Name == "slimserve_csrf_token" {
+				csrf = cookie.Value
+			}
+		}
+		require.NotEmpty(t, csrf)
+
+		form := url.Values{"username": {"admin"}, "password": {"secret123"}}
+		req := httptest.NewRequest("POST", "/admin/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w = httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		require.Equal(t, http.StatusFound, w.Code)
+		var session string
+		for _, cookie := range (&http.Response{Header: w.Header()}).Cookies() {
+			if cookie.Name == "slimserve_admin_session" {
+				session = cookie.Value
+			}
+		}
+		require.NotEmpty(t, session)
+
+		body := `{"path": "/", "name": "` + name + `", "password": "hunter2", "csrf_token": "` + csrf + `"}`
+		req = httptest.NewRequest("POST", "/admin/api/files/mkdir", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-CSRF-Token", csrf)
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: session})
+		req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: csrf})
+		w = httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.DirExists(t, filepath.Join(tmpDir, name))
+
+		assert.NotContains(t, logBuf.String(), csrf)
+	}
+
+	t.Run("debug", func(t *testing.T) {
+		logBuf.Reset()
+		mkdir(t, newServer("debug"), "logged")
+
+		logged := logBuf.String()
+		assert.Contains(t, logged, `"path":"/admin/api/files/mkdir"`)
+		assert.Contains(t, logged, `\"name\": \"logged\"`)
+		assert.Contains(t, logged, `\"password\": \"[REDACTED]\"`)
+		assert.NotContains(t, logged, "hunter2")
+		assert.NotContains(t, logged, "secret123")
+	})
+
+	t.Run("admin_credentials", func(t *testing.T) {
+		srv := newServer("debug")
+		logBuf.Reset()
+
+		body := `{"admin_username":"root","admin_password":"x-admin-pass","s3_secret_key":"x-s3-key"}`
+		req := httptest.NewRequest("POST", "/admin/api/auth", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+
+		logged := logBuf.String()
+		assert.Contains(t, logged, `"path":"/admin/api/auth"`)
+		assert.Contains(t, logged, `\"admin_username\":\"root\"`)
+		assert.NotContains(t, logged, "x-admin-pass")
+		assert.NotContains(t, logged, "x-s3-key")
+	})
+
+	t.Run("off_below_debug", func(t *testing.T) {
+		logBuf.Reset()
+		mkdir(t, newServer("info"), "quiet")
+		assert.NotContains(t, logBuf.String(), `"message":"Request body"`)
+		assert.NotContains(t, logBuf.String(), `"body":`)
+	})
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		body      string
+		want      string
+	}{
+		{
+			name:      "json",
+			mediaType: "application/json",
+			body:      `{"user":"a","Password":"p\"w","nested":{"token":"t"}}`,
+			want:      `{"user":"a","Password":"[REDACTED]","nested":{"token":"[REDACTED]"}}`,
+		},
+		{
+			name:      "json_cut_off",
+			mediaType: "application/json",
+			body:      `{"name":"x","secret":"abc`,
+			want:      `{"name":"x","secret":"[REDACTED]"`,
+		},
+		{
+			name:      "form",
+			mediaType: "application/x-www-form-urlencoded",
+			body:      "username=a&password=p&csrf_token=c&next=%2F",
+			want:      "username=a&password=[REDACTED]&csrf_token=[REDACTED]&next=%2F",
+		},
+		{
+			name:      "keys_containing_secrets",
+			mediaType: "application/json",
+			body:      `{"admin_password":"p","s3_secret_key":"k","api_token":"t","user":"a"}`,
+			want:      `{"admin_password":"[REDACTED]","s3_secret_key":"[REDACTED]","api_token":"[REDACTED]","user":"a"}`,
+		},
+		{
+			name:      "form_keys_containing_secrets",
+			mediaType: "application/x-www-form-urlencoded",
+			body:      "admin_password=p&x_csrf=c&user=a",
+			want:      "admin_password=[REDACTED]&x_csrf=[REDACTED]&user=a",
+		},
+		{
+			name:      "untyped",
+			mediaType: "",
+			body:      "token=t",
+			want:      "token=[REDACTED]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redactBody(tt.mediaType, []byte(tt.body)))
+		})
+	}
+}
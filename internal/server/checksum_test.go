@@ -0,0 +1,107 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListingChecksums(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	small := []byte("hello checksum")
+	if err := os.WriteFile(filepath.Join(tmpDir, "small.txt"), small, 0644); err != nil {
+		t.Fatalf("Failed to write small.txt: %v", err)
+	}
+	large := make([]byte, 1024*1024+1)
+	if err := os.WriteFile(filepath.Join(tmpDir, "large.bin"), large, 0644); err != nil {
+		t.Fatalf("Failed to write large.bin: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create sub: %v", err)
+	}
+
+	srv := New(&config.Config{
+		Host:              "localhost",
+		Port:              8080,
+		StoragePath:       tmpDir,
+		StorageType:       "local",
+		MaxChecksumSizeMB: 1,
+	})
+
+	get := func(t *testing.T, target string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+	checksums := func(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var data struct {
+			Files []struct {
+				Name   string `json:"name"`
+				SHA256 string `json:"sha256"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &data); err != nil {
+			t.Fatalf("Failed to decode JSON listing: %v", err)
+		}
+		sums := make(map[string]string, len(data.Files))
+		for _, f := range data.Files {
+			sums[f.Name] = f.SHA256
+		}
+		return sums
+	}
+
+	t.Run("sha256", func(t *testing.T) {
+		sums := checksums(t, get(t, "/?format=json&checksums=sha256"))
+		want := sha256.Sum256(small)
+		if got := sums["small.txt"]; got != hex.EncodeToString(want[:]) {
+			t.Errorf("Expected small.txt sha256 %x, got %q", want, got)
+		}
+		if got, ok := sums["large.bin"]; !ok || got != "" {
+			t.Errorf("Expected large.bin listed without a checksum, got %q (listed %v)", got, ok)
+		}
+		if got := sums["sub"]; got != "" {
+			t.Errorf("Expected no checksum for a folder, got %q", got)
+		}
+	})
+
+	t.Run("not_requested", func(t *testing.T) {
+		sums := checksums(t, get(t, "/?format=json"))
+		if got := sums["small.txt"]; got != "" {
+			t.Errorf("Expected no checksum without ?checksums, got %q", got)
+		}
+	})
+
+	t.Run("html_skipped", func(t *testing.T) {
+		w := get(t, "/?checksums=sha256")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		want := sha256.Sum256(small)
+		if strings.Contains(w.Body.String(), hex.EncodeToString(want[:])) {
+			t.Error("Expected the HTML listing not to include checksums")
+		}
+	})
+
+	t.Run("unknown_algorithm", func(t *testing.T) {
+		if w := get(t, "/?format=json&checksums=md5"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveConfiguration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	configFile := filepath.Join(t.TempDir(), "slimserve.json")
+	data, err := json.Marshal(map[string]any{
+		"storage_path":   t.TempDir(),
+		"enable_admin":   true,
+		"admin_username": "admin",
+		"admin_password": "password123",
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(configFile, data, 0600))
+
+	cfg, err := config.LoadFile(configFile)
+	require.NoError(t, err)
+	srv := New(cfg)
+	token := srv.sessionStore.NewToken()
+	srv.sessionStore.AddAdmin(token)
+
+	post := func(path, body, csrf string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: token})
+		if csrf != "" {
+			req.Header.Set("X-CSRF-Token", csrf)
+			req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: csrf})
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/admin/api/config", `{"thumb_jpeg_quality": 42, "listing_banner": "saved banner"}`, "save-csrf")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	t.Run("requires_csrf", func(t *testing.T) {
+		w := post("/admin/api/config/save", "", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		reloaded, err := config.LoadFile(configFile)
+		require.NoError(t, err)
+		assert.Equal(t, 85, reloaded.ThumbJpegQuality, "the file is untouched")
+	})
+
+	t.Run("requires_admin", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/admin/api/config/save", nil)
+		req.Header.Set("X-CSRF-Token", "save-csrf")
+		req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: "save-csrf"})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		assert.NotEqual(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("reload_reflects_update", func(t *testing.T) {
+		w := post("/admin/api/config/save", "", "save-csrf")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		reloaded, err := config.LoadFile(configFile)
+		require.NoError(t, err)
+		assert.Equal(t, 42, reloaded.ThumbJpegQuality)
+		assert.Equal(t, "saved banner", reloaded.ListingBanner)
+		assert.Equal(t, "password123", reloaded.AdminPassword, "secrets are saved unredacted")
+
+		info, err := os.Stat(configFile)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "the file keeps its permissions")
+		entries, err := os.ReadDir(filepath.Dir(configFile))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "no temporary files are left behind")
+	})
+
+	t.Run("runtime_password_change", func(t *testing.T) {
+		w := post("/admin/api/auth", `{"admin_password": "changed456"}`, "save-csrf")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = post("/admin/api/config/save", "", "save-csrf")
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		reloaded, err := config.LoadFile(configFile)
+		require.NoError(t, err)
+		assert.Equal(t, "password123", reloaded.AdminPassword, "the saved password is kept")
+	})
+}
+
+func TestSaveConfigurationWithoutFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	srv := New(&config.Config{
+		Host:          "localhost",
+		Port:          8080,
+		StoragePath:   t.TempDir(),
+		StorageType:   "local",
+		EnableAdmin:   true,
+		AdminUsername: "admin",
+		AdminPassword: "password123",
+	})
+	token := srv.sessionStore.NewToken()
+	srv.sessionStore.AddAdmin(token)
+
+	req := httptest.NewRequest("POST", "/admin/api/config/save", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CSRF-Token", "save-csrf")
+	req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: token})
+	req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: "save-csrf"})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "no configuration file is in use")
+}
@@ -0,0 +1,17 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setCookie sets an HttpOnly session-scoped cookie for urlPath with the
+// configured SameSite mode and domain. A negative maxAge deletes it. Cookies
+// are Secure over TLS, and always with SameSite=None, which browsers only
+// accept on secure cookies.
+func (s *Server) setCookie(c *gin.Context, name, value string, maxAge int, urlPath string) {
+	sameSite := s.config.CookieSameSiteMode()
+	c.SetSameSite(sameSite)
+	c.SetCookie(name, value, maxAge, urlPath, s.config.CookieDomain, c.Request.TLS != nil || sameSite == http.SameSiteNoneMode, true)
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCookieSettings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("hello"), 0644))
+
+	newServer := func(sameSite string) *Server {
+		return New(&config.Config{
+			Host:           "localhost",
+			Port:           8080,
+			StoragePath:    tmpDir,
+			StorageType:    "local",
+			EnableAuth:     true,
+			Username:       "user",
+			Password:       "pass",
+			EnableAdmin:    true,
+			AdminUsername:  "admin",
+			AdminPassword:  "secret123",
+			CookiePrefix:   "instance2",
+			CookieSameSite: sameSite,
+			CookieDomain:   "example.com",
+		})
+	}
+
+	// post submits form credentials to target
+	post := func(srv *Server, target string, form url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	cookieNamed := func(t *testing.T, w *httptest.ResponseRecorder, name string) *http.Cookie {
+		t.Helper()
+		for _, cookie := range (&http.Response{Header: w.Header()}).Cookies() {
+			if cookie.Name == name {
+				return cookie
+			}
+		}
+		t.Fatalf("Expected a %s cookie, got %v", name, w.Header().Values("Set-Cookie"))
+		return nil
+	}
+
+	t.Run("session", func(t *testing.T) {
+		srv := newServer(config.CookieSameSiteStrict)
+		w := post(srv, "/login", url.Values{"username": {"user"}, "password": {"pass"}})
+		require.Equal(t, http.StatusFound, w.Code)
+
+		session := cookieNamed(t, w, "instance2_session")
+		assert.Equal(t, http.SameSiteStrictMode, session.SameSite)
+		assert.Equal(t, "example.com", session.Domain)
+		assert.True(t, session.HttpOnly)
+		assert.False(t, session.Secure)
+
+		get := func(cookie *http.Cookie) int {
+			req := httptest.NewRequest("GET", "/file.txt", nil)
+			req.Header.Set("Accept", "application/json")
+			req.AddCookie(cookie)
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+			return w.Code
+		}
+		assert.Equal(t, http.StatusOK, get(&http.Cookie{Name: "instance2_session", Value: session.Value}))
+		assert.Equal(t, http.StatusUnauthorized, get(&http.Cookie{Name: "slimserve_session", Value: session.Value}))
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		srv := newServer(config.CookieSameSiteStrict)
+
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/admin/login", nil))
+		csrf := cookieNamed(t, w, "instance2_csrf_token")
+		assert.Equal(t, http.SameSiteStrictMode, csrf.SameSite)
+		assert.Equal(t, "example.com", csrf.Domain)
+
+		w = post(srv, "/admin/login", url.Values{"username": {"admin"}, "password": {"secret123"}})
+		require.Equal(t, http.StatusFound, w.Code)
+		session := cookieNamed(t, w, "instance2_admin_session")
+		assert.Equal(t, http.SameSiteStrictMode, session.SameSite)
+		assert.Equal(t, "/admin", session.Path)
+
+		// Admin API writes pass the CSRF check only with the prefixed token cookie
+		mkdir := func(csrfCookie string) int {
+			req := httptest.NewRequest("POST", "/admin/api/files/mkdir", strings.NewReader(`{"path": "/", "name": "created"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-CSRF-Token", csrf.Value)
+			req.AddCookie(&http.Cookie{Name: "instance2_admin_session", Value: session.Value})
+			req.AddCookie(&http.Cookie{Name: csrfCookie, Value: csrf.Value})
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+			return w.Code
+		}
+		assert.Equal(t, http.StatusForbidden, mkdir("slimserve_csrf_token"))
+		assert.Equal(t, http.StatusOK, mkdir("instance2_csrf_token"))
+
+		w = post(srv, "/admin/logout", nil, &http.Cookie{Name: "instance2_admin_session", Value: session.Value})
+		require.Equal(t, http.StatusFound, w.Code)
+		cleared := cookieNamed(t, w, "instance2_admin_session")
+		assert.Equal(t, -1, cleared.MaxAge)
+		assert.Equal(t, "example.com", cleared.Domain)
+	})
+
+	t.Run("same_site_none_is_secure", func(t *testing.T) {
+		srv := newServer(config.CookieSameSiteNone)
+		w := post(srv, "/login", url.Values{"username": {"user"}, "password": {"pass"}})
+		require.Equal(t, http.StatusFound, w.Code)
+
+		session := cookieNamed(t, w, "instance2_session")
+		assert.Equal(t, http.SameSiteNoneMode, session.SameSite)
+		assert.True(t, session.Secure)
+	})
+}
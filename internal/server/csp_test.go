@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+var cspNoncePattern = regexp.MustCompile(`'nonce-([A-Za-z0-9_-]+)'`)
+
+func TestContentSecurityPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newServer := func(policy string, enableAuth bool) *Server {
+		return New(&config.Config{
+			Host:                  "localhost",
+			Port:                  8080,
+			StoragePath:           t.TempDir(),
+			StorageType:           "local",
+			DisableDotFiles:       true,
+			EnableAuth:            enableAuth,
+			Username:              "user",
+			Password:              "pass",
+			ContentSecurityPolicy: policy,
+		})
+	}
+
+	get := func(srv *Server, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("nonce_per_request", func(t *testing.T) {
+		srv := newServer(config.DefaultContentSecurityPolicy, true)
+
+		seen := make(map[string]bool)
+		for i := 0; i < 2; i++ {
+			w := get(srv, "/login")
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET /login: expected status 200, got %d", w.Code)
+			}
+
+			csp := w.Header().Get("Content-Security-Policy")
+			for _, directive := range strings.Split(csp, ";") {
+				if strings.HasPrefix(strings.TrimSpace(directive), "script-src") && strings.Contains(directive, "'unsafe-inline'") {
+					t.Errorf("script-src must not allow unsafe-inline, got %q", directive)
+				}
+			}
+			match := cspNoncePattern.FindStringSubmatch(csp)
+			if match == nil {
+				t.Fatalf("Expected a nonce in the CSP header, got %q", csp)
+			}
+			nonce := match[1]
+
+			if !strings.Contains(w.Body.String(), `nonce="`+nonce+`"`) {
+				t.Errorf("Expected page scripts to carry nonce %q", nonce)
+			}
+			if seen[nonce] {
+				t.Errorf("Nonce %q was reused across requests", nonce)
+			}
+			seen[nonce] = true
+		}
+	})
+
+	t.Run("listing_page", func(t *testing.T) {
+		srv := newServer("script-src 'nonce-{nonce}'", false)
+
+		w := get(srv, "/")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /: expected status 200, got %d", w.Code)
+		}
+		match := cspNoncePattern.FindStringSubmatch(w.Header().Get("Content-Security-Policy"))
+		if match == nil {
+			t.Fatalf("Expected a nonce in the listing CSP header, got %q", w.Header().Get("Content-Security-Policy"))
+		}
+		if !strings.Contains(w.Body.String(), `nonce="`+match[1]+`"`) {
+			t.Error("Expected listing scripts to carry the header nonce")
+		}
+		if strings.Contains(w.Body.String(), "onerror=") {
+			t.Error("Listing must not use inline event handlers")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		srv := newServer("", true)
+
+		w := get(srv, "/login")
+		if csp := w.Header().Get("Content-Security-Policy"); csp != "" {
+			t.Errorf("Expected no CSP header when disabled, got %q", csp)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListingDateFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "notes.txt")
+	require.NoError(t, os.WriteFile(file, []byte("hello"), 0644))
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(file, modTime, modTime))
+
+	newServer := func(format, timezone string) *Server {
+		return New(&config.Config{
+			Host:            "localhost",
+			Port:            8080,
+			StoragePath:     tmpDir,
+			StorageType:     "local",
+			DateFormat:      format,
+			DisplayTimezone: timezone,
+		})
+	}
+	listFiles := func(t *testing.T, srv *Server) []map[string]any {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var data struct {
+			Files []map[string]any `json:"files"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &data))
+		require.Len(t, data.Files, 1)
+		return data.Files
+	}
+
+	t.Run("preset_and_timezone", func(t *testing.T) {
+		srv := newServer("rfc3339", "Asia/Tokyo")
+		file := listFiles(t, srv)[0]
+		assert.Equal(t, "2024-01-02T12:04:05+09:00", file["mod_time"])
+		assert.Equal(t, float64(modTime.Unix()), file["mod_unix"])
+
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "2024-01-02T12:04:05")
+	})
+
+	t.Run("custom_layout", func(t *testing.T) {
+		file := listFiles(t, newServer("02/01/2006 15:04 MST", "UTC"))[0]
+		assert.Equal(t, "02/01/2024 03:04 UTC", file["mod_time"])
+	})
+
+	t.Run("default", func(t *testing.T) {
+		file := listFiles(t, newServer("", "UTC"))[0]
+		assert.Equal(t, "Jan 2, 2024 03:04", file["mod_time"])
+		assert.Equal(t, float64(modTime.Unix()), file["mod_unix"])
+	})
+}
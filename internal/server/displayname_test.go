@@ -0,0 +1,64 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxDisplayNameLength(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	longName := strings.Repeat("n", 250) + ".txt"
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, longName), []byte("long"), 0644))
+
+	srv := New(&config.Config{
+		Host:                 "localhost",
+		Port:                 8080,
+		StoragePath:          tmpDir,
+		StorageType:          "local",
+		MaxDisplayNameLength: 40,
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var data struct {
+		Files []struct {
+			Name     string `json:"name"`
+			FullName string `json:"full_name"`
+			URL      string `json:"url"`
+		} `json:"files"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &data))
+	require.Len(t, data.Files, 1)
+	file := data.Files[0]
+	assert.Equal(t, strings.Repeat("n", 35)+"….txt", file.Name)
+	assert.Equal(t, longName, file.FullName)
+	assert.Equal(t, "/"+longName, file.URL)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", file.URL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "long", w.Body.String())
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	body := w.Body.String()
+	assert.Contains(t, body, `title="`+longName+`"`)
+	assert.Contains(t, body, strings.Repeat("n", 35)+"….txt")
+}
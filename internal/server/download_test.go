@@ -0,0 +1,128 @@
+package server
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestForceDownload(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "page.html"), []byte("<script>alert(1)</script>"), 0644); err != nil {
+		t.Fatalf("Failed to write page.html: %v", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			img.Set(x, y, color.RGBA{0, 255, 0, 255})
+		}
+	}
+	imgFile, err := os.Create(filepath.Join(tmpDir, "green.png"))
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+	if err := png.Encode(imgFile, img); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	imgFile.Close()
+
+	gin.SetMode(gin.TestMode)
+	newServer := func(forceDownload bool) *Server {
+		return New(&config.Config{
+			Host:               "localhost",
+			Port:               8080,
+			StoragePath:        tmpDir,
+			StorageType:        "local",
+			DisableDotFiles:    true,
+			ForceDownload:      forceDownload,
+			ThumbJpegQuality:   85,
+			ThumbMaxFileSizeMB: 10,
+		})
+	}
+
+	get := func(srv *Server, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	assertAttachment := func(t *testing.T, w *httptest.ResponseRecorder, filename string) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename=`+filename {
+			t.Errorf("Expected attachment disposition for %s, got %q", filename, cd)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/octet-stream" {
+			t.Errorf("Expected application/octet-stream, got %q", ct)
+		}
+	}
+
+	assertInline := func(t *testing.T, w *httptest.ResponseRecorder, contentType string) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if cd := w.Header().Get("Content-Disposition"); cd != "" {
+			t.Errorf("Expected no Content-Disposition, got %q", cd)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, contentType) {
+			t.Errorf("Expected Content-Type %s, got %q", contentType, ct)
+		}
+	}
+
+	t.Run("flag_on", func(t *testing.T) {
+		srv := newServer(true)
+
+		assertAttachment(t, get(srv, "/page.html"), "page.html")
+		assertAttachment(t, get(srv, "/green.png"), "green.png")
+		assertInline(t, get(srv, "/"), "text/html")
+		assertInline(t, get(srv, "/green.png?thumb=1"), "image/jpeg")
+	})
+
+	t.Run("unicode_name", func(t *testing.T) {
+		name := "unicode文件名.txt"
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("hello"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		w := get(newServer(false), "/"+url.PathEscape(name)+"?download=1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		cd := w.Header().Get("Content-Disposition")
+		want := `attachment; filename="unicode___.txt"; filename*=UTF-8''unicode%E6%96%87%E4%BB%B6%E5%90%8D.txt`
+		if cd != want {
+			t.Errorf("Expected %q, got %q", want, cd)
+		}
+		_, params, err := mime.ParseMediaType(cd)
+		if err != nil {
+			t.Fatalf("Content-Disposition does not parse: %v", err)
+		}
+		if params["filename"] != name {
+			t.Errorf("Expected filename* to decode to %q, got %q", name, params["filename"])
+		}
+	})
+
+	t.Run("query_param", func(t *testing.T) {
+		srv := newServer(false)
+
+		assertInline(t, get(srv, "/page.html"), "text/html")
+		assertAttachment(t, get(srv, "/page.html?download=1"), "page.html")
+		assertInline(t, get(srv, "/?download=1"), "text/html")
+	})
+}
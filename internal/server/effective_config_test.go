@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveConfiguration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Host:          "localhost",
+		Port:          8080,
+		StoragePath:   t.TempDir(),
+		StorageType:   "local",
+		EnableAdmin:   true,
+		AdminUsername: "admin",
+		AdminPassword: "password123",
+	}
+	cfg.SetSource("AdminPassword", config.SourceEnv)
+	srv := New(cfg)
+	token := srv.sessionStore.NewToken()
+	srv.sessionStore.AddAdmin(token)
+
+	effective := func(t *testing.T) map[string]config.EffectiveSetting {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/admin/api/config/effective", nil)
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: token})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var settings map[string]config.EffectiveSetting
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+		return settings
+	}
+
+	t.Run("redacted", func(t *testing.T) {
+		settings := effective(t)
+		assert.Equal(t, config.EffectiveSetting{Value: "[REDACTED]", Source: config.SourceEnv}, settings["admin_password"])
+		assert.Equal(t, config.SourceDefault, settings["thumb_jpeg_quality"].Source)
+		assert.NotContains(t, settings, "password_hash")
+	})
+
+	t.Run("admin_update", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/admin/api/config", strings.NewReader(`{"thumb_jpeg_quality": 42}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-CSRF-Token", "effective-csrf")
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: token})
+		req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: "effective-csrf"})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		setting := effective(t)["thumb_jpeg_quality"]
+		assert.Equal(t, float64(42), setting.Value)
+		assert.Equal(t, config.SourceAdmin, setting.Source)
+	})
+
+	t.Run("requires_admin", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/admin/api/config/effective", nil))
+		assert.NotEqual(t, http.StatusOK, w.Code)
+	})
+}
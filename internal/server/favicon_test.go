@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"slimserve/internal/config"
+	"slimserve/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFavicon(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	get := func(srv *Server, header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/favicon.ico", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("configured", func(t *testing.T) {
+		icon := filepath.Join(t.TempDir(), "brand.png")
+		data := []byte("\x89PNG\r\n\x1a\ncustom icon")
+		if err := os.WriteFile(icon, data, 0644); err != nil {
+			t.Fatalf("Failed to write favicon: %v", err)
+		}
+		srv := New(&config.Config{
+			Host:        "localhost",
+			Port:        8080,
+			StoragePath: t.TempDir(),
+			StorageType: "local",
+			FaviconPath: icon,
+		})
+
+		w := get(srv, "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+		if !bytes.Equal(w.Body.Bytes(), data) {
+			t.Errorf("Expected the configured favicon, got %q", w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+			t.Errorf("Expected Content-Type image/png, got %q", ct)
+		}
+		if cc := w.Header().Get("Cache-Control"); cc == "" {
+			t.Error("Expected a Cache-Control header")
+		}
+
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			t.Fatal("Expected an ETag header")
+		}
+		if w := get(srv, "If-None-Match", etag); w.Code != http.StatusNotModified {
+			t.Errorf("Expected 304 for a matching ETag, got %d", w.Code)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		root := t.TempDir()
+		// A favicon.ico in the served directory must not shadow the embedded one
+		if err := os.WriteFile(filepath.Join(root, "favicon.ico"), []byte("from storage"), 0644); err != nil {
+			t.Fatalf("Failed to write favicon.ico: %v", err)
+		}
+		srv := New(&config.Config{
+			Host:        "localhost",
+			Port:        8080,
+			StoragePath: root,
+			StorageType: "local",
+		})
+
+		embedded, err := web.TemplateFS.ReadFile("static/favicon.ico")
+		if err != nil {
+			t.Fatalf("Failed to read embedded favicon: %v", err)
+		}
+
+		w := get(srv, "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+		if !bytes.Equal(w.Body.Bytes(), embedded) {
+			t.Error("Expected the embedded favicon")
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "image/x-icon" {
+			t.Errorf("Expected Content-Type image/x-icon, got %q", ct)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requireWellFormedXML reads every token of body, failing on malformed XML
+func requireWellFormedXML(t *testing.T, body []byte) {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		require.NoError(t, err, "feed is not well-formed XML")
+	}
+}
+
+func TestFeeds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	write := func(name string, content string, modTime time.Time) {
+		p := filepath.Join(tmpDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+		require.NoError(t, os.Chtimes(p, modTime, modTime))
+	}
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	write("old & plain.txt", "old", base)
+	write("new.png", "png-bytes", base.Add(time.Hour))
+	write("sub/nested.txt", "nested", base.Add(2*time.Hour))
+	write(".hidden", "secret", base)
+	write("debug.log", "ignored", base)
+
+	newServer := func(enableFeeds bool) *Server {
+		return New(&config.Config{
+			Host:            "localhost",
+			Port:            8080,
+			StoragePath:     tmpDir,
+			StorageType:     "local",
+			DisableDotFiles: true,
+			IgnorePatterns:  []string{"*.log"},
+			EnableFeeds:     enableFeeds,
+		})
+	}
+	srv := newServer(true)
+	get := func(srv *Server, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "http://files.example.com"+target, nil))
+		return w
+	}
+
+	t.Run("rss", func(t *testing.T) {
+		w := get(srv, "/?format=rss")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/rss+xml; charset=utf-8", w.Header().Get("Content-Type"))
+		requireWellFormedXML(t, w.Body.Bytes())
+
+		var feed struct {
+			Channel struct {
+				Link  string `xml:"link"`
+				Items []struct {
+					Title     string `xml:"title"`
+					Link      string `xml:"link"`
+					PubDate   string `xml:"pubDate"`
+					Enclosure struct {
+						Length int64  `xml:"length,attr"`
+						Type   string `xml:"type,attr"`
+					} `xml:"enclosure"`
+				} `xml:"item"`
+			} `xml:"channel"`
+		}
+		require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &feed))
+		assert.Equal(t, "http://files.example.com/", feed.Channel.Link)
+
+		// One item per visible file, newest first; folders, dot files and
+		// ignored files are left out
+		items := feed.Channel.Items
+		require.Len(t, items, 2)
+		assert.Equal(t, "new.png", items[0].Title)
+		assert.Equal(t, "http://files.example.com/new.png", items[0].Link)
+		assert.Equal(t, "Fri, 01 Mar 2024 13:00:00 +0000", items[0].PubDate)
+		assert.Equal(t, int64(len("png-bytes")), items[0].Enclosure.Length)
+		assert.Equal(t, "image/png", items[0].Enclosure.Type)
+		assert.Equal(t, "old & plain.txt", items[1].Title)
+		assert.Equal(t, "http://files.example.com/old%20&%20plain.txt", items[1].Link)
+	})
+
+	t.Run("atom_recent", func(t *testing.T) {
+		w := get(srv, "/recent?format=atom")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/atom+xml; charset=utf-8", w.Header().Get("Content-Type"))
+		requireWellFormedXML(t, w.Body.Bytes())
+
+		var feed struct {
+			XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+			Entries []struct {
+				Title   string `xml:"title"`
+				Updated string `xml:"updated"`
+			} `xml:"entry"`
+		}
+		require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &feed))
+		require.Len(t, feed.Entries, 3)
+		assert.Equal(t, "sub/nested.txt", feed.Entries[0].Title)
+		assert.Equal(t, "2024-03-01T14:00:00Z", feed.Entries[0].Updated)
+	})
+
+	t.Run("discovery_link", func(t *testing.T) {
+		body := get(srv, "/").Body.String()
+		assert.Contains(t, body, `<link rel="alternate" type="application/rss+xml" title="/" href="?format=rss">`)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		srv := newServer(false)
+		w := get(srv, "/?format=rss")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/html"))
+		assert.NotContains(t, w.Body.String(), "application/rss+xml")
+	})
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestH2C(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "hello.txt"), []byte("hello h2c"), 0644); err != nil {
+		t.Fatalf("Failed to write hello.txt: %v", err)
+	}
+
+	// fetch requests hello.txt with a client that only speaks cleartext HTTP/2
+	fetch := func(t *testing.T, enable bool) (*http.Response, error) {
+		t.Helper()
+		srv := New(&config.Config{
+			Host:        "localhost",
+			Port:        8080,
+			StoragePath: tmpDir,
+			StorageType: "local",
+			EnableH2C:   enable,
+		})
+		ts := httptest.NewServer(srv.newHTTPServer("").Handler)
+		t.Cleanup(ts.Close)
+
+		var protocols http.Protocols
+		protocols.SetUnencryptedHTTP2(true)
+		client := &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+		t.Cleanup(client.CloseIdleConnections)
+		return client.Get(ts.URL + "/hello.txt")
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		resp, err := fetch(t, true)
+		if err != nil {
+			t.Fatalf("HTTP/2 request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.ProtoMajor != 2 {
+			t.Errorf("Expected HTTP/2, got %s", resp.Proto)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "hello h2c" {
+			t.Errorf("Expected 200 with the file, got %d %q", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		resp, err := fetch(t, false)
+		if err == nil {
+			defer resp.Body.Close()
+			if resp.ProtoMajor == 2 {
+				t.Error("Expected HTTP/2 to be refused without EnableH2C")
+			}
+		}
+	})
+}
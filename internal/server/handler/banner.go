@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"html/template"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// bannerTags are the inline elements kept in a ListingBanner. Everything
+// else is dropped, keeping its text, except script and style whose content
+// goes with them.
+var bannerTags = map[string]bool{
+	"a": true, "b": true, "strong": true, "i": true, "em": true, "u": true,
+	"code": true, "small": true, "span": true, "br": true,
+}
+
+// listingBanner returns the ListingBanner ready to embed in a listing, empty
+// when none is configured
+func (h *Handler) listingBanner() template.HTML {
+	if h.config.ListingBanner == "" {
+		return ""
+	}
+	return sanitizeBanner(h.config.ListingBanner)
+}
+
+// sanitizeBanner turns the configured banner, plain text or HTML, into markup
+// that is safe to embed. Only bannerTags survive, with no attributes other
+// than a safe href on links.
+func sanitizeBanner(banner string) template.HTML {
+	var b strings.Builder
+	var open []string
+	skip := 0
+
+	z := html.NewTokenizer(strings.NewReader(banner))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		switch tt {
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(tok.Data))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if tok.Data == "script" || tok.Data == "style" {
+				if tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			if skip > 0 || !bannerTags[tok.Data] {
+				continue
+			}
+			if tok.Data == "br" {
+				b.WriteString("<br>")
+				continue
+			}
+			b.WriteString("<" + tok.Data)
+			if tok.Data == "a" {
+				for _, attr := range tok.Attr {
+					if href, ok := safeURL(strings.TrimSpace(attr.Val)); attr.Key == "href" && ok {
+						b.WriteString(` href="` + html.EscapeString(href) + `"`)
+					}
+				}
+			}
+			b.WriteString(">")
+			if tt == html.StartTagToken {
+				open = append(open, tok.Data)
+			} else {
+				b.WriteString("</" + tok.Data + ">")
+			}
+		case html.EndTagToken:
+			if tok.Data == "script" || tok.Data == "style" {
+				skip = max(skip-1, 0)
+				continue
+			}
+			// Close the most recent matching element, and anything left open inside it
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == tok.Data {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return template.HTML(b.String())
+}
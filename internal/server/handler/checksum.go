@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"path"
+
+	"slimserve/internal/logger"
+	"slimserve/internal/security"
+	"slimserve/internal/server/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checksumSHA256 is the only algorithm ?checksums= accepts
+const checksumSHA256 = "sha256"
+
+// fillChecksums adds the SHA-256 of each listed file to a JSON listing
+// requested with ?checksums=sha256. Folders, symlinks, files larger than
+// MaxChecksumSizeMB and files that cannot be read are left without one, and
+// HTML listings are never hashed. It reports false after answering 400 for
+// an unsupported algorithm.
+func (h *Handler) fillChecksums(c *gin.Context, root security.FileSystem, relPath string, files []FileItem) bool {
+	algorithm := c.Query("checksums")
+	if algorithm == "" || !wantsJSON(c) {
+		return true
+	}
+	if algorithm != checksumSHA256 {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "unsupported checksum algorithm").
+			With("supported", []string{checksumSHA256}))
+		return false
+	}
+	if root == nil {
+		return true
+	}
+
+	ctx := c.Request.Context()
+	maxSize := int64(h.config.MaxChecksumSizeMB) * 1024 * 1024
+	for i := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		if files[i].IsFolder || files[i].IsSymlink {
+			continue
+		}
+		name := path.Join(relPath, files[i].Name)
+		sum, err := fileSHA256(root, name, maxSize)
+		if err != nil {
+			logger.Ctx(c.Request.Context()).Debug().Err(err).Str("path", name).Msg("Failed to hash file for listing")
+			continue
+		}
+		files[i].SHA256 = sum
+	}
+	return true
+}
+
+// fileSHA256 streams name through SHA-256 and returns the hex digest, or ""
+// when it is not a regular file or is larger than maxSize (0 is no limit)
+func fileSHA256(root security.FileSystem, name string, maxSize int64) (string, error) {
+	f, err := root.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() || (maxSize > 0 && info.Size() > maxSize) {
+		return "", nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"slimserve/internal/ignore"
+	"slimserve/internal/security"
+)
+
+const (
+	// dirSizeMaxEntries bounds a single directory walk so a huge tree cannot
+	// stall a listing; capped totals are shown with a "+"
+	dirSizeMaxEntries = 10000
+
+	// dirSizeTTL is how long a computed total is reused
+	dirSizeTTL = 30 * time.Second
+
+	// dirSizeCacheEntries bounds the cache; it is cleared when full
+	dirSizeCacheEntries = 1024
+)
+
+type dirSize struct {
+	bytes     int64
+	truncated bool
+	expires   time.Time
+}
+
+// dirSizeCache memoizes recursive directory totals for ShowDirSizes
+type dirSizeCache struct {
+	mu      sync.Mutex
+	entries map[string]dirSize
+	now     func() time.Time
+}
+
+func newDirSizeCache() *dirSizeCache {
+	return &dirSizeCache{
+		entries: make(map[string]dirSize),
+		now:     time.Now,
+	}
+}
+
+// Size returns the recursive size of relPath inside root and whether the walk
+// stopped maxDepth levels below relPath (0 is unlimited) or at the entry cap.
+// Symlinks and entries for which skip returns true are not counted.
+func (d *dirSizeCache) Size(root security.FileSystem, relPath string, maxDepth int, skip func(string, fs.DirEntry) bool) (int64, bool) {
+	key := root.Path() + "\x00" + relPath
+	now := d.now()
+
+	d.mu.Lock()
+	if cached, ok := d.entries[key]; ok && now.Before(cached.expires) {
+		d.mu.Unlock()
+		return cached.bytes, cached.truncated
+	}
+	d.mu.Unlock()
+
+	entries := 0
+	bytes, truncated := walkDirSize(root, relPath, 0, maxDepth, skip, &entries)
+
+	d.mu.Lock()
+	if len(d.entries) >= dirSizeCacheEntries {
+		clear(d.entries)
+	}
+	d.entries[key] = dirSize{bytes: bytes, truncated: truncated, expires: now.Add(dirSizeTTL)}
+	d.mu.Unlock()
+
+	return bytes, truncated
+}
+
+// Invalidate drops the totals relPath counts towards: its own, those of the
+// directories above it and those below it. A relPath of "." drops every
+// total of root.
+func (d *dirSizeCache) Invalidate(root security.FileSystem, relPath string) {
+	prefix := root.Path() + "\x00"
+	sep := string(filepath.Separator)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key := range d.entries {
+		cached, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		if relPath == "." || cached == relPath ||
+			strings.HasPrefix(relPath, cached+sep) || strings.HasPrefix(cached, relPath+sep) {
+			delete(d.entries, key)
+		}
+	}
+}
+
+func walkDirSize(root security.FileSystem, relPath string, depth, maxDepth int, skip func(string, fs.DirEntry) bool, entries *int) (int64, bool) {
+	children, err := root.ReadDir(relPath)
+	if err != nil {
+		return 0, false
+	}
+
+	var total int64
+	truncated := false
+	for _, child := range children {
+		*entries++
+		if *entries > dirSizeMaxEntries {
+			return total, true
+		}
+
+		childPath := filepath.Join(relPath, child.Name())
+		switch {
+		case child.Type()&fs.ModeSymlink != 0, skip(childPath, child):
+			continue
+		case child.IsDir():
+			if maxDepth > 0 && depth+1 >= maxDepth {
+				// Only a directory with something in it makes the total partial
+				if grandchildren, err := root.ReadDir(childPath); err == nil && len(grandchildren) > 0 {
+					truncated = true
+				}
+				continue
+			}
+			size, capped := walkDirSize(root, childPath, depth+1, maxDepth, skip, entries)
+			total += size
+			truncated = truncated || capped
+		default:
+			if info, err := child.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+	}
+	return total, truncated
+}
+
+// hiddenFromDirSize reports whether an entry is left out of folder sizes: dot
+// files when DisableDotFiles is set, ignored entries and files of types that
+// are not served, which listings hide or refuse
+func (h *Handler) hiddenFromDirSize(root security.FileSystem) func(string, fs.DirEntry) bool {
+	return func(relPath string, entry fs.DirEntry) bool {
+		name := entry.Name()
+		if h.config.DisableDotFiles && strings.HasPrefix(name, ".") {
+			return true
+		}
+		if !entry.IsDir() && !h.isServable(name) {
+			return true
+		}
+		ignored, err := ignore.IsIgnored(relPath, root, h.config)
+		return err != nil || ignored
+	}
+}
+
+// fillDirSizes sets Size on folder items to their recursive total
+func (h *Handler) fillDirSizes(root security.FileSystem, requestPath string, files []FileItem) {
+	if root == nil {
+		return
+	}
+	dir := strings.TrimPrefix(requestPath, "/")
+	skip := h.hiddenFromDirSize(root)
+	for i := range files {
+		if !files[i].IsFolder || files[i].IsSymlink {
+			continue
+		}
+		size, truncated := h.dirSizes.Size(root, filepath.Join(dir, files[i].Name), h.config.MaxTraversalDepth, skip)
+		files[i].Size = formatSize(size)
+		if truncated {
+			files[i].Size += "+"
+		}
+	}
+}
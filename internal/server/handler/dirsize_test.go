@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+	"slimserve/internal/security"
+	"slimserve/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSizedFile(t *testing.T, path string, size int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+}
+
+// countAll is a dirSizeCache skip function that counts every entry
+func countAll(string, fs.DirEntry) bool { return false }
+
+func TestDirSizeCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "a.bin"), 1000)
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "nested", "b.bin"), 536)
+
+	root, err := security.NewRootFS(tmpDir)
+	require.NoError(t, err)
+	defer root.Close()
+
+	cache := newDirSizeCache()
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return clock }
+
+	size, truncated := cache.Size(root, "docs", 0, countAll)
+	require.Equal(t, int64(1536), size)
+	require.False(t, truncated)
+
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "c.bin"), 64)
+
+	size, _ = cache.Size(root, "docs", 0, countAll)
+	require.Equal(t, int64(1536), size, "cached total should be reused within the TTL")
+
+	clock = clock.Add(dirSizeTTL)
+	size, _ = cache.Size(root, "docs", 0, countAll)
+	require.Equal(t, int64(1600), size, "total should be recomputed after the TTL")
+}
+
+func TestDirSizeCacheInvalidate(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "nested", "b.bin"), 500)
+	writeSizedFile(t, filepath.Join(tmpDir, "other", "c.bin"), 10)
+
+	root, err := security.NewRootFS(tmpDir)
+	require.NoError(t, err)
+	defer root.Close()
+
+	cache := newDirSizeCache()
+	for _, dir := range []string{"docs", "docs/nested", "other"} {
+		cache.Size(root, dir, 0, countAll)
+	}
+
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "nested", "d.bin"), 36)
+	writeSizedFile(t, filepath.Join(tmpDir, "other", "e.bin"), 90)
+	cache.Invalidate(root, filepath.Join("docs", "nested", "d.bin"))
+
+	size, _ := cache.Size(root, "docs", 0, countAll)
+	require.Equal(t, int64(536), size, "parent totals are dropped")
+	size, _ = cache.Size(root, filepath.Join("docs", "nested"), 0, countAll)
+	require.Equal(t, int64(536), size, "the containing directory's total is dropped")
+	size, _ = cache.Size(root, "other", 0, countAll)
+	require.Equal(t, int64(10), size, "unrelated totals are kept")
+
+	cache.Invalidate(root, ".")
+	size, _ = cache.Size(root, "other", 0, countAll)
+	require.Equal(t, int64(100), size, "the root drops every total")
+}
+
+func TestDirSizeMaxDepth(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "a.bin"), 1000)
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "nested", "b.bin"), 500)
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "nested", "deeper", "c.bin"), 36)
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "docs", "empty"), 0755))
+
+	root, err := security.NewRootFS(tmpDir)
+	require.NoError(t, err)
+	defer root.Close()
+
+	tests := []struct {
+		maxDepth  int
+		size      int64
+		truncated bool
+	}{
+		{1, 1000, true},
+		{2, 1500, true},
+		{3, 1536, false},
+		{0, 1536, false},
+	}
+	for _, tt := range tests {
+		size, truncated := newDirSizeCache().Size(root, "docs", tt.maxDepth, countAll)
+		require.Equal(t, tt.size, size, "maxDepth %d", tt.maxDepth)
+		require.Equal(t, tt.truncated, truncated, "maxDepth %d", tt.maxDepth)
+	}
+}
+
+func TestDirSizeSkipsHiddenEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "a.bin"), 1000)
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "nested", "b.bin"), 536)
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", ".env.bin"), 300)
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", ".cache", "big.bin"), 4000)
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "debug.log"), 200)
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "notes.txt"), 50)
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "nested", "skip.bin"), 700)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "docs", "nested", ".slimserveignore"), []byte("skip.bin\n"), 0644))
+
+	root, err := security.NewRootFS(tmpDir)
+	require.NoError(t, err)
+	defer root.Close()
+
+	h := NewHandler(&config.Config{
+		StoragePath:       tmpDir,
+		DisableDotFiles:   true,
+		IgnorePatterns:    []string{"*.log"},
+		AllowedServeTypes: []string{"bin"},
+	}, nil, root)
+
+	size, truncated := newDirSizeCache().Size(root, "docs", 0, h.hiddenFromDirSize(root))
+	require.Equal(t, int64(1536), size, "dot files, ignored and disallowed files are not counted")
+	require.False(t, truncated)
+}
+
+func TestShowDirSizes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "a.bin"), 1000)
+	writeSizedFile(t, filepath.Join(tmpDir, "docs", "nested", "b.bin"), 536)
+
+	root, err := security.NewRootFS(tmpDir)
+	require.NoError(t, err)
+	defer root.Close()
+
+	// docs holds 1000 + 536 bytes, which formats as "1.5 KB"
+	listing := func(t *testing.T, show bool) string {
+		t.Helper()
+		h := NewHandler(&config.Config{StoragePath: tmpDir, ShowDirSizes: show}, storage.NewLocalBackend(root, nil), root)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		h.ServeFiles(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		return w.Body.String()
+	}
+
+	t.Run("on", func(t *testing.T) {
+		require.Contains(t, listing(t, true), "1.5 KB")
+	})
+
+	t.Run("off", func(t *testing.T) {
+		require.NotContains(t, listing(t, false), "1.5 KB")
+
+		entries, err := root.ReadDir(".")
+		require.NoError(t, err)
+		h := NewHandler(&config.Config{StoragePath: tmpDir}, nil, root)
+		data := buildListingData(t.Context(), entries, "/",
+			func(_ context.Context, _ string) (bool, error) { return false, nil },
+			h.symlinkResolver(root),
+			func(os.DirEntry) string { return "folder" },
+			func(os.DirEntry) string { return "folder" },
+			nameOrder,
+		)
+		require.Len(t, data.Files, 1)
+		require.True(t, data.Files[0].IsFolder)
+		require.Empty(t, data.Files[0].Size)
+	})
+}
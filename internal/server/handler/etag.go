@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// fileETag returns a strong validator for a served file built from the same
+// FileInfo whose modification time is handed to http.ServeContent, so
+// If-None-Match and If-Range agree with Last-Modified. The nanosecond mtime
+// and size change whenever the file is rewritten.
+func fileETag(info fs.FileInfo) string {
+	return `"` + strconv.FormatInt(info.ModTime().UnixNano(), 36) + "-" + strconv.FormatInt(info.Size(), 36) + `"`
+}
+
+// listingETag returns a weak validator for a rendered listing. It hashes
+// everything the page shows: entry names, sizes and modification times as
+// displayed, plus the links, theme and version, which carry the configuration
+// that affects rendering. The CSP nonce is excluded since it changes on every
+// request. format separates the HTML and JSON representations of a listing.
+func listingETag(data ListingData, format string) string {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(append(b, format...))
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ETagMatches reports whether an If-None-Match header matches etag using the
+// weak comparison RFC 9110 prescribes for that header.
+func ETagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"slimserve/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Feed formats requested with ?format= when EnableFeeds is set
+const (
+	feedRSS  = "rss"
+	feedAtom = "atom"
+)
+
+// feedFormat returns the feed format the request asks for, or "" when it
+// wants none or feeds are disabled
+func (h *Handler) feedFormat(c *gin.Context) string {
+	if !h.config.EnableFeeds {
+		return ""
+	}
+	switch format := c.Query("format"); format {
+	case feedRSS, feedAtom:
+		return format
+	}
+	return ""
+}
+
+// feedURL returns the link to a listing's RSS feed, relative to the listing,
+// or "" when feeds are disabled
+func (h *Handler) feedURL() string {
+	if !h.config.EnableFeeds {
+		return ""
+	}
+	return "?format=" + feedRSS
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	GUID        rssGUID      `xml:"guid"`
+	PubDate     string       `xml:"pubDate"`
+	Description string       `xml:"description"`
+	Enclosure   rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string     `xml:"title"`
+	ID      string     `xml:"id"`
+	Updated string     `xml:"updated"`
+	Links   []atomLink `xml:"link"`
+	Summary string     `xml:"summary"`
+}
+
+// feedItem is a listed file as it appears in a feed
+type feedItem struct {
+	title   string
+	link    string
+	id      string // changes when the file is rewritten, so readers show it again
+	modTime time.Time
+	size    int64
+	sizeStr string
+	mime    string
+}
+
+// serveFeed writes the files of a listing, newest first, as an RSS or Atom
+// feed. Folders are left out, and so are dot files when DisableDotFiles is
+// set, as they cannot be downloaded; ignored entries never reach the
+// listing. Links are absolute, as feed readers require.
+func (h *Handler) serveFeed(c *gin.Context, data ListingData, format string) {
+	origin := h.requestOrigin(c)
+	pageURL := origin + (&url.URL{Path: h.config.URLPrefix() + data.FullPath}).EscapedPath()
+	title := data.Title + " — SlimServe"
+
+	var items []feedItem
+	for _, f := range data.Files {
+		if f.IsFolder || (h.config.DisableDotFiles && h.containsDotFile(f.FullName)) {
+			continue
+		}
+		link := origin + (&url.URL{Path: f.URL}).EscapedPath()
+		items = append(items, feedItem{
+			title:   f.FullName,
+			link:    link,
+			id:      fmt.Sprintf("%s#%d", link, f.ModUnix),
+			modTime: f.modTime,
+			size:    f.size,
+			sizeStr: f.Size,
+			mime:    h.feedMimeType(f.FullName),
+		})
+	}
+	slices.SortStableFunc(items, func(a, b feedItem) int { return b.modTime.Compare(a.modTime) })
+
+	updated := time.Now()
+	if len(items) > 0 && !items[0].modTime.IsZero() {
+		updated = items[0].modTime
+	}
+
+	var feed any
+	contentType := "application/rss+xml; charset=utf-8"
+	if format == feedAtom {
+		contentType = "application/atom+xml; charset=utf-8"
+		atom := atomFeed{
+			Title:   title,
+			ID:      pageURL,
+			Updated: updated.UTC().Format(time.RFC3339),
+			Author:  atomAuthor{Name: "SlimServe"},
+			Links: []atomLink{
+				{Href: pageURL, Rel: "alternate", Type: "text/html"},
+				{Href: pageURL + "?format=" + feedAtom, Rel: "self", Type: "application/atom+xml"},
+			},
+		}
+		for _, item := range items {
+			atom.Entries = append(atom.Entries, atomEntry{
+				Title:   item.title,
+				ID:      item.id,
+				Updated: item.modTime.UTC().Format(time.RFC3339),
+				Links: []atomLink{
+					{Href: item.link, Rel: "alternate"},
+					{Href: item.link, Rel: "enclosure", Type: item.mime, Length: item.size},
+				},
+				Summary: "Size: " + item.sizeStr,
+			})
+		}
+		feed = atom
+	} else {
+		channel := rssChannel{
+			Title:         title,
+			Link:          pageURL,
+			Description:   "Files in " + data.FullPath,
+			LastBuildDate: updated.UTC().Format(time.RFC1123Z),
+		}
+		for _, item := range items {
+			channel.Items = append(channel.Items, rssItem{
+				Title:       item.title,
+				Link:        item.link,
+				GUID:        rssGUID{Value: item.id},
+				PubDate:     item.modTime.UTC().Format(time.RFC1123Z),
+				Description: "Size: " + item.sizeStr,
+				Enclosure:   rssEnclosure{URL: item.link, Length: item.size, Type: item.mime},
+			})
+		}
+		feed = rssFeed{Version: "2.0", Channel: channel}
+	}
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("format", format).Msg("Error encoding feed")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), body...))
+}
+
+// requestOrigin returns the scheme and host the client reached the server
+// at. X-Forwarded-Proto and X-Forwarded-Host are only believed from trusted
+// proxies.
+func (h *Handler) requestOrigin(c *gin.Context) string {
+	scheme, host := "http", c.Request.Host
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if h.fromTrustedProxy(c) {
+		if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if fwd := c.GetHeader("X-Forwarded-Host"); fwd != "" {
+			host = fwd
+		}
+	}
+	return scheme + "://" + host
+}
+
+// feedMimeType returns the type a file is served with, for enclosures
+func (h *Handler) feedMimeType(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if mimeType, ok := h.mimeOverrides[ext]; ok {
+		return mimeType
+	}
+	return cmp.Or(mime.TypeByExtension(ext), "application/octet-stream")
+}
@@ -1,22 +1,33 @@
 package handler
 
 import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
+	"iter"
 	"mime"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
-	"sort"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"slimserve/internal/config"
 	"slimserve/internal/files"
+	"slimserve/internal/ignore"
 	"slimserve/internal/logger"
 	"slimserve/internal/security"
-	"slimserve/internal/server/filter"
+	"slimserve/internal/server/apierror"
 	"slimserve/internal/storage"
 	"slimserve/internal/version"
 	"slimserve/web"
@@ -25,27 +36,59 @@ import (
 )
 
 type Handler struct {
-	config    *config.Config
-	tmpl      *template.Template
-	backend   storage.Backend
-	localRoot *security.RootFS
+	config        *config.Config
+	tmpl          *template.Template
+	renderTmpl    *template.Template
+	backend       storage.Backend
+	localRoot     security.FileSystem
+	mimeOverrides map[string]string
+	iconOverrides map[string]string
+	serveTypes    []string
+	indexFiles    []string
+	landing       *template.Template
+	dirSizes      *dirSizeCache
+	recent        *recentCache
+
+	// urlPrefix is prepended to listing links. It is the configured base
+	// path, followed by the mount segment for a mount's handler.
+	urlPrefix string
+	mountName string
+	mounts    map[string]*Handler
+
+	// thumbTimeout bounds thumbnail generation; 0 waits indefinitely
+	thumbTimeout time.Duration
+
+	// trustedProxies are the peers allowed to receive X-Accel-Redirect
+	trustedProxies []netip.Prefix
+
+	// dateLayout and dateLocation format listing modification times
+	dateLayout   string
+	dateLocation *time.Location
 }
 
 type FileItem struct {
-	Name         string `json:"name"`
+	Name         string `json:"name"`      // shortened to MaxDisplayNameLength for display
+	FullName     string `json:"full_name"` // the entry's actual name
 	URL          string `json:"url"`
 	Size         string `json:"size"`
 	ModTime      string `json:"mod_time"`
+	ModUnix      int64  `json:"mod_unix"` // seconds since the epoch, for clients formatting it themselves
 	Type         string `json:"type"`
 	Icon         string `json:"icon"`
 	IsImage      bool   `json:"is_image"`
 	IsFolder     bool   `json:"is_folder"`
+	IsSymlink    bool   `json:"is_symlink,omitempty"`
 	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	SHA256       string `json:"sha256,omitempty"` // only with ?checksums=sha256
+
+	modTime time.Time
+	size    int64
 }
 
 type PathSegment struct {
-	Name string `json:"name"`
-	URL  string `json:"url"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	IsHome bool   `json:"is_home,omitempty"`
 }
 
 type ListingData struct {
@@ -53,19 +96,97 @@ type ListingData struct {
 	PathSegments []PathSegment `json:"path_segments"`
 	Files        []FileItem    `json:"files"`
 	CurrentPath  string        `json:"current_path"`
+	FullPath     string        `json:"full_path"`
 	Version      string        `json:"version,omitempty"`
-	VersionInfo  version.Info  `json:"version_info,omitempty"`
+	VersionInfo  *version.Info `json:"version_info,omitempty"`
+	Theme        string        `json:"theme"`
+	CSPNonce     string        `json:"-"`
+
+	// FeedURL links the listing's RSS feed when EnableFeeds is set
+	FeedURL string `json:"feed_url,omitempty"`
+
+	// Banner is the sanitized ListingBanner shown above the listing
+	Banner template.HTML `json:"banner,omitempty"`
+
+	// Truncated is set when MaxListingItems cut Files short; TotalCount is
+	// then the number of entries before the cut, or 0 for streamed listings
+	Truncated  bool `json:"truncated,omitempty"`
+	TotalCount int  `json:"total_count,omitempty"`
+
+	// Streamed is set for directories too large to sort in memory; Files is
+	// then empty and rows are produced by Items while the page renders
+	Streamed bool `json:"-"`
+	stream   iter.Seq[FileItem]
+}
+
+// Items returns the listing rows for the template, reading them from the
+// directory on the fly when the listing is streamed.
+func (d ListingData) Items() iter.Seq[FileItem] {
+	if d.stream != nil {
+		return d.stream
+	}
+	return slices.Values(d.Files)
+}
+
+// ParseTemplates parses the named web templates together with the functions
+// they share. {{base}} expands to the configured URL prefix so absolute links
+// keep working when SlimServe is hosted under a subpath.
+func ParseTemplates(cfg *config.Config, patterns ...string) *template.Template {
+	return template.Must(template.New("").Funcs(web.Funcs(cfg.URLPrefix)).ParseFS(web.TemplateFS, patterns...))
 }
 
-func NewHandler(cfg *config.Config, backend storage.Backend, localRoot *security.RootFS) *Handler {
-	tmpl := template.Must(template.ParseFS(web.TemplateFS, "templates/base.html", "templates/listing.html"))
+// parseListingTemplate returns the listing templates, with the configured
+// ListingTemplatePath standing in for the built-in listing.html. The built-in
+// base.html stays available to it. A template that no longer parses falls
+// back to the built-in one; Validate rejects it at startup.
+func parseListingTemplate(cfg *config.Config) *template.Template {
+	if cfg.ListingTemplatePath == "" {
+		return ParseTemplates(cfg, "templates/base.html", "templates/listing.html")
+	}
+
+	tmpl := ParseTemplates(cfg, "templates/base.html")
+	content, err := os.ReadFile(cfg.ListingTemplatePath)
+	if err == nil {
+		_, err = tmpl.New("listing.html").Parse(string(content))
+	}
+	if err != nil {
+		logger.Log.Error().Err(err).Str("path", cfg.ListingTemplatePath).Msg("Failed to load listing template, using the built-in one")
+		return ParseTemplates(cfg, "templates/base.html", "templates/listing.html")
+	}
+	return tmpl
+}
 
-	return &Handler{
-		config:    cfg,
-		tmpl:      tmpl,
-		backend:   backend,
-		localRoot: localRoot,
+func NewHandler(cfg *config.Config, backend storage.Backend, localRoot security.FileSystem) *Handler {
+	h := &Handler{
+		config:        cfg,
+		tmpl:          parseListingTemplate(cfg),
+		renderTmpl:    ParseTemplates(cfg, "templates/base.html", "templates/render.html"),
+		backend:       backend,
+		localRoot:     localRoot,
+		mimeOverrides: normalizeExtensionMap(cfg.MimeOverrides),
+		iconOverrides: normalizeExtensionMap(cfg.IconOverrides),
+		serveTypes:    normalizeServeTypes(cfg.AllowedServeTypes),
+		indexFiles:    cfg.IndexFiles,
+		landing:       loadLandingPage(cfg),
+		dirSizes:      newDirSizeCache(),
+		recent:        &recentCache{},
+		urlPrefix:     cfg.URLPrefix(),
+		thumbTimeout:  time.Duration(cfg.ThumbGenTimeoutSeconds) * time.Second,
+
+		trustedProxies: parseTrustedProxies(cfg.TrustedProxies),
+
+		dateLayout: cfg.DateLayout(),
+	}
+	if len(h.indexFiles) == 0 {
+		h.indexFiles = defaultIndexFiles
+	}
+	loc, err := cfg.DisplayLocation()
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("timezone", cfg.DisplayTimezone).Msg("Unknown display timezone, using local time")
+		loc = time.Local
 	}
+	h.dateLocation = loc
+	return h
 }
 
 func (h *Handler) ServeFiles(c *gin.Context) {
@@ -74,6 +195,22 @@ func (h *Handler) ServeFiles(c *gin.Context) {
 		requestPath = "/"
 	}
 
+	// JSON clients still get the root listing
+	if requestPath == "/" && h.landing != nil && !wantsJSON(c) {
+		h.serveLanding(c)
+		return
+	}
+
+	if requestPath == "/favicon.ico" {
+		h.serveFavicon(c)
+		return
+	}
+
+	if h.mounts != nil && !strings.HasPrefix(requestPath, "/static/") {
+		h.serveMounts(c, requestPath)
+		return
+	}
+
 	if requestPath == "/" && h.backend != nil {
 		h.serveDirectoryFromBackend(c, h.backend, ".", "/")
 		return
@@ -104,7 +241,7 @@ func (h *Handler) ServeFiles(c *gin.Context) {
 		return
 	}
 
-	c.AbortWithStatus(http.StatusNotFound)
+	abortError(c, apierror.New(http.StatusNotFound, apierror.CodeNotFound, "file not found"))
 }
 
 func (h *Handler) containsDotFile(path string) bool {
@@ -124,7 +261,7 @@ func (h *Handler) tryServeFromBackend(c *gin.Context, relPath, cleanPath string)
 	ctx := c.Request.Context()
 
 	if ignored, err := h.backend.IsIgnored(ctx, relPath); err != nil {
-		logger.Log.Error().Err(err).Str("path", relPath).Msg("Error checking if path is ignored")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", relPath).Msg("Error checking if path is ignored")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return true
 	} else if ignored {
@@ -132,6 +269,11 @@ func (h *Handler) tryServeFromBackend(c *gin.Context, relPath, cleanPath string)
 		return true
 	}
 
+	if h.symlinkBlocked(h.localRoot, relPath) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return true
+	}
+
 	info, err := h.backend.Stat(ctx, relPath)
 	if err != nil {
 		return false
@@ -139,6 +281,9 @@ func (h *Handler) tryServeFromBackend(c *gin.Context, relPath, cleanPath string)
 
 	if info.IsDir() {
 		h.serveDirectoryFromBackend(c, h.backend, relPath, cleanPath)
+	} else if !h.isServable(relPath) {
+		// Disallowed types are hidden rather than refused
+		return false
 	} else {
 		h.serveFileFromBackend(c, h.backend, relPath)
 	}
@@ -156,129 +301,331 @@ func buildListingData[E entryInterface](
 	entries []E,
 	requestPath string,
 	isIgnoredFunc func(context.Context, string) (bool, error),
+	resolveSymlink func(string, fs.FileInfo) (fs.FileInfo, bool),
 	typeFunc func(E) string,
 	iconFunc func(E) string,
+	order listingOrder,
 ) ListingData {
 	estimatedFiles := len(entries)
 	files := make([]FileItem, 0, estimatedFiles)
 
 	for _, entry := range entries {
-		entryRelPath := filepath.Join(strings.TrimPrefix(requestPath, "/"), entry.Name())
-		ignored, err := isIgnoredFunc(ctx, entryRelPath)
-		if err != nil {
-			logger.Log.Debug().Err(err).Str("path", entryRelPath).Msg("Error checking ignore patterns")
-		}
-		if ignored {
-			continue
+		if fileItem, ok := buildFileItem(ctx, entry, requestPath, isIgnoredFunc, resolveSymlink, typeFunc, iconFunc); ok {
+			files = append(files, fileItem)
 		}
+	}
 
-		info, err := entry.Info()
-		if err != nil {
-			logger.Log.Debug().Err(err).Str("path", entryRelPath).Msg("Failed to get file info")
-			continue
-		}
+	sortFiles(files, order)
 
-		fileName := entry.Name()
-		isDir := entry.IsDir()
-		isImage := !isDir && isImageFile(fileName)
+	data := newListingData(requestPath)
+	data.Files = files
+	return data
+}
 
-		fileItem := FileItem{
-			Name:     fileName,
-			URL:      buildFileURL(requestPath, fileName),
-			Size:     formatSize(info.Size()),
-			ModTime:  info.ModTime().Format("Jan 2, 2006 15:04"),
-			Type:     typeFunc(entry),
-			Icon:     iconFunc(entry),
-			IsImage:  isImage,
-			IsFolder: isDir,
-		}
+// listingOrder is how the entries of a listing are sorted
+type listingOrder struct {
+	key          string // config.SortName, SortSize or SortModified
+	desc         bool
+	groupFolders bool // folders come before files whatever the key
+}
 
-		if isImage {
-			fileItem.ThumbnailURL = buildThumbnailURL(requestPath, fileName)
-		}
+// nameOrder lists folders first, then everything by name
+var nameOrder = listingOrder{key: config.SortName, groupFolders: true}
 
-		files = append(files, fileItem)
+// listingOrder returns the order asked for with ?sort and ?order. Missing or
+// unknown values fall back to DefaultSort and DefaultOrder.
+func (h *Handler) listingOrder(c *gin.Context) listingOrder {
+	key := c.Query("sort")
+	if !slices.Contains(config.ListingSortKeys, key) {
+		key = cmp.Or(h.config.DefaultSort, config.SortName)
+	}
+	order := c.Query("order")
+	if order != config.OrderAsc && order != config.OrderDesc {
+		order = h.config.DefaultOrder
 	}
+	return listingOrder{key: key, desc: order == config.OrderDesc, groupFolders: h.config.GroupFolders}
+}
 
-	sort.Slice(files, func(i, j int) bool {
-		if files[i].IsFolder != files[j].IsFolder {
-			return files[i].IsFolder
+// sortFiles orders files by order's key, breaking ties by name. With
+// groupFolders set, folders precede files in either direction.
+func sortFiles(files []FileItem, order listingOrder) {
+	slices.SortFunc(files, func(a, b FileItem) int {
+		if order.groupFolders && a.IsFolder != b.IsFolder {
+			if a.IsFolder {
+				return -1
+			}
+			return 1
+		}
+		var n int
+		switch order.key {
+		case config.SortSize:
+			n = cmp.Compare(a.size, b.size)
+		case config.SortModified:
+			n = a.modTime.Compare(b.modTime)
 		}
-		return files[i].Name < files[j].Name
+		if n == 0 {
+			n = strings.Compare(a.Name, b.Name)
+		}
+		if order.desc {
+			return -n
+		}
+		return n
 	})
+}
+
+// limitFiles keeps the first limit sorted entries, recording the full count
+// when any are dropped. A limit of 0 keeps everything.
+func (d *ListingData) limitFiles(limit int) {
+	if limit <= 0 || len(d.Files) <= limit {
+		return
+	}
+	d.Truncated = true
+	d.TotalCount = len(d.Files)
+	d.Files = d.Files[:limit]
+}
 
+func newListingData(requestPath string) ListingData {
+	info := version.Get()
 	return ListingData{
 		Title:        filepath.Base(requestPath),
 		PathSegments: buildPathSegments(requestPath),
-		Files:        files,
 		CurrentPath:  requestPath,
+		FullPath:     path.Clean("/" + requestPath),
 		Version:      version.GetShort(),
-		VersionInfo:  version.Get(),
+		VersionInfo:  &info,
 	}
 }
 
-func determineFileType(entry os.DirEntry) string {
-	if entry.IsDir() {
-		return "folder"
+// hideVersion blanks the build version when HideVersion is set
+func (h *Handler) hideVersion(data *ListingData) {
+	if h.config.HideVersion {
+		data.Version, data.VersionInfo = "", nil
 	}
-	ext := strings.ToLower(filepath.Ext(entry.Name()))
-	if info, exists := fileExtMap[ext]; exists {
-		return info.Type
+}
+
+// shownVersion returns the short build version for pages, or "" when
+// HideVersion is set
+func (h *Handler) shownVersion() string {
+	if h.config.HideVersion {
+		return ""
 	}
-	mimeType := mime.TypeByExtension(ext)
-	if mimeType != "" {
-		fileType, _ := getFileTypeFromMime(mimeType)
-		return fileType
+	return version.GetShort()
+}
+
+// prefixURLs prepends the configured base path to every generated link
+func (d *ListingData) prefixURLs(prefix string) {
+	if prefix == "" {
+		return
+	}
+	for i := range d.PathSegments {
+		d.PathSegments[i].URL = prefix + d.PathSegments[i].URL
+	}
+	for i := range d.Files {
+		d.Files[i].prefixURLs(prefix)
 	}
-	return "file"
 }
 
-func getFileIcon(entry os.DirEntry) string {
-	if entry.IsDir() {
-		return "folder"
+// displayItem prepares a built item for output: its modification time is
+// formatted as configured and its name shortened for display. It runs last,
+// as everything before it works with the actual name. Running it twice is
+// harmless.
+func (h *Handler) displayItem(f *FileItem) {
+	f.formatModTime(h.dateLayout, h.dateLocation)
+	if f.FullName == "" {
+		f.FullName = f.Name
 	}
-	ext := strings.ToLower(filepath.Ext(entry.Name()))
-	if info, exists := fileExtMap[ext]; exists {
-		return info.Icon
+	f.Name = truncateName(f.FullName, h.config.MaxDisplayNameLength)
+}
+
+// truncateName shortens name to at most max runes, ending in an ellipsis. A
+// short extension is kept so the file type stays visible. A max of 0 keeps
+// the name whole.
+func truncateName(name string, max int) string {
+	runes := []rune(name)
+	if max <= 0 || len(runes) <= max {
+		return name
 	}
-	mimeType := mime.TypeByExtension(ext)
-	if mimeType != "" {
-		_, icon := getFileTypeFromMime(mimeType)
-		return icon
+	ext := []rune(filepath.Ext(name))
+	if len(ext) > maxKeptExtension || len(ext)+2 > max {
+		ext = nil
 	}
-	return "file"
+	return string(runes[:max-1-len(ext)]) + "…" + string(ext)
 }
 
-func determineFileTypeFromEntry(entry *storage.DirEntry) string {
-	if entry.IsDir() {
-		return "folder"
+// maxKeptExtension is the longest extension, dot included, truncateName keeps
+const maxKeptExtension = 8
+
+// formatModTime renders the modification time with layout in loc. Items
+// without one, such as mounts whose root could not be read, are left blank.
+func (f *FileItem) formatModTime(layout string, loc *time.Location) {
+	if f.modTime.IsZero() {
+		return
 	}
-	ext := strings.ToLower(filepath.Ext(entry.Name()))
-	if info, exists := fileExtMap[ext]; exists {
-		return info.Type
+	f.ModTime = f.modTime.In(loc).Format(layout)
+}
+
+func (f *FileItem) prefixURLs(prefix string) {
+	if prefix == "" {
+		return
 	}
-	mimeType := mime.TypeByExtension(ext)
-	if mimeType != "" {
-		fileType, _ := getFileTypeFromMime(mimeType)
-		return fileType
+	f.URL = prefix + f.URL
+	if f.ThumbnailURL != "" {
+		f.ThumbnailURL = prefix + f.ThumbnailURL
+	}
+}
+
+// buildFileItem converts one directory entry into a listing row. It reports
+// false for entries that are ignored, unreadable or hidden by the symlink policy.
+func buildFileItem[E entryInterface](
+	ctx context.Context,
+	entry E,
+	requestPath string,
+	isIgnoredFunc func(context.Context, string) (bool, error),
+	resolveSymlink func(string, fs.FileInfo) (fs.FileInfo, bool),
+	typeFunc func(E) string,
+	iconFunc func(E) string,
+) (FileItem, bool) {
+	entryRelPath := filepath.Join(strings.TrimPrefix(requestPath, "/"), entry.Name())
+	ignored, err := isIgnoredFunc(ctx, entryRelPath)
+	if err != nil {
+		logger.Log.Debug().Err(err).Str("path", entryRelPath).Msg("Error checking ignore patterns")
+	}
+	if ignored {
+		return FileItem{}, false
+	}
+
+	info, err := entry.Info()
+	if err != nil {
+		logger.Log.Debug().Err(err).Str("path", entryRelPath).Msg("Failed to get file info")
+		return FileItem{}, false
+	}
+
+	if info.Mode()&fs.ModeSymlink != 0 {
+		resolved, ok := resolveSymlink(entryRelPath, info)
+		if !ok {
+			return FileItem{}, false
+		}
+		info = resolved
+	}
+
+	fileName := entry.Name()
+	isDir := info.IsDir()
+	isSymlink := info.Mode()&fs.ModeSymlink != 0
+	isImage := !isDir && !isSymlink && isImageFile(fileName)
+
+	fileType, icon := typeFunc(entry), iconFunc(entry)
+	switch {
+	case isSymlink:
+		fileType, icon = "symlink", "symlink"
+	case isDir:
+		fileType, icon = "folder", "folder"
+	}
+
+	size := ""
+	if !isDir {
+		size = formatSize(info.Size())
+	}
+
+	fileItem := FileItem{
+		Name:      fileName,
+		URL:       buildFileURL(requestPath, fileName),
+		Size:      size,
+		ModTime:   info.ModTime().Format(config.DefaultDateFormat),
+		ModUnix:   info.ModTime().Unix(),
+		Type:      fileType,
+		Icon:      icon,
+		IsImage:   isImage,
+		IsFolder:  isDir,
+		IsSymlink: isSymlink,
+		modTime:   info.ModTime(),
+	}
+	if !isDir {
+		fileItem.size = info.Size()
+	}
+
+	if isImage {
+		fileItem.ThumbnailURL = buildThumbnailURL(requestPath, fileName)
 	}
-	return "file"
+
+	return fileItem, true
+}
+
+// fileTypeInfo classifies a listing entry for its type filter and icon.
+// Configured MIME overrides take precedence over the built-in table, and
+// configured icon overrides replace the icon either of them picks.
+func (h *Handler) fileTypeInfo(name string, isDir bool) FileTypeInfo {
+	if isDir {
+		return FileTypeInfo{Type: "folder", Icon: "folder"}
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	info := h.builtinTypeInfo(ext)
+	if icon, ok := h.iconOverrides[ext]; ok {
+		info.Icon = icon
+	}
+	return info
 }
 
-func getFileIconFromEntry(entry *storage.DirEntry) string {
-	if entry.IsDir() {
-		return "folder"
+// builtinTypeInfo classifies ext from the MIME overrides and built-in tables
+func (h *Handler) builtinTypeInfo(ext string) FileTypeInfo {
+	if mimeType, ok := h.mimeOverrides[ext]; ok {
+		fileType, icon := getFileTypeFromMime(mimeType)
+		return FileTypeInfo{Type: fileType, Icon: icon}
 	}
-	ext := strings.ToLower(filepath.Ext(entry.Name()))
 	if info, exists := fileExtMap[ext]; exists {
-		return info.Icon
+		return info
 	}
-	mimeType := mime.TypeByExtension(ext)
-	if mimeType != "" {
-		_, icon := getFileTypeFromMime(mimeType)
-		return icon
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		fileType, icon := getFileTypeFromMime(mimeType)
+		return FileTypeInfo{Type: fileType, Icon: icon}
+	}
+	return FileTypeInfo{Type: "file", Icon: "file"}
+}
+
+// setOverriddenContentType sets the configured Content-Type for name's
+// extension, if any, before the file is served.
+func (h *Handler) setOverriddenContentType(c *gin.Context, name string) {
+	if mimeType, ok := h.mimeOverrides[strings.ToLower(filepath.Ext(name))]; ok {
+		c.Header("Content-Type", mimeType)
+	}
+}
+
+// sniffContentType sets Content-Type from the first 512 bytes of content when
+// neither an override nor the extension determines it, then rewinds content so
+// ServeContent can still honour Range requests.
+func sniffContentType(c *gin.Context, name string, content io.ReadSeeker) error {
+	if c.Writer.Header().Get("Content-Type") != "" || mime.TypeByExtension(filepath.Ext(name)) != "" {
+		return nil
+	}
+
+	var buf [512]byte
+	n, err := io.ReadFull(content, buf[:])
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	c.Header("Content-Type", http.DetectContentType(buf[:n]))
+	return nil
+}
+
+// normalizeExtensionMap lower-cases the extensions keying a MIME or icon
+// override map and adds the leading dot so lookups can use filepath.Ext
+// directly.
+func normalizeExtensionMap(overrides map[string]string) map[string]string {
+	normalized := make(map[string]string, len(overrides))
+	for ext, value := range overrides {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" || value == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized[ext] = value
 	}
-	return "file"
+	return normalized
 }
 
 func (h *Handler) serveDirectoryFromBackend(c *gin.Context, backend storage.Backend, relPath, requestPath string) {
@@ -287,26 +634,110 @@ func (h *Handler) serveDirectoryFromBackend(c *gin.Context, backend storage.Back
 		relPath = "."
 	}
 
+	if h.config.ServeIndexHTML && h.serveIndexFromBackend(c, backend, relPath) {
+		return
+	}
+	if h.listingDisabled(c) {
+		return
+	}
+	if h.serveStreamedDirectory(c, h.localRoot, relPath, requestPath, backend.IsIgnored) {
+		return
+	}
+
 	entries, err := backend.ReadDir(ctx, relPath)
 	if err != nil {
-		logger.Log.Error().Err(err).Str("path", relPath).Msg("Error reading directory")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", relPath).Msg("Error reading directory")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	isIgnoredFunc := func(ctx context.Context, entryRelPath string) (bool, error) {
-		fullRelPath := filepath.Join(strings.TrimPrefix(requestPath, "/"), entryRelPath)
-		if _, ok := backend.(*storage.LocalBackend); ok {
-			return filter.IsIgnored(fullRelPath, h.localRoot, h.config)
+	data := buildListingData(ctx, entries, requestPath,
+		backend.IsIgnored,
+		h.symlinkResolver(h.localRoot),
+		func(e *storage.DirEntry) string { return h.fileTypeInfo(e.Name(), e.IsDir()).Type },
+		func(e *storage.DirEntry) string { return h.fileTypeInfo(e.Name(), e.IsDir()).Icon },
+		h.listingOrder(c),
+	)
+	data.Files = h.filterServable(data.Files)
+	data.limitFiles(h.config.MaxListingItems)
+	if !h.fillChecksums(c, h.localRoot, relPath, data.Files) {
+		return
+	}
+	if h.config.ShowDirSizes {
+		h.fillDirSizes(h.localRoot, requestPath, data.Files)
+	}
+	h.prefixListing(&data)
+	h.renderListing(c, data)
+}
+
+// listingDisabled answers 403 and reports true when DisableListing is set
+func (h *Handler) listingDisabled(c *gin.Context) bool {
+	if !h.config.DisableListing {
+		return false
+	}
+	abortError(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "directory listing disabled"))
+	return true
+}
+
+// wantsJSON reports whether a listing should be answered with JSON, either
+// because ?format=json was given or the client prefers it over HTML
+func wantsJSON(c *gin.Context) bool {
+	return c.Query("format") == "json" || c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+}
+
+// abortError answers with err's status, sending the error body only to
+// clients that asked for JSON so browsers keep getting plain error pages
+func abortError(c *gin.Context, err *apierror.Error) {
+	if wantsJSON(c) {
+		apierror.Abort(c, err)
+		return
+	}
+	c.AbortWithStatus(err.Status)
+}
+
+// renderListing writes the directory listing page, or the listing as JSON
+// when the client asks for it, or 304 when the client's If-None-Match still
+// matches. The CSP header is only set on full HTML responses so a
+// revalidated page keeps the nonce it was rendered with.
+func (h *Handler) renderListing(c *gin.Context, data ListingData) {
+	for i := range data.Files {
+		h.displayItem(&data.Files[i])
+	}
+	h.hideVersion(&data)
+	data.Theme = ResolveTheme(c, h.config.Theme)
+	data.Banner = h.listingBanner()
+	jsonFormat := wantsJSON(c)
+	feed := h.feedFormat(c)
+	data.FeedURL = h.feedURL()
+
+	c.Writer.Header().Add("Vary", "Accept")
+	format := "html"
+	switch {
+	case feed != "":
+		format = feed
+	case jsonFormat:
+		format = "json"
+	}
+	etag := listingETag(data, format)
+	if etag != "" {
+		c.Header("ETag", etag)
+		if ETagMatches(c.GetHeader("If-None-Match"), etag) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return
 		}
-		return backend.IsIgnored(ctx, fullRelPath)
 	}
 
-	data := buildListingData(ctx, entries, requestPath,
-		isIgnoredFunc,
-		func(e *storage.DirEntry) string { return determineFileTypeFromEntry(e) },
-		func(e *storage.DirEntry) string { return getFileIconFromEntry(e) },
-	)
+	if feed != "" {
+		h.serveFeed(c, data, feed)
+		return
+	}
+
+	if jsonFormat {
+		c.JSON(http.StatusOK, data)
+		return
+	}
+
+	data.CSPNonce = ApplyCSP(c, h.config.ContentSecurityPolicy)
 
 	c.Header("Content-Type", "text/html")
 	c.Status(http.StatusOK)
@@ -314,7 +745,7 @@ func (h *Handler) serveDirectoryFromBackend(c *gin.Context, backend storage.Back
 		return
 	}
 	if err := h.tmpl.ExecuteTemplate(c.Writer, "listing.html", data); err != nil {
-		logger.Log.Error().Err(err).Str("template", "listing.html").Msg("Error executing template")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("template", "listing.html").Msg("Error executing template")
 		c.AbortWithStatus(http.StatusInternalServerError)
 	}
 }
@@ -332,37 +763,256 @@ func (h *Handler) serveFileFromBackend(c *gin.Context, backend storage.Backend,
 		return false
 	}
 
-	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), file)
+	if h.wantsRender(c, relPath, info) {
+		h.serveRendered(c, relPath, file, info)
+		return true
+	}
+
+	h.setOverriddenContentType(c, relPath)
+	if err := sniffContentType(c, relPath, file); err != nil {
+		return false
+	}
+	h.setDownloadHeaders(c, filepath.Base(relPath))
+	if h.accelRedirect(c, relPath) {
+		return true
+	}
+	content, served, release := h.withPrecompressed(c, relPath, file, info, func(name string) (io.ReadSeekCloser, fs.FileInfo, error) {
+		if h.symlinkBlocked(h.localRoot, name) {
+			return nil, nil, fs.ErrPermission
+		}
+		sidecar, err := backend.Open(ctx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		sidecarInfo, err := backend.Stat(ctx, name)
+		if err != nil {
+			sidecar.Close() //nolint:errcheck
+			return nil, nil, err
+		}
+		return sidecar, sidecarInfo, nil
+	})
+	defer release()
+	c.Header("ETag", fileETag(served))
+	http.ServeContent(c.Writer, c.Request, info.Name(), served.ModTime(), content)
 	return true
 }
 
-func (h *Handler) serveDirectoryFromRoot(c *gin.Context, root *security.RootFS, relPath, requestPath string) {
+// setDownloadHeaders forces an attachment disposition with a content type the
+// browser will not render when ForceDownload is on or ?download=1 is given.
+// Thumbnail requests are left alone.
+func (h *Handler) setDownloadHeaders(c *gin.Context, name string) {
+	if c.Query("thumb") == "1" {
+		return
+	}
+	if !h.config.ForceDownload && c.Query("download") != "1" {
+		return
+	}
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", attachmentDisposition(name))
+}
+
+// attachmentDisposition returns an attachment Content-Disposition for name.
+// Names outside printable ASCII get an RFC 5987 filename* parameter holding
+// the UTF-8 name and, for clients that ignore it, a filename with every
+// other character replaced by an underscore.
+func attachmentDisposition(name string) string {
+	if !strings.ContainsFunc(name, func(r rune) bool { return r < 0x20 || r > 0x7e }) {
+		return mime.FormatMediaType("attachment", map[string]string{"filename": name})
+	}
+
+	var fallback, encoded strings.Builder
+	for _, r := range name {
+		if r >= 0x20 && r <= 0x7e && r != '"' && r != '\\' {
+			fallback.WriteRune(r)
+		} else {
+			fallback.WriteByte('_')
+		}
+	}
+	for _, b := range []byte(name) {
+		if isAttrChar(b) {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, fallback.String(), encoded.String())
+}
+
+// isAttrChar reports whether b may appear unescaped in an RFC 5987 value
+func isAttrChar(b byte) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+}
+
+// defaultIndexFiles are served in place of the listing when ServeIndexHTML is
+// enabled and IndexFiles is empty
+var defaultIndexFiles = []string{"index.html"}
+
+// serveIndexFromBackend serves the first of the configured index files that
+// exists in the directory and is not ignored. It reports whether a response
+// was written.
+func (h *Handler) serveIndexFromBackend(c *gin.Context, backend storage.Backend, relPath string) bool {
+	ctx := c.Request.Context()
+	for _, name := range h.indexFiles {
+		if !h.isServable(name) {
+			continue
+		}
+		indexPath := filepath.Join(relPath, name)
+
+		if ignored, err := backend.IsIgnored(ctx, indexPath); err != nil || ignored {
+			continue
+		}
+		if h.symlinkBlocked(h.localRoot, indexPath) {
+			continue
+		}
+
+		info, err := backend.Stat(ctx, indexPath)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		if h.serveFileFromBackend(c, backend, indexPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveIndexFromRoot is the FileSystem counterpart of serveIndexFromBackend
+func (h *Handler) serveIndexFromRoot(c *gin.Context, root security.FileSystem, relPath string) bool {
+	for _, name := range h.indexFiles {
+		if !h.isServable(name) {
+			continue
+		}
+		indexPath := filepath.Join(relPath, name)
+
+		if ignored, err := ignore.IsIgnored(indexPath, root, h.config); err != nil || ignored {
+			continue
+		}
+
+		info, err := root.Stat(indexPath)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		if h.serveFileFromRoot(c, root, indexPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) serveDirectoryFromRoot(c *gin.Context, root security.FileSystem, relPath, requestPath string) {
 	if relPath == "" {
 		relPath = "."
 	}
 
+	if h.config.ServeIndexHTML && h.serveIndexFromRoot(c, root, relPath) {
+		return
+	}
+	if h.listingDisabled(c) {
+		return
+	}
+	isIgnored := func(ctx context.Context, path string) (bool, error) { return ignore.IsIgnored(path, root, h.config) }
+	if h.serveStreamedDirectory(c, root, relPath, requestPath, isIgnored) {
+		return
+	}
+
 	entries, err := root.ReadDir(relPath)
 	if err != nil {
-		logger.Log.Error().Err(err).Str("path", relPath).Msg("Error reading directory")
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", relPath).Msg("Error reading directory")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
 	data := buildListingData(c.Request.Context(), entries, requestPath,
-		func(ctx context.Context, path string) (bool, error) { return filter.IsIgnored(path, root, h.config) },
-		determineFileType,
-		getFileIcon,
+		isIgnored,
+		h.symlinkResolver(root),
+		func(e fs.DirEntry) string { return h.fileTypeInfo(e.Name(), e.IsDir()).Type },
+		func(e fs.DirEntry) string { return h.fileTypeInfo(e.Name(), e.IsDir()).Icon },
+		h.listingOrder(c),
 	)
-
-	c.Header("Content-Type", "text/html")
-	c.Status(http.StatusOK)
-	if c.Request.Method == http.MethodHead {
+	data.Files = h.filterServable(data.Files)
+	data.limitFiles(h.config.MaxListingItems)
+	if !h.fillChecksums(c, root, relPath, data.Files) {
 		return
 	}
-	if err := h.tmpl.ExecuteTemplate(c.Writer, "listing.html", data); err != nil {
-		logger.Log.Error().Err(err).Str("template", "listing.html").Msg("Error executing template")
-		c.AbortWithStatus(http.StatusInternalServerError)
+	if h.config.ShowDirSizes {
+		h.fillDirSizes(root, requestPath, data.Files)
+	}
+	h.prefixListing(&data)
+	h.renderListing(c, data)
+}
+
+// symlinkResolver applies the configured SymlinkPolicy to a listing entry
+// whose Lstat info reports a symlink. It returns the info to display and
+// whether the entry should be listed at all.
+func (h *Handler) symlinkResolver(root security.FileSystem) func(string, fs.FileInfo) (fs.FileInfo, bool) {
+	return func(relPath string, info fs.FileInfo) (fs.FileInfo, bool) {
+		switch h.config.SymlinkPolicy {
+		case config.SymlinkDeny:
+			return nil, false
+		case config.SymlinkShow:
+			return info, true
+		}
+
+		if root == nil {
+			return info, true
+		}
+		// RootFS refuses targets outside the root, so broken and escaping
+		// links are both dropped here.
+		target, err := root.Stat(relPath)
+		if err != nil {
+			logger.Log.Debug().Err(err).Str("path", relPath).Msg("Skipping unresolvable symlink")
+			return nil, false
+		}
+		return target, true
+	}
+}
+
+// symlinkBlocked reports whether relPath traverses a symlink that the
+// configured SymlinkPolicy does not allow to be followed.
+func (h *Handler) symlinkBlocked(root security.FileSystem, relPath string) bool {
+	if root == nil || h.config.SymlinkPolicy == "" || h.config.SymlinkPolicy == config.SymlinkFollow {
+		return false
+	}
+
+	current := ""
+	for _, part := range strings.Split(filepath.ToSlash(relPath), "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		current = filepath.Join(current, part)
+		info, err := root.Lstat(current)
+		if err != nil {
+			// Let the caller report the missing path
+			return false
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ThemeCookie holds the theme a visitor picked with the theme toggle
+const ThemeCookie = "slimserve_theme"
+
+// ResolveTheme returns the theme to render: the visitor's cookie override if
+// it names a concrete theme, otherwise the configured default.
+func ResolveTheme(c *gin.Context, configured string) string {
+	if theme, err := c.Cookie(ThemeCookie); err == nil && (theme == config.ThemeLight || theme == config.ThemeDark) {
+		return theme
+	}
+	if configured == config.ThemeLight || configured == config.ThemeDark {
+		return configured
 	}
+	return config.ThemeAuto
 }
 
 func buildFileURL(basePath, fileName string) string {
@@ -427,6 +1077,62 @@ func getFileTypeFromMime(mimeType string) (string, string) {
 	}
 }
 
+// ThumbnailOptions are the settings listing thumbnails are generated with.
+// Thumbnails built ahead of time must use the same ones to land in the cache
+// entry that ?thumb=1 requests look up.
+func ThumbnailOptions(cfg *config.Config) files.Options {
+	return files.Options{
+		MaxDim:      250,
+		MaxCacheMB:  cfg.MaxThumbCacheMB,
+		JpegQuality: cfg.ThumbJpegQuality,
+		MaxFileMB:   cfg.ThumbMaxFileSizeMB,
+		Animated:    cfg.ThumbAnimated,
+		Compress:    cfg.ThumbCacheGzip,
+
+		PruneHighPercent: cfg.ThumbPruneHighPercent,
+		PruneLowPercent:  cfg.ThumbPruneLowPercent,
+	}
+}
+
+// thumbnailOptions are ThumbnailOptions with the format negotiated from the
+// request: AVIF when ThumbAVIF is set and the client lists image/avif, then
+// WebP when ThumbWebP is set and the client lists image/webp, JPEG otherwise
+func (h *Handler) thumbnailOptions(c *gin.Context) files.Options {
+	opts := ThumbnailOptions(h.config)
+	if h.config.ThumbAVIF || h.config.ThumbWebP {
+		c.Writer.Header().Add("Vary", "Accept")
+		accept := c.GetHeader("Accept")
+		switch {
+		case h.config.ThumbAVIF && acceptsMediaType(accept, "image/avif"):
+			opts.Format = files.FormatAVIF
+		case h.config.ThumbWebP && acceptsMediaType(accept, "image/webp"):
+			opts.Format = files.FormatWebP
+		}
+	}
+	return opts
+}
+
+// acceptsMediaType reports whether an Accept header names mediaType with a
+// non-zero quality. Wildcards do not count: browsers that can show a format
+// list it explicitly.
+func acceptsMediaType(header, mediaType string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), mediaType) {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil && q <= 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
 func isImageFile(fileName string) bool {
 	ext := strings.ToLower(filepath.Ext(fileName))
 	mimeType := mime.TypeByExtension(ext)
@@ -440,23 +1146,26 @@ func buildThumbnailURL(basePath, fileName string) string {
 	return basePath + "/" + fileName + "?thumb=1"
 }
 
-func buildPathSegments(requestPath string) []PathSegment {
-	if requestPath == "/" {
-		return nil
-	}
+// homeSegmentName labels the leading breadcrumb that links to the root
+const homeSegmentName = "Home"
 
+// buildPathSegments returns the breadcrumb trail for requestPath, starting
+// with a home segment. Segment URLs are percent-escaped so names with spaces
+// or non-ASCII characters link correctly.
+func buildPathSegments(requestPath string) []PathSegment {
 	parts := strings.Split(strings.Trim(requestPath, "/"), "/")
-	segments := make([]PathSegment, 0, len(parts))
+	segments := make([]PathSegment, 0, len(parts)+1)
+	segments = append(segments, PathSegment{Name: homeSegmentName, URL: "/", IsHome: true})
 
 	var pathBuilder strings.Builder
-	pathBuilder.Grow(len(requestPath))
+	pathBuilder.Grow(len(requestPath) * 3)
 
 	for _, part := range parts {
 		if part == "" {
 			continue
 		}
 		pathBuilder.WriteByte('/')
-		pathBuilder.WriteString(part)
+		pathBuilder.WriteString(url.PathEscape(part))
 
 		segments = append(segments, PathSegment{
 			Name: part,
@@ -467,6 +1176,20 @@ func buildPathSegments(requestPath string) []PathSegment {
 	return segments
 }
 
+// staticCacheControl lets browsers reuse embedded assets for a day before
+// revalidating them against staticModTime.
+const staticCacheControl = "public, max-age=86400"
+
+// staticModTime is the Last-Modified time of embedded assets. They only change
+// with a new build, so the build date is used, falling back to process start
+// for development builds without one.
+var staticModTime = func() time.Time {
+	if t, err := version.GetBuildTime(); err == nil {
+		return t
+	}
+	return time.Now().Truncate(time.Second)
+}()
+
 func (h *Handler) serveStaticFile(c *gin.Context, requestPath string) {
 	filePath := strings.TrimPrefix(requestPath, "/")
 
@@ -476,34 +1199,67 @@ func (h *Handler) serveStaticFile(c *gin.Context, requestPath string) {
 		return
 	}
 
-	ext := filepath.Ext(filePath)
-	switch ext {
+	c.Header("Content-Type", staticContentType(filePath))
+	c.Header("Cache-Control", staticCacheControl)
+	http.ServeContent(c.Writer, c.Request, filePath, staticModTime, bytes.NewReader(fileData))
+}
+
+// serveFavicon answers /favicon.ico with the configured FaviconPath, or the
+// embedded icon when none is set. The file is opened per request so it can be
+// swapped without a restart.
+func (h *Handler) serveFavicon(c *gin.Context) {
+	if h.config.FaviconPath == "" {
+		h.serveStaticFile(c, "/static/favicon.ico")
+		return
+	}
+
+	file, err := os.Open(h.config.FaviconPath)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", h.config.FaviconPath).Msg("Failed to open favicon")
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.Header("Content-Type", staticContentType(h.config.FaviconPath))
+	c.Header("Cache-Control", staticCacheControl)
+	c.Header("ETag", fileETag(info))
+	http.ServeContent(c.Writer, c.Request, "favicon.ico", info.ModTime(), file)
+}
+
+// staticContentType maps an asset's extension to its Content-Type
+func staticContentType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
 	case ".css":
-		c.Header("Content-Type", "text/css")
+		return "text/css"
 	case ".js":
-		c.Header("Content-Type", "application/javascript")
+		return "application/javascript"
 	case ".png":
-		c.Header("Content-Type", "image/png")
+		return "image/png"
 	case ".jpg", ".jpeg":
-		c.Header("Content-Type", "image/jpeg")
+		return "image/jpeg"
 	case ".gif":
-		c.Header("Content-Type", "image/gif")
+		return "image/gif"
 	case ".svg":
-		c.Header("Content-Type", "image/svg+xml")
+		return "image/svg+xml"
 	case ".ico":
-		c.Header("Content-Type", "image/x-icon")
+		return "image/x-icon"
 	default:
-		c.Header("Content-Type", "application/octet-stream")
+		return "application/octet-stream"
 	}
+}
 
-	if c.Request.Method == http.MethodHead {
-		c.Status(http.StatusOK)
-		return
+func (h *Handler) serveFileFromRoot(c *gin.Context, root security.FileSystem, relPath string) bool {
+	if h.symlinkBlocked(root, relPath) {
+		return false
 	}
-	c.Data(http.StatusOK, c.GetHeader("Content-Type"), fileData)
-}
 
-func (h *Handler) serveFileFromRoot(c *gin.Context, root *security.RootFS, relPath string) bool {
 	file, err := root.Open(relPath)
 	if err != nil {
 		return false
@@ -515,16 +1271,60 @@ func (h *Handler) serveFileFromRoot(c *gin.Context, root *security.RootFS, relPa
 		return false
 	}
 
-	http.ServeContent(c.Writer, c.Request, fileInfo.Name(), fileInfo.ModTime(), file)
+	h.setOverriddenContentType(c, fileInfo.Name())
+	if err := sniffContentType(c, fileInfo.Name(), file); err != nil {
+		return false
+	}
+	h.setDownloadHeaders(c, fileInfo.Name())
+	if h.accelRedirect(c, relPath) {
+		return true
+	}
+	content, served, release := h.withPrecompressed(c, relPath, file, fileInfo, func(name string) (io.ReadSeekCloser, fs.FileInfo, error) {
+		if h.symlinkBlocked(root, name) {
+			return nil, nil, fs.ErrPermission
+		}
+		sidecar, err := root.Open(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		sidecarInfo, err := sidecar.Stat()
+		if err != nil {
+			sidecar.Close() //nolint:errcheck
+			return nil, nil, err
+		}
+		return sidecar, sidecarInfo, nil
+	})
+	defer release()
+	c.Header("ETag", fileETag(served))
+	http.ServeContent(c.Writer, c.Request, fileInfo.Name(), served.ModTime(), content)
 	return true
 }
 
 func (h *Handler) serveThumbnail(c *gin.Context, relPath string) {
-	if h.localRoot == nil {
+	if h.backend == nil || !h.isServable(relPath) {
 		c.AbortWithStatus(http.StatusNotFound)
 		return
 	}
 
+	if ignored, err := h.backend.IsIgnored(c.Request.Context(), relPath); err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", relPath).Msg("Error checking if path is ignored")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	} else if ignored {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	if h.localRoot == nil {
+		h.serveRemoteThumbnail(c, relPath)
+		return
+	}
+
+	if h.symlinkBlocked(h.localRoot, relPath) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
 	info, err := h.localRoot.Stat(relPath)
 	if err != nil {
 		c.AbortWithStatus(http.StatusNotFound)
@@ -544,12 +1344,20 @@ func (h *Handler) serveThumbnail(c *gin.Context, relPath string) {
 		return
 	}
 
-	thumbPath, err := files.GenerateWithCacheLimit(filepath.Join(h.localRoot.Path(), relPath), 250, h.config.MaxThumbCacheMB, h.config.ThumbJpegQuality, h.config.ThumbMaxFileSizeMB)
+	ctx := c.Request.Context()
+	if h.thumbTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.thumbTimeout)
+		defer cancel()
+	}
+
+	thumbPath, err := files.GenerateWithContext(ctx, filepath.Join(h.localRoot.Path(), relPath), h.thumbnailOptions(c))
 	if err != nil {
 		if err == files.ErrFileTooLarge {
 			c.AbortWithStatus(http.StatusRequestEntityTooLarge)
 			return
 		}
+		// A slow or failed thumbnail falls back to the original image
 		if h.serveFileFromRoot(c, h.localRoot, relPath) {
 			return
 		}
@@ -557,5 +1365,112 @@ func (h *Handler) serveThumbnail(c *gin.Context, relPath string) {
 		return
 	}
 
-	c.File(thumbPath)
+	h.serveThumbnailFile(c, thumbPath, info.ModTime())
+}
+
+// serveRemoteThumbnail is serveThumbnail for backends without a local root.
+// The thumbnail is generated from a local copy of the image when the backend
+// can make one; otherwise the original is served.
+func (h *Handler) serveRemoteThumbnail(c *gin.Context, relPath string) {
+	ctx := c.Request.Context()
+	info, err := h.backend.Stat(ctx, relPath)
+	if err != nil || info.IsDir() {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	copier, ok := h.backend.(storage.LocalCopier)
+	if !ok || !isImageFile(filepath.Base(relPath)) {
+		if !h.serveFileFromBackend(c, h.backend, relPath) {
+			c.AbortWithStatus(http.StatusNotFound)
+		}
+		return
+	}
+	// Checked before downloading rather than left to the generator
+	if info.Size() > int64(h.config.ThumbMaxFileSizeMB)*1024*1024 {
+		c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if h.thumbTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.thumbTimeout)
+		defer cancel()
+	}
+
+	srcPath, err := copier.LocalCopy(ctx, relPath, files.SourceCopyDir())
+	var thumbPath string
+	if err == nil {
+		thumbPath, err = files.GenerateWithContext(ctx, srcPath, h.thumbnailOptions(c))
+		// Copies share the thumbnail cache's limit
+		if pruneErr := files.PruneSourceCopies(srcPath, h.config.MaxThumbCacheMB); pruneErr != nil {
+			logger.Ctx(c.Request.Context()).Warn().Err(pruneErr).Msg("Failed to prune source copies")
+		}
+	}
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Debug().Err(err).Str("path", relPath).Msg("Serving original image instead of thumbnail")
+		if !h.serveFileFromBackend(c, h.backend, relPath) {
+			c.AbortWithStatus(http.StatusNotFound)
+		}
+		return
+	}
+
+	h.serveThumbnailFile(c, thumbPath, info.ModTime())
+}
+
+// serveThumbnailFile writes a generated thumbnail with its length, type and
+// caching headers. HEAD requests get the same headers and no body.
+// Last-Modified is the source image's modification time: the cached file's
+// own time tracks when it was last served.
+func (h *Handler) serveThumbnailFile(c *gin.Context, thumbPath string, modTime time.Time) {
+	file, err := os.Open(thumbPath)
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("thumbnail", thumbPath).Msg("Error opening thumbnail")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	// A thumbnail's file name is its cache key, which changes whenever the
+	// source file or the thumbnail size does, so it doubles as a strong ETag.
+	name := strings.TrimSuffix(filepath.Base(thumbPath), files.CompressedExt)
+	etag := strings.TrimSuffix(name, filepath.Ext(name))
+	if maxAge := h.config.ThumbCacheMaxAge; maxAge > 0 {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	}
+	c.Header("Content-Type", mime.TypeByExtension(filepath.Ext(name)))
+
+	var content io.ReadSeeker = file
+	if name != filepath.Base(thumbPath) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if acceptsEncoding(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Header("Content-Encoding", "gzip")
+		} else {
+			// Thumbnails are small enough to decompress in memory, which
+			// keeps ranges working on the decoded bytes
+			decoded, err := gunzipAll(file)
+			if err != nil {
+				logger.Ctx(c.Request.Context()).Error().Err(err).Str("thumbnail", thumbPath).Msg("Error decompressing thumbnail")
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+			content = bytes.NewReader(decoded)
+			etag += "-identity"
+		}
+	}
+	c.Header("ETag", `"`+etag+`"`)
+
+	// ServeContent sets Content-Length, answers HEAD without a body and
+	// handles If-None-Match and If-Modified-Since.
+	http.ServeContent(c.Writer, c.Request, "", modTime, content)
+}
+
+// gunzipAll reads and decompresses a whole gzip stream
+func gunzipAll(r io.Reader) ([]byte, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
 }
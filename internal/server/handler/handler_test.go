@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"slimserve/internal/storage"
+)
+
+func TestBuildPathSegments(t *testing.T) {
+	home := PathSegment{Name: homeSegmentName, URL: "/", IsHome: true}
+
+	tests := []struct {
+		name        string
+		requestPath string
+		expected    []PathSegment
+	}{
+		{
+			name:        "root",
+			requestPath: "/",
+			expected:    []PathSegment{home},
+		},
+		{
+			name:        "nested",
+			requestPath: "/docs/reports",
+			expected: []PathSegment{
+				home,
+				{Name: "docs", URL: "/docs"},
+				{Name: "reports", URL: "/docs/reports"},
+			},
+		},
+		{
+			name:        "spaces_and_unicode",
+			requestPath: "/my docs/résumé 2024/日本",
+			expected: []PathSegment{
+				home,
+				{Name: "my docs", URL: "/my%20docs"},
+				{Name: "résumé 2024", URL: "/my%20docs/r%C3%A9sum%C3%A9%202024"},
+				{Name: "日本", URL: "/my%20docs/r%C3%A9sum%C3%A9%202024/%E6%97%A5%E6%9C%AC"},
+			},
+		},
+		{
+			name:        "reserved_characters",
+			requestPath: "/a?b/c#d",
+			expected: []PathSegment{
+				home,
+				{Name: "a?b", URL: "/a%3Fb"},
+				{Name: "c#d", URL: "/a%3Fb/c%23d"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildPathSegments(tt.requestPath)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("buildPathSegments(%q) = %+v, want %+v", tt.requestPath, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildListingDataFullPath(t *testing.T) {
+	data := buildListingData(context.Background(), []*storage.DirEntry{}, "/my docs/sub",
+		func(context.Context, string) (bool, error) { return false, nil },
+		nil,
+		func(*storage.DirEntry) string { return "file" },
+		func(*storage.DirEntry) string { return "file" },
+		nameOrder,
+	)
+
+	if data.FullPath != "/my docs/sub" {
+		t.Errorf("FullPath = %q, want %q", data.FullPath, "/my docs/sub")
+	}
+	if len(data.PathSegments) != 3 || !data.PathSegments[0].IsHome {
+		t.Errorf("Expected home segment followed by two path segments, got %+v", data.PathSegments)
+	}
+}
+
+func TestListingDataLimitFiles(t *testing.T) {
+	listing := func(n int) ListingData {
+		data := newListingData("/")
+		for i := 0; i < n; i++ {
+			data.Files = append(data.Files, FileItem{Name: fmt.Sprintf("file%02d", i)})
+		}
+		return data
+	}
+
+	tests := []struct {
+		name          string
+		items, limit  int
+		wantLen       int
+		wantTruncated bool
+		wantTotal     int
+	}{
+		{"over_limit", 12, 5, 5, true, 12},
+		{"at_limit", 5, 5, 5, false, 0},
+		{"under_limit", 3, 5, 3, false, 0},
+		{"unlimited", 12, 0, 12, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := listing(tt.items)
+			data.limitFiles(tt.limit)
+			if len(data.Files) != tt.wantLen {
+				t.Errorf("Expected %d files, got %d", tt.wantLen, len(data.Files))
+			}
+			if data.Truncated != tt.wantTruncated || data.TotalCount != tt.wantTotal {
+				t.Errorf("Expected Truncated=%v TotalCount=%d, got %v and %d",
+					tt.wantTruncated, tt.wantTotal, data.Truncated, data.TotalCount)
+			}
+			if tt.wantLen > 0 && data.Files[0].Name != "file00" {
+				t.Errorf("Expected the first sorted entries to be kept, got %s first", data.Files[0].Name)
+			}
+		})
+	}
+}
+
+func TestTruncateName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{"unlimited", "a-rather-long-name.txt", 0, "a-rather-long-name.txt"},
+		{"fits", "short.txt", 9, "short.txt"},
+		{"keeps_extension", "a-rather-long-name.txt", 12, "a-rathe….txt"},
+		{"long_extension", "archive.verylongextension", 10, "archive.v…"},
+		{"no_room_for_extension", "abcdefgh.txt", 5, "abcd…"},
+		{"runes", "ünïcödé-ñämé.md", 10, "ünïcöd….md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateName(tt.in, tt.max); got != tt.want {
+				t.Errorf("truncateName(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentDisposition(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"report.pdf", `attachment; filename=report.pdf`},
+		{"my report.pdf", `attachment; filename="my report.pdf"`},
+		{"unicode文件名.txt", `attachment; filename="unicode___.txt"; filename*=UTF-8''unicode%E6%96%87%E4%BB%B6%E5%90%8D.txt`},
+		{"emoji🎉.png", `attachment; filename="emoji_.png"; filename*=UTF-8''emoji%F0%9F%8E%89.png`},
+		{`café "menu".pdf`, `attachment; filename="caf_ _menu_.pdf"; filename*=UTF-8''caf%C3%A9%20%22menu%22.pdf`},
+		{"tab\there.txt", `attachment; filename="tab_here.txt"; filename*=UTF-8''tab%09here.txt`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attachmentDisposition(tt.name); got != tt.expected {
+				t.Errorf("attachmentDisposition(%q) = %q, want %q", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
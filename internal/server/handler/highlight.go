@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"html/template"
+	"path/filepath"
+	"strings"
+)
+
+// syntax describes just enough of a language to colour comments, strings,
+// numbers and keywords. It is a lexer, not a parser: output is always the
+// escaped source with <span> classes around recognised tokens.
+type syntax struct {
+	lineComments []string
+	blockComment [2]string
+	quotes       string // characters that open a string; ` may span lines
+	keywords     map[string]bool
+}
+
+func words(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(list) {
+		set[w] = true
+	}
+	return set
+}
+
+var (
+	goSyntax = &syntax{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+		keywords: words(`break case chan const continue default defer else fallthrough for func go goto if
+			import interface map package range return select struct switch type var nil true false iota`),
+	}
+	jsSyntax = &syntax{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+		keywords: words(`async await break case catch class const continue debugger default delete do else
+			export extends finally for from function if import in instanceof interface let new null of return
+			static super switch this throw true false try type typeof undefined var void while yield`),
+	}
+	cSyntax = &syntax{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+		keywords: words(`auto bool break case catch char class const continue default delete do double else
+			enum extern false float for if inline int long namespace new nullptr private protected public
+			return short signed sizeof static struct switch template this throw true try typedef union
+			unsigned using virtual void volatile while #include #define #ifdef #ifndef #endif`),
+	}
+	javaSyntax = &syntax{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+		keywords: words(`abstract boolean break byte case catch char class const continue default do double
+			else enum extends final finally float for fun if implements import instanceof int interface long
+			new null override package private protected public return short static super switch this throw
+			throws true false try val var void while`),
+	}
+	rustSyntax = &syntax{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"",
+		keywords: words(`as async await break const continue crate dyn else enum extern false fn for if impl
+			in let loop match mod move mut pub ref return self Self static struct super trait true type unsafe
+			use where while`),
+	}
+	pythonSyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+		keywords: words(`and as assert async await break class continue def del elif else except False
+			finally for from global if import in is lambda None nonlocal not or pass raise return True try
+			while with yield`),
+	}
+	rubySyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+		keywords: words(`alias and begin break case class def defined? do else elsif end ensure false for if
+			in module next nil not or redo rescue retry return self super then true undef unless until when
+			while yield require`),
+	}
+	shellSyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+		keywords: words(`case do done elif else esac exit export fi for function if in local readonly
+			return set shift then unset until while`),
+	}
+	sqlSyntax = &syntax{
+		lineComments: []string{"--"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "'\"",
+		keywords: words(`ALTER AND AS ASC BY CREATE DELETE DESC DISTINCT DROP FROM GROUP HAVING IN INDEX
+			INSERT INTO IS JOIN KEY LEFT LIMIT NOT NULL ON OR ORDER PRIMARY SELECT SET TABLE UNION UPDATE
+			VALUES WHERE alter and as asc by create delete desc distinct drop from group having in index
+			insert into is join key left limit not null on or order primary select set table union update
+			values where`),
+	}
+	cssSyntax = &syntax{
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+		keywords:     words(`@media @import @font-face @keyframes !important`),
+	}
+	configSyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+		keywords:     words(`true false null yes no on off`),
+	}
+	jsonSyntax = &syntax{
+		quotes:   "\"",
+		keywords: words(`true false null`),
+	}
+)
+
+// codeSyntaxes maps file extensions to the syntax they are highlighted with
+var codeSyntaxes = map[string]*syntax{
+	".go":    goSyntax,
+	".js":    jsSyntax,
+	".mjs":   jsSyntax,
+	".cjs":   jsSyntax,
+	".jsx":   jsSyntax,
+	".ts":    jsSyntax,
+	".tsx":   jsSyntax,
+	".c":     cSyntax,
+	".h":     cSyntax,
+	".cc":    cSyntax,
+	".cpp":   cSyntax,
+	".hpp":   cSyntax,
+	".cs":    javaSyntax,
+	".java":  javaSyntax,
+	".kt":    javaSyntax,
+	".rs":    rustSyntax,
+	".py":    pythonSyntax,
+	".rb":    rubySyntax,
+	".sh":    shellSyntax,
+	".bash":  shellSyntax,
+	".zsh":   shellSyntax,
+	".sql":   sqlSyntax,
+	".css":   cssSyntax,
+	".yaml":  configSyntax,
+	".yml":   configSyntax,
+	".toml":  configSyntax,
+	".ini":   configSyntax,
+	".conf":  configSyntax,
+	".json":  jsonSyntax,
+	".proto": cSyntax,
+}
+
+// languageAliases maps fence info strings that are not an extension
+var languageAliases = map[string]string{
+	"golang":     "go",
+	"javascript": "js",
+	"typescript": "ts",
+	"python":     "py",
+	"ruby":       "rb",
+	"rust":       "rs",
+	"shell":      "sh",
+	"console":    "sh",
+	"csharp":     "cs",
+	"kotlin":     "kt",
+	"c++":        "cpp",
+}
+
+// syntaxForFile returns the syntax for a file name, or nil for non-code files
+func syntaxForFile(name string) *syntax {
+	return codeSyntaxes[strings.ToLower(filepath.Ext(name))]
+}
+
+// syntaxForLanguage returns the syntax for a fenced code block's language
+func syntaxForLanguage(lang string) *syntax {
+	lang = strings.ToLower(lang)
+	if alias, ok := languageAliases[lang]; ok {
+		lang = alias
+	}
+	return codeSyntaxes["."+lang]
+}
+
+// highlight returns code as escaped HTML with tok-comment, tok-string,
+// tok-number and tok-keyword spans
+func (syn *syntax) highlight(code string) template.HTML {
+	var b strings.Builder
+	span := func(class, text string) {
+		b.WriteString(`<span class="` + class + `">` + template.HTMLEscapeString(text) + "</span>")
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+
+		if open := syn.blockComment[0]; open != "" && strings.HasPrefix(rest, open) {
+			end := strings.Index(rest[len(open):], syn.blockComment[1])
+			n := len(rest)
+			if end >= 0 {
+				n = len(open) + end + len(syn.blockComment[1])
+			}
+			span("tok-comment", rest[:n])
+			i += n
+			continue
+		}
+
+		if syn.startsLineComment(rest) {
+			n := strings.IndexByte(rest, '\n')
+			if n < 0 {
+				n = len(rest)
+			}
+			span("tok-comment", rest[:n])
+			i += n
+			continue
+		}
+
+		c := code[i]
+		switch {
+		case strings.IndexByte(syn.quotes, c) >= 0:
+			n := stringLength(rest, c)
+			span("tok-string", rest[:n])
+			i += n
+		case c >= '0' && c <= '9' && (i == 0 || !isWordByte(code[i-1])):
+			n := 1
+			for n < len(rest) && (isWordByte(rest[n]) || rest[n] == '.') {
+				n++
+			}
+			span("tok-number", rest[:n])
+			i += n
+		case isWordByte(c) || c == '@' || c == '#' || c == '!':
+			n := 1
+			for n < len(rest) && (isWordByte(rest[n]) || rest[n] == '-' && c == '@' || rest[n] == '?') {
+				n++
+			}
+			if syn.keywords[rest[:n]] {
+				span("tok-keyword", rest[:n])
+			} else {
+				b.WriteString(template.HTMLEscapeString(rest[:n]))
+			}
+			i += n
+		default:
+			b.WriteString(template.HTMLEscapeString(rest[:1]))
+			i++
+		}
+	}
+	return template.HTML(b.String())
+}
+
+func (syn *syntax) startsLineComment(s string) bool {
+	for _, prefix := range syn.lineComments {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stringLength returns the length of the string literal opening s, stopping at
+// the end of the line for unterminated literals other than backquoted ones
+func stringLength(s string, quote byte) int {
+	for n := 1; n < len(s); n++ {
+		switch s[n] {
+		case '\\':
+			if quote != '`' {
+				n++
+			}
+		case '\n':
+			if quote != '`' {
+				return n
+			}
+		case quote:
+			return n + 1
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+
+	"slimserve/internal/config"
+	"slimserve/internal/logger"
+	"slimserve/internal/storage"
+	"slimserve/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LandingData is what a LandingPage template is executed with
+type LandingData struct {
+	Title    string
+	Links    []FileItem // the mounts, or the root's top-level folders
+	Theme    string
+	Version  string
+	CSPNonce string
+}
+
+// loadLandingPage parses the configured LandingPage as an html/template with
+// the same functions as the built-in pages. It returns nil when none is set
+// or the file cannot be parsed, leaving / to the directory listing.
+func loadLandingPage(cfg *config.Config) *template.Template {
+	if cfg.LandingPage == "" {
+		return nil
+	}
+	tmpl, err := template.New(filepath.Base(cfg.LandingPage)).Funcs(web.Funcs(cfg.URLPrefix)).ParseFiles(cfg.LandingPage)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("path", cfg.LandingPage).Msg("Failed to load landing page, serving the listing instead")
+		return nil
+	}
+	return tmpl
+}
+
+// serveLanding renders the landing page for GET /
+func (h *Handler) serveLanding(c *gin.Context) {
+	data := LandingData{
+		Title:   "SlimServe",
+		Theme:   ResolveTheme(c, h.config.Theme),
+		Version: h.shownVersion(),
+	}
+	if h.mounts != nil {
+		data.Links = h.mountItems()
+	} else {
+		data.Links = h.rootFolders(c)
+	}
+	for i := range data.Links {
+		data.Links[i].prefixURLs(h.urlPrefix)
+	}
+	data.CSPNonce = ApplyCSP(c, h.config.ContentSecurityPolicy)
+
+	c.Header("Content-Type", "text/html")
+	c.Status(http.StatusOK)
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+	if err := h.landing.Execute(c.Writer, data); err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("template", h.landing.Name()).Msg("Error executing template")
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+// mountItems lists the configured mounts as folders, sorted by name
+func (h *Handler) mountItems() []FileItem {
+	names := make([]string, 0, len(h.mounts))
+	for name := range h.mounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	items := make([]FileItem, 0, len(names))
+	for _, name := range names {
+		item := FileItem{
+			Name:     name,
+			URL:      "/" + url.PathEscape(name),
+			Type:     "folder",
+			Icon:     "folder",
+			IsFolder: true,
+		}
+		if info, err := h.mounts[name].localRoot.Stat("."); err == nil {
+			item.modTime = info.ModTime()
+			item.ModUnix = info.ModTime().Unix()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// rootFolders lists the folders at the top of the served root, with the same
+// dot file, ignore and symlink rules as the listing
+func (h *Handler) rootFolders(c *gin.Context) []FileItem {
+	if h.backend == nil {
+		return nil
+	}
+	ctx := c.Request.Context()
+	entries, err := h.backend.ReadDir(ctx, ".")
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Msg("Error reading root directory for landing page")
+		return nil
+	}
+
+	data := buildListingData(ctx, entries, "/",
+		h.backend.IsIgnored,
+		h.symlinkResolver(h.localRoot),
+		func(e *storage.DirEntry) string { return h.fileTypeInfo(e.Name(), e.IsDir()).Type },
+		func(e *storage.DirEntry) string { return h.fileTypeInfo(e.Name(), e.IsDir()).Icon },
+		nameOrder,
+	)
+	folders := data.Files[:0]
+	for _, item := range data.Files {
+		if item.IsFolder {
+			folders = append(folders, item)
+		}
+	}
+	return folders
+}
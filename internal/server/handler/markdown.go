@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"html/template"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	listItemPattern = regexp.MustCompile(`^\s{0,3}([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	rulePattern     = regexp.MustCompile(`^\s{0,3}(?:-(?:\s*-){2,}|\*(?:\s*\*){2,}|_(?:\s*_){2,})\s*$`)
+)
+
+// renderMarkdown converts the common subset of Markdown (headings,
+// paragraphs, lists, block quotes, fenced code, rules and inline emphasis,
+// code, links and images) to HTML. Raw HTML in the source is escaped rather
+// than passed through and link targets are limited to safe schemes, so the
+// result is safe to embed without further sanitizing.
+func renderMarkdown(src []byte) template.HTML {
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	return template.HTML(renderBlocks(strings.Split(text, "\n")))
+}
+
+func renderBlocks(lines []string) string {
+	var b strings.Builder
+	var para, items []string
+	var listTag string
+
+	flushPara := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + renderInline(strings.Join(para, "\n")) + "</p>\n")
+			para = nil
+		}
+	}
+	closeList := func() {
+		if listTag == "" {
+			return
+		}
+		b.WriteString("<" + listTag + ">\n")
+		for _, item := range items {
+			b.WriteString("<li>" + renderInline(item) + "</li>\n")
+		}
+		b.WriteString("</" + listTag + ">\n")
+		listTag, items = "", nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if fence := codeFence(trimmed); fence != "" {
+			flushPara()
+			closeList()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			lang := strings.Fields(strings.TrimLeft(trimmed, fence[:1]) + " ")
+			b.WriteString(renderCodeBlock(firstOr(lang, ""), strings.Join(code, "\n")))
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flushPara()
+			closeList()
+		case headingLevel(trimmed) > 0:
+			flushPara()
+			closeList()
+			level := headingLevel(trimmed)
+			content := strings.TrimRight(strings.TrimSpace(trimmed[level:]), "#")
+			tag := "h" + strconv.Itoa(level)
+			b.WriteString("<" + tag + ">" + renderInline(strings.TrimSpace(content)) + "</" + tag + ">\n")
+		case rulePattern.MatchString(line):
+			flushPara()
+			closeList()
+			b.WriteString("<hr>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flushPara()
+			closeList()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				inner := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(inner, " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n" + renderBlocks(quoted) + "</blockquote>\n")
+		case listItemPattern.MatchString(line):
+			flushPara()
+			m := listItemPattern.FindStringSubmatch(line)
+			tag := "ul"
+			if m[1][0] >= '0' && m[1][0] <= '9' {
+				tag = "ol"
+			}
+			if tag != listTag {
+				closeList()
+				listTag = tag
+			}
+			items = append(items, m[2])
+		case listTag != "" && (line[0] == ' ' || line[0] == '\t'):
+			// An indented line continues the previous list item
+			items[len(items)-1] += "\n" + trimmed
+		default:
+			closeList()
+			para = append(para, trimmed)
+		}
+	}
+	flushPara()
+	closeList()
+	return b.String()
+}
+
+// codeFence returns the fence a line opens a code block with, if any
+func codeFence(line string) string {
+	for _, fence := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, fence) {
+			return fence
+		}
+	}
+	return ""
+}
+
+// headingLevel returns the level of an ATX heading line, or 0
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ') {
+		return 0
+	}
+	return level
+}
+
+func renderCodeBlock(lang, code string) string {
+	if syn := syntaxForLanguage(lang); syn != nil {
+		return `<pre class="code-block"><code>` + string(syn.highlight(code)) + "</code></pre>\n"
+	}
+	return `<pre class="code-block"><code>` + template.HTMLEscapeString(code) + "</code></pre>\n"
+}
+
+func firstOr(values []string, fallback string) string {
+	if len(values) > 0 {
+		return values[0]
+	}
+	return fallback
+}
+
+// renderInline renders emphasis, code spans, links and images within a block,
+// escaping all other text
+func renderInline(s string) string {
+	var b strings.Builder
+	plain := 0
+	noCloser := map[string]int{}
+	flush := func(end int) {
+		b.WriteString(template.HTMLEscapeString(s[plain:end]))
+	}
+
+	for i := 0; i < len(s); {
+		var html string
+		n := 0
+
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) && strings.IndexByte("\\`*_[]()#+-.!<>", s[i+1]) >= 0 {
+				html, n = template.HTMLEscapeString(s[i+1:i+2]), 2
+			}
+		case '\n':
+			// Two trailing spaces make a hard line break
+			if strings.HasSuffix(s[plain:i], "  ") {
+				flush(i - 2)
+				plain = i
+				html, n = "<br>\n", 1
+			}
+		case '`':
+			ticks := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			delim := s[i : i+ticks]
+			if end := strings.Index(s[i+ticks:], delim); end >= 0 {
+				code := strings.TrimSpace(s[i+ticks : i+ticks+end])
+				html, n = "<code>"+template.HTMLEscapeString(code)+"</code>", 2*ticks+end
+			}
+		case '!':
+			if text, dest, size, ok := parseLink(s[i+1:]); ok {
+				if src, safe := safeURL(dest); safe {
+					html = `<img src="` + template.HTMLEscapeString(src) + `" alt="` + template.HTMLEscapeString(text) + `">`
+				} else {
+					html = template.HTMLEscapeString(text)
+				}
+				n = 1 + size
+			}
+		case '[':
+			if text, dest, size, ok := parseLink(s[i:]); ok {
+				if href, safe := safeURL(dest); safe {
+					html = `<a href="` + template.HTMLEscapeString(href) + `">` + renderInline(text) + "</a>"
+				} else {
+					html = renderInline(text)
+				}
+				n = size
+			}
+		case '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 1 {
+				target := s[i+1 : i+end]
+				if u, err := url.Parse(target); err == nil && (u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "mailto") {
+					html, n = `<a href="`+template.HTMLEscapeString(target)+`">`+template.HTMLEscapeString(target)+"</a>", end+1
+				}
+			}
+		case '*', '_':
+			html, n = renderEmphasis(s, i, noCloser)
+		}
+
+		if n == 0 {
+			i++
+			continue
+		}
+		flush(i)
+		b.WriteString(html)
+		i += n
+		plain = i
+	}
+	flush(len(s))
+	return b.String()
+}
+
+// renderEmphasis renders the *em* or **strong** span opening at i, reporting
+// the bytes consumed, or 0 when the delimiter does not open a span. As in
+// CommonMark, underscores inside words are left alone.
+//
+// Whether a position can close a span does not depend on the opener, so once
+// a search for a delimiter fails no later opener of it can succeed. noCloser
+// records where each delimiter's failed search began, which keeps a line full
+// of unmatched openers linear instead of rescanning the rest for each one.
+func renderEmphasis(s string, i int, noCloser map[string]int) (string, int) {
+	marker := s[i]
+	width := 1
+	if i+1 < len(s) && s[i+1] == marker {
+		width = 2
+	}
+	if marker == '_' && i > 0 && isWordByte(s[i-1]) {
+		return "", 0
+	}
+	delim := s[i : i+width]
+	start := i + width
+	if start >= len(s) || s[start] == ' ' || s[start] == '\n' {
+		return "", 0
+	}
+	if from, ok := noCloser[delim]; ok && start+1 >= from {
+		return "", 0
+	}
+	for j := start + 1; j+width <= len(s); j++ {
+		if s[j:j+width] != delim || s[j-1] == ' ' {
+			continue
+		}
+		if marker == '_' && j+width < len(s) && isWordByte(s[j+width]) {
+			continue
+		}
+		tag := "em"
+		if width == 2 {
+			tag = "strong"
+		}
+		return "<" + tag + ">" + renderInline(s[start:j]) + "</" + tag + ">", j + width - i
+	}
+	noCloser[delim] = start + 1
+	return "", 0
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// parseLink parses "[text](destination)" at the start of s and reports the
+// bytes it spans. An optional quoted title after the destination is dropped.
+func parseLink(s string) (text, dest string, size int, ok bool) {
+	if !strings.HasPrefix(s, "[") {
+		return "", "", 0, false
+	}
+	depth := 0
+	closeText := -1
+	for j := 0; j < len(s) && closeText < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeText = j
+			}
+		}
+	}
+	if closeText < 0 || closeText+1 >= len(s) || s[closeText+1] != '(' {
+		return "", "", 0, false
+	}
+	// Parentheses in the destination must balance, as in CommonMark
+	closeDest, depth := -1, 0
+	for j := closeText + 2; j < len(s) && closeDest < 0; j++ {
+		switch s[j] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				closeDest = j - closeText - 2
+			}
+			depth--
+		}
+	}
+	if closeDest < 0 {
+		return "", "", 0, false
+	}
+	inner := strings.TrimSpace(s[closeText+2 : closeText+2+closeDest])
+	dest = strings.Trim(firstOr(strings.Fields(inner), ""), "<>")
+	return s[1:closeText], dest, closeText + 3 + closeDest, true
+}
+
+// safeURL accepts relative references and http, https and mailto URLs, so
+// script and data URLs in a document cannot end up in an href or src
+func safeURL(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return raw, true
+	}
+	return "", false
+}
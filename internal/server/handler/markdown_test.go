@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"heading", "## Title ##", "<h2>Title</h2>\n"},
+		{"paragraph", "one\ntwo", "<p>one\ntwo</p>\n"},
+		{"emphasis", "**bold** and _em_ but snake_case_name", "<p><strong>bold</strong> and <em>em</em> but snake_case_name</p>\n"},
+		{"code_span", "use `a < b`", "<p>use <code>a &lt; b</code></p>\n"},
+		{"list", "- a\n- b\n  more\n\n1. x", "<ul>\n<li>a</li>\n<li>b\nmore</li>\n</ul>\n<ol>\n<li>x</li>\n</ol>\n"},
+		{"quote", "> quoted\n> text", "<blockquote>\n<p>quoted\ntext</p>\n</blockquote>\n"},
+		{"rule", "a\n\n---\n", "<p>a</p>\n<hr>\n"},
+		{"fence", "```\n<b>\n```", "<pre class=\"code-block\"><code>&lt;b&gt;</code></pre>\n"},
+		{"raw_html", "<img src=x onerror=alert(1)>", "<p>&lt;img src=x onerror=alert(1)&gt;</p>\n"},
+		{"link", "[a](/docs/b.md \"title\")", "<p><a href=\"/docs/b.md\">a</a></p>\n"},
+		{"unsafe_link", "[a](javascript:alert(1)) ![i](data:image/png;base64,AA)", "<p>a i</p>\n"},
+		{"autolink", "<https://example.com/?a=1&b=2>", "<p><a href=\"https://example.com/?a=1&amp;b=2\">https://example.com/?a=1&amp;b=2</a></p>\n"},
+		{"escape", `\*not em\*`, "<p>*not em*</p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(renderMarkdown([]byte(tt.src))); got != tt.want {
+				t.Errorf("renderMarkdown(%q)\n got %q\nwant %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownUnmatchedEmphasis(t *testing.T) {
+	// Each opener used to rescan the rest of the line for a closer
+	for _, opener := range []string{"*", "_"} {
+		t.Run(opener, func(t *testing.T) {
+			src := strings.Repeat(opener+"a ", 50000)
+			begin := time.Now()
+			got := string(renderMarkdown([]byte(src)))
+			if elapsed := time.Since(begin); elapsed > time.Second {
+				t.Errorf("Rendering %d unmatched %q took %v", 50000, opener, elapsed)
+			}
+			if want := "<p>" + strings.TrimSpace(src) + "</p>\n"; got != want {
+				t.Errorf("Expected the openers as plain text, got %.60q...", got)
+			}
+		})
+	}
+}
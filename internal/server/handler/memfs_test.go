@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"slimserve/internal/config"
+	"slimserve/internal/security"
+	"slimserve/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memFS is a security.FileSystem over an in-memory tree
+type memFS struct {
+	fsys fs.FS
+}
+
+func (m memFS) Open(name string) (security.File, error) {
+	f, err := m.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return memFile{f}, nil
+}
+
+func (m memFS) Stat(name string) (fs.FileInfo, error)      { return fs.Stat(m.fsys, name) }
+func (m memFS) Lstat(name string) (fs.FileInfo, error)     { return fs.Stat(m.fsys, name) }
+func (m memFS) ReadDir(name string) ([]fs.DirEntry, error) { return fs.ReadDir(m.fsys, name) }
+func (m memFS) Path() string                               { return "" }
+func (m memFS) Check() error                               { return nil }
+func (m memFS) Close() error                               { return nil }
+
+func (m memFS) OpenRoot(name string) (security.FileSystem, error) {
+	sub, err := fs.Sub(m.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return memFS{sub}, nil
+}
+
+// memFile adds the directory and seek methods MapFS only provides on
+// directories and regular files respectively
+type memFile struct {
+	fs.File
+}
+
+func (f memFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if dir, ok := f.File.(fs.ReadDirFile); ok {
+		return dir.ReadDir(n)
+	}
+	return nil, errors.New("not a directory")
+}
+
+func (f memFile) Seek(offset int64, whence int) (int64, error) {
+	if seeker, ok := f.File.(io.Seeker); ok {
+		return seeker.Seek(offset, whence)
+	}
+	return 0, errors.New("not seekable")
+}
+
+func TestHandlerServesInMemoryFileSystem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	root := memFS{fstest.MapFS{
+		"hello.txt":        {Data: []byte("hello from memory"), ModTime: modTime},
+		"docs/readme.md":   {Data: []byte("# readme"), ModTime: modTime},
+		"docs/notes.txt":   {Data: []byte("notes"), ModTime: modTime},
+		"secret/.keep":     {Data: nil, ModTime: modTime},
+		".slimserveignore": {Data: []byte("secret\n"), ModTime: modTime},
+	}}
+	h := NewHandler(&config.Config{}, storage.NewFSBackend(root, nil), root)
+
+	serve := func(target string, header http.Header) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			c.Request.Header[k] = v
+		}
+		c.Params = gin.Params{{Key: "path", Value: c.Request.URL.Path}}
+		h.ServeFiles(c)
+		return w
+	}
+
+	t.Run("root listing", func(t *testing.T) {
+		w := serve("/?format=json", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var data ListingData
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &data))
+		var names []string
+		for _, f := range data.Files {
+			names = append(names, f.Name)
+		}
+		assert.Contains(t, names, "docs")
+		assert.Contains(t, names, "hello.txt")
+		assert.NotContains(t, names, "secret", "ignore files are read from the in-memory tree")
+	})
+
+	t.Run("subdirectory listing", func(t *testing.T) {
+		w := serve("/docs/?format=json", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "readme.md")
+		assert.Contains(t, w.Body.String(), "notes.txt")
+	})
+
+	t.Run("file content", func(t *testing.T) {
+		w := serve("/hello.txt", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "hello from memory", w.Body.String())
+	})
+
+	t.Run("range request", func(t *testing.T) {
+		w := serve("/hello.txt", http.Header{"Range": {"bytes=6-9"}})
+		require.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "from", w.Body.String())
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		w := serve("/nope.txt", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("ignored path", func(t *testing.T) {
+		w := serve("/secret/.keep", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
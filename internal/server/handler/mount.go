@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"slimserve/internal/config"
+	"slimserve/internal/security"
+	"slimserve/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mount is a local directory served under its own top-level URL segment
+type Mount struct {
+	Name    string
+	Backend storage.Backend
+	Root    security.FileSystem
+}
+
+// NewMountHandler returns a handler that lists the mounts at the root and
+// hands /<name>/... to a handler rooted at that mount's directory, so equal
+// relative paths in different mounts never collide.
+func NewMountHandler(cfg *config.Config, mounts []Mount) *Handler {
+	h := NewHandler(cfg, nil, nil)
+	h.mounts = make(map[string]*Handler, len(mounts))
+	for _, m := range mounts {
+		mh := NewHandler(cfg, m.Backend, m.Root)
+		mh.mountName = m.Name
+		mh.urlPrefix = h.urlPrefix + "/" + url.PathEscape(m.Name)
+		mh.landing = nil
+		h.mounts[m.Name] = mh
+	}
+	return h
+}
+
+// serveMounts dispatches a request to the mount named by its first segment
+func (h *Handler) serveMounts(c *gin.Context, requestPath string) {
+	cleanPath := path.Clean("/" + requestPath)
+	if cleanPath == "/" {
+		h.serveMountIndex(c)
+		return
+	}
+
+	name, rest, _ := strings.Cut(strings.TrimPrefix(cleanPath, "/"), "/")
+	mount, ok := h.mounts[name]
+	if !ok {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.Params = gin.Params{{Key: "path", Value: "/" + rest}}
+	mount.ServeFiles(c)
+}
+
+// serveMountIndex lists the configured mounts as folders
+func (h *Handler) serveMountIndex(c *gin.Context) {
+	if h.listingDisabled(c) {
+		return
+	}
+
+	data := newListingData("/")
+	data.Files = h.mountItems()
+	h.prefixListing(&data)
+	h.renderListing(c, data)
+}
+
+// prefixListing points the listing's links at this handler's URL prefix. A
+// mount's breadcrumb starts at the site root and then names the mount.
+func (h *Handler) prefixListing(data *ListingData) {
+	data.prefixURLs(h.urlPrefix)
+	if h.mountName == "" {
+		return
+	}
+
+	data.PathSegments[0] = PathSegment{Name: h.mountName, URL: h.urlPrefix}
+	data.PathSegments = append([]PathSegment{{Name: homeSegmentName, URL: h.config.URLPrefix() + "/", IsHome: true}}, data.PathSegments...)
+	data.FullPath = path.Join("/", h.mountName, data.FullPath)
+	if data.Title == "/" {
+		data.Title = h.mountName
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+
+	"slimserve/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseTrustedProxies turns TrustedProxies entries into prefixes, treating a
+// bare address as a single-host prefix. Invalid entries are skipped; Validate
+// reports them.
+func parseTrustedProxies(entries []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		} else if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		}
+	}
+	return prefixes
+}
+
+// fromTrustedProxy reports whether the request's direct peer is a trusted proxy
+func (h *Handler) fromTrustedProxy(c *gin.Context) bool {
+	addr, err := netip.ParseAddr(c.RemoteIP())
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range h.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// accelRedirect hands the local file at relPath to the fronting proxy with an
+// X-Accel-Redirect header and an empty body, reporting whether it did. It
+// applies only when XAccelRedirect is set and the request came straight from
+// a trusted proxy; other clients are served the body as usual. Content-Type
+// and Content-Disposition are still sent for nginx to pass on, while ranges
+// and conditional requests are left to the proxy.
+func (h *Handler) accelRedirect(c *gin.Context, relPath string) bool {
+	if h.config.XAccelRedirect == "" || h.localRoot == nil || !h.fromTrustedProxy(c) {
+		return false
+	}
+
+	segments := strings.Split(path.Join(h.mountName, relPath), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	internal := strings.TrimSuffix(h.config.XAccelRedirect, "/") + "/" + strings.Join(segments, "/")
+
+	logger.Ctx(c.Request.Context()).Debug().Str("path", relPath).Str("internal", internal).Msg("Offloading file to proxy")
+	if c.Writer.Header().Get("Content-Type") == "" {
+		c.Header("Content-Type", mime.TypeByExtension(path.Ext(relPath)))
+	}
+	c.Header("X-Accel-Redirect", internal)
+	c.Status(http.StatusOK)
+	return true
+}
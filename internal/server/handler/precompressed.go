@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// precompressedEncodings are the sidecar suffixes looked for next to a file,
+// in order of preference
+var precompressedEncodings = []struct {
+	encoding string
+	suffix   string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// sidecarOpener opens a file next to the one being served
+type sidecarOpener func(name string) (io.ReadSeekCloser, fs.FileInfo, error)
+
+// withPrecompressed swaps in a pre-compressed sidecar of name, such as
+// style.css.gz for style.css, when ServePrecompressed is on and the client
+// accepts its encoding. Sidecars older than the original are ignored so a
+// stale copy is never served. It returns the content and info to serve and a
+// func that releases the sidecar.
+func (h *Handler) withPrecompressed(c *gin.Context, name string, file io.ReadSeeker, info fs.FileInfo, open sidecarOpener) (io.ReadSeeker, fs.FileInfo, func()) {
+	if !h.config.ServePrecompressed || c.Query("thumb") == "1" {
+		return file, info, func() {}
+	}
+	c.Writer.Header().Add("Vary", "Accept-Encoding")
+
+	accept := c.GetHeader("Accept-Encoding")
+	for _, pc := range precompressedEncodings {
+		if !acceptsEncoding(accept, pc.encoding) {
+			continue
+		}
+		sidecar, sidecarInfo, err := open(name + pc.suffix)
+		if err != nil {
+			continue
+		}
+		if !sidecarInfo.Mode().IsRegular() || sidecarInfo.ModTime().Before(info.ModTime()) {
+			sidecar.Close() //nolint:errcheck
+			continue
+		}
+
+		// The type comes from the original; ServeContent would otherwise
+		// sniff the compressed bytes
+		if c.Writer.Header().Get("Content-Type") == "" {
+			c.Header("Content-Type", mime.TypeByExtension(path.Ext(name)))
+		}
+		c.Header("Content-Encoding", pc.encoding)
+		return sidecar, sidecarInfo, func() { sidecar.Close() } //nolint:errcheck
+	}
+	return file, info, func() {}
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows encoding,
+// by name or through *, with a non-zero quality
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.TrimSpace(coding)
+		if !strings.EqualFold(coding, encoding) && coding != "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.EqualFold(key, "q") {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		return q > 0
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"slimserve/internal/logger"
+	"slimserve/internal/server/apierror"
+	"slimserve/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultRecentLimit is the number of files /recent returns without ?limit
+	defaultRecentLimit = 50
+	// maxRecentLimit caps ?limit and is how many files a walk keeps
+	maxRecentLimit = 500
+)
+
+// recentCacheTTL is how long a walk for /recent is reused. It is a variable
+// so tests can disable caching.
+var recentCacheTTL = 30 * time.Second
+
+// recentFile is a /recent row together with the time it is sorted by
+type recentFile struct {
+	item    FileItem
+	modTime time.Time
+}
+
+// recentCache holds the newest files of the last walk
+type recentCache struct {
+	mu       sync.Mutex
+	computed time.Time
+	files    []recentFile
+}
+
+// reset makes the next request walk the roots again
+func (r *recentCache) reset() {
+	r.mu.Lock()
+	r.files = nil
+	r.mu.Unlock()
+}
+
+// HasRootEntry reports whether the served root has an entry called name.
+func (h *Handler) HasRootEntry(ctx context.Context, name string) bool {
+	if h.backend == nil {
+		return false
+	}
+	_, err := h.backend.Stat(ctx, name)
+	return err == nil
+}
+
+// ServeRecent lists the most recently modified files across every served
+// root, newest first, as a listing page or as JSON when ?format=json is given
+// or the client prefers it.
+func (h *Handler) ServeRecent(c *gin.Context) {
+	if h.listingDisabled(c) {
+		return
+	}
+
+	limit := defaultRecentLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			abortError(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "limit must be a positive integer"))
+			return
+		}
+		limit = min(n, maxRecentLimit)
+	}
+
+	recent := h.recentFiles(c.Request.Context())
+	items := make([]FileItem, 0, min(limit, len(recent)))
+	for _, f := range recent[:min(limit, len(recent))] {
+		h.displayItem(&f.item)
+		items = append(items, f.item)
+	}
+
+	if wantsJSON(c) {
+		c.JSON(http.StatusOK, items)
+		return
+	}
+
+	data := newListingData("/")
+	data.Title = "Recent files"
+	data.FullPath = "/recent"
+	data.PathSegments = append(data.PathSegments, PathSegment{Name: "Recent", URL: "/recent"})
+	data.prefixURLs(h.urlPrefix)
+	data.Files = items
+	h.renderListing(c, data)
+}
+
+// recentFiles returns the newest files, walking the roots again once the
+// cached walk is older than recentCacheTTL.
+func (h *Handler) recentFiles(ctx context.Context) []recentFile {
+	h.recent.mu.Lock()
+	defer h.recent.mu.Unlock()
+
+	if h.recent.files != nil && time.Since(h.recent.computed) < recentCacheTTL {
+		return h.recent.files
+	}
+
+	var files []recentFile
+	if h.mounts != nil {
+		for _, mh := range h.mounts {
+			files = mh.collectRecent(ctx, files)
+		}
+	} else {
+		files = h.collectRecent(ctx, files)
+	}
+	files = newestRecent(files)
+
+	h.recent.files = files
+	h.recent.computed = time.Now()
+	return files
+}
+
+// collectRecent appends this handler's files to files, walking at most
+// MaxTraversalDepth levels. Dot files, ignored and disallowed files are left
+// out, and symlinks are not followed so loops cannot stall the walk.
+func (h *Handler) collectRecent(ctx context.Context, files []recentFile) []recentFile {
+	if h.backend == nil {
+		return files
+	}
+	maxDepth := h.config.MaxTraversalDepth
+
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		if ctx.Err() != nil {
+			return
+		}
+		entries, err := h.backend.ReadDir(ctx, dir)
+		if err != nil {
+			logger.Log.Debug().Err(err).Str("path", dir).Msg("Failed to read directory for recent files")
+			return
+		}
+
+		requestPath := "/"
+		if dir != "." {
+			requestPath += dir
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if h.config.DisableDotFiles && strings.HasPrefix(name, ".") {
+				continue
+			}
+			relPath := path.Join(dir, name)
+			if dir == "." {
+				relPath = name
+			}
+
+			if entry.IsDir() {
+				if maxDepth > 0 && depth+1 >= maxDepth {
+					continue
+				}
+				if ignored, err := h.backend.IsIgnored(ctx, relPath); err == nil && !ignored {
+					walk(relPath, depth+1)
+				}
+				continue
+			}
+			if !h.isServable(name) {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			item, ok := buildFileItem(ctx, entry, requestPath,
+				h.backend.IsIgnored,
+				func(string, fs.FileInfo) (fs.FileInfo, bool) { return nil, false },
+				func(e *storage.DirEntry) string { return h.fileTypeInfo(e.Name(), e.IsDir()).Type },
+				func(e *storage.DirEntry) string { return h.fileTypeInfo(e.Name(), e.IsDir()).Icon },
+			)
+			if !ok {
+				continue
+			}
+			item.Name = path.Join(h.mountName, relPath)
+			item.prefixURLs(h.urlPrefix)
+			files = append(files, recentFile{item: item, modTime: info.ModTime()})
+			if len(files) > 2*maxRecentLimit {
+				files = newestRecent(files)
+			}
+		}
+	}
+	walk(".", 0)
+	return files
+}
+
+// newestRecent sorts files newest first and keeps at most maxRecentLimit
+func newestRecent(files []recentFile) []recentFile {
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].modTime.Equal(files[j].modTime) {
+			return files[i].modTime.After(files[j].modTime)
+		}
+		return files[i].item.Name < files[j].item.Name
+	})
+	if len(files) > maxRecentLimit {
+		files = files[:maxRecentLimit]
+	}
+	if files == nil {
+		files = []recentFile{}
+	}
+	return files
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"slimserve/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRenderSize caps the files shown as HTML; larger ones are served as they are
+const maxRenderSize = 2 << 20
+
+// RenderData is what render.html is executed with
+type RenderData struct {
+	Title        string
+	PathSegments []PathSegment
+	Content      template.HTML
+	Markdown     bool
+	Theme        string
+	Version      string
+	CSPNonce     string
+}
+
+func isMarkdown(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown", ".mdown":
+		return true
+	}
+	return false
+}
+
+// wantsRender reports whether a file is shown as an HTML page rather than
+// served as is: markdown and source files with ?render=1, and markdown by
+// default when RenderMarkdown is set. ?raw=1 and downloads always get the
+// original bytes.
+func (h *Handler) wantsRender(c *gin.Context, name string, info fs.FileInfo) bool {
+	if c.Query("raw") == "1" || c.Query("download") == "1" || h.config.ForceDownload || info.Size() > maxRenderSize {
+		return false
+	}
+	render := c.Query("render") == "1"
+	if isMarkdown(name) {
+		return render || h.config.RenderMarkdown
+	}
+	return render && syntaxForFile(name) != nil
+}
+
+// serveRendered shows a markdown file as HTML, or a source file with syntax
+// highlighting, inside the listing theme
+func (h *Handler) serveRendered(c *gin.Context, relPath string, file io.Reader, info fs.FileInfo) {
+	src, err := io.ReadAll(io.LimitReader(file, maxRenderSize))
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", relPath).Msg("Error reading file to render")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	data := RenderData{
+		Title:        info.Name(),
+		PathSegments: buildPathSegments(relPath),
+		Markdown:     isMarkdown(relPath),
+		Theme:        ResolveTheme(c, h.config.Theme),
+		Version:      h.shownVersion(),
+	}
+	if data.Markdown {
+		data.Content = renderMarkdown(src)
+	} else {
+		data.Content = `<pre class="code-block"><code>` + syntaxForFile(relPath).highlight(string(src)) + `</code></pre>`
+	}
+	for i := range data.PathSegments {
+		data.PathSegments[i].URL = h.urlPrefix + data.PathSegments[i].URL
+	}
+	data.CSPNonce = ApplyCSP(c, h.config.ContentSecurityPolicy)
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+	if err := h.renderTmpl.ExecuteTemplate(c.Writer, "render.html", data); err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("template", "render.html").Msg("Error executing template")
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
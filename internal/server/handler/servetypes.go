@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// normalizeServeTypes lower-cases the AllowedServeTypes entries. Entries
+// without glob metacharacters are extensions, with or without the dot.
+func normalizeServeTypes(types []string) []string {
+	normalized := make([]string, 0, len(types))
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if !strings.ContainsAny(t, "*?[") && !strings.HasPrefix(t, ".") {
+			t = "." + t
+		}
+		normalized = append(normalized, t)
+	}
+	return normalized
+}
+
+// isServable reports whether a file may be listed and served under the
+// configured AllowedServeTypes. Directories are not checked here.
+func (h *Handler) isServable(name string) bool {
+	if len(h.serveTypes) == 0 {
+		return true
+	}
+
+	base := strings.ToLower(filepath.Base(name))
+	ext := filepath.Ext(base)
+	for _, t := range h.serveTypes {
+		if strings.ContainsAny(t, "*?[") {
+			if ok, _ := path.Match(t, base); ok {
+				return true
+			}
+		} else if ext == t {
+			return true
+		}
+	}
+	return false
+}
+
+// filterServable drops listing rows for files that may not be served
+func (h *Handler) filterServable(items []FileItem) []FileItem {
+	if len(h.serveTypes) == 0 {
+		return items
+	}
+	kept := items[:0]
+	for _, item := range items {
+		if item.IsFolder || h.isServable(item.Name) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
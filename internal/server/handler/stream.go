@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"iter"
+	"net/http"
+
+	"slimserve/internal/logger"
+	"slimserve/internal/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamListingThreshold is the entry count above which a directory listing
+// is rendered unsorted while the directory is read, instead of being
+// collected and sorted first. It is a variable so tests can lower it.
+var streamListingThreshold = 10000
+
+// streamBatchSize is how many entries are read from the directory at a time
+const streamBatchSize = 256
+
+// serveStreamedDirectory renders the listing for relPath without holding all
+// entries in memory when the directory has more than streamListingThreshold
+// entries. It reports false, without writing anything, for smaller
+// directories and JSON requests so the caller can render the usual sorted
+// listing.
+func (h *Handler) serveStreamedDirectory(c *gin.Context, root security.FileSystem, relPath, requestPath string, isIgnored func(context.Context, string) (bool, error)) bool {
+	if root == nil || wantsJSON(c) || h.feedFormat(c) != "" || !exceedsEntries(root, relPath, streamListingThreshold) {
+		return false
+	}
+
+	ctx := c.Request.Context()
+	data := newListingData(requestPath)
+	h.hideVersion(&data)
+	h.prefixListing(&data)
+	data.Streamed = true
+	// The directory has more entries than the threshold, so a smaller limit
+	// is certain to cut it short; the total is not counted
+	limit := h.config.MaxListingItems
+	data.Truncated = limit > 0 && limit < streamListingThreshold
+	data.stream = func(yield func(FileItem) bool) {
+		shown := 0
+		for entry := range readDirEntries(root, relPath) {
+			if limit > 0 && shown >= limit {
+				return
+			}
+			fileItem, ok := buildFileItem(ctx, entry, requestPath,
+				isIgnored,
+				h.symlinkResolver(root),
+				func(e fs.DirEntry) string { return h.fileTypeInfo(e.Name(), e.IsDir()).Type },
+				func(e fs.DirEntry) string { return h.fileTypeInfo(e.Name(), e.IsDir()).Icon },
+			)
+			if !ok || (!fileItem.IsFolder && !h.isServable(fileItem.Name)) {
+				continue
+			}
+			fileItem.prefixURLs(h.urlPrefix)
+			h.displayItem(&fileItem)
+			shown++
+			if !yield(fileItem) {
+				return
+			}
+		}
+	}
+	data.Theme = ResolveTheme(c, h.config.Theme)
+	data.Banner = h.listingBanner()
+	data.CSPNonce = ApplyCSP(c, h.config.ContentSecurityPolicy)
+	data.FeedURL = h.feedURL()
+
+	// Accept picks JSON or a feed instead, as in renderListing. There is no
+	// ETag: it would take reading the whole directory first.
+	c.Writer.Header().Add("Vary", "Accept")
+	c.Header("Content-Type", "text/html")
+	c.Status(http.StatusOK)
+	if c.Request.Method == http.MethodHead {
+		return true
+	}
+	// The status line is already on the wire by the time a late error occurs,
+	// so it can only be logged.
+	if err := h.tmpl.ExecuteTemplate(c.Writer, "listing.html", data); err != nil {
+		logger.Ctx(c.Request.Context()).Error().Err(err).Str("template", "listing.html").Msg("Error executing template")
+	}
+	return true
+}
+
+// exceedsEntries reports whether the directory has more than limit entries,
+// reading at most limit+1 of them.
+func exceedsEntries(root security.FileSystem, relPath string, limit int) bool {
+	dir, err := root.Open(relPath)
+	if err != nil {
+		return false
+	}
+	defer dir.Close()
+
+	seen := 0
+	for seen <= limit {
+		entries, err := dir.ReadDir(streamBatchSize)
+		seen += len(entries)
+		if err != nil {
+			break
+		}
+	}
+	return seen > limit
+}
+
+// readDirEntries yields the entries of relPath in directory order, reading
+// streamBatchSize at a time.
+func readDirEntries(root security.FileSystem, relPath string) iter.Seq[fs.DirEntry] {
+	return func(yield func(fs.DirEntry) bool) {
+		dir, err := root.Open(relPath)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("path", relPath).Msg("Error reading directory")
+			return
+		}
+		defer dir.Close()
+
+		for {
+			entries, err := dir.ReadDir(streamBatchSize)
+			for _, entry := range entries {
+				if !yield(entry) {
+					return
+				}
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					logger.Log.Error().Err(err).Str("path", relPath).Msg("Error reading directory")
+				}
+				return
+			}
+		}
+	}
+}
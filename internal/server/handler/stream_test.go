@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+	"slimserve/internal/security"
+	"slimserve/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamTestHandler(tb testing.TB, numFiles int) (*Handler, func()) {
+	tb.Helper()
+	tmpDir := tb.TempDir()
+	for i := 0; i < numFiles; i++ {
+		if err := os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("file_%06d.txt", i)), nil, 0644); err != nil {
+			tb.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	root, err := security.NewRootFS(tmpDir)
+	if err != nil {
+		tb.Fatalf("Failed to open root: %v", err)
+	}
+	h := NewHandler(&config.Config{StoragePath: tmpDir}, storage.NewLocalBackend(root, nil), root)
+	return h, func() { root.Close() }
+}
+
+func TestStreamedListing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer func(old int) { streamListingThreshold = old }(streamListingThreshold)
+	streamListingThreshold = 100
+
+	serve := func(h *Handler, method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(method, "/", nil)
+		h.ServeFiles(c)
+		return w
+	}
+
+	t.Run("large directory is streamed", func(t *testing.T) {
+		h, cleanup := newStreamTestHandler(t, 300)
+		defer cleanup()
+
+		w := serve(h, "GET")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		body := w.Body.String()
+		require.Contains(t, body, "Large folder, unsorted")
+		for i := 0; i < 300; i++ {
+			name := fmt.Sprintf("file_%06d.txt", i)
+			// Once in the table and once in the grid view
+			require.Equal(t, 2, strings.Count(body, `title="`+name+`"`), "entry %s", name)
+		}
+
+		head := serve(h, "HEAD")
+		require.Equal(t, http.StatusOK, head.Code)
+		require.Empty(t, head.Body.String())
+
+		for _, resp := range []*httptest.ResponseRecorder{w, head} {
+			require.Equal(t, []string{"Accept"}, resp.Header().Values("Vary"))
+			require.Equal(t, "text/html", resp.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("small directory is sorted", func(t *testing.T) {
+		h, cleanup := newStreamTestHandler(t, 100)
+		defer cleanup()
+
+		w := serve(h, "GET")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "100 items")
+		require.NotContains(t, w.Body.String(), "Large folder, unsorted")
+		require.Equal(t, []string{"Accept"}, w.Header().Values("Vary"))
+	})
+
+	t.Run("streamed listing stops at the item limit", func(t *testing.T) {
+		h, cleanup := newStreamTestHandler(t, 300)
+		defer cleanup()
+		h.config.MaxListingItems = 50
+
+		body := serve(h, "GET").Body.String()
+		require.Contains(t, body, "Large folder, unsorted, truncated")
+		// Each shown entry appears in the table and the grid view
+		require.Equal(t, 2*50, strings.Count(body, `title="file_`))
+	})
+
+	t.Run("sorted listing over the item limit is truncated", func(t *testing.T) {
+		h, cleanup := newStreamTestHandler(t, 80)
+		defer cleanup()
+		h.config.MaxListingItems = 30
+
+		body := serve(h, "GET").Body.String()
+		require.Contains(t, body, "Showing 30 of 80 items")
+		require.Contains(t, body, `title="file_000029.txt"`)
+		require.NotContains(t, body, `title="file_000030.txt"`)
+	})
+
+	t.Run("streamed listing shows the banner", func(t *testing.T) {
+		h, cleanup := newStreamTestHandler(t, 300)
+		defer cleanup()
+		h.config.ListingBanner = `Mirror <b>maintenance</b><script>alert(1)</script>`
+
+		body := serve(h, "GET").Body.String()
+		require.Contains(t, body, "Large folder, unsorted")
+		require.Contains(t, body, "Mirror <b>maintenance</b>")
+		require.NotContains(t, body, "alert(1)")
+	})
+
+	t.Run("reading stops when the consumer does", func(t *testing.T) {
+		h, cleanup := newStreamTestHandler(t, 1000)
+		defer cleanup()
+
+		read := 0
+		for range readDirEntries(h.localRoot, ".") {
+			read++
+			if read == 10 {
+				break
+			}
+		}
+		require.Equal(t, 10, read)
+	})
+}
+
+// discardWriter is a ResponseWriter that drops the body so benchmarks measure
+// the handler rather than the recorder's buffer
+type discardWriter struct {
+	header http.Header
+	writes int
+	peak   uint64
+}
+
+func (w *discardWriter) Header() http.Header { return w.header }
+func (w *discardWriter) WriteHeader(int)     {}
+func (w *discardWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes%5000 == 0 {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		w.peak = max(w.peak, m.HeapInuse)
+	}
+	return len(p), nil
+}
+
+// BenchmarkStreamedListing renders a 20k entry directory with and without
+// streaming and reports the peak heap observed while the body was written.
+// The streamed variant stays flat as the directory grows.
+func BenchmarkStreamedListing(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	h, cleanup := newStreamTestHandler(b, 20000)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		name      string
+		threshold int
+	}{
+		{"sorted", 1 << 30},
+		{"streamed", 1000},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			defer func(old int) { streamListingThreshold = old }(streamListingThreshold)
+			streamListingThreshold = tc.threshold
+
+			var peak uint64
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				w := &discardWriter{header: http.Header{}}
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest("GET", "/", nil)
+				h.ServeFiles(c)
+				peak = max(peak, w.peak)
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"slimserve/internal/storage"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
@@ -149,6 +150,101 @@ func TestThumbnailGeneration(t *testing.T) {
 	}
 }
 
+func TestThumbnailConditionalGet(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			img.Set(x, y, color.RGBA{0, 0, 255, 255})
+		}
+	}
+	file, err := os.Create(filepath.Join(tmpDir, "blue.png"))
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(file, img))
+	file.Close()
+
+	cfg := &config.Config{
+		StoragePath:        tmpDir,
+		StorageType:        "local",
+		DisableDotFiles:    true,
+		ThumbJpegQuality:   85,
+		ThumbMaxFileSizeMB: 20,
+	}
+	root, err := security.NewRootFS(tmpDir)
+	require.NoError(t, err)
+	defer root.Close()
+
+	handler := NewHandler(cfg, storage.NewLocalBackend(root, nil), root)
+	gin.SetMode(gin.TestMode)
+
+	request := func(ifModifiedSince string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/blue.png?thumb=1", nil)
+		if ifModifiedSince != "" {
+			c.Request.Header.Set("If-Modified-Since", ifModifiedSince)
+		}
+		c.Params = gin.Params{{Key: "path", Value: "/blue.png"}}
+		handler.ServeFiles(c)
+		c.Writer.WriteHeaderNow()
+		return w
+	}
+
+	w := request("")
+	require.Equal(t, http.StatusOK, w.Code)
+	lastModified := w.Header().Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatal("Expected Last-Modified header on thumbnail response")
+	}
+
+	w = request(lastModified)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected status 304 for conditional thumbnail request, got %d", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected empty body for 304, got %d bytes", w.Body.Len())
+	}
+}
+
+func TestThumbnailTimeoutFallback(t *testing.T) {
+	t.Setenv("SLIMSERVE_CACHE_DIR", t.TempDir())
+	tmpDir := t.TempDir()
+
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	file, err := os.Create(filepath.Join(tmpDir, "slow.png"))
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(file, img))
+	file.Close()
+	original, err := os.ReadFile(filepath.Join(tmpDir, "slow.png"))
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		StoragePath:        tmpDir,
+		StorageType:        "local",
+		ThumbJpegQuality:   85,
+		ThumbMaxFileSizeMB: 20,
+	}
+	root, err := security.NewRootFS(tmpDir)
+	require.NoError(t, err)
+	defer root.Close()
+
+	handler := NewHandler(cfg, storage.NewLocalBackend(root, nil), root)
+	// No image can be thumbnailed this quickly, so generation always times out
+	handler.thumbTimeout = time.Nanosecond
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/slow.png?thumb=1", nil)
+	c.Params = gin.Params{{Key: "path", Value: "/slow.png"}}
+	handler.ServeFiles(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	require.Equal(t, original, w.Body.Bytes(), "timed out thumbnail should fall back to the original image")
+}
+
 func TestThumbnailURLGeneration(t *testing.T) {
 	tests := []struct {
 		basePath string
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"path/filepath"
+
+	"slimserve/internal/files"
+	"slimserve/internal/fswatch"
+	"slimserve/internal/logger"
+)
+
+// WatchRoots watches the handler's local root, or each mount's, and drops
+// cached data as files change instead of waiting for it to expire. It returns
+// a function that stops every watcher. Roots that cannot be watched are
+// logged and keep relying on expiry.
+func (h *Handler) WatchRoots() (stop func()) {
+	var watchers []*fswatch.Watcher
+	watch := func(target *Handler) {
+		if target.localRoot == nil {
+			return
+		}
+		w, err := fswatch.Watch(target.localRoot.Path(), func(ev fswatch.Event) {
+			target.invalidate(ev)
+			// /recent of a mount handler is served from the parent's cache
+			h.recent.reset()
+		})
+		if err != nil {
+			logger.Log.Warn().Err(err).Str("root", target.localRoot.Path()).Msg("Cannot watch root for changes, cached listing data expires instead")
+			return
+		}
+		watchers = append(watchers, w)
+	}
+
+	if h.mounts != nil {
+		for _, mh := range h.mounts {
+			watch(mh)
+		}
+	} else {
+		watch(h)
+	}
+
+	return func() {
+		for _, w := range watchers {
+			if err := w.Close(); err != nil {
+				logger.Log.Warn().Err(err).Msg("Failed to close file watcher")
+			}
+		}
+	}
+}
+
+// invalidate drops cached data derived from a changed path: folder sizes it
+// counts towards, the /recent walk and, once an image is removed, its
+// thumbnails. Thumbnails of rewritten images are left alone since their
+// cache key already changes with the source.
+func (h *Handler) invalidate(ev fswatch.Event) {
+	relPath := filepath.FromSlash(ev.Path)
+	h.dirSizes.Invalidate(h.localRoot, relPath)
+	h.recent.reset()
+
+	if ev.Removed && files.IsImageFile(relPath) {
+		src := filepath.Join(h.localRoot.Path(), relPath)
+		if _, err := files.InvalidateSource(files.CacheDir(), src); err != nil {
+			logger.Log.Debug().Err(err).Str("path", ev.Path).Msg("Failed to drop thumbnails of removed image")
+		}
+	}
+}
@@ -318,3 +318,46 @@ func TestHandler_HeadRequest_StaticAndDirectory(t *testing.T) {
 		}
 	})
 }
+
+func TestHandler_StaticCachingHeaders(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	const path = "/static/css/theme.css"
+
+	c, w := createTestContext(path, "GET")
+	handler.ServeFiles(c)
+	c.Writer.WriteHeaderNow()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	lastModified := w.Header().Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatal("Expected Last-Modified header on static asset")
+	}
+	if cc := w.Header().Get("Cache-Control"); !strings.Contains(cc, "max-age=") {
+		t.Errorf("Expected Cache-Control with max-age, got %q", cc)
+	}
+
+	c, w = createTestContext(path, "GET")
+	c.Request.Header.Set("If-Modified-Since", lastModified)
+	handler.ServeFiles(c)
+	c.Writer.WriteHeaderNow()
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected status 304 for conditional request, got %d", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected empty body for 304, got %d bytes", w.Body.Len())
+	}
+
+	c, w = createTestContext(path, "GET")
+	c.Request.Header.Set("If-Modified-Since", "Mon, 02 Jan 2006 15:04:05 GMT")
+	handler.ServeFiles(c)
+	c.Writer.WriteHeaderNow()
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for stale If-Modified-Since, got %d", w.Code)
+	}
+}
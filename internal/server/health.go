@@ -0,0 +1,102 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"slimserve/internal/logger"
+	"slimserve/internal/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rootHealth remembers the last known health of each served root so that
+// only changes are logged.
+type rootHealth struct {
+	mu      sync.Mutex
+	healthy map[string]bool
+}
+
+// servedRoots returns the local roots being served keyed by the URL path they
+// are served under: "/" for the storage path and "/<name>" for each mount.
+func (s *Server) servedRoots() map[string]security.FileSystem {
+	roots := make(map[string]security.FileSystem, len(s.mounts)+1)
+	if s.localRoot != nil {
+		roots["/"] = s.localRoot
+	}
+	for _, m := range s.mounts {
+		roots["/"+m.Name] = m.Root
+	}
+	return roots
+}
+
+// checkRoots checks every served root, reopening directories that were
+// recreated, and returns whether each one is reachable. A local storage path
+// that could not be opened at startup is reported as unhealthy.
+func (s *Server) checkRoots() map[string]bool {
+	results := make(map[string]bool)
+	storageDir := s.config.GetStorageDir()
+	if s.localRoot == nil && !storageDir.IsS3() {
+		results["/"] = false
+	}
+
+	errs := make(map[string]error)
+	for name, root := range s.servedRoots() {
+		err := root.Check()
+		results[name] = err == nil
+		errs[name] = err
+	}
+
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	if s.health.healthy == nil {
+		s.health.healthy = make(map[string]bool)
+	}
+	for name, healthy := range results {
+		was, seen := s.health.healthy[name]
+		switch {
+		case !healthy && (was || !seen):
+			logger.Log.Error().Err(errs[name]).Str("root", name).Msg("Served directory is unavailable")
+		case healthy && seen && !was:
+			logger.Log.Info().Str("root", name).Msg("Served directory recovered")
+		}
+		s.health.healthy[name] = healthy
+	}
+	return results
+}
+
+// monitorRoots runs checkRoots every interval until stop is closed
+func (s *Server) monitorRoots(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.checkRoots()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// handleReadyz reports 503 while any served directory is unreachable. It
+// needs no login, so with EnableAuth the per-root health, which names the
+// mounts, is left out.
+func (s *Server) handleReadyz(c *gin.Context) {
+	roots := s.checkRoots()
+
+	status, code := "ready", http.StatusOK
+	for _, healthy := range roots {
+		if !healthy {
+			status, code = "unavailable", http.StatusServiceUnavailable
+			break
+		}
+	}
+	if s.config.EnableAuth {
+		c.JSON(code, gin.H{"status": status})
+		return
+	}
+	c.JSON(code, gin.H{"status": status, "roots": roots})
+}
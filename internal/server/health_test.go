@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRootRecovery(t *testing.T) {
+	servedDir := filepath.Join(t.TempDir(), "served")
+	if err := os.Mkdir(servedDir, 0755); err != nil {
+		t.Fatalf("Failed to create served dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(servedDir, "hello.txt"), []byte("before"), 0644); err != nil {
+		t.Fatalf("Failed to write hello.txt: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     servedDir,
+		StorageType:     "local",
+		DisableDotFiles: true,
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	readyz := func(t *testing.T, wantCode int, wantHealthy bool) {
+		t.Helper()
+		w := get("/readyz")
+		if w.Code != wantCode {
+			t.Fatalf("Expected /readyz status %d, got %d", wantCode, w.Code)
+		}
+		var body struct {
+			Status string          `json:"status"`
+			Roots  map[string]bool `json:"roots"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode /readyz response: %v", err)
+		}
+		if healthy, ok := body.Roots["/"]; !ok || healthy != wantHealthy {
+			t.Errorf("Expected root / healthy=%v, got %v (present=%v)", wantHealthy, healthy, ok)
+		}
+	}
+
+	readyz(t, http.StatusOK, true)
+
+	if err := os.RemoveAll(servedDir); err != nil {
+		t.Fatalf("Failed to remove served dir: %v", err)
+	}
+	readyz(t, http.StatusServiceUnavailable, false)
+
+	if err := os.Mkdir(servedDir, 0755); err != nil {
+		t.Fatalf("Failed to recreate served dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(servedDir, "hello.txt"), []byte("after"), 0644); err != nil {
+		t.Fatalf("Failed to write hello.txt: %v", err)
+	}
+	readyz(t, http.StatusOK, true)
+
+	// The root now points at the recreated directory
+	w := get("/hello.txt")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 after recovery, got %d", w.Code)
+	}
+	if w.Body.String() != "after" {
+		t.Errorf("Expected recreated file content, got %q", w.Body.String())
+	}
+}
+
+func TestReadyzWithAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:        "localhost",
+		Port:        8080,
+		StoragePath: t.TempDir(),
+		StorageType: "local",
+		EnableAuth:  true,
+		Username:    "user",
+		Password:    "secret123",
+	})
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected /readyz status 200 without a login, got %d", w.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode /readyz response: %v", err)
+	}
+	if body["status"] != "ready" {
+		t.Errorf("Expected status ready, got %v", body["status"])
+	}
+	if _, ok := body["roots"]; ok {
+		t.Errorf("Expected no per-root health with auth enabled, got %v", body["roots"])
+	}
+}
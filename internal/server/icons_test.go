@@ -0,0 +1,60 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"slimserve/internal/config"
+	"slimserve/internal/server/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIconOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"book.EPUB", "notes.md", "bundle.zip", "data.bin", "clip.prop"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte("content"), 0644))
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:        "localhost",
+		Port:        8080,
+		StoragePath: tmpDir,
+		StorageType: "local",
+		MimeOverrides: map[string]string{
+			".prop": "video/x-prop",
+		},
+		IconOverrides: map[string]string{
+			"epub":  "file-text",
+			".md":   "file-pdf",
+			".prop": "archive",
+		},
+	})
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/?format=json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var data handler.ListingData
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &data))
+	icons := make(map[string]string)
+	types := make(map[string]string)
+	for _, f := range data.Files {
+		icons[f.Name] = f.Icon
+		types[f.Name] = f.Type
+	}
+
+	assert.Equal(t, "file-text", icons["book.EPUB"], "custom extensions map to an existing icon, case-insensitively")
+	assert.Equal(t, "file-pdf", icons["notes.md"], "overrides win over the built-in table")
+	assert.Equal(t, "archive", icons["clip.prop"], "overrides win over MIME overrides")
+	assert.Equal(t, "video", types["clip.prop"], "the type filter still follows the MIME type")
+	assert.Equal(t, "archive", icons["bundle.zip"], "other extensions keep their icons")
+	assert.Equal(t, "file", icons["data.bin"])
+}
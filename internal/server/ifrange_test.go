@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIfRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// rangeGet requests the first four bytes of path under an If-Range validator
+	rangeGet := func(srv *Server, path, ifRange string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Range", "bytes=0-3")
+		req.Header.Set("If-Range", ifRange)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	expectRange := func(t *testing.T, w *httptest.ResponseRecorder) {
+		t.Helper()
+		if w.Code != http.StatusPartialContent {
+			t.Fatalf("Expected status 206 for a fresh validator, got %d", w.Code)
+		}
+		if w.Body.Len() != 4 {
+			t.Errorf("Expected 4 bytes, got %d", w.Body.Len())
+		}
+	}
+	expectFull := func(t *testing.T, w *httptest.ResponseRecorder, fullLen int) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for a stale validator, got %d", w.Code)
+		}
+		if w.Body.Len() != fullLen {
+			t.Errorf("Expected the full %d bytes, got %d", fullLen, w.Body.Len())
+		}
+	}
+
+	t.Run("served_file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		path := filepath.Join(tmpDir, "data.bin")
+		if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+			t.Fatalf("Failed to write data.bin: %v", err)
+		}
+		old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatalf("Failed to set mtime: %v", err)
+		}
+
+		srv := New(&config.Config{
+			Host:            "localhost",
+			Port:            8080,
+			StoragePath:     tmpDir,
+			StorageType:     "local",
+			DisableDotFiles: true,
+		})
+
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/data.bin", nil))
+		etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+		if etag == "" || lastModified == "" {
+			t.Fatalf("Expected ETag and Last-Modified, got %q and %q", etag, lastModified)
+		}
+
+		expectRange(t, rangeGet(srv, "/data.bin", etag))
+		expectRange(t, rangeGet(srv, "/data.bin", lastModified))
+
+		// Rewrite the file: both validators are now stale
+		if err := os.WriteFile(path, []byte("abcdefghijkl"), 0644); err != nil {
+			t.Fatalf("Failed to rewrite data.bin: %v", err)
+		}
+		expectFull(t, rangeGet(srv, "/data.bin", etag), 12)
+		expectFull(t, rangeGet(srv, "/data.bin", lastModified), 12)
+	})
+
+	t.Run("thumbnail", func(t *testing.T) {
+		srv := newThumbnailServer(t, &config.Config{})
+
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/photo.png?thumb=1", nil))
+		etag, size := w.Header().Get("ETag"), w.Body.Len()
+		if etag == "" {
+			t.Fatal("Expected a thumbnail ETag")
+		}
+
+		expectRange(t, rangeGet(srv, "/photo.png?thumb=1", etag))
+		expectFull(t, rangeGet(srv, "/photo.png?thumb=1", `"stale"`), size)
+	})
+
+	t.Run("static_asset", func(t *testing.T) {
+		srv := New(&config.Config{
+			Host:        "localhost",
+			Port:        8080,
+			StoragePath: t.TempDir(),
+			StorageType: "local",
+		})
+
+		const asset = "/static/css/theme.css"
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", asset, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d", asset, w.Code)
+		}
+		lastModified, size := w.Header().Get("Last-Modified"), w.Body.Len()
+
+		expectRange(t, rangeGet(srv, asset, lastModified))
+		expectFull(t, rangeGet(srv, asset, "Mon, 01 Jan 2001 00:00:00 GMT"), size)
+	})
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNestedIgnoreFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		".slimserveignore":         "*.tmp\n",
+		"root.txt":                 "root",
+		"drafts.txt":               "drafts at root are visible",
+		"docs/.slimserveignore":    "drafts.txt\n",
+		"docs/drafts.txt":          "hidden by docs ignore file",
+		"docs/readme.txt":          "visible",
+		"docs/scratch.tmp":         "hidden by root ignore file",
+		"docs/deep/drafts.txt":     "hidden by docs ignore file",
+		"docs/deep/notes.txt":      "visible",
+		"other/drafts.txt":         "not covered by docs rules",
+		"other/.slimserveignore":   "",
+		"other/nested/keep.txt":    "visible",
+		"other/nested/scratch.tmp": "hidden by root ignore file",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	cfg := &config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     tmpDir,
+		StorageType:     "local",
+		DisableDotFiles: true,
+	}
+	srv := New(cfg)
+	gin.SetMode(gin.TestMode)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("direct_access", func(t *testing.T) {
+		tests := []struct {
+			path           string
+			expectedStatus int
+		}{
+			{"/root.txt", http.StatusOK},
+			{"/drafts.txt", http.StatusOK},
+			{"/docs/readme.txt", http.StatusOK},
+			{"/docs/drafts.txt", http.StatusForbidden},
+			{"/docs/scratch.tmp", http.StatusForbidden},
+			{"/docs/deep/drafts.txt", http.StatusForbidden},
+			{"/docs/deep/notes.txt", http.StatusOK},
+			{"/other/drafts.txt", http.StatusOK},
+			{"/other/nested/keep.txt", http.StatusOK},
+			{"/other/nested/scratch.tmp", http.StatusForbidden},
+		}
+
+		for _, tt := range tests {
+			if w := get(tt.path); w.Code != tt.expectedStatus {
+				t.Errorf("GET %s: expected status %d, got %d", tt.path, tt.expectedStatus, w.Code)
+			}
+		}
+	})
+
+	t.Run("listing", func(t *testing.T) {
+		tests := []struct {
+			path    string
+			visible []string
+			hidden  []string
+		}{
+			{"/", []string{"root.txt", "drafts.txt", "docs", "other"}, []string{".slimserveignore"}},
+			{"/docs", []string{"readme.txt", "deep"}, []string{"drafts.txt", "scratch.tmp"}},
+			{"/docs/deep", []string{"notes.txt"}, []string{"drafts.txt"}},
+			{"/other/nested", []string{"keep.txt"}, []string{"scratch.tmp"}},
+		}
+
+		for _, tt := range tests {
+			w := get(tt.path)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s: expected status 200, got %d", tt.path, w.Code)
+			}
+			body := w.Body.String()
+			for _, name := range tt.visible {
+				if !strings.Contains(body, name) {
+					t.Errorf("Listing of %s should contain %s", tt.path, name)
+				}
+			}
+			for _, name := range tt.hidden {
+				if strings.Contains(body, name) {
+					t.Errorf("Listing of %s should not contain %s", tt.path, name)
+				}
+			}
+		}
+	})
+}
+
+func TestIgnoreNegationPatterns(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"app.log":               "ignored",
+		"important.log":         "re-included by config",
+		"server.logger":         "not a .log file",
+		"logs/debug.log":        "ignored",
+		"logs/important.log":    "re-included by config",
+		"logs/.slimserveignore": "!debug.log\n",
+		"keep/.slimserveignore": "important.log\n",
+		"keep/important.log":    "ignored again by a later file rule",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	cfg := &config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     tmpDir,
+		StorageType:     "local",
+		DisableDotFiles: true,
+		IgnorePatterns:  []string{"*.log", "!important.log"},
+	}
+	srv := New(cfg)
+	gin.SetMode(gin.TestMode)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{"/app.log", http.StatusForbidden},
+		{"/important.log", http.StatusOK},
+		{"/server.logger", http.StatusOK},
+		{"/logs/important.log", http.StatusOK},
+		{"/logs/debug.log", http.StatusOK},
+		{"/keep/important.log", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if w := get(tt.path); w.Code != tt.expectedStatus {
+			t.Errorf("GET %s: expected status %d, got %d", tt.path, tt.expectedStatus, w.Code)
+		}
+	}
+
+	w := get("/")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /: expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "important.log") {
+		t.Error("Listing should contain re-included important.log")
+	}
+	if !strings.Contains(body, "server.logger") {
+		t.Error("Listing should contain server.logger")
+	}
+	if strings.Contains(body, "app.log") {
+		t.Error("Listing should not contain ignored app.log")
+	}
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServeIndexHTML(t *testing.T) {
+	const indexContent = "<html><body>custom index page</body></html>"
+
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"index.html":           indexContent,
+		"site/index.html":      indexContent,
+		"site/about.txt":       "about",
+		"hidden/index.html":    indexContent,
+		"hidden/notes.txt":     "notes",
+		"plain/readme.txt":     "no index here",
+		".slimserveignore":     "hidden/index.html\n",
+		"dir/index.html/a.txt": "index.html is a directory here",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	newServer := func(serveIndex bool) *Server {
+		return New(&config.Config{
+			Host:            "localhost",
+			Port:            8080,
+			StoragePath:     tmpDir,
+			StorageType:     "local",
+			DisableDotFiles: true,
+			ServeIndexHTML:  serveIndex,
+		})
+	}
+
+	get := func(srv *Server, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		srv := newServer(true)
+
+		for _, path := range []string{"/", "/site"} {
+			w := get(srv, path)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s: expected status 200, got %d", path, w.Code)
+			}
+			if w.Body.String() != indexContent {
+				t.Errorf("GET %s: expected index.html content, got: %s", path, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+				t.Errorf("GET %s: expected text/html content type, got %q", path, ct)
+			}
+		}
+
+		tests := []struct {
+			path     string
+			contains string
+		}{
+			{"/plain", "readme.txt"},
+			{"/hidden", "notes.txt"},
+			{"/dir", "index.html"},
+		}
+		for _, tt := range tests {
+			w := get(srv, tt.path)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s: expected status 200, got %d", tt.path, w.Code)
+			}
+			body := w.Body.String()
+			if strings.Contains(body, "custom index page") {
+				t.Errorf("GET %s: expected listing, got index.html content", tt.path)
+			}
+			if !strings.Contains(body, tt.contains) {
+				t.Errorf("GET %s: expected listing to contain %q", tt.path, tt.contains)
+			}
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		srv := newServer(false)
+
+		w := get(srv, "/site")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /site: expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		if strings.Contains(body, "custom index page") {
+			t.Error("Expected listing when ServeIndexHTML is off, got index.html content")
+		}
+		if !strings.Contains(body, "about.txt") || !strings.Contains(body, "index.html") {
+			t.Error("Expected listing to show about.txt and index.html")
+		}
+	})
+}
+
+func TestIndexFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"legacy/default.html":      "default page",
+		"both/index.htm":           "htm page",
+		"both/default.html":        "default page",
+		"ignored/index.htm":        "ignored page",
+		"ignored/default.html":     "fallback page",
+		"ignored/.slimserveignore": "index.htm\n",
+		"plain/readme.txt":         "no index here",
+		"classic/index.html":       "classic page",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     tmpDir,
+		StorageType:     "local",
+		DisableDotFiles: true,
+		ServeIndexHTML:  true,
+		IndexFiles:      []string{"index.htm", "default.html"},
+	})
+
+	tests := []struct {
+		path     string
+		wantBody string
+	}{
+		{"/legacy", "default page"},
+		{"/both", "htm page"},
+		{"/ignored", "fallback page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("Expected %q, got %q", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+
+	// index.html is not in the list, so these directories fall back to the listing
+	for path, entry := range map[string]string{"/plain": "readme.txt", "/classic": "index.html"} {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), entry) || !strings.Contains(w.Body.String(), "<html") {
+				t.Errorf("Expected a listing containing %s, got: %s", entry, w.Body.String())
+			}
+		})
+	}
+}
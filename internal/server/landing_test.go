@@ -0,0 +1,108 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLandingPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	landing := filepath.Join(t.TempDir(), "landing.html")
+	page := `<h1>Welcome</h1>{{range .Links}}<a href="{{.URL}}">{{.Name}}</a>{{end}}`
+	if err := os.WriteFile(landing, []byte(page), 0644); err != nil {
+		t.Fatalf("Failed to write landing page: %v", err)
+	}
+
+	newRoot := func(t *testing.T) string {
+		t.Helper()
+		dir := t.TempDir()
+		if err := os.Mkdir(filepath.Join(dir, "photos"), 0755); err != nil {
+			t.Fatalf("Failed to create photos: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("hi"), 0644); err != nil {
+			t.Fatalf("Failed to write readme.txt: %v", err)
+		}
+		return dir
+	}
+	get := func(srv *Server, target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("configured", func(t *testing.T) {
+		srv := New(&config.Config{
+			Host:        "localhost",
+			Port:        8080,
+			StoragePath: newRoot(t),
+			StorageType: "local",
+			LandingPage: landing,
+		})
+
+		w := get(srv, "/", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, "<h1>Welcome</h1>") || !strings.Contains(body, `<a href="/photos">photos</a>`) {
+			t.Errorf("Expected the landing page linking photos, got %q", body)
+		}
+		if strings.Contains(body, "readme.txt") {
+			t.Error("Expected only folders to be linked from the landing page")
+		}
+
+		if w := get(srv, "/photos/", ""); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Welcome") {
+			t.Errorf("Expected subdirectories to keep their listing, got %d", w.Code)
+		}
+		if w := get(srv, "/", "application/json"); !strings.Contains(w.Body.String(), `"readme.txt"`) {
+			t.Errorf("Expected JSON clients to get the root listing, got %q", w.Body.String())
+		}
+	})
+
+	t.Run("mounts", func(t *testing.T) {
+		srv := New(&config.Config{
+			Host:        "localhost",
+			Port:        8080,
+			StoragePath: newRoot(t),
+			StorageType: "local",
+			Mounts:      map[string]string{"music": newRoot(t), "docs": newRoot(t)},
+			LandingPage: landing,
+		})
+
+		body := get(srv, "/", "").Body.String()
+		docs, music := strings.Index(body, `<a href="/docs">docs</a>`), strings.Index(body, `<a href="/music">music</a>`)
+		if docs < 0 || music < docs {
+			t.Errorf("Expected links to docs then music, got %q", body)
+		}
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		srv := New(&config.Config{
+			Host:        "localhost",
+			Port:        8080,
+			StoragePath: newRoot(t),
+			StorageType: "local",
+		})
+
+		w := get(srv, "/", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if body := w.Body.String(); strings.Contains(body, "Welcome") || !strings.Contains(body, "readme.txt") {
+			t.Error("Expected the directory listing at / without a landing page")
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+)
+
+// freeAddr returns a loopback address that was free a moment ago.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Failed to get available port:", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestRunMultipleAddresses(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "hello.txt"), []byte("hello from both"), 0644); err != nil {
+		t.Fatalf("Failed to write hello.txt: %v", err)
+	}
+
+	addrs := []string{freeAddr(t), freeAddr(t)}
+	srv := New(&config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		ListenAddrs:     addrs,
+		StoragePath:     tmpDir,
+		StorageType:     "local",
+		DisableDotFiles: true,
+	})
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- srv.Run()
+	}()
+
+	for _, addr := range addrs {
+		url := fmt.Sprintf("http://%s/hello.txt", addr)
+		var resp *http.Response
+		var err error
+		for i := 0; i < 50; i++ {
+			if resp, err = http.Get(url); err == nil {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", url, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "hello from both" {
+			t.Errorf("GET %s: expected 200 with the file, got %d %q", url, resp.StatusCode, body)
+		}
+	}
+
+	if err := srv.GracefulShutdown(context.Background()); err != nil {
+		t.Fatalf("GracefulShutdown failed: %v", err)
+	}
+	select {
+	case err := <-runErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("Expected Run to return ErrServerClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after shutdown")
+	}
+
+	for _, addr := range addrs {
+		if _, err := http.Get(fmt.Sprintf("http://%s/hello.txt", addr)); err == nil {
+			t.Errorf("Expected %s to be closed after shutdown", addr)
+		}
+	}
+}
+
+func TestRunAddressInUse(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Failed to get available port:", err)
+	}
+	defer busy.Close()
+
+	free := freeAddr(t)
+	srv := New(&config.Config{
+		Host:        "localhost",
+		Port:        8080,
+		StoragePath: t.TempDir(),
+		StorageType: "local",
+	})
+
+	if err := srv.Run(free, busy.Addr().String()); err == nil {
+		t.Fatal("Expected Run to fail when an address is in use")
+	}
+
+	// The address bound before the failure must have been released
+	ln, err := net.Listen("tcp", free)
+	if err != nil {
+		t.Errorf("Expected %s to be released, got %v", free, err)
+	} else {
+		ln.Close()
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListingETag(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to write a.txt: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     tmpDir,
+		StorageType:     "local",
+		DisableDotFiles: true,
+	})
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag on the listing")
+	}
+
+	t.Run("unchanged_directory_not_modified", func(t *testing.T) {
+		w := get(etag)
+		if w.Code != http.StatusNotModified {
+			t.Fatalf("Expected status 304, got %d", w.Code)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("Expected empty body on 304, got %d bytes", w.Body.Len())
+		}
+		if csp := w.Header().Get("Content-Security-Policy"); csp != "" {
+			t.Errorf("Expected no new CSP nonce on 304, got %q", csp)
+		}
+	})
+
+	t.Run("mismatched_etag_renders", func(t *testing.T) {
+		if w := get(`W/"stale"`); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for a stale ETag, got %d", w.Code)
+		}
+	})
+
+	t.Run("new_file_changes_etag", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(tmpDir, "b.txt"), []byte("b"), 0644); err != nil {
+			t.Fatalf("Failed to write b.txt: %v", err)
+		}
+		w := get(etag)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 after adding a file, got %d", w.Code)
+		}
+		if got := w.Header().Get("ETag"); got == "" || got == etag {
+			t.Errorf("Expected a new ETag after adding a file, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListingTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create docs: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "notes.txt"), []byte("hi"), 0644); err != nil {
+		t.Fatalf("Failed to write notes.txt: %v", err)
+	}
+
+	writeTemplate := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "listing.html")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write listing template: %v", err)
+		}
+		return path
+	}
+	newServer := func(templatePath string) *Server {
+		return New(&config.Config{
+			Host:                "localhost",
+			Port:                8080,
+			StoragePath:         root,
+			StorageType:         "local",
+			ListingTemplatePath: templatePath,
+		})
+	}
+	get := func(srv *Server, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	t.Run("custom", func(t *testing.T) {
+		tmpl := writeTemplate(t, `<p>CUSTOM-LISTING-MARKER {{.CurrentPath}}</p><ul>{{range .Items}}<li>{{.Name}}</li>{{end}}</ul>`)
+		w := get(newServer(tmpl), "/docs/")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, "CUSTOM-LISTING-MARKER /docs") {
+			t.Errorf("Expected the custom template's marker, got %q", body)
+		}
+		if !strings.Contains(body, "<li>notes.txt</li>") {
+			t.Errorf("Expected the directory's files in the custom listing, got %q", body)
+		}
+	})
+
+	t.Run("custom_with_base", func(t *testing.T) {
+		tmpl := writeTemplate(t, `{{define "listing.html"}}{{template "base" .}}{{end}}{{define "content"}}<p>BASE-LISTING-MARKER</p>{{end}}`)
+		w := get(newServer(tmpl), "/docs/")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, "BASE-LISTING-MARKER") || !strings.Contains(body, "<!DOCTYPE html>") {
+			t.Errorf("Expected the marker inside the built-in base page, got %q", body)
+		}
+	})
+
+	t.Run("broken_template_fails_validation", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.StoragePath = root
+		cfg.ListingTemplatePath = writeTemplate(t, `{{range .Items}}`)
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), "listing_template_path") {
+			t.Errorf("Expected a listing_template_path error, got %v", err)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		w := get(newServer(""), "/docs/")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if strings.Contains(w.Body.String(), "MARKER") {
+			t.Error("Expected the built-in listing without a custom template")
+		}
+	})
+}
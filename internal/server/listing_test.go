@@ -0,0 +1,146 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDisableListing(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"readme.txt":      "top level",
+		"docs/guide.txt":  "guide",
+		"site/index.html": "<html>site</html>",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     tmpDir,
+		StorageType:     "local",
+		DisableDotFiles: true,
+		DisableListing:  true,
+		ServeIndexHTML:  true,
+	})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/readme.txt", http.StatusOK, "top level"},
+		{"/docs/guide.txt", http.StatusOK, "guide"},
+		{"/site", http.StatusOK, "<html>site</html>"},
+		{"/", http.StatusForbidden, ""},
+		{"/docs", http.StatusForbidden, ""},
+		{"/docs/", http.StatusForbidden, ""},
+		{"/recent", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListingDefaultSort(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	for i, entry := range []struct {
+		name string
+		size int
+	}{
+		{"b.txt", 30},
+		{"c.txt", 10},
+		{"a.txt", 20},
+	} {
+		path := filepath.Join(tmpDir, entry.name)
+		if err := os.WriteFile(path, make([]byte, entry.size), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", entry.name, err)
+		}
+		modTime := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set mod time for %s: %v", entry.name, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "zdir"), 0755); err != nil {
+		t.Fatalf("Failed to create zdir: %v", err)
+	}
+	if err := os.Chtimes(filepath.Join(tmpDir, "zdir"), base.Add(-time.Hour), base.Add(-time.Hour)); err != nil {
+		t.Fatalf("Failed to set mod time for zdir: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	names := func(t *testing.T, cfg *config.Config, query string) []string {
+		t.Helper()
+		cfg.Host, cfg.Port, cfg.StoragePath, cfg.StorageType = "localhost", 8080, tmpDir, "local"
+		w := httptest.NewRecorder()
+		New(cfg).ServeHTTP(w, httptest.NewRequest("GET", "/?format=json"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+		var listing struct {
+			Files []struct {
+				Name string `json:"name"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &listing); err != nil {
+			t.Fatalf("Failed to decode listing: %v", err)
+		}
+		var got []string
+		for _, f := range listing.Files {
+			got = append(got, f.Name)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name  string
+		cfg   config.Config
+		query string
+		want  []string
+	}{
+		{"defaults", config.Config{GroupFolders: true}, "", []string{"zdir", "a.txt", "b.txt", "c.txt"}},
+		{"modified_desc", config.Config{DefaultSort: config.SortModified, DefaultOrder: config.OrderDesc, GroupFolders: true}, "", []string{"zdir", "a.txt", "c.txt", "b.txt"}},
+		{"modified_desc_ungrouped", config.Config{DefaultSort: config.SortModified, DefaultOrder: config.OrderDesc}, "", []string{"a.txt", "c.txt", "b.txt", "zdir"}},
+		{"size_asc", config.Config{DefaultSort: config.SortSize, GroupFolders: true}, "", []string{"zdir", "c.txt", "a.txt", "b.txt"}},
+		{"query_overrides_default", config.Config{DefaultSort: config.SortModified, DefaultOrder: config.OrderDesc, GroupFolders: true}, "&sort=name&order=asc", []string{"zdir", "a.txt", "b.txt", "c.txt"}},
+		{"query_sort_keeps_default_order", config.Config{DefaultOrder: config.OrderDesc, GroupFolders: true}, "&sort=size", []string{"zdir", "b.txt", "a.txt", "c.txt"}},
+		{"unknown_query_ignored", config.Config{DefaultSort: config.SortSize, GroupFolders: true}, "&sort=owner&order=sideways", []string{"zdir", "c.txt", "a.txt", "b.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if got := names(t, &cfg, tt.query); !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"slimserve/internal/server/apierror"
 	"slimserve/internal/server/auth"
 
 	"github.com/gin-gonic/gin"
@@ -12,7 +13,7 @@ import (
 
 func (s *Server) showLogin(c *gin.Context) {
 	next := validateRedirectURL(c.DefaultQuery("next", "/"))
-	data := s.addVersionToTemplateData(gin.H{"next": next})
+	data := s.templateData(c, gin.H{"next": next})
 	if errMsg := c.Query("error"); errMsg != "" {
 		data["error"] = errMsg
 	}
@@ -34,7 +35,7 @@ func (s *Server) doLogin(c *gin.Context) {
 			Next     string `json:"next"`
 		}
 		if err := c.ShouldBindJSON(&jsonData); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request format"})
+			apierror.Write(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request format"))
 			return
 		}
 		username, password, next = jsonData.Username, jsonData.Password, jsonData.Next
@@ -46,11 +47,11 @@ func (s *Server) doLogin(c *gin.Context) {
 
 	if !s.validateCredentials(username, password) {
 		if strings.Contains(c.GetHeader("Accept"), "application/json") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			apierror.Write(c, apierror.New(http.StatusUnauthorized, apierror.CodeInvalidCredentials, "invalid credentials"))
 			return
 		}
 		c.Status(http.StatusOK)
-		if err := s.loginTmpl.ExecuteTemplate(c.Writer, "base", gin.H{"error": "Invalid username or password", "next": next}); err != nil {
+		if err := s.loginTmpl.ExecuteTemplate(c.Writer, "base", s.templateData(c, gin.H{"error": "Invalid username or password", "next": next})); err != nil {
 			http.Error(c.Writer, "failed to render login page", http.StatusInternalServerError)
 		}
 		return
@@ -59,13 +60,12 @@ func (s *Server) doLogin(c *gin.Context) {
 	token := s.sessionStore.NewToken()
 	s.sessionStore.Add(token)
 
-	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie("slimserve_session", token, 0, "/", "", c.Request.TLS != nil, true)
+	s.setCookie(c, s.config.SessionCookieName(), token, 0, s.url("/"))
 
 	if strings.Contains(contentType, "application/json") {
-		c.JSON(http.StatusOK, gin.H{"success": true, "redirect": next})
+		c.JSON(http.StatusOK, gin.H{"success": true, "redirect": s.url(next)})
 	} else {
-		c.Redirect(http.StatusFound, next)
+		c.Redirect(http.StatusFound, s.url(next))
 	}
 }
 
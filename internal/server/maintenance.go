@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"slimserve/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// underMaintenance answers path with the maintenance page and reports true
+// while MaintenanceMode is on. Static assets stay reachable so the page is
+// styled, and /readyz so load balancers keep routing to the admin interface.
+func (s *Server) underMaintenance(c *gin.Context, path string) bool {
+	if !s.config.MaintenanceMode {
+		return false
+	}
+	if strings.HasPrefix(path, "/static/") || path == "/favicon.ico" || path == "/readyz" {
+		return false
+	}
+
+	data := s.templateData(c, gin.H{"Title": "Maintenance", "message": s.config.MaintenanceMessage})
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusServiceUnavailable)
+	if c.Request.Method != http.MethodHead {
+		if err := s.maintenanceTmpl.ExecuteTemplate(c.Writer, "base", data); err != nil {
+			logger.Ctx(c.Request.Context()).Error().Err(err).Msg("Failed to render maintenance page")
+		}
+	}
+	c.Abort()
+	return true
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "hello.txt"), []byte("hello"), 0644))
+
+	srv := New(&config.Config{
+		Host:               "localhost",
+		Port:               8080,
+		StoragePath:        tmpDir,
+		StorageType:        "local",
+		EnableAdmin:        true,
+		AdminUsername:      "admin",
+		AdminPassword:      "password123",
+		MaintenanceMode:    true,
+		MaintenanceMessage: "Back at <noon>",
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("public_routes_get_503_page", func(t *testing.T) {
+		for _, path := range []string{"/", "/hello.txt", "/?format=json", "/version"} {
+			w := get(path)
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code, path)
+			assert.Contains(t, w.Body.String(), "Down for maintenance", path)
+			assert.Contains(t, w.Body.String(), "Back at &lt;noon&gt;", "the message is escaped")
+			assert.NotContains(t, w.Body.String(), "hello", path)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"), path)
+		}
+	})
+
+	t.Run("static_and_readiness_stay_up", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get("/static/css/theme.css").Code)
+		assert.Equal(t, http.StatusOK, get("/readyz").Code)
+	})
+
+	var session string
+	t.Run("admin_login_still_works", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get("/admin/login").Code)
+
+		form := url.Values{"username": {"admin"}, "password": {"password123"}}
+		req := httptest.NewRequest("POST", "/admin/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+		session = extractAdminCookie(w, "slimserve_admin_session")
+		require.NotEmpty(t, session)
+	})
+
+	t.Run("turning_off_restores_serving", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/admin/api/config", strings.NewReader(`{"maintenance_mode": false}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-CSRF-Token", "maintenance-csrf")
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: session})
+		req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: "maintenance-csrf"})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, config.SourceAdmin, srv.config.Source("MaintenanceMode"))
+
+		w = get("/hello.txt")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "hello", w.Body.String())
+		assert.Equal(t, http.StatusOK, get("/").Code)
+	})
+}
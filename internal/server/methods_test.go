@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRouteMethods(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("content"), 0644))
+	newServer := func(enableAuth bool) *Server {
+		return New(&config.Config{
+			Host:        "localhost",
+			Port:        8080,
+			StoragePath: tmpDir,
+			StorageType: "local",
+			EnableAuth:  enableAuth,
+			Username:    "user",
+			Password:    "pass",
+		})
+	}
+	srv := newServer(false)
+
+	tests := []struct {
+		method     string
+		target     string
+		wantStatus int
+		wantAllow  string
+	}{
+		{"GET", "/file.txt", http.StatusOK, ""},
+		{"HEAD", "/file.txt", http.StatusOK, ""},
+		{"PATCH", "/file.txt", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS"},
+		{"DELETE", "/", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS"},
+		{"POST", "/version", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS"},
+		{"PUT", "/static/css/theme.css", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS"},
+		{"OPTIONS", "/file.txt", http.StatusNoContent, "GET, HEAD, OPTIONS"},
+		{"PROPFIND", "/file.txt", http.StatusNotImplemented, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantAllow, w.Header().Get("Allow"))
+			if tt.wantStatus != http.StatusOK {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("login", func(t *testing.T) {
+		srv := newServer(true)
+
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("PATCH", "/login", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD, POST, OPTIONS", w.Header().Get("Allow"))
+
+		w = httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("POST", "/login", nil))
+		assert.NotEqual(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
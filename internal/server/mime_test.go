@@ -0,0 +1,131 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMimeOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"notes.md":   "# Notes",
+		"scene.PROP": "proprietary data",
+		"readme.txt": "plain text",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     tmpDir,
+		StorageType:     "local",
+		DisableDotFiles: true,
+		MimeOverrides: map[string]string{
+			".md":  "text/markdown; charset=utf-8",
+			"prop": "video/x-prop",
+		},
+	})
+
+	t.Run("content_type", func(t *testing.T) {
+		tests := []struct {
+			path     string
+			expected string
+		}{
+			{"/notes.md", "text/markdown; charset=utf-8"},
+			{"/scene.PROP", "video/x-prop"},
+			{"/readme.txt", "text/plain; charset=utf-8"},
+		}
+
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s: expected status 200, got %d", tt.path, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.expected {
+				t.Errorf("GET %s: expected Content-Type %q, got %q", tt.path, tt.expected, ct)
+			}
+		}
+	})
+
+	t.Run("listing_type", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		// scene.PROP is the only video in the directory, so the video filter
+		// and icon can only come from the override.
+		body := w.Body.String()
+		if !strings.Contains(body, "filter === 'video'") {
+			t.Error("Expected overridden extension to be listed as a video")
+		}
+		if !strings.Contains(body, "sprite.svg#film") {
+			t.Error("Expected overridden extension to use the video icon")
+		}
+	})
+}
+
+func TestSniffContentType(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "This file has no extension but is plainly text.\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "README"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write README: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     tmpDir,
+		StorageType:     "local",
+		DisableDotFiles: true,
+	})
+
+	t.Run("full", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/README", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+			t.Errorf("Expected text/plain content type, got %q", ct)
+		}
+		if w.Body.String() != content {
+			t.Errorf("Expected full body after sniffing, got %q", w.Body.String())
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/README", nil)
+		req.Header.Set("Range", "bytes=0-3")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusPartialContent {
+			t.Fatalf("Expected status 206, got %d", w.Code)
+		}
+		if w.Body.String() != "This" {
+			t.Errorf("Expected range body %q, got %q", "This", w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+			t.Errorf("Expected text/plain content type, got %q", ct)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMounts(t *testing.T) {
+	photosDir := t.TempDir()
+	docsDir := t.TempDir()
+	// The same relative path exists in both mounts; each must resolve to its own
+	if err := os.WriteFile(filepath.Join(photosDir, "readme.txt"), []byte("from photos"), 0644); err != nil {
+		t.Fatalf("Failed to write photos/readme.txt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(docsDir, "readme.txt"), []byte("from docs"), 0644); err != nil {
+		t.Fatalf("Failed to write docs/readme.txt: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(docsDir, "guides"), 0755); err != nil {
+		t.Fatalf("Failed to create docs/guides: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     t.TempDir(),
+		StorageType:     "local",
+		DisableDotFiles: true,
+		Mounts:          map[string]string{"photos": photosDir, "docs": docsDir},
+	})
+	defer srv.Shutdown(t.Context())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("each_mount_serves_its_directory", func(t *testing.T) {
+		for path, want := range map[string]string{
+			"/photos/readme.txt": "from photos",
+			"/docs/readme.txt":   "from docs",
+		} {
+			w := get(path)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s: expected status 200, got %d", path, w.Code)
+			}
+			if w.Body.String() != want {
+				t.Errorf("GET %s: expected %q, got %q", path, want, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("root_lists_mounts", func(t *testing.T) {
+		w := get("/")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		for _, href := range []string{`href="/docs"`, `href="/photos"`} {
+			if !strings.Contains(w.Body.String(), href) {
+				t.Errorf("Expected root listing to contain %s", href)
+			}
+		}
+	})
+
+	t.Run("mount_listing_links_stay_inside_mount", func(t *testing.T) {
+		w := get("/docs/")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, `href="/docs/readme.txt"`) || !strings.Contains(body, `href="/docs/guides"`) {
+			t.Errorf("Expected listing links under /docs, got %s", body)
+		}
+	})
+
+	t.Run("unknown_mount", func(t *testing.T) {
+		if w := get("/music/readme.txt"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
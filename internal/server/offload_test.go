@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestXAccelRedirect(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "videos"), 0755); err != nil {
+		t.Fatalf("Failed to create videos: %v", err)
+	}
+	const content = "large file contents"
+	if err := os.WriteFile(filepath.Join(tmpDir, "videos", "big movie.mp4"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	newServer := func(prefix string) *Server {
+		return New(&config.Config{
+			Host:           "localhost",
+			Port:           8080,
+			StoragePath:    tmpDir,
+			StorageType:    "local",
+			TrustedProxies: []string{"10.0.0.0/8"},
+			XAccelRedirect: prefix,
+		})
+	}
+	get := func(srv *Server, peer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/videos/big%20movie.mp4", nil)
+		req.RemoteAddr = peer + ":40000"
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("enabled_trusted_proxy", func(t *testing.T) {
+		w := get(newServer("/internal/"), "10.1.2.3")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if got, want := w.Header().Get("X-Accel-Redirect"), "/internal/videos/big%20movie.mp4"; got != want {
+			t.Errorf("Expected X-Accel-Redirect %q, got %q", want, got)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("Expected an empty body, got %q", w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "video/mp4" {
+			t.Errorf("Expected Content-Type video/mp4, got %q", ct)
+		}
+	})
+
+	streamed := func(t *testing.T, w *httptest.ResponseRecorder) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if h := w.Header().Get("X-Accel-Redirect"); h != "" {
+			t.Errorf("Expected no X-Accel-Redirect, got %q", h)
+		}
+		if w.Body.String() != content {
+			t.Errorf("Expected the file body, got %q", w.Body.String())
+		}
+	}
+
+	t.Run("enabled_untrusted_client", func(t *testing.T) {
+		streamed(t, get(newServer("/internal"), "203.0.113.5"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		streamed(t, get(newServer(""), "10.1.2.3"))
+	})
+}
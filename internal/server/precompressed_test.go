@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServePrecompressed(t *testing.T) {
+	const css = "body { color: red; }"
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(css)) //nolint:errcheck
+	zw.Close()            //nolint:errcheck
+
+	tmpDir := t.TempDir()
+	files := map[string][]byte{
+		"style.css":    []byte(css),
+		"style.css.gz": gz.Bytes(),
+		"style.css.br": []byte("brotli bytes"),
+		"app.js":       []byte("console.log(1)"),
+		"app.js.gz":    []byte("stale gzip"),
+	}
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		mtime := modTime
+		if name == "app.js.gz" {
+			// app.js was edited after its sidecar was generated
+			mtime = modTime.Add(-time.Hour)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Failed to set mtime of %s: %v", name, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	newServer := func(enabled bool) *Server {
+		return New(&config.Config{
+			Host:               "localhost",
+			Port:               8080,
+			StoragePath:        tmpDir,
+			StorageType:        "local",
+			ServePrecompressed: enabled,
+		})
+	}
+	get := func(srv *Server, path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name           string
+		enabled        bool
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+		wantBody       []byte
+	}{
+		{"gzip_sidecar", true, "/style.css", "gzip", "gzip", gz.Bytes()},
+		{"brotli_preferred", true, "/style.css", "gzip, deflate, br", "br", []byte("brotli bytes")},
+		{"brotli_refused", true, "/style.css", "br;q=0, gzip", "gzip", gz.Bytes()},
+		{"no_accept_encoding", true, "/style.css", "", "", []byte(css)},
+		{"stale_sidecar_ignored", true, "/app.js", "gzip", "", []byte("console.log(1)")},
+		{"disabled", false, "/style.css", "gzip", "", []byte(css)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(newServer(tt.enabled), tt.path, tt.acceptEncoding)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Expected Content-Encoding %q, got %q", tt.wantEncoding, got)
+			}
+			if !bytes.Equal(w.Body.Bytes(), tt.wantBody) {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, w.Body.Bytes())
+			}
+			if ct := w.Header().Get("Content-Type"); tt.path == "/style.css" && ct != "text/css; charset=utf-8" {
+				t.Errorf("Expected the original's Content-Type, got %q", ct)
+			}
+		})
+	}
+
+	t.Run("vary", func(t *testing.T) {
+		w := get(newServer(true), "/style.css", "gzip")
+		if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("Expected Vary: Accept-Encoding, got %q", vary)
+		}
+		if w.Header().Get("ETag") == get(newServer(true), "/style.css", "").Header().Get("ETag") {
+			t.Error("Expected the compressed and identity responses to have different ETags")
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	clientIP := func(t *testing.T, trusted []string, peer string, headers map[string]string) string {
+		t.Helper()
+		srv := New(&config.Config{
+			Host:           "localhost",
+			Port:           8080,
+			StoragePath:    t.TempDir(),
+			StorageType:    "local",
+			TrustedProxies: trusted,
+		})
+		srv.GetEngine().GET("/client-ip", func(c *gin.Context) {
+			c.String(200, c.ClientIP())
+		})
+
+		req := httptest.NewRequest("GET", "/client-ip", nil)
+		req.RemoteAddr = peer + ":40000"
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	forwarded := map[string]string{"X-Forwarded-For": "198.51.100.7"}
+
+	tests := []struct {
+		name    string
+		trusted []string
+		peer    string
+		headers map[string]string
+		want    string
+	}{
+		{"no_proxies_trusted_by_default", nil, "203.0.113.5", forwarded, "203.0.113.5"},
+		{"trusted_peer_forwarded_for", []string{"203.0.113.0/24"}, "203.0.113.5", forwarded, "198.51.100.7"},
+		{"trusted_peer_real_ip", []string{"203.0.113.5"}, "203.0.113.5", map[string]string{"X-Real-IP": "198.51.100.8"}, "198.51.100.8"},
+		{"untrusted_peer_ignored", []string{"10.0.0.1"}, "203.0.113.5", forwarded, "203.0.113.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clientIP(t, tt.trusted, tt.peer, tt.headers); got != tt.want {
+				t.Errorf("Expected client IP %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "hello.txt"), []byte("hello"), 0644))
+
+	srv := New(&config.Config{
+		Host:          "localhost",
+		Port:          8080,
+		StoragePath:   tmpDir,
+		StorageType:   "local",
+		EnableAdmin:   true,
+		AdminUsername: "admin",
+		AdminPassword: "password123",
+		ReadOnly:      true,
+	})
+
+	form := url.Values{"username": {"admin"}, "password": {"password123"}}
+	req := httptest.NewRequest("POST", "/admin/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	session := extractAdminCookie(w, "slimserve_admin_session")
+	require.NotEmpty(t, session)
+
+	admin := func(method, path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("X-CSRF-Token", "readonly-csrf")
+		req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: session})
+		req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: "readonly-csrf"})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("admin_writes_are_forbidden", func(t *testing.T) {
+		writes := []struct{ path, body string }{
+			{"/admin/api/files/delete", `{"path": "", "filename": "hello.txt"}`},
+			{"/admin/api/files/delete-batch", `{"path": "", "filenames": ["hello.txt"]}`},
+			{"/admin/api/files/mkdir", `{"path": "", "name": "new"}`},
+			{"/admin/api/files/move", `{"source": "hello.txt", "destination": "moved.txt"}`},
+			{"/admin/api/upload", ""},
+		}
+		for _, tc := range writes {
+			w := admin("POST", tc.path, "application/json", tc.body)
+			assert.Equal(t, http.StatusForbidden, w.Code, tc.path)
+			assert.Contains(t, w.Body.String(), "read-only", tc.path)
+		}
+
+		_, err := os.Stat(filepath.Join(tmpDir, "hello.txt"))
+		assert.NoError(t, err, "file should survive")
+		_, err = os.Stat(filepath.Join(tmpDir, "new"))
+		assert.True(t, os.IsNotExist(err), "directory should not be created")
+		_, err = os.Stat(filepath.Join(tmpDir, "moved.txt"))
+		assert.True(t, os.IsNotExist(err), "file should not be moved")
+	})
+
+	t.Run("admin_reads_work", func(t *testing.T) {
+		w := admin("GET", "/admin/api/files", "", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "hello.txt")
+	})
+
+	t.Run("file_routes_accept_only_get_and_head", func(t *testing.T) {
+		for _, method := range []string{"GET", "HEAD"} {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(method, "/hello.txt", nil))
+			assert.Equal(t, http.StatusOK, w.Code, method)
+		}
+		for _, method := range []string{"POST", "PUT", "DELETE", "PATCH"} {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(method, "/hello.txt", nil))
+			assert.Equal(t, http.StatusMethodNotAllowed, w.Code, method)
+			assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"), method)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecentFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	files := []struct {
+		name string
+		age  time.Duration
+	}{
+		{"old.txt", 5 * time.Hour},
+		{"docs/newest.txt", 0},
+		{"docs/deep/middle.txt", 2 * time.Hour},
+		{"recent.jpg", time.Hour},
+		{".secret", 0},
+		{".hidden/inside.txt", 0},
+		{"debug.log", 0},
+		{".slimserveignore", 10 * time.Hour},
+	}
+	for _, f := range files {
+		path := filepath.Join(tmpDir, f.name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", f.name, err)
+		}
+		content := f.name
+		if f.name == ".slimserveignore" {
+			content = "*.log\n"
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", f.name, err)
+		}
+		mtime := base.Add(-f.age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Failed to set mtime of %s: %v", f.name, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     tmpDir,
+		StorageType:     "local",
+		DisableDotFiles: true,
+	})
+
+	recent := func(t *testing.T, query string) []string {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/recent"+query, nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var items []struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatalf("Failed to decode /recent response: %v", err)
+		}
+		names := make([]string, 0, len(items))
+		for _, item := range items {
+			if item.URL != "/"+item.Name {
+				t.Errorf("Expected URL /%s, got %s", item.Name, item.URL)
+			}
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	t.Run("newest_first_without_hidden_files", func(t *testing.T) {
+		want := []string{"docs/newest.txt", "recent.jpg", "docs/deep/middle.txt", "old.txt"}
+		if got := recent(t, ""); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		want := []string{"docs/newest.txt", "recent.jpg"}
+		if got := recent(t, "?limit=2"); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("invalid_limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/recent?limit=zero", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/recent", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "docs/newest.txt") {
+			t.Error("Expected the HTML view to list docs/newest.txt")
+		}
+	})
+}
+
+func TestRecentDoesNotShadowEntry(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "recent"), 0755); err != nil {
+		t.Fatalf("Failed to create recent: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "recent", "photo.txt"), []byte("hi"), 0644); err != nil {
+		t.Fatalf("Failed to write photo.txt: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:        "localhost",
+		Port:        8080,
+		StoragePath: tmpDir,
+		StorageType: "local",
+	})
+
+	req := httptest.NewRequest("GET", "/recent", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code == http.StatusOK {
+		var listing struct {
+			CurrentPath string `json:"current_path"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &listing); err != nil {
+			t.Fatalf("Failed to decode listing: %v", err)
+		}
+		if listing.CurrentPath != "/recent" {
+			t.Errorf("Expected the recent directory's listing, got current_path %q", listing.CurrentPath)
+		}
+	} else if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("Expected the recent directory, got status %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/recent/photo.txt", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "hi" {
+		t.Errorf("Expected recent/photo.txt to be served, got %d %q", w.Code, w.Body.String())
+	}
+}
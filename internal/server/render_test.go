@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderFiles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	readme := "# Hello\n\nSome *text* and a [link](https://example.com).\n\n<script>alert(1)</script>\n\n[bad](javascript:alert(1))\n"
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "README.md"), []byte(readme), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n\n// entry point\nfunc main() { println(\"<hi>\") }\n"), 0644))
+
+	newServer := func(renderMarkdown bool) *Server {
+		return New(&config.Config{
+			Host:           "localhost",
+			Port:           8080,
+			StoragePath:    tmpDir,
+			StorageType:    "local",
+			RenderMarkdown: renderMarkdown,
+		})
+	}
+	get := func(srv *Server, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	t.Run("markdown_render", func(t *testing.T) {
+		w := get(newServer(false), "/README.md?render=1")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/html"))
+
+		body := w.Body.String()
+		assert.Contains(t, body, "<h1>Hello</h1>")
+		assert.Contains(t, body, "<em>text</em>")
+		assert.Contains(t, body, `<a href="https://example.com">link</a>`)
+		assert.Contains(t, body, "&lt;script&gt;alert(1)&lt;/script&gt;")
+		assert.NotContains(t, body, "<script>alert(1)")
+		assert.NotContains(t, body, "javascript:")
+	})
+
+	t.Run("markdown_raw", func(t *testing.T) {
+		w := get(newServer(true), "/README.md?raw=1")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, readme, w.Body.String())
+	})
+
+	t.Run("markdown_default", func(t *testing.T) {
+		assert.Equal(t, readme, get(newServer(false), "/README.md").Body.String())
+		assert.Contains(t, get(newServer(true), "/README.md").Body.String(), "<h1>Hello</h1>")
+	})
+
+	t.Run("code", func(t *testing.T) {
+		w := get(newServer(false), "/main.go?render=1")
+		require.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, `<span class="tok-keyword">func</span>`)
+		assert.Contains(t, body, `<span class="tok-comment">// entry point</span>`)
+		assert.Contains(t, body, `<span class="tok-string">&#34;&lt;hi&gt;&#34;</span>`)
+
+		w = get(newServer(true), "/main.go")
+		assert.NotContains(t, w.Body.String(), "tok-keyword")
+	})
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+	"slimserve/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logBuf bytes.Buffer
+	originalLogger := logger.Log
+	logger.Log = zerolog.New(&logBuf)
+	t.Cleanup(func() { logger.Log = originalLogger })
+
+	newServer := func(header string) *Server {
+		cfg := config.Default()
+		cfg.StoragePath = t.TempDir()
+		cfg.RequestIDHeader = header
+		return New(cfg)
+	}
+
+	// logLines returns the log entries written for the last request
+	logLines := func(t *testing.T) []map[string]any {
+		t.Helper()
+		var lines []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(logBuf.String()), "\n") {
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &entry), "log line %q", line)
+			lines = append(lines, entry)
+		}
+		logBuf.Reset()
+		return lines
+	}
+
+	t.Run("provided_id_is_echoed_and_logged", func(t *testing.T) {
+		srv := newServer("")
+		logBuf.Reset()
+
+		req := httptest.NewRequest("GET", "/missing.txt", nil)
+		req.Header.Set("X-Request-ID", "proxy-abc-123")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		assert.Equal(t, "proxy-abc-123", w.Header().Get("X-Request-ID"))
+		lines := logLines(t)
+		require.NotEmpty(t, lines)
+		for _, entry := range lines {
+			assert.Equal(t, "proxy-abc-123", entry["request_id"], "log entry %v", entry)
+		}
+	})
+
+	t.Run("missing_id_is_generated", func(t *testing.T) {
+		srv := newServer("")
+		logBuf.Reset()
+
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		id := w.Header().Get("X-Request-ID")
+		assert.Len(t, id, 32)
+		lines := logLines(t)
+		require.NotEmpty(t, lines)
+		assert.Equal(t, id, lines[len(lines)-1]["request_id"])
+	})
+
+	t.Run("unusable_id_is_replaced", func(t *testing.T) {
+		srv := newServer("")
+		logBuf.Reset()
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-ID", "forged\tline")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		assert.Len(t, w.Header().Get("X-Request-ID"), 32)
+	})
+
+	t.Run("custom_header", func(t *testing.T) {
+		srv := newServer("X-Trace-Id")
+		logBuf.Reset()
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Trace-Id", "trace-42")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		assert.Equal(t, "trace-42", w.Header().Get("X-Trace-Id"))
+		assert.Empty(t, w.Header().Get("X-Request-ID"))
+		lines := logLines(t)
+		require.NotEmpty(t, lines)
+		assert.Equal(t, "trace-42", lines[len(lines)-1]["request_id"])
+	})
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"slimserve/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRobotsTxt is served when authentication is on and no robots.txt is
+// configured, so crawlers do not try to index what is behind the login
+const defaultRobotsTxt = "User-agent: *\nDisallow: /\n"
+
+// serveRobots answers /robots.txt with RobotsTxt, the file at RobotsTxtPath,
+// or defaultRobotsTxt when authentication is on, and reports whether it did.
+// Otherwise the request is left to the file handler, which serves a
+// robots.txt from the storage root if there is one.
+func (s *Server) serveRobots(c *gin.Context) bool {
+	if s.config.RobotsTxtPath != "" {
+		file, err := os.Open(s.config.RobotsTxtPath)
+		if err != nil {
+			logger.Ctx(c.Request.Context()).Error().Err(err).Str("path", s.config.RobotsTxtPath).Msg("Failed to open robots.txt")
+			c.AbortWithStatus(http.StatusNotFound)
+			return true
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			c.AbortWithStatus(http.StatusNotFound)
+			return true
+		}
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		http.ServeContent(c.Writer, c.Request, "robots.txt", info.ModTime(), file)
+		return true
+	}
+
+	content := s.config.RobotsTxt
+	if content == "" {
+		if !s.config.EnableAuth {
+			return false
+		}
+		content = defaultRobotsTxt
+	}
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	http.ServeContent(c.Writer, c.Request, "robots.txt", time.Time{}, strings.NewReader(content))
+	return true
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRobotsTxt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newServer := func(t *testing.T, modify func(*config.Config)) *Server {
+		storage := t.TempDir()
+		if err := os.WriteFile(filepath.Join(storage, "robots.txt"), []byte("from storage"), 0644); err != nil {
+			t.Fatalf("Failed to write robots.txt: %v", err)
+		}
+		cfg := &config.Config{
+			Host:        "localhost",
+			Port:        8080,
+			StoragePath: storage,
+			StorageType: "local",
+		}
+		modify(cfg)
+		return New(cfg)
+	}
+	get := func(srv *Server) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/robots.txt", nil))
+		return w
+	}
+	assertRobots := func(t *testing.T, w *httptest.ResponseRecorder, want string) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+		if body := w.Body.String(); body != want {
+			t.Errorf("Expected %q, got %q", want, body)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+			t.Errorf("Expected text/plain, got %q", ct)
+		}
+	}
+
+	t.Run("inline", func(t *testing.T) {
+		srv := newServer(t, func(cfg *config.Config) {
+			cfg.RobotsTxt = "User-agent: *\nDisallow: /private/\n"
+		})
+		assertRobots(t, get(srv), "User-agent: *\nDisallow: /private/\n")
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "robots.txt")
+		if err := os.WriteFile(path, []byte("User-agent: *\nAllow: /\n"), 0644); err != nil {
+			t.Fatalf("Failed to write robots.txt: %v", err)
+		}
+		srv := newServer(t, func(cfg *config.Config) { cfg.RobotsTxtPath = path })
+		assertRobots(t, get(srv), "User-agent: *\nAllow: /\n")
+
+		// The file is read per request
+		if err := os.WriteFile(path, []byte("User-agent: *\nDisallow: /\n"), 0644); err != nil {
+			t.Fatalf("Failed to rewrite robots.txt: %v", err)
+		}
+		assertRobots(t, get(srv), "User-agent: *\nDisallow: /\n")
+	})
+
+	t.Run("auth_default_disallows", func(t *testing.T) {
+		srv := newServer(t, func(cfg *config.Config) {
+			cfg.EnableAuth = true
+			cfg.Username = "user"
+			cfg.Password = "secret"
+		})
+		// Served without logging in, and not the robots.txt in storage
+		assertRobots(t, get(srv), "User-agent: *\nDisallow: /\n")
+	})
+
+	t.Run("auth_with_configured_content", func(t *testing.T) {
+		srv := newServer(t, func(cfg *config.Config) {
+			cfg.EnableAuth = true
+			cfg.Username = "user"
+			cfg.Password = "secret"
+			cfg.RobotsTxt = "User-agent: *\nAllow: /public/\n"
+		})
+		assertRobots(t, get(srv), "User-agent: *\nAllow: /public/\n")
+	})
+
+	t.Run("unset_without_auth_serves_storage", func(t *testing.T) {
+		srv := newServer(t, func(cfg *config.Config) {})
+		w := get(srv)
+		if w.Code != http.StatusOK || w.Body.String() != "from storage" {
+			t.Errorf("Expected the robots.txt from storage, got %d %q", w.Code, w.Body.String())
+		}
+	})
+}
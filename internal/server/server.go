@@ -1,46 +1,74 @@
 package server
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
 	"html/template"
+	"io"
+	"net"
 	"net/http"
+	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"slimserve/internal/config"
+	"slimserve/internal/files"
+	"slimserve/internal/ignore"
 	"slimserve/internal/logger"
 	"slimserve/internal/security"
 	"slimserve/internal/server/admin"
+	"slimserve/internal/server/apierror"
 	"slimserve/internal/server/auth"
-	"slimserve/internal/server/filter"
 	"slimserve/internal/server/handler"
 	"slimserve/internal/storage"
 	"slimserve/internal/version"
-	"slimserve/web"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 type Server struct {
-	config         *config.Config
-	engine         *gin.Engine
-	server         *http.Server
-	backend        storage.Backend
-	localRoot      *security.RootFS
-	sessionStore   *auth.SessionStore
-	loginTmpl      *template.Template
-	adminLoginTmpl *template.Template
-	adminTmpl      *template.Template
-	uploadManager  *admin.UploadManager
-	adminHandler   *AdminHandler
-	adminUtils     *admin.Utils
+	config          *config.Config
+	engine          *gin.Engine
+	servers         []*http.Server // one per listen address, set by Run
+	backend         storage.Backend
+	localRoot       security.FileSystem
+	singleFile      string // base name of the file served when StoragePath is a file
+	mounts          []handler.Mount
+	health          rootHealth
+	stopMonitor     chan struct{}
+	stopWatch       func() // stops the EnableFSWatch watchers; nil when off
+	sessionStore    *auth.SessionStore
+	loginTmpl       *template.Template
+	maintenanceTmpl *template.Template
+	adminLoginTmpl  *template.Template
+	adminTmpl       *template.Template
+	uploadManager   *admin.UploadManager
+	adminHandler    *AdminHandler
+	adminUtils      *admin.Utils
+	adminRateLimit  gin.HandlerFunc // shared so the limit holds across requests
+	bodyLog         gin.HandlerFunc // nil unless admin API bodies are logged
+	uploadThumbs    chan struct{}   // slots for thumbnails generated after uploads
+	accessLogFile   *os.File
+
+	// draining is set once Shutdown begins; new requests then get 503
+	draining atomic.Bool
 }
 
 func New(cfg *config.Config) *Server {
 	storageDir := cfg.GetStorageDir()
 
 	var backend storage.Backend
-	var localRoot *security.RootFS
+	var localRoot security.FileSystem
+	var singleFile string
 
 	if storageDir.IsS3() {
 		cacheBytes := int64(0)
@@ -54,52 +82,93 @@ func New(cfg *config.Config) *Server {
 			backend = s3Backend
 		}
 	} else {
-		root, err := security.NewRootFS(storageDir.Path)
+		rootDir := storageDir.Path
+		if info, err := os.Stat(rootDir); err == nil && info.Mode().IsRegular() {
+			// A single file is served from a root on its parent directory;
+			// every other path in that directory stays hidden.
+			singleFile = filepath.Base(rootDir)
+			rootDir = filepath.Dir(rootDir)
+		}
+		root, err := security.NewRootFS(rootDir)
 		if err != nil {
-			logger.Log.Warn().Err(err).Str("directory", storageDir.Path).Msg("Failed to create RootFS for directory")
+			logger.Log.Warn().Err(err).Str("directory", rootDir).Msg("Failed to create RootFS for directory")
 		} else {
 			localRoot = root
 			backend = storage.NewLocalBackend(root, cfg.IgnorePatterns)
 		}
 	}
 
+	var mounts []handler.Mount
+	for name, dir := range cfg.Mounts {
+		root, err := security.NewRootFS(dir)
+		if err != nil {
+			logger.Log.Warn().Err(err).Str("mount", name).Str("directory", dir).Msg("Failed to create RootFS for mount")
+			continue
+		}
+		mounts = append(mounts, handler.Mount{
+			Name:    name,
+			Backend: storage.NewLocalBackend(root, cfg.IgnorePatterns),
+			Root:    root,
+		})
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 
 	engine := gin.New()
 	engine.Use(gin.Recovery())
+	// Forwarded client IP headers are only believed from configured proxies;
+	// with none configured ClientIP is always the direct peer.
+	if err := engine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Log.Warn().Err(err).Strs("trusted_proxies", cfg.TrustedProxies).Msg("Invalid trusted proxies, trusting none")
+		_ = engine.SetTrustedProxies(nil)
+	}
 
-	loginTmpl := template.Must(template.ParseFS(web.TemplateFS, "templates/base.html", "templates/login.html"))
+	loginTmpl := handler.ParseTemplates(cfg, "templates/base.html", "templates/login.html")
+	maintenanceTmpl := handler.ParseTemplates(cfg, "templates/base.html", "templates/maintenance.html")
 
 	var adminLoginTmpl, adminTmpl *template.Template
 	if cfg.EnableAdmin {
-		adminLoginTmpl = template.Must(template.ParseFS(web.TemplateFS, "templates/admin_login.html"))
-		adminTmpl = template.Must(template.ParseFS(web.TemplateFS,
+		adminLoginTmpl = handler.ParseTemplates(cfg, "templates/admin_login.html")
+		adminTmpl = handler.ParseTemplates(cfg,
 			"templates/admin_base.html",
 			"templates/admin_components.html",
 			"templates/admin_dashboard.html",
 			"templates/admin_upload.html",
 			"templates/admin_files.html",
 			"templates/admin_config.html",
-			"templates/admin_status.html"))
+			"templates/admin_status.html")
 	}
 
 	srv := &Server{
-		config:         cfg,
-		engine:         engine,
-		backend:        backend,
-		localRoot:      localRoot,
-		sessionStore:   auth.NewSessionStore(),
-		loginTmpl:      loginTmpl,
-		adminLoginTmpl: adminLoginTmpl,
-		adminTmpl:      adminTmpl,
-		uploadManager:  admin.NewUploadManager(cfg.MaxConcurrentUploads),
-		adminUtils:     admin.NewUtils(),
+		config:          cfg,
+		engine:          engine,
+		backend:         backend,
+		localRoot:       localRoot,
+		singleFile:      singleFile,
+		mounts:          mounts,
+		sessionStore:    auth.NewSessionStore(),
+		loginTmpl:       loginTmpl,
+		maintenanceTmpl: maintenanceTmpl,
+		adminLoginTmpl:  adminLoginTmpl,
+		adminTmpl:       adminTmpl,
+		uploadManager:   admin.NewUploadManager(cfg.MaxConcurrentUploads, time.Duration(cfg.UploadStaleSeconds)*time.Second),
+		adminUtils:      admin.NewUtils(),
+		adminRateLimit:  admin.AdminRateLimitMiddleware(),
+		uploadThumbs:    make(chan struct{}, maxUploadThumbnailJobs),
 	}
 
 	if cfg.EnableAdmin {
 		srv.adminHandler = NewAdminHandler(srv)
 	}
 
+	if cfg.LogRequestBodies {
+		if strings.EqualFold(cfg.LogLevel, "debug") {
+			srv.bodyLog = bodyLogMiddleware(cfg.LogRequestBodyBytes)
+		} else {
+			logger.Log.Warn().Str("log_level", cfg.LogLevel).Msg("Request body logging needs log level debug, leaving it off")
+		}
+	}
+
 	srv.setupRoutes()
 	return srv
 }
@@ -111,8 +180,7 @@ func (s *Server) applyAdminMiddleware(c *gin.Context) bool {
 		return false
 	}
 
-	rateLimit := admin.AdminRateLimitMiddleware()
-	rateLimit(c)
+	s.adminRateLimit(c)
 	if c.IsAborted() {
 		return false
 	}
@@ -123,7 +191,7 @@ func (s *Server) applyAdminMiddleware(c *gin.Context) bool {
 		return false
 	}
 
-	csrfProtection := admin.CSRFProtectionMiddleware()
+	csrfProtection := admin.CSRFProtectionMiddleware(s.config)
 	csrfProtection(c)
 	if c.IsAborted() {
 		return false
@@ -133,6 +201,11 @@ func (s *Server) applyAdminMiddleware(c *gin.Context) bool {
 }
 
 func (s *Server) handleAdminRoute(c *gin.Context, path, method string) {
+	// Logged before authentication so rejected calls can be diagnosed too
+	if s.bodyLog != nil && strings.HasPrefix(path, "/admin/api/") {
+		s.bodyLog(c)
+	}
+
 	switch {
 	case path == "/admin/login" && (method == "GET" || method == "HEAD"):
 		s.showAdminLogin(c)
@@ -162,55 +235,69 @@ func (s *Server) handleAdminRoute(c *gin.Context, path, method string) {
 		s.showAdminConfig(c)
 	case path == "/admin/status" && (method == "GET" || method == "HEAD"):
 		s.showAdminStatus(c)
-	case path == "/admin/api/stats" && (method == "GET" || method == "HEAD"):
-		s.adminHandler.getSystemStats(c)
-	case path == "/admin/api/status" && (method == "GET" || method == "HEAD"):
-		s.adminHandler.getSystemStatus(c)
-	case path == "/admin/api/activity" && (method == "GET" || method == "HEAD"):
-		s.adminHandler.getRecentActivity(c)
-	case path == "/admin/api/config" && (method == "GET" || method == "HEAD"):
-		s.adminHandler.getConfiguration(c)
-	case path == "/admin/api/config" && method == "POST":
-		s.adminHandler.updateConfiguration(c)
-	case path == "/admin/api/auth" && (method == "GET" || method == "HEAD"):
-		s.adminHandler.getAuthConfig(c)
-	case path == "/admin/api/auth" && method == "POST":
-		s.adminHandler.updateAuthConfig(c)
-	case path == "/admin/api/files" && (method == "GET" || method == "HEAD"):
-		s.adminHandler.listFiles(c)
-	case path == "/admin/api/files/delete" && method == "POST":
-		s.adminHandler.deleteFile(c)
-	case path == "/admin/api/files/mkdir" && method == "POST":
-		s.adminHandler.createDirectory(c)
-	case path == "/admin/api/files/move" && method == "POST":
-		s.adminHandler.moveFile(c)
-	case path == "/admin/api/upload" && method == "POST":
-		s.handleFileUpload(c)
-	case path == "/admin/api/upload/progress" && (method == "GET" || method == "HEAD"):
-		s.getUploadProgress(c)
+	case path == adminOpenAPIPath && (method == "GET" || method == "HEAD"):
+		s.serveAdminOpenAPI(c)
 	default:
+		if route := findAdminAPIRoute(path, method); route != nil {
+			if route.write && s.config.ReadOnly {
+				apierror.Write(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "server is read-only"))
+				return
+			}
+			route.handle(s, c)
+			return
+		}
 		c.AbortWithStatus(http.StatusNotFound)
 	}
 }
 
 func (s *Server) createUnifiedHandler(fileHandler *handler.Handler) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if !s.stripBasePath(c) {
+			return
+		}
+
 		path := c.Request.URL.Path
 		method := c.Request.Method
 
+		if s.config.EnableAdmin && strings.HasPrefix(path, "/admin") {
+			s.handleAdminRoute(c, path, method)
+			return
+		}
+
+		// Everything else only reads, apart from submitting the login form
+		allowed := readMethods
+		switch {
+		case s.config.EnableAuth && path == "/login":
+			allowed = loginMethods
+		case s.config.ReadOnly:
+			allowed = readOnlyMethods
+		}
+		if !allowMethod(c, allowed) {
+			return
+		}
+
+		if s.underMaintenance(c, path) {
+			return
+		}
+
 		if strings.HasPrefix(path, "/static/") || path == "/favicon.ico" {
 			c.Params = gin.Params{{Key: "path", Value: path}}
 			fileHandler.ServeFiles(c)
 			return
 		}
 
-		if path == "/version" && (method == "GET" || method == "HEAD") {
+		if path == "/version" {
 			s.handleVersion(c)
 			return
 		}
 
-		if s.config.EnableAdmin && strings.HasPrefix(path, "/admin") {
-			s.handleAdminRoute(c, path, method)
+		if path == "/readyz" {
+			s.handleReadyz(c)
+			return
+		}
+
+		// Crawlers never log in, so robots.txt is answered before the auth check
+		if path == "/robots.txt" && s.serveRobots(c) {
 			return
 		}
 
@@ -237,21 +324,256 @@ func (s *Server) createUnifiedHandler(fileHandler *handler.Handler) gin.HandlerF
 			}
 		}
 
+		if s.singleFile != "" && !s.singleFileAllowed(c, path) {
+			return
+		}
+
+		// A served entry called recent keeps its URL
+		if path == "/recent" && !fileHandler.HasRootEntry(c.Request.Context(), "recent") {
+			fileHandler.ServeRecent(c)
+			return
+		}
+
 		c.Params = gin.Params{{Key: "path", Value: path}}
 		fileHandler.ServeFiles(c)
 	}
 }
 
+// Methods accepted by file, listing and status routes, by /login, and by
+// the former when ReadOnly is set. OPTIONS is still answered in read-only
+// mode, listing only GET and HEAD.
+var (
+	readMethods     = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+	loginMethods    = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodOptions}
+	readOnlyMethods = []string{http.MethodGet, http.MethodHead}
+)
+
+// allowMethod reports whether the request's method is one of allowed and may
+// proceed. OPTIONS is answered with the Allow header, other standard methods
+// get 405 and methods this server knows nothing of get 501.
+func allowMethod(c *gin.Context, allowed []string) bool {
+	method := c.Request.Method
+	if method != http.MethodOptions && slices.Contains(allowed, method) {
+		return true
+	}
+
+	switch method {
+	case http.MethodOptions:
+		c.Header("Allow", strings.Join(allowed, ", "))
+		c.AbortWithStatus(http.StatusNoContent)
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodTrace:
+		c.Header("Allow", strings.Join(allowed, ", "))
+		c.AbortWithStatus(http.StatusMethodNotAllowed)
+	default:
+		c.AbortWithStatus(http.StatusNotImplemented)
+	}
+	return false
+}
+
+// stripBasePath removes the configured base path from the request so routing
+// and the middlewares see root-relative paths. Requests outside the base path
+// get a 404, and the bare prefix redirects to its trailing-slash form.
+func (s *Server) stripBasePath(c *gin.Context) bool {
+	prefix := s.config.URLPrefix()
+	if prefix == "" {
+		return true
+	}
+
+	requestPath := c.Request.URL.Path
+	switch {
+	case requestPath == prefix:
+		target := prefix + "/"
+		if c.Request.URL.RawQuery != "" {
+			target += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, target)
+		c.Abort()
+		return false
+	case strings.HasPrefix(requestPath, prefix+"/"):
+		c.Request.URL.Path = strings.TrimPrefix(requestPath, prefix)
+		c.Request.URL.RawPath = ""
+		return true
+	default:
+		c.AbortWithStatus(http.StatusNotFound)
+		return false
+	}
+}
+
+// url returns the public URL for a root-relative path, adding the base path
+func (s *Server) url(p string) string {
+	return s.config.URLPrefix() + p
+}
+
+// singleFileAllowed restricts requests to the configured file when the
+// storage path is a single file. The root has nothing to list and answers
+// 405, with the Allow header a 405 requires; any other path is 404 so the
+// parent directory is never exposed.
+func (s *Server) singleFileAllowed(c *gin.Context, requestPath string) bool {
+	switch filepath.Clean(requestPath) {
+	case "/" + s.singleFile:
+		return true
+	case "/":
+		c.Header("Allow", strings.Join(readOnlyMethods, ", "))
+		c.AbortWithStatus(http.StatusMethodNotAllowed)
+	default:
+		c.AbortWithStatus(http.StatusNotFound)
+	}
+	return false
+}
+
 func (s *Server) setupRoutes() {
 	fileHandler := handler.NewHandler(s.config, s.backend, s.localRoot)
+	if len(s.mounts) > 0 {
+		fileHandler = handler.NewMountHandler(s.config, s.mounts)
+	}
+	if s.config.EnableFSWatch {
+		s.stopWatch = fileHandler.WatchRoots()
+	}
 
-	s.engine.Use(logger.Middleware())
+	// First, so requests the limits below turn away carry it too
+	if s.config.SendServerHeader {
+		s.engine.Use(serverHeaderMiddleware(s.config.HideVersion))
+	}
+	s.engine.Use(logger.RequestIDMiddleware(s.config.RequestIDHeaderName()))
+	s.engine.Use(s.requestLogMiddleware())
+	if s.config.MaxURLLength > 0 {
+		s.engine.Use(maxURLLengthMiddleware(s.config.MaxURLLength))
+	}
+	s.engine.Use(drainingMiddleware(&s.draining))
+	if s.config.MaxRequestBodyMB > 0 {
+		s.engine.Use(maxRequestBodyMiddleware(int64(s.config.MaxRequestBodyMB)*1024*1024, s.url(adminUploadPath)))
+	}
+	if s.config.MaxConnections > 0 {
+		s.engine.Use(maxConnectionsMiddleware(s.config.MaxConnections))
+	}
 
 	unifiedHandler := s.createUnifiedHandler(fileHandler)
 
 	s.engine.NoRoute(unifiedHandler)
 }
 
+// requestLogMiddleware returns the access log middleware when an access log
+// format is configured, and the default structured request log otherwise.
+func (s *Server) requestLogMiddleware() gin.HandlerFunc {
+	if s.config.AccessLogFormat == "" {
+		return logger.Middleware()
+	}
+
+	var out io.Writer = os.Stdout
+	if s.config.AccessLogFile != "" {
+		f, err := os.OpenFile(s.config.AccessLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			logger.Log.Warn().Err(err).Str("file", s.config.AccessLogFile).Msg("Failed to open access log file, using stdout")
+		} else {
+			s.accessLogFile = f
+			out = f
+		}
+	}
+
+	middleware, err := logger.AccessLogMiddleware(s.config.AccessLogFormat, out)
+	if err != nil {
+		logger.Log.Warn().Err(err).Msg("Invalid access log format, using default request logging")
+		return logger.Middleware()
+	}
+	return middleware
+}
+
+// serverHeaderMiddleware identifies SlimServe and its version on every response
+func serverHeaderMiddleware(hideVersion bool) gin.HandlerFunc {
+	serverHeader := "SlimServe/" + version.GetShort()
+	if hideVersion {
+		serverHeader = "SlimServe"
+	}
+	return func(c *gin.Context) {
+		c.Header("Server", serverHeader)
+		c.Next()
+	}
+}
+
+// maxURLLengthMiddleware answers 414 for request targets longer than limit
+// bytes, before any path cleaning or filesystem lookups are done for them.
+func maxURLLengthMiddleware(limit int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		target := c.Request.RequestURI
+		if target == "" {
+			target = c.Request.URL.RequestURI()
+		}
+		if len(target) > limit {
+			logger.Ctx(c.Request.Context()).Warn().
+				Int("length", len(target)).
+				Int("max_url_length", limit).
+				Msg("Request rejected: URL too long")
+			c.AbortWithStatus(http.StatusRequestURITooLong)
+			return
+		}
+		c.Next()
+	}
+}
+
+// maxRequestBodyMiddleware answers 413 for request bodies over limit bytes.
+// Bodies of unknown length are cut off at the limit, so a handler reading
+// further gets an error instead of the rest. POSTs to uploadPath keep their
+// own, larger limit.
+func maxRequestBodyMiddleware(limit int64, uploadPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodPost && c.Request.URL.Path == uploadPath {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			logger.Ctx(c.Request.Context()).Warn().
+				Int64("content_length", c.Request.ContentLength).
+				Int64("max_request_body_bytes", limit).
+				Str("path", c.Request.URL.Path).
+				Msg("Request rejected: body too large")
+			c.Header("Connection", "close")
+			c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+			return
+		}
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		c.Next()
+	}
+}
+
+// drainingMiddleware answers 503 with Retry-After once draining is set, so
+// requests arriving during shutdown fail fast instead of starting work that
+// may be cut off. Connection: close sends keep-alive clients elsewhere.
+func drainingMiddleware(draining *atomic.Bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !draining.Load() {
+			c.Next()
+			return
+		}
+		c.Header("Connection", "close")
+		c.Header("Retry-After", "1")
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+	}
+}
+
+// maxConnectionsMiddleware lets at most limit requests run at once. Requests
+// over the limit are answered with 503 and Retry-After right away rather than
+// queued, so a burst cannot pile up goroutines and open files.
+func maxConnectionsMiddleware(limit int) gin.HandlerFunc {
+	slots := make(chan struct{}, limit)
+	return func(c *gin.Context) {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			c.Next()
+		default:
+			logger.Ctx(c.Request.Context()).Warn().
+				Str("path", c.Request.URL.Path).
+				Int("max_connections", limit).
+				Msg("Request rejected: connection limit reached")
+			c.Header("Retry-After", "1")
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+		}
+	}
+}
+
 func (s *Server) accessControlMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestedPath := c.Request.URL.Path
@@ -311,26 +633,154 @@ func (s *Server) accessControlMiddleware() gin.HandlerFunc {
 	}
 }
 
-func (s *Server) Run(addr string) error {
-	s.server = &http.Server{
-		Addr:    addr,
-		Handler: s.engine,
+// Run serves on every address in addrs, or on the configured
+// ListenAddresses when none are given, and blocks until all of them have
+// stopped. Every address is bound before serving starts, so a port in use
+// fails the whole call. If one server fails, the others are closed too. The
+// first error is returned, http.ErrServerClosed after a Shutdown.
+func (s *Server) Run(addrs ...string) error {
+	if len(addrs) == 0 {
+		addrs = s.config.ListenAddresses()
+	}
+
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		listeners = append(listeners, ln)
 	}
-	return s.server.ListenAndServe()
+
+	servers := make([]*http.Server, len(addrs))
+	for i, addr := range addrs {
+		servers[i] = s.newHTTPServer(addr)
+	}
+	s.servers = servers
+
+	s.stopMonitor = make(chan struct{})
+	if s.config.RootHealthCheckSeconds > 0 {
+		go s.monitorRoots(time.Duration(s.config.RootHealthCheckSeconds)*time.Second, s.stopMonitor)
+	}
+	go s.uploadManager.RunReaper(s.stopMonitor)
+
+	errs := make(chan error, len(servers))
+	for i, srv := range servers {
+		go func() { errs <- srv.Serve(listeners[i]) }()
+	}
+
+	first := <-errs
+	if !errors.Is(first, http.ErrServerClosed) {
+		logger.Log.Error().Err(first).Msg("Server stopped unexpectedly, closing remaining listeners")
+		for _, srv := range servers {
+			srv.Close()
+		}
+	}
+	for range len(servers) - 1 {
+		<-errs
+	}
+	return first
+}
+
+// httpHandler is the engine behind the handler timeout, when one is configured
+func (s *Server) httpHandler() http.Handler {
+	if s.config.HandlerTimeoutSeconds > 0 {
+		return newTimeoutHandler(s.engine, time.Duration(s.config.HandlerTimeoutSeconds)*time.Second)
+	}
+	return s.engine
 }
 
+// newHTTPServer builds the http.Server for addr with the configured timeouts.
+// With EnableH2C the handler also accepts cleartext HTTP/2.
+func (s *Server) newHTTPServer(addr string) *http.Server {
+	h := s.httpHandler()
+	if s.config.EnableH2C {
+		h = h2c.NewHandler(h, &http2.Server{})
+	}
+	return &http.Server{
+		Addr:         addr,
+		Handler:      h,
+		ReadTimeout:  time.Duration(s.config.ReadTimeoutSeconds) * time.Second,
+		WriteTimeout: time.Duration(s.config.WriteTimeoutSeconds) * time.Second,
+		IdleTimeout:  time.Duration(s.config.IdleTimeoutSeconds) * time.Second,
+
+		MaxHeaderBytes: s.config.MaxHeaderBytes,
+	}
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests until
+// ctx is done, after which any remaining connections are closed forcibly.
+// Requests that still arrive meanwhile are refused with 503.
 func (s *Server) Shutdown(ctx context.Context) error {
-	if s.server == nil {
+	s.draining.Store(true)
+	if s.stopWatch != nil {
+		s.stopWatch()
+		s.stopWatch = nil
+	}
+	if s.servers == nil {
 		return nil
 	}
 
+	if s.stopMonitor != nil {
+		close(s.stopMonitor)
+		s.stopMonitor = nil
+	}
+
+	// Shut every listener down at once so they share the grace period
+	shutdownErrs := make([]error, len(s.servers))
+	var wg sync.WaitGroup
+	for i, srv := range s.servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shutdownErrs[i] = srv.Shutdown(ctx)
+			if shutdownErrs[i] != nil {
+				logger.Log.Warn().Err(shutdownErrs[i]).Str("addr", srv.Addr).Msg("Graceful shutdown interrupted, closing remaining connections")
+				if closeErr := srv.Close(); closeErr != nil {
+					logger.Log.Warn().Err(closeErr).Str("addr", srv.Addr).Msg("Failed to close server")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	err := cmp.Or(shutdownErrs...)
+
 	if s.localRoot != nil {
-		if err := s.localRoot.Close(); err != nil {
-			logger.Log.Warn().Err(err).Msg("Failed to close RootFS")
+		if closeErr := s.localRoot.Close(); closeErr != nil {
+			logger.Log.Warn().Err(closeErr).Msg("Failed to close RootFS")
+		}
+	}
+	for _, m := range s.mounts {
+		if closeErr := m.Root.Close(); closeErr != nil {
+			logger.Log.Warn().Err(closeErr).Str("mount", m.Name).Msg("Failed to close RootFS")
 		}
 	}
 
-	return s.server.Shutdown(ctx)
+	if flushErr := files.FlushIndexes(); flushErr != nil {
+		logger.Log.Warn().Err(flushErr).Msg("Failed to write thumbnail cache index")
+	}
+
+	if s.accessLogFile != nil {
+		if closeErr := s.accessLogFile.Close(); closeErr != nil {
+			logger.Log.Warn().Err(closeErr).Msg("Failed to close access log file")
+		}
+	}
+
+	return err
+}
+
+// GracefulShutdown calls Shutdown with the configured ShutdownTimeoutSeconds
+// as the grace period. A timeout of 0 waits for in-flight requests indefinitely.
+func (s *Server) GracefulShutdown(ctx context.Context) error {
+	if s.config.ShutdownTimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.ShutdownTimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	return s.Shutdown(ctx)
 }
 
 func (s *Server) GetEngine() *gin.Engine {
@@ -338,23 +788,78 @@ func (s *Server) GetEngine() *gin.Engine {
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	s.engine.ServeHTTP(w, r)
+	s.httpHandler().ServeHTTP(w, r)
+}
+
+// versionResponse is the /version body: the build info, plus runtime stats
+// when VersionStats is enabled
+type versionResponse struct {
+	version.Info
+	Runtime *runtimeStats `json:"runtime,omitempty"`
+}
+
+type runtimeStats struct {
+	Uptime           string `json:"uptime"`
+	Goroutines       int    `json:"goroutines"`
+	MemoryAlloc      string `json:"memory_alloc"`
+	MemoryAllocBytes uint64 `json:"memory_alloc_bytes"`
+	Roots            int    `json:"roots"`
+	ThumbCache       string `json:"thumb_cache"`
+	ThumbCacheBytes  int64  `json:"thumb_cache_bytes"`
 }
 
 func (s *Server) handleVersion(c *gin.Context) {
-	versionInfo := version.Get()
-	c.JSON(http.StatusOK, versionInfo)
+	if s.config.HideVersion {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	resp := versionResponse{Info: version.Get()}
+	if s.config.VersionStats {
+		resp.Runtime = s.runtimeStats()
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// runtimeStats collects the optional /version stats
+func (s *Server) runtimeStats() *runtimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := &runtimeStats{
+		Uptime:           s.adminUtils.GetUptime(),
+		Goroutines:       runtime.NumGoroutine(),
+		MemoryAlloc:      s.adminUtils.FormatBytes(m.Alloc),
+		MemoryAllocBytes: m.Alloc,
+		Roots:            len(s.servedRoots()),
+	}
+	if cm, err := files.NewCacheManager(files.CacheDir(), s.config.MaxThumbCacheMB); err == nil {
+		_, stats.ThumbCacheBytes, _ = cm.Stats()
+	}
+	stats.ThumbCache = s.adminUtils.FormatBytes(uint64(stats.ThumbCacheBytes))
+	return stats
 }
 
 func (s *Server) addVersionToTemplateData(data gin.H) gin.H {
 	if data == nil {
 		data = gin.H{}
 	}
+	if s.config.HideVersion {
+		return data
+	}
 	data["Version"] = version.GetShort()
 	data["VersionInfo"] = version.Get()
 	return data
 }
 
-func isIgnored(relPath string, root *security.RootFS, cfg *config.Config) (bool, error) {
-	return filter.IsIgnored(relPath, root, cfg)
+// templateData adds the values shared by every rendered page and sets the
+// Content-Security-Policy header whose nonce the page's scripts carry.
+func (s *Server) templateData(c *gin.Context, data gin.H) gin.H {
+	data = s.addVersionToTemplateData(data)
+	data["Theme"] = handler.ResolveTheme(c, s.config.Theme)
+	data["CSPNonce"] = handler.ApplyCSP(c, s.config.ContentSecurityPolicy)
+	return data
+}
+
+func isIgnored(relPath string, root security.FileSystem, cfg *config.Config) (bool, error) {
+	return ignore.IsIgnored(relPath, root, cfg)
 }
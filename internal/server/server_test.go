@@ -1,13 +1,16 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"slimserve/internal/config"
+	"slimserve/internal/version"
 	"strings"
 	"testing"
 	"time"
@@ -150,3 +153,200 @@ func TestServerIntegration(t *testing.T) {
 		}
 	})
 }
+
+func TestServerHeader(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		enabled bool
+		paths   []string
+	}{
+		{name: "enabled", enabled: true, paths: []string{"/", "/version", "/missing.txt"}},
+		{name: "disabled", enabled: false, paths: []string{"/", "/version"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := New(&config.Config{
+				Host:             "localhost",
+				Port:             8080,
+				StoragePath:      tmpDir,
+				StorageType:      "local",
+				DisableDotFiles:  true,
+				SendServerHeader: tt.enabled,
+			})
+
+			for _, path := range tt.paths {
+				w := httptest.NewRecorder()
+				srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+				header := w.Header().Get("Server")
+				if tt.enabled {
+					expected := "SlimServe/" + version.GetShort()
+					if header != expected {
+						t.Errorf("GET %s: expected Server header %q, got %q", path, expected, header)
+					}
+				} else if header != "" {
+					t.Errorf("GET %s: expected no Server header, got %q", path, header)
+				}
+			}
+		})
+	}
+
+	t.Run("rejected requests", func(t *testing.T) {
+		srv := New(&config.Config{
+			Host:             "localhost",
+			Port:             8080,
+			StoragePath:      tmpDir,
+			StorageType:      "local",
+			SendServerHeader: true,
+			MaxURLLength:     64,
+		})
+		expected := "SlimServe/" + version.GetShort()
+
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/"+strings.Repeat("a", 100), nil))
+		if w.Code != http.StatusRequestURITooLong || w.Header().Get("Server") != expected {
+			t.Errorf("Long URL: got status %d and Server header %q", w.Code, w.Header().Get("Server"))
+		}
+
+		srv.draining.Store(true)
+		w = httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Server") != expected {
+			t.Errorf("Draining: got status %d and Server header %q", w.Code, w.Header().Get("Server"))
+		}
+	})
+}
+
+func TestHideVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The version badge is the only element placed in the top left corner
+	const badge = "fixed top-4 left-4"
+
+	newServer := func(hide bool) *Server {
+		return New(&config.Config{
+			Host:             "localhost",
+			Port:             8080,
+			StoragePath:      tmpDir,
+			StorageType:      "local",
+			DisableDotFiles:  true,
+			SendServerHeader: true,
+			HideVersion:      hide,
+		})
+	}
+	get := func(srv *Server, path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get(newServer(false), "/", ""); !strings.Contains(w.Body.String(), badge) {
+		t.Fatalf("Expected the version badge without HideVersion")
+	}
+
+	srv := newServer(true)
+
+	w := get(srv, "/", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected listing status 200, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), badge) {
+		t.Errorf("Expected no version in the HTML listing")
+	}
+	if header := w.Header().Get("Server"); header != "SlimServe" {
+		t.Errorf("Expected Server header without version, got %q", header)
+	}
+
+	w = get(srv, "/", "application/json")
+	var listing map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &listing); err != nil {
+		t.Fatalf("Failed to decode JSON listing: %v", err)
+	}
+	for _, key := range []string{"version", "version_info"} {
+		if _, ok := listing[key]; ok {
+			t.Errorf("Expected no %q in the JSON listing, got %v", key, listing[key])
+		}
+	}
+
+	if w := get(srv, "/version", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected /version to answer 404, got %d", w.Code)
+	}
+}
+
+func TestVersionStats(t *testing.T) {
+	t.Setenv("SLIMSERVE_CACHE_DIR", t.TempDir())
+	tmpDir := t.TempDir()
+
+	get := func(t *testing.T, enabled bool) map[string]any {
+		t.Helper()
+		srv := New(&config.Config{
+			Host:            "localhost",
+			Port:            8080,
+			StoragePath:     tmpDir,
+			StorageType:     "local",
+			DisableDotFiles: true,
+			VersionStats:    enabled,
+		})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/version", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode /version response: %v", err)
+		}
+		return body
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		body := get(t, false)
+		want, err := json.Marshal(version.Get())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var plain map[string]any
+		if err := json.Unmarshal(want, &plain); err != nil {
+			t.Fatal(err)
+		}
+		if len(body) != len(plain) {
+			t.Errorf("Expected only build info, got %v", body)
+		}
+		for k, v := range plain {
+			if body[k] != v {
+				t.Errorf("Expected %s=%v, got %v", k, v, body[k])
+			}
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		body := get(t, true)
+		if body["version"] != version.Get().Version {
+			t.Errorf("Expected build info to be kept, got version %v", body["version"])
+		}
+		stats, ok := body["runtime"].(map[string]any)
+		if !ok {
+			t.Fatalf("Expected a runtime object, got %v", body["runtime"])
+		}
+		for _, key := range []string{"uptime", "goroutines", "memory_alloc", "memory_alloc_bytes", "roots", "thumb_cache", "thumb_cache_bytes"} {
+			if _, ok := stats[key]; !ok {
+				t.Errorf("Expected runtime field %q", key)
+			}
+		}
+		if stats["roots"] != float64(1) {
+			t.Errorf("Expected 1 served root, got %v", stats["roots"])
+		}
+		if g, _ := stats["goroutines"].(float64); g < 1 {
+			t.Errorf("Expected a positive goroutine count, got %v", stats["goroutines"])
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAllowedServeTypes(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"photo.jpg", "song.MP3", "notes.txt", "report-2024.pdf", "other.pdf", "sub/inner.txt"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:              "localhost",
+		Port:              8080,
+		StoragePath:       tmpDir,
+		StorageType:       "local",
+		DisableDotFiles:   true,
+		AllowedServeTypes: []string{"jpg", ".mp3", "report-*.pdf"},
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("listing_shows_only_allowed_files", func(t *testing.T) {
+		w := get("/")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		for _, name := range []string{"photo.jpg", "song.MP3", "report-2024.pdf", "sub"} {
+			if !strings.Contains(body, name) {
+				t.Errorf("Expected %s in listing", name)
+			}
+		}
+		for _, name := range []string{"notes.txt", "other.pdf"} {
+			if strings.Contains(body, name) {
+				t.Errorf("Expected %s to be hidden from listing", name)
+			}
+		}
+	})
+
+	t.Run("direct_access", func(t *testing.T) {
+		tests := []struct {
+			path string
+			want int
+		}{
+			{"/photo.jpg", http.StatusOK},
+			{"/song.MP3", http.StatusOK},
+			{"/report-2024.pdf", http.StatusOK},
+			{"/notes.txt", http.StatusNotFound},
+			{"/other.pdf", http.StatusNotFound},
+			{"/sub/inner.txt", http.StatusNotFound},
+			{"/sub/", http.StatusOK},
+		}
+		for _, tt := range tests {
+			if w := get(tt.path); w.Code != tt.want {
+				t.Errorf("GET %s: expected status %d, got %d", tt.path, tt.want, w.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startSlowServer runs a server with a /slow route that blocks for delay.
+// started receives a value once the slow handler is running.
+func startSlowServer(t *testing.T, timeoutSeconds int, delay time.Duration) (*Server, string, <-chan struct{}, <-chan error) {
+	t.Helper()
+
+	cfg := &config.Config{
+		Host:                   "localhost",
+		Port:                   8080,
+		StoragePath:            t.TempDir(),
+		StorageType:            "local",
+		DisableDotFiles:        true,
+		ShutdownTimeoutSeconds: timeoutSeconds,
+	}
+	srv := New(cfg)
+
+	started := make(chan struct{}, 1)
+	srv.engine.GET("/slow", func(c *gin.Context) {
+		started <- struct{}{}
+		select {
+		case <-time.After(delay):
+			c.String(http.StatusOK, "done")
+		case <-c.Request.Context().Done():
+		}
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Failed to get available port:", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- srv.Run(addr)
+	}()
+
+	baseURL := fmt.Sprintf("http://%s", addr)
+	for i := 0; i < 50; i++ {
+		if resp, err := http.Get(baseURL + "/version"); err == nil {
+			resp.Body.Close()
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return srv, baseURL, started, runErr
+}
+
+func TestGracefulShutdownTimeout(t *testing.T) {
+	t.Run("timeout_forces_close", func(t *testing.T) {
+		srv, baseURL, started, runErr := startSlowServer(t, 1, 30*time.Second)
+
+		clientErr := make(chan error, 1)
+		go func() {
+			resp, err := http.Get(baseURL + "/slow")
+			if err == nil {
+				resp.Body.Close()
+			}
+			clientErr <- err
+		}()
+		<-started
+
+		begin := time.Now()
+		err := srv.GracefulShutdown(context.Background())
+		elapsed := time.Since(begin)
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+		if elapsed < time.Second || elapsed > 5*time.Second {
+			t.Errorf("Expected shutdown after the 1s timeout, took %v", elapsed)
+		}
+		if err := <-clientErr; err == nil {
+			t.Error("Expected in-flight request to be cut off")
+		}
+		if err := <-runErr; !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("Expected Run to return ErrServerClosed, got %v", err)
+		}
+	})
+
+	t.Run("zero_waits_for_requests", func(t *testing.T) {
+		srv, baseURL, started, runErr := startSlowServer(t, 0, 1500*time.Millisecond)
+
+		status := make(chan int, 1)
+		go func() {
+			resp, err := http.Get(baseURL + "/slow")
+			if err != nil {
+				status <- 0
+				return
+			}
+			resp.Body.Close()
+			status <- resp.StatusCode
+		}()
+		<-started
+
+		if err := srv.GracefulShutdown(context.Background()); err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+		if code := <-status; code != http.StatusOK {
+			t.Errorf("Expected in-flight request to complete with 200, got %d", code)
+		}
+		if err := <-runErr; !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("Expected Run to return ErrServerClosed, got %v", err)
+		}
+	})
+}
+
+func TestShutdownDraining(t *testing.T) {
+	srv, baseURL, started, runErr := startSlowServer(t, 0, 500*time.Millisecond)
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(baseURL + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- srv.GracefulShutdown(context.Background())
+	}()
+	for !srv.draining.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The listener may already be closed, so hand the request to the server
+	// directly the way a connection accepted just before shutdown would
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest("GET", "/version", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 while draining, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header while draining")
+	}
+
+	if code := <-status; code != http.StatusOK {
+		t.Errorf("Expected the in-flight request to complete with 200, got %d", code)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+	if err := <-runErr; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Expected Run to return ErrServerClosed, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSingleFileRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "report.txt")
+	if err := os.WriteFile(target, []byte("quarterly numbers"), 0644); err != nil {
+		t.Fatalf("Failed to write report.txt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "private.txt"), []byte("do not share"), 0644); err != nil {
+		t.Fatalf("Failed to write private.txt: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create sub: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:            "localhost",
+		Port:            8080,
+		StoragePath:     target,
+		StorageType:     "local",
+		DisableDotFiles: true,
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	t.Run("serves_the_file", func(t *testing.T) {
+		w := get("/report.txt")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if w.Body.String() != "quarterly numbers" {
+			t.Errorf("Expected file content, got %q", w.Body.String())
+		}
+	})
+
+	t.Run("root_listing_not_allowed", func(t *testing.T) {
+		w := get("/")
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("Expected status 405, got %d", w.Code)
+		}
+		if allow := w.Header().Get("Allow"); allow != "GET, HEAD" {
+			t.Errorf("Expected Allow: GET, HEAD, got %q", allow)
+		}
+		if body := w.Body.String(); body != "" {
+			t.Errorf("Expected empty body for root, got %q", body)
+		}
+	})
+
+	t.Run("parent_directory_hidden", func(t *testing.T) {
+		for _, path := range []string{"/private.txt", "/sub", "/sub/", "/private.txt?thumb=1"} {
+			if w := get(path); w.Code != http.StatusNotFound {
+				t.Errorf("GET %s: expected status 404, got %d", path, w.Code)
+			}
+		}
+		if w := get("/report.txt/../private.txt"); w.Code == http.StatusOK {
+			t.Errorf("Expected traversal to a sibling to be refused, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSymlinkPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	outsideDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "target.txt"), []byte("target content"), 0644); err != nil {
+		t.Fatalf("Failed to write target: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create sub: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "sub", "inner.txt"), []byte("inner content"), 0644); err != nil {
+		t.Fatalf("Failed to write inner: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outsideDir, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("Failed to write outside file: %v", err)
+	}
+
+	links := map[string]string{
+		"link.txt":   "target.txt",
+		"linkdir":    "sub",
+		"escape.txt": filepath.Join(outsideDir, "secret.txt"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(tmpDir, name)); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	newServer := func(policy string) *Server {
+		return New(&config.Config{
+			Host:            "localhost",
+			Port:            8080,
+			StoragePath:     tmpDir,
+			StorageType:     "local",
+			DisableDotFiles: true,
+			SymlinkPolicy:   policy,
+		})
+	}
+
+	get := func(srv *Server, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	tests := []struct {
+		policy        string
+		linkStatus    int
+		linkDirStatus int
+		listedLinks   []string
+		hiddenLinks   []string
+	}{
+		{
+			policy:        config.SymlinkFollow,
+			linkStatus:    http.StatusOK,
+			linkDirStatus: http.StatusOK,
+			listedLinks:   []string{"link.txt", "linkdir"},
+			hiddenLinks:   []string{"escape.txt"},
+		},
+		{
+			policy:        config.SymlinkDeny,
+			linkStatus:    http.StatusForbidden,
+			linkDirStatus: http.StatusForbidden,
+			hiddenLinks:   []string{"link.txt", "linkdir", "escape.txt"},
+		},
+		{
+			policy:        config.SymlinkShow,
+			linkStatus:    http.StatusForbidden,
+			linkDirStatus: http.StatusForbidden,
+			listedLinks:   []string{"link.txt", "linkdir", "escape.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			srv := newServer(tt.policy)
+
+			w := get(srv, "/link.txt")
+			if w.Code != tt.linkStatus {
+				t.Errorf("GET /link.txt: expected status %d, got %d", tt.linkStatus, w.Code)
+			}
+			if tt.linkStatus == http.StatusOK && w.Body.String() != "target content" {
+				t.Errorf("GET /link.txt: expected target content, got %q", w.Body.String())
+			}
+
+			if w := get(srv, "/linkdir/inner.txt"); w.Code != tt.linkDirStatus {
+				t.Errorf("GET /linkdir/inner.txt: expected status %d, got %d", tt.linkDirStatus, w.Code)
+			}
+
+			if w := get(srv, "/escape.txt"); w.Code == http.StatusOK {
+				t.Error("GET /escape.txt: symlink escaping the root must never be served")
+			}
+
+			w = get(srv, "/")
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET /: expected status 200, got %d", w.Code)
+			}
+			body := w.Body.String()
+			if !strings.Contains(body, "target.txt") {
+				t.Error("Expected listing to contain target.txt")
+			}
+			for _, name := range tt.listedLinks {
+				if !strings.Contains(body, name) {
+					t.Errorf("Expected listing to contain %s", name)
+				}
+			}
+			for _, name := range tt.hiddenLinks {
+				if strings.Contains(body, name) {
+					t.Errorf("Expected listing to hide %s", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"slimserve/internal/config"
+	"slimserve/internal/server/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListingTheme(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		theme    string
+		cookie   string
+		expected string
+	}{
+		{name: "light", theme: config.ThemeLight, expected: `<html lang="en" data-theme="light" class="light">`},
+		{name: "dark", theme: config.ThemeDark, expected: `<html lang="en" data-theme="dark">`},
+		{name: "auto", theme: config.ThemeAuto, expected: `<html lang="en" data-theme="auto">`},
+		{name: "unset_defaults_to_auto", theme: "", expected: `<html lang="en" data-theme="auto">`},
+		{name: "cookie_overrides_config", theme: config.ThemeDark, cookie: "light", expected: `<html lang="en" data-theme="light" class="light">`},
+		{name: "invalid_cookie_ignored", theme: config.ThemeDark, cookie: "neon", expected: `<html lang="en" data-theme="dark">`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := New(&config.Config{
+				Host:            "localhost",
+				Port:            8080,
+				StoragePath:     tmpDir,
+				StorageType:     "local",
+				DisableDotFiles: true,
+				Theme:           tt.theme,
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: handler.ThemeCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.expected) {
+				t.Errorf("Expected listing to contain %q", tt.expected)
+			}
+		})
+	}
+}
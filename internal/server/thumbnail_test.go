@@ -0,0 +1,420 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+	"slimserve/internal/files"
+	"slimserve/internal/server/handler"
+
+	"github.com/gen2brain/avif"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/image/webp"
+)
+
+// newThumbnailServer serves a directory holding a single 40x40 photo.png,
+// with thumbnails cached in a per-test directory.
+func newThumbnailServer(t *testing.T, cfg *config.Config) *Server {
+	t.Helper()
+	t.Setenv("SLIMSERVE_CACHE_DIR", t.TempDir())
+
+	tmpDir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			img.Set(x, y, color.RGBA{0, 0, 255, 255})
+		}
+	}
+	f, err := os.Create(filepath.Join(tmpDir, "photo.png"))
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	f.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg.Host = "localhost"
+	cfg.Port = 8080
+	cfg.StoragePath = tmpDir
+	cfg.StorageType = "local"
+	cfg.ThumbJpegQuality = 85
+	cfg.ThumbMaxFileSizeMB = 10
+	return New(cfg)
+}
+
+func TestThumbnailHead(t *testing.T) {
+	srv := newThumbnailServer(t, &config.Config{})
+
+	get := httptest.NewRecorder()
+	srv.ServeHTTP(get, httptest.NewRequest("GET", "/photo.png?thumb=1", nil))
+	if get.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", get.Code)
+	}
+	if cl := get.Header().Get("Content-Length"); cl != strconv.Itoa(get.Body.Len()) {
+		t.Errorf("Expected Content-Length %d, got %q", get.Body.Len(), cl)
+	}
+
+	head := httptest.NewRecorder()
+	srv.ServeHTTP(head, httptest.NewRequest("HEAD", "/photo.png?thumb=1", nil))
+	if head.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", head.Code)
+	}
+	if ct := head.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Expected Content-Type image/jpeg, got %q", ct)
+	}
+	if cl := head.Header().Get("Content-Length"); cl != strconv.Itoa(get.Body.Len()) {
+		t.Errorf("Expected Content-Length %d, got %q", get.Body.Len(), cl)
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("Expected empty body for HEAD, got %d bytes", head.Body.Len())
+	}
+}
+
+func TestThumbnailFormatNegotiation(t *testing.T) {
+	srv := newThumbnailServer(t, &config.Config{ThumbWebP: true})
+
+	request := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/photo.png?thumb=1", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if vary := w.Header().Values("Vary"); !slices.Contains(vary, "Accept") {
+			t.Errorf("Expected Vary: Accept, got %q", vary)
+		}
+		return w
+	}
+
+	webpResp := request("image/avif,image/webp,image/apng,*/*;q=0.8")
+	if ct := webpResp.Header().Get("Content-Type"); ct != "image/webp" {
+		t.Errorf("Expected Content-Type image/webp, got %q", ct)
+	}
+	if _, err := webp.Decode(webpResp.Body); err != nil {
+		t.Errorf("Expected a WebP thumbnail: %v", err)
+	}
+
+	jpegResp := request("image/jpeg,*/*;q=0.5")
+	if ct := jpegResp.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Expected Content-Type image/jpeg, got %q", ct)
+	}
+	if _, err := jpeg.Decode(jpegResp.Body); err != nil {
+		t.Errorf("Expected a JPEG thumbnail: %v", err)
+	}
+
+	if request("image/webp;q=0").Header().Get("Content-Type") != "image/jpeg" {
+		t.Error("Expected JPEG when image/webp is refused with q=0")
+	}
+
+	if webpResp.Header().Get("ETag") == jpegResp.Header().Get("ETag") {
+		t.Error("Expected WebP and JPEG thumbnails to have different ETags")
+	}
+	for _, pattern := range []string{"*.webp", "*.jpg"} {
+		matches, _ := filepath.Glob(filepath.Join(files.CacheDir(), pattern))
+		if len(matches) != 1 {
+			t.Errorf("Expected one %s thumbnail in the cache, got %v", pattern, matches)
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		srv := newThumbnailServer(t, &config.Config{})
+		req := httptest.NewRequest("GET", "/photo.png?thumb=1", nil)
+		req.Header.Set("Accept", "image/webp")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if ct := w.Header().Get("Content-Type"); ct != "image/jpeg" {
+			t.Errorf("Expected Content-Type image/jpeg with WebP off, got %q", ct)
+		}
+	})
+}
+
+func TestThumbnailCacheGzip(t *testing.T) {
+	srv := newThumbnailServer(t, &config.Config{ThumbCacheGzip: true})
+
+	request := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/photo.png?thumb=1", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "image/jpeg" {
+			t.Errorf("Expected Content-Type image/jpeg, got %q", ct)
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("Expected Vary: Accept-Encoding, got %q", vary)
+		}
+		return w
+	}
+
+	gz := request("gzip, deflate")
+	if ce := gz.Header().Get("Content-Encoding"); ce != "gzip" {
+		t.Fatalf("Expected Content-Encoding gzip, got %q", ce)
+	}
+	zr, err := gzip.NewReader(gz.Body)
+	if err != nil {
+		t.Fatalf("Expected a gzip body: %v", err)
+	}
+	if _, _, err := image.Decode(zr); err != nil {
+		t.Errorf("Expected the gzip body to hold an image: %v", err)
+	}
+
+	plain := request("")
+	if ce := plain.Header().Get("Content-Encoding"); ce != "" {
+		t.Errorf("Expected no Content-Encoding without gzip support, got %q", ce)
+	}
+	if _, _, err := image.Decode(plain.Body); err != nil {
+		t.Errorf("Expected a decompressed image: %v", err)
+	}
+	if plain.Header().Get("ETag") == gz.Header().Get("ETag") {
+		t.Error("Expected the compressed and decompressed thumbnails to have different ETags")
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(files.CacheDir(), "*.jpg"+files.CompressedExt))
+	if len(matches) != 1 {
+		t.Errorf("Expected one compressed thumbnail in the cache, got %v", matches)
+	}
+}
+
+func TestThumbnailCacheHeaders(t *testing.T) {
+	srv := newThumbnailServer(t, &config.Config{ThumbCacheMaxAge: 3600})
+
+	request := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/photo.png?thumb=1", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=3600" {
+		t.Errorf("Expected Cache-Control public, max-age=3600, got %q", cc)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected ETag header on thumbnail response")
+	}
+
+	w = request(etag)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected status 304 for matching If-None-Match, got %d", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected empty body for 304, got %d bytes", w.Body.Len())
+	}
+
+	if w := request(`"stale"`); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for mismatched If-None-Match, got %d", w.Code)
+	}
+
+	t.Run("zero_max_age_omits_header", func(t *testing.T) {
+		srv := newThumbnailServer(t, &config.Config{})
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", "/photo.png?thumb=1", nil))
+		if cc := w.Header().Get("Cache-Control"); cc != "" {
+			t.Errorf("Expected no Cache-Control, got %q", cc)
+		}
+	})
+}
+
+func TestThumbnailAVIF(t *testing.T) {
+	srv := newThumbnailServer(t, &config.Config{ThumbAVIF: true, ThumbWebP: true})
+
+	request := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/photo.png?thumb=1", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if vary := w.Header().Values("Vary"); !slices.Contains(vary, "Accept") {
+			t.Errorf("Expected Vary: Accept, got %q", vary)
+		}
+		return w
+	}
+
+	w := request("image/avif,image/webp,image/apng,*/*;q=0.8")
+	if ct := w.Header().Get("Content-Type"); ct != "image/avif" {
+		t.Fatalf("Expected Content-Type image/avif, got %q", ct)
+	}
+	img, err := avif.Decode(w.Body)
+	if err != nil {
+		t.Fatalf("Expected an AVIF thumbnail: %v", err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(40, 40) {
+		t.Errorf("Expected a 40x40 thumbnail, got %v", got)
+	}
+	matches, _ := filepath.Glob(filepath.Join(files.CacheDir(), "*-avif.avif"))
+	if len(matches) != 1 {
+		t.Errorf("Expected one AVIF thumbnail in the cache, got %v", matches)
+	}
+
+	if ct := request("image/webp").Header().Get("Content-Type"); ct != "image/webp" {
+		t.Errorf("Expected WebP for clients without AVIF, got %q", ct)
+	}
+	if ct := request("image/jpeg,*/*;q=0.5").Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Expected JPEG for clients without AVIF or WebP, got %q", ct)
+	}
+	if ct := request("image/avif;q=0").Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Expected JPEG when image/avif is refused with q=0, got %q", ct)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		srv := newThumbnailServer(t, &config.Config{})
+		req := httptest.NewRequest("GET", "/photo.png?thumb=1", nil)
+		req.Header.Set("Accept", "image/avif")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if ct := w.Header().Get("Content-Type"); ct != "image/jpeg" {
+			t.Errorf("Expected Content-Type image/jpeg with AVIF off, got %q", ct)
+		}
+	})
+}
+
+func TestThumbnailRanges(t *testing.T) {
+	srv := newThumbnailServer(t, &config.Config{ThumbCacheMaxAge: 3600})
+
+	request := func(target, rangeHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	full := request("/photo.png?thumb=1", "")
+	if full.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", full.Code)
+	}
+	size := full.Body.Len()
+
+	w := request("/photo.png?thumb=1", "bytes=0-9")
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("Expected status 206, got %d", w.Code)
+	}
+	if want := "bytes 0-9/" + strconv.Itoa(size); w.Header().Get("Content-Range") != want {
+		t.Errorf("Expected Content-Range %q, got %q", want, w.Header().Get("Content-Range"))
+	}
+	if !bytes.Equal(w.Body.Bytes(), full.Body.Bytes()[:10]) {
+		t.Error("Expected the first 10 bytes of the thumbnail")
+	}
+
+	for _, tc := range []struct {
+		name, target string
+		size         int
+	}{
+		{"thumbnail", "/photo.png?thumb=1", size},
+		{"static", "/static/css/theme.css", request("/static/css/theme.css", "").Body.Len()},
+	} {
+		t.Run(tc.name+"_unsatisfiable", func(t *testing.T) {
+			w := request(tc.target, "bytes="+strconv.Itoa(tc.size+100)+"-")
+			if w.Code != http.StatusRequestedRangeNotSatisfiable {
+				t.Fatalf("Expected status 416, got %d", w.Code)
+			}
+			if want := "bytes */" + strconv.Itoa(tc.size); w.Header().Get("Content-Range") != want {
+				t.Errorf("Expected Content-Range %q, got %q", want, w.Header().Get("Content-Range"))
+			}
+			// Caches do not key on Range, so a refused range must not be cacheable
+			if cc := w.Header().Get("Cache-Control"); cc != "" {
+				t.Errorf("Expected no Cache-Control on a refused range, got %q", cc)
+			}
+		})
+
+		// ServeContent refuses a range it cannot parse without a Content-Range
+		t.Run(tc.name+"_malformed", func(t *testing.T) {
+			w := request(tc.target, "bytes=9-2")
+			if w.Code != http.StatusRequestedRangeNotSatisfiable {
+				t.Fatalf("Expected status 416, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestThumbnailOnUpload(t *testing.T) {
+	srv := newThumbnailServer(t, &config.Config{
+		EnableAdmin:          true,
+		AdminUsername:        "admin",
+		AdminPassword:        "password123",
+		MaxUploadSizeMB:      10,
+		MaxConcurrentUploads: 1,
+		ThumbOnUpload:        true,
+	})
+	token := srv.sessionStore.NewToken()
+	srv.sessionStore.AddAdmin(token)
+
+	photo, err := os.ReadFile(filepath.Join(srv.config.StoragePath, "photo.png"))
+	if err != nil {
+		t.Fatalf("Failed to read image: %v", err)
+	}
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("files", "upload.png")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(photo)
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/admin/api/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-CSRF-Token", "upload-csrf")
+	req.AddCookie(&http.Cookie{Name: "slimserve_admin_session", Value: token})
+	req.AddCookie(&http.Cookie{Name: "slimserve_csrf_token", Value: "upload-csrf"})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The thumbnail is generated in the background; it is the only file in
+	// this test's cache directory
+	cacheDir := os.Getenv("SLIMSERVE_CACHE_DIR")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		thumbs, _ := filepath.Glob(filepath.Join(cacheDir, "*.jpg"))
+		if len(thumbs) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected one cached thumbnail after upload, found %v", thumbs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	expected, err := files.GenerateWithContext(t.Context(), filepath.Join(srv.config.StoragePath, "upload.png"), handler.ThumbnailOptions(srv.config))
+	if err != nil {
+		t.Fatalf("Failed to look up thumbnail: %v", err)
+	}
+	if thumbs, _ := filepath.Glob(filepath.Join(cacheDir, "*.jpg")); len(thumbs) != 1 || thumbs[0] != expected {
+		t.Errorf("Expected the upload's thumbnail to be the one ?thumb=1 uses (%s), got %v", expected, thumbs)
+	}
+}
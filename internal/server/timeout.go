@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"slimserve/internal/logger"
+)
+
+// timeoutHandler answers 503 when next has not started its response within
+// timeout. The request context carries the same deadline, so context-aware
+// work is cancelled too, but the reply does not wait for it: a handler that
+// ignores its context keeps running in the background and its writes are
+// discarded. A response already in progress is left alone.
+//
+// Unlike http.TimeoutHandler the response is not buffered, so downloads
+// stream as before once they begin.
+type timeoutHandler struct {
+	next    http.Handler
+	timeout time.Duration
+}
+
+func newTimeoutHandler(next http.Handler, timeout time.Duration) http.Handler {
+	return &timeoutHandler{next: next, timeout: timeout}
+}
+
+func (h *timeoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	tw := &timeoutWriter{w: w, header: make(http.Header)}
+	done := make(chan struct{})
+	panics := make(chan any, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panics <- p
+			}
+			close(done)
+		}()
+		h.next.ServeHTTP(tw, r.WithContext(ctx))
+	}()
+
+	select {
+	case <-done:
+		select {
+		case p := <-panics:
+			panic(p)
+		default:
+		}
+		// A handler that wrote nothing still gets its headers sent
+		tw.WriteHeader(http.StatusOK)
+	case <-ctx.Done():
+		if tw.timeOut() {
+			logger.Log.Warn().
+				Str("path", r.URL.Path).
+				Dur("timeout", h.timeout).
+				Msg("Request exceeded handler timeout")
+			w.Header().Set("Content-Length", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		// The response has begun; let the handler finish it
+		<-done
+		select {
+		case p := <-panics:
+			panic(p)
+		default:
+		}
+	}
+}
+
+// timeoutWriter holds the handler's headers back until it starts its
+// response, and drops everything once timeOut has claimed the response
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu       sync.Mutex
+	started  bool
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// start sends the headers with code unless the response was taken over by the
+// timeout or already started
+func (tw *timeoutWriter) start(code int) error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return http.ErrHandlerTimeout
+	}
+	if !tw.started {
+		tw.started = true
+		dst := tw.w.Header()
+		for k, v := range tw.header {
+			dst[k] = v
+		}
+		tw.w.WriteHeader(code)
+	}
+	return nil
+}
+
+// timeOut claims the response for the timeout, reporting false when the
+// handler had already started it
+func (tw *timeoutWriter) timeOut() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.started {
+		return false
+	}
+	tw.timedOut = true
+	return true
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	_ = tw.start(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	if err := tw.start(http.StatusOK); err != nil {
+		return 0, err
+	}
+	return tw.w.Write(b)
+}
+
+// ReadFrom keeps the underlying writer's sendfile path for file downloads
+func (tw *timeoutWriter) ReadFrom(r io.Reader) (int64, error) {
+	if err := tw.start(http.StatusOK); err != nil {
+		return 0, err
+	}
+	if rf, ok := tw.w.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(tw.w, r)
+}
+
+func (tw *timeoutWriter) Flush() {
+	if err := tw.start(http.StatusOK); err != nil {
+		return
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServerTimeouts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := New(&config.Config{
+		Host:                "localhost",
+		Port:                8080,
+		StoragePath:         t.TempDir(),
+		StorageType:         "local",
+		ReadTimeoutSeconds:  10,
+		WriteTimeoutSeconds: 20,
+		IdleTimeoutSeconds:  30,
+		MaxHeaderBytes:      4096,
+	})
+
+	hs := srv.newHTTPServer("127.0.0.1:0")
+	if hs.MaxHeaderBytes != 4096 {
+		t.Errorf("Expected MaxHeaderBytes 4096, got %d", hs.MaxHeaderBytes)
+	}
+	if hs.ReadTimeout != 10*time.Second {
+		t.Errorf("Expected ReadTimeout 10s, got %v", hs.ReadTimeout)
+	}
+	if hs.WriteTimeout != 20*time.Second {
+		t.Errorf("Expected WriteTimeout 20s, got %v", hs.WriteTimeout)
+	}
+	if hs.IdleTimeout != 30*time.Second {
+		t.Errorf("Expected IdleTimeout 30s, got %v", hs.IdleTimeout)
+	}
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newServer := func(timeoutSeconds int) *Server {
+		srv := New(&config.Config{
+			Host:                  "localhost",
+			Port:                  8080,
+			StoragePath:           t.TempDir(),
+			StorageType:           "local",
+			HandlerTimeoutSeconds: timeoutSeconds,
+		})
+		// Ignores the request context, so only the server can cut it off
+		srv.engine.GET("/slow", func(c *gin.Context) {
+			time.Sleep(5 * time.Second)
+			c.String(http.StatusOK, "done")
+		})
+		srv.engine.GET("/streaming", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+			c.Writer.WriteHeaderNow()
+			time.Sleep(1500 * time.Millisecond)
+			c.String(http.StatusOK, "done")
+		})
+		srv.engine.GET("/fast", func(c *gin.Context) {
+			c.String(http.StatusOK, "done")
+		})
+		return srv
+	}
+
+	get := func(srv *Server, path string) (*httptest.ResponseRecorder, time.Duration) {
+		w := httptest.NewRecorder()
+		begin := time.Now()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w, time.Since(begin)
+	}
+
+	t.Run("slow_handler_cut_off", func(t *testing.T) {
+		w, elapsed := get(newServer(1), "/slow")
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", w.Code)
+		}
+		if elapsed > 3*time.Second {
+			t.Errorf("Expected the handler to be cut off after about 1s, took %v", elapsed)
+		}
+	})
+
+	t.Run("started_response_left_alone", func(t *testing.T) {
+		w, _ := get(newServer(1), "/streaming")
+		if w.Code != http.StatusOK || w.Body.String() != "done" {
+			t.Errorf("Expected 200 done, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("fast_handler_unaffected", func(t *testing.T) {
+		w, _ := get(newServer(1), "/fast")
+		if w.Code != http.StatusOK || w.Body.String() != "done" {
+			t.Errorf("Expected 200 done, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("disabled_sets_no_deadline", func(t *testing.T) {
+		srv := newServer(0)
+		srv.engine.GET("/deadline", func(c *gin.Context) {
+			_, ok := c.Request.Context().Deadline()
+			if ok {
+				c.String(http.StatusOK, "deadline")
+				return
+			}
+			c.String(http.StatusOK, "none")
+		})
+		w, _ := get(srv, "/deadline")
+		if w.Body.String() != "none" {
+			t.Errorf("Expected no request deadline, got %q", w.Body.String())
+		}
+	})
+}
+
+func TestMaxConnections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const limit = 2
+	srv := New(&config.Config{
+		Host:           "localhost",
+		Port:           8080,
+		StoragePath:    t.TempDir(),
+		StorageType:    "local",
+		MaxConnections: limit,
+	})
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	srv.engine.GET("/block", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	srv.engine.GET("/fast", func(c *gin.Context) {
+		c.String(http.StatusOK, "done")
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	// Fill every slot with a request that waits for release
+	var wg sync.WaitGroup
+	codes := make(chan int, limit)
+	for i := 0; i < limit; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- get("/block").Code
+		}()
+		<-entered
+	}
+
+	w := get("/fast")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 over the limit, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header on the 503")
+	}
+
+	close(release)
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("Expected requests within the limit to succeed, got %d", code)
+		}
+	}
+
+	if w := get("/fast"); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 once slots were freed, got %d", w.Code)
+	}
+}
+
+func TestMaxURLLength(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "hello.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatalf("Failed to write hello.txt: %v", err)
+	}
+	newServer := func(limit int) *Server {
+		return New(&config.Config{
+			Host:         "localhost",
+			Port:         8080,
+			StoragePath:  tmpDir,
+			StorageType:  "local",
+			MaxURLLength: limit,
+		})
+	}
+	get := func(srv *Server, target string) int {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w.Code
+	}
+
+	longPath := "/" + strings.Repeat("a", 300)
+	tests := []struct {
+		name   string
+		limit  int
+		target string
+		want   int
+	}{
+		{"normal", 256, "/hello.txt", http.StatusOK},
+		{"long_path", 256, longPath, http.StatusRequestURITooLong},
+		{"long_query", 256, "/hello.txt?q=" + strings.Repeat("x", 300), http.StatusRequestURITooLong},
+		{"at_limit", len("/hello.txt?q=xx"), "/hello.txt?q=xx", http.StatusOK},
+		{"disabled", 0, longPath, http.StatusNotFound},
+		{"default_allows_long_names", config.Default().MaxURLLength, longPath, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := get(newServer(tt.limit), tt.target); got != tt.want {
+				t.Errorf("GET %.40s... with limit %d: expected %d, got %d", tt.target, tt.limit, tt.want, got)
+			}
+		})
+	}
+}
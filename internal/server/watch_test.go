@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSWatchInvalidatesListing(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("file watching is only implemented on Linux")
+	}
+	gin.SetMode(gin.TestMode)
+
+	newServer := func(t *testing.T, watch bool) (*Server, string) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(tmpDir, "sub"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "sub", "a.txt"), make([]byte, 100), 0644))
+		srv := New(&config.Config{
+			Host:          "localhost",
+			Port:          8080,
+			StoragePath:   tmpDir,
+			StorageType:   "local",
+			ShowDirSizes:  true,
+			EnableFSWatch: watch,
+		})
+		t.Cleanup(func() { srv.Shutdown(t.Context()) })
+		return srv, tmpDir
+	}
+	// list returns the root listing's ETag and the size shown for sub
+	list := func(t *testing.T, srv *Server) (string, string) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var data struct {
+			Files []struct {
+				Name string `json:"name"`
+				Size string `json:"size"`
+			} `json:"files"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &data))
+		require.Len(t, data.Files, 1)
+		return w.Header().Get("ETag"), data.Files[0].Size
+	}
+
+	t.Run("watched", func(t *testing.T) {
+		srv, dir := newServer(t, true)
+		etag, size := list(t, srv)
+		assert.Equal(t, "100 B", size)
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.txt"), make([]byte, 50), 0644))
+		assert.Eventually(t, func() bool {
+			newETag, newSize := list(t, srv)
+			return newETag != etag && newSize == "150 B"
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("unwatched", func(t *testing.T) {
+		srv, dir := newServer(t, false)
+		etag, _ := list(t, srv)
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.txt"), make([]byte, 50), 0644))
+		time.Sleep(50 * time.Millisecond)
+		newETag, size := list(t, srv)
+		assert.Equal(t, etag, newETag, "folder sizes are cached until they expire")
+		assert.Equal(t, "100 B", size)
+	})
+}
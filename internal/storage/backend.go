@@ -6,18 +6,21 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"time"
 
+	"slimserve/internal/ignore"
 	"slimserve/internal/logger"
 	"slimserve/internal/security"
 )
 
 type FileInfo struct {
-	name    string
-	size    int64
-	modTime time.Time
-	isDir   bool
+	name      string
+	size      int64
+	modTime   time.Time
+	isDir     bool
+	isSymlink bool
 }
 
 func (f *FileInfo) Name() string       { return f.name }
@@ -26,6 +29,9 @@ func (f *FileInfo) ModTime() time.Time { return f.modTime }
 func (f *FileInfo) IsDir() bool        { return f.isDir }
 func (f *FileInfo) Sys() interface{}   { return nil }
 func (f *FileInfo) Mode() fs.FileMode {
+	if f.isSymlink {
+		return fs.ModeSymlink | 0777
+	}
 	if f.isDir {
 		return fs.ModeDir | 0755
 	}
@@ -41,6 +47,9 @@ type DirEntry struct {
 func (d *DirEntry) Name() string { return d.name }
 func (d *DirEntry) IsDir() bool  { return d.isDir }
 func (d *DirEntry) Type() fs.FileMode {
+	if d.info != nil && d.info.isSymlink {
+		return fs.ModeSymlink
+	}
 	if d.isDir {
 		return fs.ModeDir
 	}
@@ -57,6 +66,12 @@ type Backend interface {
 	Close() error
 }
 
+// LocalCopier is implemented by remote backends that can copy a file into a
+// local directory
+type LocalCopier interface {
+	LocalCopy(ctx context.Context, key, dir string) (string, error)
+}
+
 // Uploader is an interface for backends that support writing
 type Uploader interface {
 	Backend
@@ -65,25 +80,54 @@ type Uploader interface {
 	Move(ctx context.Context, srcKey, destKey string) error
 }
 
-type LocalBackend struct {
-	root           *security.RootFS
+// StreamUploader is implemented by uploaders that can write a file from a
+// reader without holding all of it in memory
+type StreamUploader interface {
+	Uploader
+	PutStream(ctx context.Context, key string, r io.Reader) error
+}
+
+// TreeUploader is implemented by uploaders that can create directories and
+// delete whole trees
+type TreeUploader interface {
+	Uploader
+	MkdirAll(ctx context.Context, key string) error
+	RemoveAll(ctx context.Context, key string) error
+}
+
+// FSBackend serves any security.FileSystem read-only
+type FSBackend struct {
+	root           security.FileSystem
 	path           string
 	ignorePatterns []string
 }
 
-func NewLocalBackend(root *security.RootFS, ignorePatterns []string) *LocalBackend {
-	return &LocalBackend{
+func NewFSBackend(root security.FileSystem, ignorePatterns []string) *FSBackend {
+	return &FSBackend{
 		root:           root,
 		path:           root.Path(),
 		ignorePatterns: ignorePatterns,
 	}
 }
 
-func (l *LocalBackend) Path() string {
+// LocalBackend serves a local directory and supports writing to it
+type LocalBackend struct {
+	*FSBackend
+	local *security.RootFS
+}
+
+func NewLocalBackend(root *security.RootFS, ignorePatterns []string) *LocalBackend {
+	return &LocalBackend{
+		FSBackend: NewFSBackend(root, ignorePatterns),
+		local:     root,
+	}
+}
+
+func (l *FSBackend) Path() string {
 	return l.path
 }
 
-func (l *LocalBackend) Stat(ctx context.Context, name string) (*FileInfo, error) {
+func (l *FSBackend) Stat(ctx context.Context, name string) (*FileInfo, error) {
 	info, err := l.root.Stat(name)
 	if err != nil {
 		return nil, err
@@ -96,7 +140,7 @@ func (l *LocalBackend) Stat(ctx context.Context, name string) (*FileInfo, error)
 	}, nil
 }
 
-func (l *LocalBackend) ReadDir(ctx context.Context, name string) ([]*DirEntry, error) {
+func (l *FSBackend) ReadDir(ctx context.Context, name string) ([]*DirEntry, error) {
 	entries, err := l.root.ReadDir(name)
 	if err != nil {
 		return nil, err
@@ -112,56 +156,68 @@ func (l *LocalBackend) ReadDir(ctx context.Context, name string) ([]*DirEntry, e
 			name:  e.Name(),
 			isDir: e.IsDir(),
 			info: &FileInfo{
-				name:    info.Name(),
-				size:    info.Size(),
-				modTime: info.ModTime(),
-				isDir:   info.IsDir(),
+				name:      info.Name(),
+				size:      info.Size(),
+				modTime:   info.ModTime(),
+				isDir:     info.IsDir(),
+				isSymlink: e.Type()&fs.ModeSymlink != 0,
 			},
 		})
 	}
 	return result, nil
 }
 
-func (l *LocalBackend) Open(ctx context.Context, name string) (io.ReadSeekCloser, error) {
+func (l *FSBackend) Open(ctx context.Context, name string) (io.ReadSeekCloser, error) {
 	return l.root.Open(name)
 }
 
+// MatchIgnore reports whether relPath matches the gitignore-style patterns.
+// Patterns are evaluated in order and the last match wins, so a later
+// "!pattern" re-includes a path hidden by an earlier one.
 func MatchIgnore(relPath string, patterns []string) bool {
-	if filepath.Base(relPath) == ".slimserveignore" {
-		return true
-	}
-	for _, pattern := range patterns {
-		matched, err := filepath.Match(pattern, relPath)
-		if err != nil {
-			continue
-		}
-		if matched {
-			return true
-		}
+	ignored, err := ignore.IsIgnoredWithPatterns(relPath, nil, patterns)
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("path", relPath).Msg("Failed to evaluate ignore patterns")
+		return false
 	}
-	return false
+	return ignored
 }
 
-func (l *LocalBackend) IsIgnored(ctx context.Context, relPath string) (bool, error) {
-	return MatchIgnore(relPath, l.ignorePatterns), nil
+// IsIgnored checks the global patterns and every .slimserveignore file along relPath
+func (l *FSBackend) IsIgnored(ctx context.Context, relPath string) (bool, error) {
+	return ignore.IsIgnoredWithPatterns(relPath, l.root, l.ignorePatterns)
 }
 
-func (l *LocalBackend) Close() error {
+func (l *FSBackend) Close() error {
 	return l.root.Close()
 }
 
+// Put writes data to key atomically, so a failed write never leaves a
+// truncated file in place of an existing one
 func (l *LocalBackend) Put(ctx context.Context, key string, data []byte) error {
-	f, err := l.root.Create(key)
-	if err != nil {
+	return l.PutStream(ctx, key, bytes.NewReader(data))
+}
+
+// PutStream is Put reading the content from r, with the same atomicity
+func (l *LocalBackend) PutStream(ctx context.Context, key string, r io.Reader) error {
+	return l.local.WriteFileAtomic(key, 0644, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
 		return err
-	}
-	defer f.Close()
-	_, err = f.Write(data)
-	return err
+	})
 }
 
 func (l *LocalBackend) Delete(ctx context.Context, key string) error {
-	return l.root.Remove(key)
+	return l.local.Remove(key)
+}
+
+// MkdirAll creates the directory key and any missing parents
+func (l *LocalBackend) MkdirAll(ctx context.Context, key string) error {
+	return l.local.MkdirAll(key, 0755)
+}
+
+// RemoveAll deletes key and, for a directory, everything below it
+func (l *LocalBackend) RemoveAll(ctx context.Context, key string) error {
+	return l.local.RemoveAll(key)
 }
 
 func (l *LocalBackend) Move(ctx context.Context, srcKey, destKey string) error {
@@ -183,10 +239,17 @@ func (s *S3Backend) Stat(ctx context.Context, key string) (*FileInfo, error) {
 		return nil, err
 	}
 	if obj == nil {
-		return nil, os.ErrNotExist
+		isDir, err := s.isDir(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if !isDir {
+			return nil, os.ErrNotExist
+		}
+		return &FileInfo{name: path.Base(key), isDir: true}, nil
 	}
 	return &FileInfo{
-		name:    key,
+		name:    path.Base(key),
 		size:    obj.Size,
 		modTime: obj.LastModified,
 		isDir:   obj.IsDir,
@@ -200,11 +263,12 @@ func (s *S3Backend) ReadDir(ctx context.Context, prefix string) ([]*DirEntry, er
 	}
 	result := make([]*DirEntry, 0, len(objects))
 	for _, obj := range objects {
+		name := path.Base(obj.Key)
 		result = append(result, &DirEntry{
-			name:  obj.Key,
+			name:  name,
 			isDir: obj.IsDir,
 			info: &FileInfo{
-				name:    obj.Key,
+				name:    name,
 				size:    obj.Size,
 				modTime: obj.LastModified,
 				isDir:   obj.IsDir,
@@ -214,7 +278,20 @@ func (s *S3Backend) ReadDir(ctx context.Context, prefix string) ([]*DirEntry, er
 	return result, nil
 }
 
+// Open serves objects small enough for the object cache from memory and
+// streams larger ones
 func (s *S3Backend) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	obj, err := s.StatObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, os.ErrNotExist
+	}
+	if s.cache == nil || !s.cache.Fits(obj.Size) {
+		return &objectReader{ctx: ctx, s: s, key: key, size: obj.Size}, nil
+	}
+
 	data, err := s.Get(ctx, key)
 	if err != nil {
 		return nil, err
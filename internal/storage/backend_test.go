@@ -54,3 +54,28 @@ func TestDirEntry_Type(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchIgnore(t *testing.T) {
+	patterns := []string{"*.log", "!important.log", "tmp/"}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"app.log", true},
+		{"important.log", false},
+		{"nested/important.log", false},
+		{"app.logger", false},
+		{"tmp/scratch.txt", true},
+		{"readme.md", false},
+		{".slimserveignore", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := MatchIgnore(tt.path, patterns); got != tt.expected {
+				t.Errorf("MatchIgnore(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
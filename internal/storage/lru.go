@@ -25,9 +25,14 @@ func (c *ByteCache) Get(key string) ([]byte, bool) {
 	return c.lru.Get(key)
 }
 
+// Fits reports whether an entry of size bytes would be cached
+func (c *ByteCache) Fits(size int64) bool {
+	return size <= c.maxBytes/2
+}
+
 func (c *ByteCache) Set(key string, data []byte) {
 	dataLen := int64(len(data))
-	if dataLen > c.maxBytes/2 {
+	if !c.Fits(dataLen) {
 		return
 	}
 
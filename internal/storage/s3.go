@@ -2,10 +2,14 @@ package storage
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -62,6 +66,12 @@ func NewS3Backend(cfg *config.DirectoryConfig, cacheMaxBytes int64, ignorePatter
 	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
 		if cfg.Endpoint != "" {
 			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			// S3-compatible servers such as MinIO rarely resolve bucket
+			// subdomains, so custom endpoints address buckets by path. Many
+			// also omit response checksums, which is not worth a warning
+			// on every download.
+			o.UsePathStyle = true
+			o.DisableLogOutputChecksumValidationSkipped = true
 		}
 	})
 
@@ -73,7 +83,7 @@ func NewS3Backend(cfg *config.DirectoryConfig, cacheMaxBytes int64, ignorePatter
 	return &S3Backend{
 		client:         client,
 		bucket:         cfg.Path,
-		prefix:         cfg.Prefix,
+		prefix:         strings.Trim(cfg.Prefix, "/"),
 		cache:          cache,
 		cfg:            cfg,
 		ignorePatterns: ignorePatterns,
@@ -87,9 +97,12 @@ func (s *S3Backend) Path() string {
 	return "s3://" + s.bucket + "/" + s.prefix
 }
 
+// fullPath maps a slash-separated relative path, "." for the root, to its
+// object key
 func (s *S3Backend) fullPath(key string) string {
-	if s.prefix == "" {
-		return key
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if s.prefix == "" || key == "" {
+		return cmp.Or(s.prefix, key)
 	}
 	return s.prefix + "/" + key
 }
@@ -140,17 +153,13 @@ func (s *S3Backend) Get(ctx context.Context, key string) ([]byte, error) {
 	}
 
 	load := func() ([]byte, error) {
-		fullKey := s.fullPath(key)
-		resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
-			Bucket: aws.String(s.bucket),
-			Key:    aws.String(fullKey),
-		})
+		body, err := s.getObject(ctx, key, 0)
 		if err != nil {
-			return nil, fmt.Errorf("get object: %w", err)
+			return nil, err
 		}
-		defer resp.Body.Close()
+		defer body.Close()
 
-		data, err := io.ReadAll(resp.Body)
+		data, err := io.ReadAll(body)
 		if err != nil {
 			return nil, fmt.Errorf("read body: %w", err)
 		}
@@ -191,12 +200,18 @@ func (s *S3Backend) Get(ctx context.Context, key string) ([]byte, error) {
 }
 
 func (s *S3Backend) Put(ctx context.Context, key string, data []byte) error {
+	return s.PutStream(ctx, key, bytes.NewReader(data))
+}
+
+// PutStream uploads the content of r to key. Over plain HTTP the SDK has to
+// sign the payload, so r must then also be an io.Seeker.
+func (s *S3Backend) PutStream(ctx context.Context, key string, r io.Reader) error {
 	fullKey := s.fullPath(key)
 
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(fullKey),
-		Body:   bytes.NewReader(data),
+		Body:   r,
 	})
 	if err != nil {
 		return fmt.Errorf("put object: %w", err)
@@ -227,11 +242,49 @@ func (s *S3Backend) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
-func (s *S3Backend) List(ctx context.Context, prefix string) ([]S3Object, error) {
-	fullPrefix := s.fullPath(prefix)
-	if fullPrefix != "" && !strings.HasSuffix(fullPrefix, "/") {
-		fullPrefix += "/"
+// getObject returns the body of key from offset on
+func (s *S3Backend) getObject(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.fullPath(key)),
+	}
+	if offset > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
 	}
+	resp, err := s.client.GetObject(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("get object: %w", err)
+	}
+	return resp.Body, nil
+}
+
+// dirPrefix returns the key prefix shared by every object below the
+// directory name
+func (s *S3Backend) dirPrefix(name string) string {
+	if full := s.fullPath(name); full != "" {
+		return full + "/"
+	}
+	return ""
+}
+
+// isDir reports whether any object lies below name. S3 has no directories,
+// so a directory exists exactly while it has objects.
+func (s *S3Backend) isDir(ctx context.Context, name string) (bool, error) {
+	resp, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(s.dirPrefix(name)),
+		MaxKeys: aws.Int32(1),
+	})
+	if err != nil {
+		return false, fmt.Errorf("list objects: %w", err)
+	}
+	return len(resp.Contents) > 0, nil
+}
+
+// List returns the objects directly below prefix and its subdirectories,
+// taken from the common prefixes of a delimited listing
+func (s *S3Backend) List(ctx context.Context, prefix string) ([]S3Object, error) {
+	fullPrefix := s.dirPrefix(prefix)
 
 	var objects []S3Object
 	var continuationToken *string
@@ -249,6 +302,10 @@ func (s *S3Backend) List(ctx context.Context, prefix string) ([]S3Object, error)
 
 		for _, obj := range resp.Contents {
 			key := *obj.Key
+			if key == fullPrefix {
+				// Placeholder object created for an empty folder
+				continue
+			}
 			if s.prefix != "" {
 				key = strings.TrimPrefix(key, s.prefix+"/")
 			}
@@ -316,3 +373,106 @@ func (s *S3Backend) Move(ctx context.Context, srcKey, destKey string) error {
 
 	return nil
 }
+
+// objectReader streams an object with ranged GETs. Seeking only moves the
+// offset; the next Read reopens the object there.
+type objectReader struct {
+	ctx    context.Context
+	s      *S3Backend
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *objectReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.s.getObject(r.ctx, r.key, r.offset)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *objectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	if offset != r.offset && r.body != nil {
+		r.body.Close() //nolint:errcheck
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *objectReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	return r.body.Close()
+}
+
+// LocalCopy downloads key into dir and returns the copy's path, for work
+// that needs a local file such as thumbnail generation. A copy whose size
+// and modification time still match the object is reused, so results keyed
+// on the file stay valid between requests.
+func (s *S3Backend) LocalCopy(ctx context.Context, key, dir string) (string, error) {
+	obj, err := s.StatObject(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if obj == nil {
+		return "", os.ErrNotExist
+	}
+
+	dst := filepath.Join(dir, s.cacheKey(key)+strings.ToLower(path.Ext(key)))
+	if info, err := os.Stat(dst); err == nil && info.Size() == obj.Size && info.ModTime().Equal(obj.LastModified) {
+		return dst, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	body, err := s.getObject(ctx, key, 0)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(dir, ".download-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(tmp.Name(), obj.LastModified, obj.LastModified)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(tmp.Name()) //nolint:errcheck
+		return "", fmt.Errorf("copy object: %w", err)
+	}
+	return dst, nil
+}
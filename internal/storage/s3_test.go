@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"slimserve/internal/config"
+)
+
+var fakeS3ModTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeS3 answers the path-style ListObjectsV2, HeadObject and GetObject
+// requests S3Backend makes for read access
+type fakeS3 struct {
+	bucket  string
+	objects map[string][]byte
+	gets    atomic.Int32
+}
+
+type fakeListResult struct {
+	XMLName        xml.Name `xml:"ListBucketResult"`
+	Name           string
+	Prefix         string
+	KeyCount       int
+	MaxKeys        int
+	IsTruncated    bool
+	Contents       []fakeListObject
+	CommonPrefixes []fakeListPrefix
+}
+
+type fakeListObject struct {
+	Key          string
+	LastModified string
+	Size         int
+}
+
+type fakeListPrefix struct {
+	Prefix string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket)
+	if !ok {
+		http.Error(w, "no such bucket", http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(rest, "/")
+	if key == "" {
+		f.list(w, r)
+		return
+	}
+
+	data, ok := f.objects[key]
+	if !ok {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusNotFound)
+		if r.Method != http.MethodHead {
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>") //nolint:errcheck
+		}
+		return
+	}
+	if r.Method == http.MethodGet {
+		f.gets.Add(1)
+	}
+	http.ServeContent(w, r, "", fakeS3ModTime, bytes.NewReader(data))
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	maxKeys := 1000
+	if n, err := strconv.Atoi(query.Get("max-keys")); err == nil {
+		maxKeys = n
+	}
+
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := fakeListResult{Name: f.bucket, Prefix: prefix, MaxKeys: maxKeys}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) || result.KeyCount >= maxKeys {
+			continue
+		}
+		if i := strings.Index(key[len(prefix):], delimiter); delimiter != "" && i >= 0 {
+			common := key[:len(prefix)+i+1]
+			if !slices.Contains(result.CommonPrefixes, fakeListPrefix{common}) {
+				result.CommonPrefixes = append(result.CommonPrefixes, fakeListPrefix{common})
+				result.KeyCount++
+			}
+			continue
+		}
+		result.Contents = append(result.Contents, fakeListObject{
+			Key:          key,
+			LastModified: fakeS3ModTime.Format(time.RFC3339),
+			Size:         len(f.objects[key]),
+		})
+		result.KeyCount++
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result) //nolint:errcheck
+}
+
+func newFakeS3Backend(t *testing.T, prefix string, objects map[string][]byte) (*S3Backend, *fakeS3) {
+	t.Helper()
+	fake := &fakeS3{bucket: "test-bucket", objects: objects}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	backend, err := NewS3Backend(&config.DirectoryConfig{
+		Path:      fake.bucket,
+		Type:      config.BackendS3,
+		Region:    "us-east-1",
+		Endpoint:  srv.URL,
+		AccessKey: "test",
+		SecretKey: "test",
+		Prefix:    prefix,
+	}, 0, nil)
+	if err != nil {
+		t.Fatalf("NewS3Backend: %v", err)
+	}
+	return backend, fake
+}
+
+func entryNames(entries []*DirEntry) []string {
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestS3BackendReadAccess(t *testing.T) {
+	ctx := context.Background()
+	backend, _ := newFakeS3Backend(t, "site/", map[string][]byte{
+		"site/hello.txt":       []byte("hello from s3"),
+		"site/docs/":           nil,
+		"site/docs/readme.md":  []byte("# readme"),
+		"site/docs/img/a.png":  []byte("png"),
+		"elsewhere/hidden.txt": []byte("outside the prefix"),
+	})
+
+	t.Run("root listing", func(t *testing.T) {
+		entries, err := backend.ReadDir(ctx, ".")
+		if err != nil {
+			t.Fatalf("ReadDir: %v", err)
+		}
+		if got, want := entryNames(entries), []string{"docs/", "hello.txt"}; !slices.Equal(got, want) {
+			t.Errorf("root entries = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("directory listing", func(t *testing.T) {
+		entries, err := backend.ReadDir(ctx, "docs")
+		if err != nil {
+			t.Fatalf("ReadDir: %v", err)
+		}
+		if got, want := entryNames(entries), []string{"img/", "readme.md"}; !slices.Equal(got, want) {
+			t.Errorf("docs entries = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		info, err := backend.Stat(ctx, "docs/img")
+		if err != nil || !info.IsDir() || info.Name() != "img" {
+			t.Errorf("Stat(docs/img) = %+v, %v, want directory img", info, err)
+		}
+		info, err = backend.Stat(ctx, "docs/readme.md")
+		if err != nil || info.IsDir() || info.Name() != "readme.md" || info.Size() != 8 {
+			t.Errorf("Stat(docs/readme.md) = %+v, %v, want 8 byte file readme.md", info, err)
+		}
+		if _, err := backend.Stat(ctx, "missing"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Stat(missing) error = %v, want ErrNotExist", err)
+		}
+	})
+
+	t.Run("stream with seek", func(t *testing.T) {
+		f, err := backend.Open(ctx, "hello.txt")
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		defer f.Close()
+
+		if size, err := f.Seek(0, io.SeekEnd); err != nil || size != 13 {
+			t.Fatalf("Seek(end) = %d, %v, want 13", size, err)
+		}
+		if _, err := f.Seek(6, io.SeekStart); err != nil {
+			t.Fatalf("Seek: %v", err)
+		}
+		data, err := io.ReadAll(f)
+		if err != nil || string(data) != "from s3" {
+			t.Errorf("read after seek = %q, %v, want %q", data, err, "from s3")
+		}
+	})
+
+	t.Run("open missing", func(t *testing.T) {
+		if _, err := backend.Open(ctx, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Open(missing.txt) error = %v, want ErrNotExist", err)
+		}
+	})
+}
+
+func TestS3BackendLocalCopy(t *testing.T) {
+	ctx := context.Background()
+	backend, fake := newFakeS3Backend(t, "", map[string][]byte{
+		"photos/cat.PNG": []byte("not really a png"),
+	})
+	dir := t.TempDir()
+
+	path, err := backend.LocalCopy(ctx, "photos/cat.PNG", dir)
+	if err != nil {
+		t.Fatalf("LocalCopy: %v", err)
+	}
+	if !strings.HasSuffix(path, ".png") {
+		t.Errorf("copy %q should keep the lower-cased extension", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "not really a png" {
+		t.Fatalf("copy content = %q, %v", data, err)
+	}
+	if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(fakeS3ModTime) {
+		t.Errorf("copy should carry the object's modification time, got %v", info.ModTime())
+	}
+
+	again, err := backend.LocalCopy(ctx, "photos/cat.PNG", dir)
+	if err != nil || again != path {
+		t.Fatalf("second LocalCopy = %q, %v, want %q", again, err, path)
+	}
+	if gets := fake.gets.Load(); gets != 1 {
+		t.Errorf("object downloaded %d times, want 1", gets)
+	}
+
+	if _, err := backend.LocalCopy(ctx, "photos/dog.png", dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("LocalCopy(missing) error = %v, want ErrNotExist", err)
+	}
+}
@@ -1,21 +1,64 @@
 package storage
 
 import (
+	"cmp"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
+
+	"slimserve/internal/logger"
 
 	"github.com/hashicorp/golang-lru/v2"
 )
 
+// ThumbCache tracks the thumbnails in cacheDir in least recently used order.
+// Once they take more than the high-water mark a background prune evicts the
+// coldest down to the low-water mark, so the request that tips the cache over
+// does not wait for it.
 type ThumbCache struct {
 	lru       *lru.Cache[string, thumbValue]
 	maxBytes  int64
 	currBytes int64
 	cacheDir  string
+	highBytes int64
+	lowBytes  int64
+	onEvict   func(keys []string)
+}
+
+// Default water marks, as percentages of the cache size
+const (
+	DefaultThumbPruneHighPercent = 100
+	DefaultThumbPruneLowPercent  = 90
+)
+
+// thumbPruner serializes the prunes of one cache directory. Every request
+// builds its own ThumbCache, so this is shared through thumbPruners.
+type thumbPruner struct {
+	running atomic.Bool
+	wg      sync.WaitGroup
+}
+
+// thumbPruners maps a cache directory to its *thumbPruner
+var thumbPruners sync.Map
+
+func prunerFor(cacheDir string) *thumbPruner {
+	p, _ := thumbPruners.LoadOrStore(filepath.Clean(cacheDir), &thumbPruner{})
+	return p.(*thumbPruner)
+}
+
+// WaitForThumbPrune blocks until no prune of cacheDir is running
+func WaitForThumbPrune(cacheDir string) {
+	prunerFor(cacheDir).wg.Wait()
 }
 
+// compressedExt is the suffix of gzip-compressed thumbnails. Their sizes are
+// the compressed ones, which is what counts against the cache limit.
+const compressedExt = ".gz"
+
 type thumbValue struct {
 	Size int64
 	Ext  string
@@ -34,6 +77,7 @@ func NewThumbCache(cacheDir string, maxBytes int64) (*ThumbCache, error) {
 		currBytes: 0,
 		cacheDir:  cacheDir,
 	}
+	tc.SetWaterMarks(DefaultThumbPruneHighPercent, DefaultThumbPruneLowPercent)
 
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return nil, err
@@ -52,8 +96,14 @@ func (tc *ThumbCache) rebuild() error {
 		return err
 	}
 
+	// Modification times record the last access (see Touch), so replaying
+	// them oldest first restores the recency order. Ties break by key to keep
+	// eviction deterministic.
 	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].ModTime < entries[j].ModTime
+		if entries[i].ModTime != entries[j].ModTime {
+			return entries[i].ModTime < entries[j].ModTime
+		}
+		return entries[i].Key < entries[j].Key
 	})
 
 	for _, entry := range entries {
@@ -78,7 +128,12 @@ func (tc *ThumbCache) collectEntries() ([]thumbEntry, error) {
 		if err != nil {
 			return nil
 		}
-		if d.IsDir() || !isImageFile(d.Name()) {
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		plain := strings.TrimSuffix(name, compressedExt)
+		if !isImageFile(plain) {
 			return nil
 		}
 
@@ -87,15 +142,16 @@ func (tc *ThumbCache) collectEntries() ([]thumbEntry, error) {
 			return nil
 		}
 
-		name := d.Name()
-		ext := filepath.Ext(name)
+		// The extension of a compressed thumbnail covers both suffixes, as
+		// in .jpg.gz, so the key names the same entry either way
+		ext := filepath.Ext(plain) + name[len(plain):]
 		key := name[:len(name)-len(ext)]
 
 		entries = append(entries, thumbEntry{
 			Key:     key,
 			Size:    info.Size(),
 			Ext:     ext,
-			ModTime: info.ModTime().Unix(),
+			ModTime: info.ModTime().UnixNano(),
 		})
 		return nil
 	})
@@ -106,7 +162,7 @@ func (tc *ThumbCache) collectEntries() ([]thumbEntry, error) {
 func isImageFile(filename string) bool {
 	ext := filepath.Ext(filename)
 	switch ext {
-	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".avif":
 		return true
 	default:
 		return false
@@ -118,16 +174,71 @@ func (tc *ThumbCache) Get(key string) bool {
 	return ok
 }
 
+// Touch marks key as used at the given time. The file's modification time is
+// updated too, so the recency survives a rebuild from disk.
+func (tc *ThumbCache) Touch(key string, at time.Time) bool {
+	val, ok := tc.lru.Get(key)
+	if !ok {
+		return false
+	}
+	if err := os.Chtimes(filepath.Join(tc.cacheDir, key+val.Ext), at, at); err != nil {
+		logger.Log.Debug().Err(err).Str("key", key).Msg("Failed to touch cached thumbnail")
+	}
+	return true
+}
+
 func (tc *ThumbCache) Contains(key string) bool {
 	return tc.lru.Contains(key)
 }
 
+// SetWaterMarks sets the percentages of the cache size above which a prune
+// starts and down to which it evicts. Zero keeps the default.
+func (tc *ThumbCache) SetWaterMarks(highPercent, lowPercent int) {
+	highPercent = cmp.Or(highPercent, DefaultThumbPruneHighPercent)
+	lowPercent = min(cmp.Or(lowPercent, DefaultThumbPruneLowPercent), highPercent)
+	tc.highBytes = tc.maxBytes * int64(highPercent) / 100
+	tc.lowBytes = tc.maxBytes * int64(lowPercent) / 100
+}
+
 func (tc *ThumbCache) Set(key string, size int64, ext string) {
 	if size > tc.maxBytes/2 {
 		return
 	}
 
-	for atomic.LoadInt64(&tc.currBytes)+size > tc.maxBytes {
+	tc.lru.Add(key, thumbValue{Size: size, Ext: ext})
+	if atomic.AddInt64(&tc.currBytes, size) > tc.highBytes {
+		tc.schedulePrune()
+	}
+}
+
+// schedulePrune starts a background prune of the cache directory unless one
+// is already running; that one, or the next Set over the mark, catches up.
+func (tc *ThumbCache) schedulePrune() {
+	p := prunerFor(tc.cacheDir)
+	if !p.running.CompareAndSwap(false, true) {
+		return
+	}
+	target := tc.lowBytes
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer p.running.Store(false)
+		tc.prune(target)
+	}()
+}
+
+// OnEvict sets a function called with the keys that prune and Delete remove
+func (tc *ThumbCache) OnEvict(fn func(keys []string)) {
+	tc.onEvict = fn
+}
+
+// prune evicts the least recently used thumbnails until at most target bytes
+// are cached
+func (tc *ThumbCache) prune(target int64) {
+	start := time.Now()
+	var evicted []string
+	freed := int64(0)
+	for atomic.LoadInt64(&tc.currBytes) > target {
 		evictedKey, evictedVal, ok := tc.lru.RemoveOldest()
 		if !ok {
 			atomic.StoreInt64(&tc.currBytes, 0)
@@ -135,10 +246,18 @@ func (tc *ThumbCache) Set(key string, size int64, ext string) {
 		}
 		atomic.AddInt64(&tc.currBytes, -evictedVal.Size)
 		os.Remove(filepath.Join(tc.cacheDir, evictedKey+evictedVal.Ext))
+		evicted = append(evicted, evictedKey)
+		freed += evictedVal.Size
 	}
-
-	tc.lru.Add(key, thumbValue{Size: size, Ext: ext})
-	atomic.AddInt64(&tc.currBytes, size)
+	if len(evicted) > 0 && tc.onEvict != nil {
+		tc.onEvict(evicted)
+	}
+	logger.Log.Info().
+		Int("evicted", len(evicted)).
+		Int64("freed_bytes", freed).
+		Int64("cache_bytes", atomic.LoadInt64(&tc.currBytes)).
+		Dur("took", time.Since(start)).
+		Msg("Thumbnail cache pruned")
 }
 
 func (tc *ThumbCache) Delete(key string) bool {
@@ -147,6 +266,9 @@ func (tc *ThumbCache) Delete(key string) bool {
 		atomic.AddInt64(&tc.currBytes, -val.Size)
 		tc.lru.Remove(key)
 		os.Remove(filepath.Join(tc.cacheDir, key+val.Ext))
+		if tc.onEvict != nil {
+			tc.onEvict([]string{key})
+		}
 		return true
 	}
 	return false
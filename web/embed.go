@@ -1,6 +1,15 @@
 package web
 
-import "embed"
+import (
+	"embed"
+	"html/template"
+)
 
 //go:embed templates/* static/*
 var TemplateFS embed.FS
+
+// Funcs returns the functions the embedded templates use. base reports the
+// URL prefix that absolute links are rendered under.
+func Funcs(base func() string) template.FuncMap {
+	return template.FuncMap{"base": base}
+}
@@ -22,7 +22,7 @@ func TestEmbeddedAssets(t *testing.T) {
 	}
 
 	// Test that templates can be parsed
-	tmpl, err := template.ParseFS(TemplateFS, "templates/*.html")
+	tmpl, err := template.New("").Funcs(Funcs(func() string { return "" })).ParseFS(TemplateFS, "templates/*.html")
 	if err != nil {
 		t.Fatalf("Failed to parse embedded templates: %v", err)
 	}
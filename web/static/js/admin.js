@@ -39,11 +39,12 @@ window.adminUtils = {
             defaultOptions.body = JSON.stringify(options.body);
         }
 
-        const response = await fetch(url, { ...defaultOptions, ...options });
+        const basePath = document.querySelector('meta[name="base-path"]')?.content || '';
+        const response = await fetch(basePath + url, { ...defaultOptions, ...options });
         
         if (!response.ok) {
-            const error = await response.json().catch(() => ({ error: 'Request failed' }));
-            throw new Error(error.error || `HTTP ${response.status}`);
+            const error = await response.json().catch(() => ({}));
+            throw new Error(error.error?.message || `HTTP ${response.status}`);
         }
 
         return response.json();
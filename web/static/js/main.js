@@ -79,6 +79,17 @@ document.addEventListener('DOMContentLoaded', function () {
             ? 'dark' : 'light';
     }
 
+    // Theme rendered by the server from its configured default or our cookie
+    function getServerTheme() {
+        const theme = root.getAttribute('data-theme');
+        return theme === 'light' || theme === 'dark' ? theme : null;
+    }
+
+    function persistTheme(theme) {
+        safeSetItem(storageKey, theme);
+        document.cookie = 'slimserve_theme=' + theme + '; path=/; max-age=31536000; SameSite=Lax';
+    }
+
     function applyTheme(theme) {
         if (theme === 'dark') {
             root.classList.remove('light');
@@ -95,14 +106,14 @@ document.addEventListener('DOMContentLoaded', function () {
         if (!toggleBtn) return;
         let theme = safeGetItem(storageKey);
         if (theme !== 'light' && theme !== 'dark') {
-            theme = getPreferred();
+            theme = getServerTheme() || getPreferred();
         }
         applyTheme(theme);
 
         toggleBtn.addEventListener('click', () => {
             const current = root.getAttribute('data-theme') === 'dark' ? 'dark' : 'light';
             const next = current === 'dark' ? 'light' : 'dark';
-            safeSetItem(storageKey, next);
+            persistTheme(next);
             applyTheme(next);
         });
     }